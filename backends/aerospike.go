@@ -2,6 +2,7 @@ package backends
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"time"
@@ -14,14 +15,16 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const setName = "uuid"
-const binValue = "value"
+// Set and bin names used when config.Aerospike doesn't specify any
+const defaultSetName = "uuid"
+const defaultBinName = "value"
 
 // AerospikeDB is a wrapper for the Aerospike client
 type AerospikeDB interface {
-	NewUUIDKey(namespace string, key string) (*as.Key, error)
-	Get(key *as.Key) (*as.Record, error)
+	NewUUIDKey(namespace string, setName string, key string) (*as.Key, error)
+	Get(policy *as.BasePolicy, key *as.Key, binNames ...string) (*as.Record, error)
 	Put(policy *as.WritePolicy, key *as.Key, binMap as.BinMap) error
+	IsConnected() bool
 }
 
 // AerospikeDBClient implements the AerospikeDB interface
@@ -30,8 +33,8 @@ type AerospikeDBClient struct {
 }
 
 // Get performs the as.Client Get operation
-func (db AerospikeDBClient) Get(key *as.Key) (*as.Record, error) {
-	return db.client.Get(nil, key, binValue)
+func (db AerospikeDBClient) Get(policy *as.BasePolicy, key *as.Key, binNames ...string) (*as.Record, error) {
+	return db.client.Get(policy, key, binNames...)
 }
 
 // Put performs the as.Client Put operation
@@ -39,17 +42,26 @@ func (db AerospikeDBClient) Put(policy *as.WritePolicy, key *as.Key, binMap as.B
 	return db.client.Put(policy, key, binMap)
 }
 
+// IsConnected tells whether the client is connected to at least one active node of the cluster
+func (db AerospikeDBClient) IsConnected() bool {
+	return db.client.IsConnected()
+}
+
 // NewUUIDKey creates an aerospike key so we can store data under it
-func (db *AerospikeDBClient) NewUUIDKey(namespace string, key string) (*as.Key, error) {
+func (db *AerospikeDBClient) NewUUIDKey(namespace string, setName string, key string) (*as.Key, error) {
 	return as.NewKey(namespace, setName, key)
 }
 
 // AerospikeBackend upon creation will instantiates, and configure the Aerospike client. Implements
 // the Backend interface
 type AerospikeBackend struct {
-	namespace string
-	client    AerospikeDB
-	metrics   *metrics.Metrics
+	namespace   string
+	setName     string
+	binName     string
+	readPolicy  *as.BasePolicy
+	writePolicy *as.WritePolicy
+	client      AerospikeDB
+	metrics     *metrics.Metrics
 }
 
 // NewAerospikeBackend validates config.Aerospike and returns an AerospikeBackend
@@ -61,7 +73,11 @@ func NewAerospikeBackend(cfg config.Aerospike, metrics *metrics.Metrics) *Aerosp
 }
 
 func newAerospikeBackend(newAerospikeClient NewAerospikeClientFunc, cfg config.Aerospike, metrics *metrics.Metrics) *AerospikeBackend {
-	clientPolicy := generateAerospikeClientPolicy(cfg)
+	clientPolicy, err := generateAerospikeClientPolicy(cfg)
+	if err != nil {
+		log.Fatalf("Error creating Aerospike backend: %s", err.Error())
+		return nil
+	}
 	hosts, err := generateHostsList(cfg)
 	if err != nil {
 		log.Fatalf("Error creating Aerospike backend: %s", err.Error())
@@ -75,36 +91,36 @@ func newAerospikeBackend(newAerospikeClient NewAerospikeClientFunc, cfg config.A
 	}
 	log.Infof("Connected to Aerospike host(s) %v on port %d", append(cfg.Hosts, cfg.Host), cfg.Port)
 
-	// client.DefaultPolicy.MaxRetries determines the maximum number of retries before aborting a transaction.
-	// Default for read: 2 (initial attempt + 2 retries = 3 attempts)
-	if cfg.MaxReadRetries > 2 {
-		client.DefaultPolicy.MaxRetries = cfg.MaxReadRetries
+	backend := &AerospikeBackend{
+		namespace:   cfg.Namespace,
+		setName:     cfg.SetName,
+		binName:     cfg.BinName,
+		readPolicy:  generateAerospikeReadPolicy(cfg),
+		writePolicy: generateAerospikeWritePolicy(cfg),
+		client:      &AerospikeDBClient{client},
+		metrics:     metrics,
 	}
-
-	// client.DefaultWritePolicy.MaxRetries determines the maximum number of retries for write before aborting
-	// a transaction. Prebid Cache uses the Aerospike backend to do CREATE_ONLY writes, which are idempotent so
-	// it's safe to increase the maximum value of write retries.
-	// Default for write: 0 (no retries)
-	if cfg.MaxWriteRetries > 0 {
-		client.DefaultWritePolicy.MaxRetries = cfg.MaxWriteRetries
+	if backend.setName == "" {
+		backend.setName = defaultSetName
 	}
-
-	return &AerospikeBackend{
-		namespace: cfg.Namespace,
-		client:    &AerospikeDBClient{client},
-		metrics:   metrics,
+	if backend.binName == "" {
+		backend.binName = defaultBinName
 	}
+	return backend
 }
 
 // generateAerospikeClientPolicy returns an Aerospike ClientPolicy object configured according to values
 // in config.Aerospike fields
-func generateAerospikeClientPolicy(cfg config.Aerospike) *as.ClientPolicy {
+func generateAerospikeClientPolicy(cfg config.Aerospike) (*as.ClientPolicy, error) {
 	clientPolicy := as.NewClientPolicy()
 	// cfg.User and cfg.Password are optional parameters
 	// if left blank in the config, they will default to the empty
 	// string and be ignored
 	clientPolicy.User = cfg.User
 	clientPolicy.Password = cfg.Password
+	if cfg.AuthMode == config.AerospikeAuthExternal {
+		clientPolicy.AuthMode = as.AuthModeExternal
+	}
 
 	// Connection idle timeout default is 55 seconds
 	if cfg.ConnIdleTimeoutSecs > 0 {
@@ -116,7 +132,75 @@ func generateAerospikeClientPolicy(cfg config.Aerospike) *as.ClientPolicy {
 		clientPolicy.ConnectionQueueSize = cfg.ConnQueueSize
 	}
 
-	return clientPolicy
+	// The client keeps track of the rack of each node so reads can be sent to the closest replica
+	if cfg.RackAware {
+		clientPolicy.RackAware = true
+		clientPolicy.RackId = cfg.RackID
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig := &tls.Config{}
+		if cfg.TLS.CAFile != "" {
+			pool, err := utils.LoadCertPool(cfg.TLS.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.TLS.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to load key pair %s, %s: %v", cfg.TLS.CertFile, cfg.TLS.KeyFile, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		clientPolicy.TlsConfig = tlsConfig
+	}
+
+	return clientPolicy, nil
+}
+
+// generateAerospikeReadPolicy returns the policy of the Get calls
+func generateAerospikeReadPolicy(cfg config.Aerospike) *as.BasePolicy {
+	policy := as.NewPolicy()
+
+	// MaxRetries determines the maximum number of retries before aborting a transaction.
+	// Default for read: 2 (initial attempt + 2 retries = 3 attempts)
+	if cfg.MaxReadRetries > 2 {
+		policy.MaxRetries = cfg.MaxReadRetries
+	}
+
+	// Client defaults are a 30 seconds socket timeout and a 1 second total timeout
+	if cfg.ReadSocketTimeoutMillis > 0 {
+		policy.SocketTimeout = time.Duration(cfg.ReadSocketTimeoutMillis) * time.Millisecond
+	}
+	if cfg.ReadTotalTimeoutMillis > 0 {
+		policy.TotalTimeout = time.Duration(cfg.ReadTotalTimeoutMillis) * time.Millisecond
+	}
+
+	if cfg.RackAware {
+		policy.ReplicaPolicy = as.PREFER_RACK
+	}
+	return policy
+}
+
+// generateAerospikeWritePolicy returns the policy of the Put calls. The expiration is set on each call.
+func generateAerospikeWritePolicy(cfg config.Aerospike) *as.WritePolicy {
+	policy := as.NewWritePolicy(0, 0)
+	policy.RecordExistsAction = as.CREATE_ONLY
+
+	// MaxRetries determines the maximum number of retries for write before aborting a transaction.
+	// Prebid Cache uses the Aerospike backend to do CREATE_ONLY writes, which are idempotent so
+	// it's safe to increase the maximum value of write retries.
+	// Default for write: 0 (no retries)
+	if cfg.MaxWriteRetries > 0 {
+		policy.MaxRetries = cfg.MaxWriteRetries
+	}
+
+	// Writes are not bounded unless configured
+	policy.SocketTimeout = time.Duration(cfg.WriteSocketTimeoutMillis) * time.Millisecond
+	policy.TotalTimeout = time.Duration(cfg.WriteTotalTimeoutMillis) * time.Millisecond
+	return policy
 }
 
 func generateHostsList(cfg config.Aerospike) ([]*as.Host, error) {
@@ -132,6 +216,12 @@ func generateHostsList(cfg config.Aerospike) ([]*as.Host, error) {
 	for _, host := range cfg.Hosts {
 		hosts = append(hosts, as.NewHost(host, cfg.Port))
 	}
+	// Node certificates get verified against the TLS name of their host
+	if cfg.TLS.Enabled {
+		for _, host := range hosts {
+			host.TLSName = cfg.TLS.Name
+		}
+	}
 	if len(hosts) == 0 {
 		return nil, errors.New("Cannot connect to empty Aerospike host(s)")
 	}
@@ -141,11 +231,11 @@ func generateHostsList(cfg config.Aerospike) ([]*as.Host, error) {
 // Get creates an aerospike key based on the UUID key parameter, perfomrs the client's Get call
 // and validates results. Can return a KEY_NOT_FOUND error or other Aerospike server errors
 func (a *AerospikeBackend) Get(ctx context.Context, key string) (string, error) {
-	asKey, err := a.client.NewUUIDKey(a.namespace, key)
+	asKey, err := a.client.NewUUIDKey(a.namespace, a.setName, key)
 	if err != nil {
 		return "", classifyAerospikeError(err)
 	}
-	rec, err := a.client.Get(asKey)
+	rec, err := a.client.Get(a.readPolicy, asKey, a.binName)
 	if err != nil {
 		return "", classifyAerospikeError(err)
 	}
@@ -153,9 +243,9 @@ func (a *AerospikeBackend) Get(ctx context.Context, key string) (string, error)
 		return "", errors.New("Nil record")
 	}
 
-	value, found := rec.Bins[binValue]
+	value, found := rec.Bins[a.binName]
 	if !found {
-		return "", errors.New("No 'value' bucket found")
+		return "", fmt.Errorf("No '%s' bucket found", a.binName)
 	}
 
 	str, isString := value.(string)
@@ -169,24 +259,30 @@ func (a *AerospikeBackend) Get(ctx context.Context, key string) (string, error)
 // Put creates an aerospike key based on the UUID key parameter and stores the value using the
 // client's Put implementaion. Can return a RECORD_EXISTS error or other Aerospike server errors
 func (a *AerospikeBackend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
-	asKey, err := a.client.NewUUIDKey(a.namespace, key)
+	asKey, err := a.client.NewUUIDKey(a.namespace, a.setName, key)
 	if err != nil {
 		return classifyAerospikeError(err)
 	}
 
-	bins := as.BinMap{binValue: value}
-	policy := &as.WritePolicy{
-		Expiration:         uint32(ttlSeconds),
-		RecordExistsAction: as.CREATE_ONLY,
-	}
+	bins := as.BinMap{a.binName: value}
+	policy := *a.writePolicy
+	policy.Expiration = uint32(ttlSeconds)
 
-	if err := a.client.Put(policy, asKey, bins); err != nil {
+	if err := a.client.Put(&policy, asKey, bins); err != nil {
 		return classifyAerospikeError(err)
 	}
 
 	return nil
 }
 
+// HealthCheck makes sure the Aerospike client is connected to the cluster
+func (a *AerospikeBackend) HealthCheck(ctx context.Context) error {
+	if !a.client.IsConnected() {
+		return errors.New("Aerospike client is not connected to any cluster node")
+	}
+	return nil
+}
+
 func classifyAerospikeError(err error) error {
 	if err != nil {
 		ae := &as.AerospikeError{}
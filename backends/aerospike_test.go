@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/prebid/prebid-cache/metrics"
 	"github.com/prebid/prebid-cache/metrics/metricstest"
 	"github.com/prebid/prebid-cache/utils"
+	"github.com/prebid/prebid-cache/utils/certtest"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 
@@ -96,12 +98,112 @@ func TestGenerateAerospikeClientPolicy(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
-			asPolicy := generateAerospikeClientPolicy(tc.inCfg)
+			asPolicy, err := generateAerospikeClientPolicy(tc.inCfg)
+			assert.NoError(t, err)
 			assert.Equal(t, tc.expected, asPolicy)
 		})
 	}
 }
 
+func TestGenerateAerospikeClientPolicyAuthAndRack(t *testing.T) {
+	asPolicy, err := generateAerospikeClientPolicy(config.Aerospike{
+		User:      "foobar",
+		Password:  "password",
+		AuthMode:  config.AerospikeAuthExternal,
+		RackAware: true,
+		RackID:    3,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, as.AuthModeExternal, asPolicy.AuthMode)
+	assert.True(t, asPolicy.RackAware)
+	assert.Equal(t, 3, asPolicy.RackId)
+	assert.Nil(t, asPolicy.TlsConfig)
+}
+
+func TestGenerateAerospikeClientPolicyTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	caFile := ca.WriteCA(t, dir)
+	pair := ca.Issue(t, dir, "client")
+
+	testCases := []struct {
+		desc               string
+		inTLS              config.AerospikeTLS
+		expectedError      bool
+		expectedRootCAs    bool
+		expectedClientCert bool
+	}{
+		{
+			desc:  "System roots, no client certificate",
+			inTLS: config.AerospikeTLS{Enabled: true},
+		},
+		{
+			desc:               "Private CA and client certificate",
+			inTLS:              config.AerospikeTLS{Enabled: true, CAFile: caFile, CertFile: pair.CertFile, KeyFile: pair.KeyFile},
+			expectedRootCAs:    true,
+			expectedClientCert: true,
+		},
+		{
+			desc:          "CA file doesn't exist",
+			inTLS:         config.AerospikeTLS{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")},
+			expectedError: true,
+		},
+		{
+			desc:          "Client certificate file doesn't exist",
+			inTLS:         config.AerospikeTLS{Enabled: true, CertFile: filepath.Join(dir, "missing.pem"), KeyFile: pair.KeyFile},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		asPolicy, err := generateAerospikeClientPolicy(config.Aerospike{TLS: tc.inTLS})
+		if tc.expectedError {
+			assert.Error(t, err, tc.desc)
+			continue
+		}
+		if !assert.NoError(t, err, tc.desc) || !assert.NotNil(t, asPolicy.TlsConfig, tc.desc) {
+			continue
+		}
+		assert.Equal(t, tc.expectedRootCAs, asPolicy.TlsConfig.RootCAs != nil, tc.desc)
+		assert.Equal(t, tc.expectedClientCert, len(asPolicy.TlsConfig.Certificates) == 1, tc.desc)
+	}
+}
+
+func TestGenerateAerospikeReadPolicy(t *testing.T) {
+	defaultPolicy := generateAerospikeReadPolicy(config.Aerospike{MaxReadRetries: 2})
+	assert.Equal(t, as.NewPolicy(), defaultPolicy)
+
+	policy := generateAerospikeReadPolicy(config.Aerospike{
+		MaxReadRetries:          5,
+		ReadSocketTimeoutMillis: 50,
+		ReadTotalTimeoutMillis:  100,
+		RackAware:               true,
+	})
+	assert.Equal(t, 5, policy.MaxRetries)
+	assert.Equal(t, 50*time.Millisecond, policy.SocketTimeout)
+	assert.Equal(t, 100*time.Millisecond, policy.TotalTimeout)
+	assert.Equal(t, as.PREFER_RACK, policy.ReplicaPolicy)
+}
+
+func TestGenerateAerospikeWritePolicy(t *testing.T) {
+	defaultPolicy := generateAerospikeWritePolicy(config.Aerospike{})
+	assert.Equal(t, as.CREATE_ONLY, defaultPolicy.RecordExistsAction)
+	assert.Equal(t, 0, defaultPolicy.MaxRetries)
+	assert.Zero(t, defaultPolicy.SocketTimeout)
+	assert.Zero(t, defaultPolicy.TotalTimeout)
+
+	policy := generateAerospikeWritePolicy(config.Aerospike{
+		MaxWriteRetries:          3,
+		WriteSocketTimeoutMillis: 75,
+		WriteTotalTimeoutMillis:  150,
+	})
+	assert.Equal(t, as.CREATE_ONLY, policy.RecordExistsAction)
+	assert.Equal(t, 3, policy.MaxRetries)
+	assert.Equal(t, 75*time.Millisecond, policy.SocketTimeout)
+	assert.Equal(t, 150*time.Millisecond, policy.TotalTimeout)
+}
+
 func TestGenerateHostsList(t *testing.T) {
 	type testOutput struct {
 		hosts []*as.Host
@@ -382,9 +484,8 @@ func TestAerospikeClientGet(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	aerospikeBackend := &AerospikeBackend{
-		metrics: m,
-	}
+	aerospikeBackend := NewMockAerospikeBackend(nil)
+	aerospikeBackend.metrics = m
 
 	testCases := []struct {
 		desc              string
@@ -457,9 +558,8 @@ func TestClientPut(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	aerospikeBackend := &AerospikeBackend{
-		metrics: m,
-	}
+	aerospikeBackend := NewMockAerospikeBackend(nil)
+	aerospikeBackend.metrics = m
 
 	testCases := []struct {
 		desc              string
@@ -518,3 +618,103 @@ func TestClientPut(t *testing.T) {
 		}
 	}
 }
+
+func TestAerospikeHealthCheck(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inClient      AerospikeDB
+		expectedError bool
+	}{
+		{
+			desc:     "Client is connected to the cluster",
+			inClient: &GoodAerospikeClient{},
+		},
+		{
+			desc:          "Client lost connection to every cluster node",
+			inClient:      &ErrorProneAerospikeClient{ServerError: "TEST_DISCONNECTED"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := NewMockAerospikeBackend(tc.inClient).HealthCheck(context.Background())
+		assert.Equal(t, tc.expectedError, err != nil, tc.desc)
+	}
+}
+
+func TestGenerateHostsListTLSName(t *testing.T) {
+	hosts, err := generateHostsList(config.Aerospike{
+		Hosts: []string{"foo.com", "bar.com"},
+		Port:  3000,
+		TLS:   config.AerospikeTLS{Enabled: true, Name: "aerospike.internal"},
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, hosts, 2) {
+		assert.Equal(t, "aerospike.internal", hosts[0].TLSName)
+		assert.Equal(t, "aerospike.internal", hosts[1].TLSName)
+	}
+}
+
+// recordingAerospikeClient stores the values in memory and records the arguments of the last calls
+type recordingAerospikeClient struct {
+	GoodAerospikeClient
+	setName     string
+	binNames    []string
+	readPolicy  *as.BasePolicy
+	writePolicy *as.WritePolicy
+	bins        as.BinMap
+}
+
+func (c *recordingAerospikeClient) NewUUIDKey(namespace string, setName string, key string) (*as.Key, error) {
+	c.setName = setName
+	return as.NewKey(namespace, setName, key)
+}
+
+func (c *recordingAerospikeClient) Get(policy *as.BasePolicy, key *as.Key, binNames ...string) (*as.Record, error) {
+	c.readPolicy = policy
+	c.binNames = binNames
+	return &as.Record{Bins: c.bins}, nil
+}
+
+func (c *recordingAerospikeClient) Put(policy *as.WritePolicy, key *as.Key, binMap as.BinMap) error {
+	c.writePolicy = policy
+	c.bins = binMap
+	return nil
+}
+
+func TestAerospikeSetBinNamesAndPolicies(t *testing.T) {
+	cfg := config.Aerospike{
+		Namespace:               "prebid",
+		SetName:                 "cache",
+		BinName:                 "payload",
+		MaxWriteRetries:         1,
+		ReadTotalTimeoutMillis:  100,
+		WriteTotalTimeoutMillis: 150,
+	}
+	client := &recordingAerospikeClient{}
+	backend := &AerospikeBackend{
+		namespace:   cfg.Namespace,
+		setName:     cfg.SetName,
+		binName:     cfg.BinName,
+		readPolicy:  generateAerospikeReadPolicy(cfg),
+		writePolicy: generateAerospikeWritePolicy(cfg),
+		client:      client,
+	}
+
+	err := backend.Put(context.Background(), "key", "value", 60)
+	assert.NoError(t, err)
+	assert.Equal(t, "cache", client.setName)
+	assert.Equal(t, as.BinMap{"payload": "value"}, client.bins)
+	assert.Equal(t, uint32(60), client.writePolicy.Expiration)
+	assert.Equal(t, as.CREATE_ONLY, client.writePolicy.RecordExistsAction)
+	assert.Equal(t, 1, client.writePolicy.MaxRetries)
+	assert.Equal(t, 150*time.Millisecond, client.writePolicy.TotalTimeout)
+	assert.Equal(t, uint32(0), backend.writePolicy.Expiration, "The expiration of a call must not leak into the next ones")
+
+	value, err := backend.Get(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, []string{"payload"}, client.binNames)
+	assert.Equal(t, 100*time.Millisecond, client.readPolicy.TotalTimeout)
+}
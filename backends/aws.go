@@ -0,0 +1,58 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// loadAWSConfig loads the configuration of the AWS SDK clients. The configured static credentials are
+// used if any, or else the default chain of the SDK: environment variables, shared config and
+// credentials files and their profiles, web identity tokens as used by IRSA, and the ECS and EC2
+// instance metadata endpoints. Requests time out after timeoutMillis, keep up to maxIdleConns
+// connections open and aren't retried, as a cached value is only worth so much waiting.
+func loadAWSConfig(ctx context.Context, region, accessKeyID, secretAccessKey, sessionToken string, timeoutMillis int, maxIdleConns int) (aws.Config, error) {
+	httpClient := awshttp.NewBuildableClient().
+		WithTimeout(time.Duration(timeoutMillis) * time.Millisecond).
+		WithTransportOptions(func(transport *http.Transport) {
+			transport.MaxIdleConns = maxIdleConns
+			transport.MaxIdleConnsPerHost = maxIdleConns
+		})
+
+	options := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(region),
+		awsconfig.WithHTTPClient(httpClient),
+		awsconfig.WithRetryMaxAttempts(1),
+	}
+	if accessKeyID != "" {
+		options = append(options, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, sessionToken)))
+	}
+	return awsconfig.LoadDefaultConfig(ctx, options...)
+}
+
+// awsStatusCode returns the HTTP status code of the response an AWS SDK error was built from, or zero
+// if the request got no response
+func awsStatusCode(err error) int {
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode()
+	}
+	return 0
+}
+
+// classifyAWSTimeout turns the client timeouts into context.DeadlineExceeded, so a slow service gets
+// reported like an expired request context
+func classifyAWSTimeout(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
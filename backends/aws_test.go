@@ -0,0 +1,71 @@
+package backends
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAWSConfig(t *testing.T) {
+	credentialsFile := filepath.Join(t.TempDir(), "credentials")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte("[cache]\naws_access_key_id = profile-key\naws_secret_access_key = profile-secret\n"), 0600))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	t.Setenv("AWS_SESSION_TOKEN", "env-token")
+
+	testCases := []struct {
+		desc                string
+		profile             string
+		inAccessKeyID       string
+		inSecretAccessKey   string
+		expectedCredentials aws.Credentials
+	}{
+		{
+			desc:                "Configured credentials come first",
+			inAccessKeyID:       "key",
+			inSecretAccessKey:   "secret",
+			expectedCredentials: aws.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"},
+		},
+		{
+			desc:                "Environment variables come next in the default chain",
+			expectedCredentials: aws.Credentials{AccessKeyID: "env-key", SecretAccessKey: "env-secret", SessionToken: "env-token"},
+		},
+		{
+			desc:                "Profiles of the shared credentials file",
+			profile:             "cache",
+			expectedCredentials: aws.Credentials{AccessKeyID: "profile-key", SecretAccessKey: "profile-secret"},
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.profile != "" {
+			// Variables set along with a profile take precedence over it
+			t.Setenv("AWS_ACCESS_KEY_ID", "")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+			t.Setenv("AWS_SESSION_TOKEN", "")
+			t.Setenv("AWS_PROFILE", tc.profile)
+		}
+
+		awsConfig, err := loadAWSConfig(context.Background(), "us-east-1", tc.inAccessKeyID, tc.inSecretAccessKey, "", 1000, 10)
+		if !assert.NoError(t, err, tc.desc) {
+			continue
+		}
+		assert.Equal(t, "us-east-1", awsConfig.Region, tc.desc)
+		assert.Equal(t, 1, awsConfig.RetryMaxAttempts, "Requests aren't retried: "+tc.desc)
+
+		credentials, err := awsConfig.Credentials.Retrieve(context.Background())
+		if assert.NoError(t, err, tc.desc) {
+			assert.Equal(t, tc.expectedCredentials.AccessKeyID, credentials.AccessKeyID, tc.desc)
+			assert.Equal(t, tc.expectedCredentials.SecretAccessKey, credentials.SecretAccessKey, tc.desc)
+			assert.Equal(t, tc.expectedCredentials.SessionToken, credentials.SessionToken, tc.desc)
+		}
+	}
+}
@@ -9,3 +9,18 @@ type Backend interface {
 	Put(ctx context.Context, key string, value string, ttlSeconds int) error
 	Get(ctx context.Context, key string) (string, error)
 }
+
+// HealthChecker is an optional interface implemented by backends that can tell whether the storage
+// service they communicate with is reachable. Decorators forward health checks to their delegate.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// CheckHealth runs the backend health check if it implements HealthChecker. Backends that can't
+// check their storage service are assumed to be healthy.
+func CheckHealth(ctx context.Context, backend Backend) error {
+	if checker, ok := backend.(HealthChecker); ok {
+		return checker.HealthCheck(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package backends
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	boltFileName = "prebid-cache.db"
+	// boltCompactionBatchSize bounds the number of expired values deleted per transaction, so the
+	// compactor doesn't block puts for long
+	boltCompactionBatchSize = 1000
+)
+
+var (
+	// boltValuesBucket maps keys to their expiration time followed by their value
+	boltValuesBucket = []byte("values")
+	// boltExpiriesBucket indexes keys by expiration time, so the expired ones can be found in order
+	boltExpiriesBucket = []byte("expiries")
+	// boltMetaBucket holds the size of the stored keys and values
+	boltMetaBucket = []byte("meta")
+	boltSizeKey    = []byte("size")
+)
+
+// BoltBackend implements the Backend interface and stores values in a bbolt database file on the
+// local disk, so they survive restarts. bbolt doesn't expire values by itself, so expiration times
+// are kept in an index that a background compactor walks to delete the expired values.
+type BoltBackend struct {
+	db      *bolt.DB
+	maxSize uint64
+	now     func() time.Time
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewBoltBackend opens or creates the database file in cfg.DataDir and starts the compactor
+func NewBoltBackend(cfg config.Bolt) *BoltBackend {
+	backend, err := newBoltBackend(cfg, time.Now)
+	if err != nil {
+		log.Fatalf("Error creating Bolt backend: %v", err)
+		panic("BoltBackend failure. This shouldn't happen.")
+	}
+	log.Infof("Prebid Cache will write to %s", backend.db.Path())
+
+	go backend.runCompactor(time.Duration(cfg.CompactionIntervalSeconds) * time.Second)
+	return backend
+}
+
+func newBoltBackend(cfg config.Bolt, now func() time.Time) (*BoltBackend, error) {
+	if err := os.MkdirAll(cfg.DataDir, 0750); err != nil {
+		return nil, err
+	}
+	// The timeout keeps a second process from waiting forever on the lock of the file
+	db, err := bolt.Open(filepath.Join(cfg.DataDir, boltFileName), 0640, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{boltValuesBucket, boltExpiriesBucket, boltMetaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltBackend{
+		db:      db,
+		maxSize: uint64(cfg.MaxSizeMB) * 1024 * 1024,
+		now:     now,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Get returns the value stored under key, or a KEY_NOT_FOUND error if there's none or it expired
+func (b *BoltBackend) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := b.db.View(func(tx *bolt.Tx) error {
+		entry := tx.Bucket(boltValuesBucket).Get([]byte(key))
+		if entry == nil || b.expired(entry) {
+			return utils.NewPBCError(utils.KEY_NOT_FOUND)
+		}
+		// The entry is only valid during the transaction, so copy it
+		value = string(entry[8:])
+		return nil
+	})
+	return value, err
+}
+
+// Put stores value under key for ttlSeconds, or forever if ttlSeconds isn't positive. It returns a
+// RECORD_EXISTS error if key holds a value that didn't expire yet.
+func (b *BoltBackend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
+	var expiration int64
+	if ttlSeconds > 0 {
+		expiration = b.now().Add(time.Duration(ttlSeconds) * time.Second).UnixNano()
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		values := tx.Bucket(boltValuesBucket)
+		size := b.size(tx)
+
+		if entry := values.Get([]byte(key)); entry != nil {
+			if !b.expired(entry) {
+				return utils.NewPBCError(utils.RECORD_EXISTS)
+			}
+			// The compactor didn't get to delete the expired value yet
+			if err := deleteBoltEntry(tx, []byte(key), entry); err != nil {
+				return err
+			}
+			size -= uint64(len(key) + len(entry) - 8)
+		}
+
+		size += uint64(len(key) + len(value))
+		if b.maxSize > 0 && size > b.maxSize {
+			return utils.NewPBCError(utils.PUT_INTERNAL_SERVER, fmt.Sprintf("Bolt error. The store reached its size limit of %d bytes", b.maxSize))
+		}
+
+		entry := make([]byte, 8+len(value))
+		binary.BigEndian.PutUint64(entry, uint64(expiration))
+		copy(entry[8:], value)
+		if err := values.Put([]byte(key), entry); err != nil {
+			return err
+		}
+		if expiration > 0 {
+			if err := tx.Bucket(boltExpiriesBucket).Put(boltExpiryKey(expiration, []byte(key)), nil); err != nil {
+				return err
+			}
+		}
+		return b.setSize(tx, size)
+	})
+}
+
+// HealthCheck makes sure the database file is open and readable
+func (b *BoltBackend) HealthCheck(ctx context.Context) error {
+	return b.db.View(func(tx *bolt.Tx) error { return nil })
+}
+
+// Close stops the compactor and closes the database file
+func (b *BoltBackend) Close() error {
+	close(b.stop)
+	<-b.done
+	return b.db.Close()
+}
+
+// runCompactor deletes the expired values every interval until the backend gets closed
+func (b *BoltBackend) runCompactor(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			if deleted, err := b.compact(); err != nil {
+				log.Errorf("Bolt compaction error after deleting %d expired values: %v", deleted, err)
+			} else if deleted > 0 {
+				log.Debugf("Bolt compaction deleted %d expired values", deleted)
+			}
+		}
+	}
+}
+
+// compact deletes the values that expired, in batches of boltCompactionBatchSize, and returns how
+// many it deleted
+func (b *BoltBackend) compact() (int, error) {
+	now := b.now().UnixNano()
+	deleted := 0
+	for {
+		batch := 0
+		err := b.db.Update(func(tx *bolt.Tx) error {
+			// Deleting while iterating makes bbolt cursors skip keys, so collect the expired ones first
+			var expiredKeys [][]byte
+			cursor := tx.Bucket(boltExpiriesBucket).Cursor()
+			for indexKey, _ := cursor.First(); indexKey != nil && len(expiredKeys) < boltCompactionBatchSize; indexKey, _ = cursor.Next() {
+				if int64(binary.BigEndian.Uint64(indexKey)) > now {
+					break
+				}
+				expiredKeys = append(expiredKeys, append([]byte(nil), indexKey...))
+			}
+
+			values := tx.Bucket(boltValuesBucket)
+			size := b.size(tx)
+			for _, indexKey := range expiredKeys {
+				key := indexKey[8:]
+				if entry := values.Get(key); entry != nil {
+					size -= uint64(len(key) + len(entry) - 8)
+					if err := values.Delete(key); err != nil {
+						return err
+					}
+				}
+				if err := tx.Bucket(boltExpiriesBucket).Delete(indexKey); err != nil {
+					return err
+				}
+			}
+			batch = len(expiredKeys)
+			return b.setSize(tx, size)
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += batch
+		if batch < boltCompactionBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// expired tells whether the entry of the values bucket has an expiration time that's past
+func (b *BoltBackend) expired(entry []byte) bool {
+	expiration := int64(binary.BigEndian.Uint64(entry))
+	return expiration > 0 && expiration <= b.now().UnixNano()
+}
+
+// size returns the size of the stored keys and values
+func (b *BoltBackend) size(tx *bolt.Tx) uint64 {
+	if size := tx.Bucket(boltMetaBucket).Get(boltSizeKey); size != nil {
+		return binary.BigEndian.Uint64(size)
+	}
+	return 0
+}
+
+func (b *BoltBackend) setSize(tx *bolt.Tx, size uint64) error {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, size)
+	return tx.Bucket(boltMetaBucket).Put(boltSizeKey, buf)
+}
+
+// deleteBoltEntry deletes key, whose entry in the values bucket is entry, along with its index key
+func deleteBoltEntry(tx *bolt.Tx, key []byte, entry []byte) error {
+	if err := tx.Bucket(boltValuesBucket).Delete(key); err != nil {
+		return err
+	}
+	expiration := int64(binary.BigEndian.Uint64(entry))
+	if expiration == 0 {
+		return nil
+	}
+	return tx.Bucket(boltExpiriesBucket).Delete(boltExpiryKey(expiration, key))
+}
+
+// boltExpiryKey returns the key of the expiries bucket of key, which sorts by expiration time
+func boltExpiryKey(expiration int64, key []byte) []byte {
+	indexKey := make([]byte, 8+len(key))
+	binary.BigEndian.PutUint64(indexKey, uint64(expiration))
+	copy(indexKey[8:], key)
+	return indexKey
+}
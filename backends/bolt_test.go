@@ -0,0 +1,133 @@
+package backends
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+// fakeClock lets tests move time forward to expire values
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestBoltBackend(t *testing.T, cfg config.Bolt, clock *fakeClock) *BoltBackend {
+	backend, err := newBoltBackend(cfg, clock.Now)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	close(backend.done)
+	t.Cleanup(func() { backend.db.Close() })
+	return backend
+}
+
+func TestBoltGetAndPut(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	backend := newTestBoltBackend(t, config.Bolt{DataDir: t.TempDir()}, clock)
+	ctx := context.Background()
+
+	_, err := backend.Get(ctx, "someKey")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of a missing key")
+
+	assert.NoError(t, backend.Put(ctx, "someKey", "someValue", 60), "Put of a new key")
+
+	value, err := backend.Get(ctx, "someKey")
+	assert.NoError(t, err, "Get of an existing key")
+	assert.Equal(t, "someValue", value, "Get of an existing key")
+
+	err = backend.Put(ctx, "someKey", "otherValue", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "Put of an existing key")
+
+	clock.now = clock.now.Add(time.Minute)
+
+	_, err = backend.Get(ctx, "someKey")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of an expired key")
+
+	assert.NoError(t, backend.Put(ctx, "someKey", "otherValue", 60), "Put of an expired key")
+	value, err = backend.Get(ctx, "someKey")
+	assert.NoError(t, err, "Get of a key put again after it expired")
+	assert.Equal(t, "otherValue", value, "Get of a key put again after it expired")
+
+	assert.NoError(t, backend.HealthCheck(ctx), "Health check")
+}
+
+func TestBoltCompact(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	backend := newTestBoltBackend(t, config.Bolt{DataDir: t.TempDir()}, clock)
+	ctx := context.Background()
+
+	for i := 0; i < boltCompactionBatchSize+10; i++ {
+		assert.NoError(t, backend.Put(ctx, "short"+time.Duration(i).String(), "value", 10))
+	}
+	assert.NoError(t, backend.Put(ctx, "long", "value", 120))
+	assert.NoError(t, backend.Put(ctx, "forever", "value", 0))
+
+	deleted, err := backend.compact()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, deleted, "Nothing expired yet")
+
+	clock.now = clock.now.Add(time.Minute)
+	deleted, err = backend.compact()
+	assert.NoError(t, err)
+	assert.Equal(t, boltCompactionBatchSize+10, deleted, "Values expired in more than one batch get deleted")
+
+	backend.db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, 2, tx.Bucket(boltValuesBucket).Stats().KeyN, "Values that didn't expire are kept")
+		assert.Equal(t, 1, tx.Bucket(boltExpiriesBucket).Stats().KeyN, "Values that never expire aren't indexed")
+		assert.Equal(t, uint64(len("long")+len("value")+len("forever")+len("value")), backend.size(tx), "Size accounts for the deleted values")
+		return nil
+	})
+
+	value, err := backend.Get(ctx, "long")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func TestBoltSizeLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	backend := newTestBoltBackend(t, config.Bolt{DataDir: t.TempDir(), MaxSizeMB: 1}, clock)
+	ctx := context.Background()
+
+	largeValue := string(make([]byte, 600*1024))
+	assert.NoError(t, backend.Put(ctx, "first", largeValue, 10))
+
+	err := backend.Put(ctx, "second", largeValue, 10)
+	assert.Equal(t, utils.NewPBCError(utils.PUT_INTERNAL_SERVER, "Bolt error. The store reached its size limit of 1048576 bytes"), err)
+
+	clock.now = clock.now.Add(time.Minute)
+	_, err = backend.compact()
+	assert.NoError(t, err)
+
+	assert.NoError(t, backend.Put(ctx, "second", largeValue, 10), "Space gets freed when values expire")
+}
+
+func TestBoltPersistence(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	cfg := config.Bolt{DataDir: filepath.Join(t.TempDir(), "nested", "dir")}
+
+	backend, err := newBoltBackend(cfg, clock.Now)
+	if !assert.NoError(t, err, "The data directory gets created") {
+		return
+	}
+	assert.NoError(t, backend.Put(context.Background(), "someKey", "someValue", 60))
+	assert.NoError(t, backend.db.Close())
+
+	backend = newTestBoltBackend(t, cfg, clock)
+	value, err := backend.Get(context.Background(), "someKey")
+	assert.NoError(t, err, "Values survive restarts")
+	assert.Equal(t, "someValue", value, "Values survive restarts")
+}
+
+func TestBoltCompactor(t *testing.T) {
+	backend := NewBoltBackend(config.Bolt{DataDir: t.TempDir(), CompactionIntervalSeconds: 1})
+	assert.NoError(t, backend.Close(), "Close stops the compactor and closes the database")
+	assert.Error(t, backend.HealthCheck(context.Background()), "Health checks fail once the database is closed")
+}
@@ -2,6 +2,11 @@ package backends
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/prebid/prebid-cache/config"
@@ -15,14 +20,17 @@ type CassandraDB interface {
 	Init() error
 	Get(ctx context.Context, key string) (string, error)
 	Put(ctx context.Context, key string, value string, ttlSeconds int) (bool, error)
+	Closed() bool
 }
 
 // CassandraDBClient is a wrapper for the Cassandra client 'gocql' that
 // interacts with the Cassandra server and implements the CassandraDB interface
 type CassandraDBClient struct {
-	cfg     config.Cassandra
-	cluster *gocql.ClusterConfig
-	session *gocql.Session
+	cfg              config.Cassandra
+	cluster          *gocql.ClusterConfig
+	session          *gocql.Session
+	readConsistency  gocql.Consistency
+	writeConsistency gocql.Consistency
 }
 
 // Get returns the value associated with the provided `key` parameter
@@ -31,7 +39,7 @@ func (c *CassandraDBClient) Get(ctx context.Context, key string) (string, error)
 
 	err := c.session.Query(`SELECT value FROM cache WHERE key = ? LIMIT 1`, key).
 		WithContext(ctx).
-		Consistency(gocql.One).
+		Consistency(c.readConsistency).
 		Scan(&res)
 
 	return res, err
@@ -45,22 +53,144 @@ func (c *CassandraDBClient) Put(ctx context.Context, key string, value string, t
 
 	return c.session.Query(`INSERT INTO cache (key, value) VALUES (?, ?) IF NOT EXISTS USING TTL ?`, key, value, ttlSeconds).
 		WithContext(ctx).
+		Consistency(c.writeConsistency).
 		ScanCAS(&insertedKey, &insertedValue)
 }
 
+// Closed tells whether the Cassandra session was never created or has been closed
+func (c *CassandraDBClient) Closed() bool {
+	return c.session == nil || c.session.Closed()
+}
+
 // Init initializes Cassandra cluster and session with the configuration
 // loaded from environment variables or configuration files at startup
 func (c *CassandraDBClient) Init() error {
-	c.cluster = gocql.NewCluster(c.cfg.Hosts)
-	c.cluster.Keyspace = c.cfg.Keyspace
-	c.cluster.Consistency = gocql.LocalOne
-
 	var err error
+	if c.readConsistency, err = parseCassandraConsistency(c.cfg.ReadConsistency, gocql.One); err != nil {
+		return err
+	}
+	if c.writeConsistency, err = parseCassandraConsistency(c.cfg.WriteConsistency, gocql.LocalOne); err != nil {
+		return err
+	}
+
+	if c.cluster, err = newCassandraCluster(c.cfg); err != nil {
+		return err
+	}
+
+	if c.cfg.CreateSchemaOnStart {
+		if err := createCassandraSchema(c.cluster, c.cfg); err != nil {
+			return err
+		}
+	}
+
 	c.session, err = c.cluster.CreateSession()
 
 	return err
 }
 
+// newCassandraCluster returns the gocql cluster configuration that corresponds to config.Cassandra
+func newCassandraCluster(cfg config.Cassandra) (*gocql.ClusterConfig, error) {
+	cluster := gocql.NewCluster(cfg.Hosts)
+	cluster.Keyspace = cfg.Keyspace
+	cluster.Consistency = gocql.LocalOne
+	cluster.ProtoVersion = cfg.ProtocolVersion
+
+	if cfg.TimeoutMillis > 0 {
+		cluster.Timeout = time.Duration(cfg.TimeoutMillis) * time.Millisecond
+	}
+	if cfg.ConnectTimeoutMillis > 0 {
+		cluster.ConnectTimeout = time.Duration(cfg.ConnectTimeoutMillis) * time.Millisecond
+	}
+
+	if cfg.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}
+	}
+
+	// Queries go to a replica of the key in the local data center whenever possible
+	if cfg.LocalDC != "" {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy(cfg.LocalDC))
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig := &tls.Config{InsecureSkipVerify: cfg.TLS.InsecureSkipVerify}
+		if cfg.TLS.CAFile != "" {
+			pool, err := utils.LoadCertPool(cfg.TLS.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.TLS.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to load key pair %s, %s: %v", cfg.TLS.CertFile, cfg.TLS.KeyFile, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		// Host verification is driven by tlsConfig.InsecureSkipVerify
+		cluster.SslOpts = &gocql.SslOptions{Config: tlsConfig}
+	}
+
+	return cluster, nil
+}
+
+// parseCassandraConsistency returns the consistency level named level, or defaultLevel if empty
+func parseCassandraConsistency(level string, defaultLevel gocql.Consistency) (gocql.Consistency, error) {
+	if level == "" {
+		return defaultLevel, nil
+	}
+	consistency, err := gocql.ParseConsistencyWrapper(level)
+	if err != nil {
+		return defaultLevel, fmt.Errorf("Invalid Cassandra consistency level %s: %v", level, err)
+	}
+	return consistency, nil
+}
+
+// cassandraSchema returns the statements that create the keyspace and the cache table. Values
+// are written with a TTL, so the table gets compacted by time windows: expired entries are dropped
+// a whole SSTable at a time instead of being rewritten over and over.
+func cassandraSchema(cfg config.Cassandra) []string {
+	replication := fmt.Sprintf("'class': 'SimpleStrategy', 'replication_factor': %d", cfg.ReplicationFactor)
+	if cfg.LocalDC != "" {
+		replication = fmt.Sprintf("'class': 'NetworkTopologyStrategy', '%s': %d", strings.ReplaceAll(cfg.LocalDC, "'", "''"), cfg.ReplicationFactor)
+	}
+
+	return []string{
+		fmt.Sprintf(`CREATE KEYSPACE IF NOT EXISTS %s WITH replication = {%s}`, cfg.Keyspace, replication),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.cache (
+    key text,
+    value text,
+    PRIMARY KEY (key)
+) WITH default_time_to_live = %d
+    AND gc_grace_seconds = 10800
+    AND compaction = {'class': 'TimeWindowCompactionStrategy', 'compaction_window_unit': 'HOURS', 'compaction_window_size': 1}`, cfg.Keyspace, cfg.DefaultTTL),
+	}
+}
+
+// createCassandraSchema creates the keyspace and the cache table if they don't exist yet. The session
+// isn't bound to the keyspace as it may not exist.
+func createCassandraSchema(cluster *gocql.ClusterConfig, cfg config.Cassandra) error {
+	schemaCluster := *cluster
+	schemaCluster.Keyspace = ""
+
+	session, err := schemaCluster.CreateSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	for _, statement := range cassandraSchema(cfg) {
+		if err := session.Query(statement).Exec(); err != nil {
+			return fmt.Errorf("Failed to create the Cassandra schema: %v", err)
+		}
+	}
+	log.Infof("Cassandra keyspace %s and table %s.cache are ready", cfg.Keyspace, cfg.Keyspace)
+	return nil
+}
+
 // CassandraBackend implements the Backend interface and get called from
 // our Prebid Cache's endpoint handle functions.
 type CassandraBackend struct {
@@ -106,3 +236,11 @@ func (back *CassandraBackend) Put(ctx context.Context, key string, value string,
 	}
 	return err
 }
+
+// HealthCheck makes sure the Cassandra session is still open
+func (back *CassandraBackend) HealthCheck(ctx context.Context) error {
+	if back.client.Closed() {
+		return errors.New("Cassandra session is closed")
+	}
+	return nil
+}
@@ -3,10 +3,14 @@ package backends
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gocql/gocql"
+	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/utils"
+	"github.com/prebid/prebid-cache/utils/certtest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -171,3 +175,140 @@ func TestCassandraClientPut(t *testing.T) {
 		}
 	}
 }
+
+func TestCassandraHealthCheck(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inClient      CassandraDB
+		expectedError bool
+	}{
+		{
+			desc:     "Session is open",
+			inClient: &GoodCassandraClient{},
+		},
+		{
+			desc:          "Session was closed",
+			inClient:      &ErrorProneCassandraClient{},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := NewMockCassandraBackend(0, tc.inClient).HealthCheck(context.Background())
+		assert.Equal(t, tc.expectedError, err != nil, tc.desc)
+	}
+
+	// A client that never got its session initialized is closed
+	assert.True(t, (&CassandraDBClient{}).Closed())
+}
+
+func TestNewCassandraCluster(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	caFile := ca.WriteCA(t, dir)
+	pair := ca.Issue(t, dir, "client")
+
+	t.Run("defaults", func(t *testing.T) {
+		cluster, err := newCassandraCluster(config.Cassandra{Hosts: "127.0.0.1", Keyspace: "prebid"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"127.0.0.1"}, cluster.Hosts)
+		assert.Equal(t, "prebid", cluster.Keyspace)
+		assert.Equal(t, 0, cluster.ProtoVersion)
+		assert.Equal(t, 600*time.Millisecond, cluster.Timeout)
+		assert.Nil(t, cluster.Authenticator)
+		assert.Nil(t, cluster.PoolConfig.HostSelectionPolicy)
+		assert.Nil(t, cluster.SslOpts)
+	})
+
+	t.Run("authentication, data center, protocol and timeouts", func(t *testing.T) {
+		cluster, err := newCassandraCluster(config.Cassandra{
+			Hosts:                "127.0.0.1",
+			Username:             "user",
+			Password:             "secret",
+			LocalDC:              "dc1",
+			ProtocolVersion:      4,
+			TimeoutMillis:        200,
+			ConnectTimeoutMillis: 1000,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, gocql.PasswordAuthenticator{Username: "user", Password: "secret"}, cluster.Authenticator)
+		assert.NotNil(t, cluster.PoolConfig.HostSelectionPolicy)
+		assert.Equal(t, 4, cluster.ProtoVersion)
+		assert.Equal(t, 200*time.Millisecond, cluster.Timeout)
+		assert.Equal(t, time.Second, cluster.ConnectTimeout)
+	})
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		cluster, err := newCassandraCluster(config.Cassandra{
+			Hosts: "127.0.0.1",
+			TLS:   config.CassandraTLS{Enabled: true, CAFile: caFile, CertFile: pair.CertFile, KeyFile: pair.KeyFile},
+		})
+
+		if assert.NoError(t, err) && assert.NotNil(t, cluster.SslOpts) {
+			assert.NotNil(t, cluster.SslOpts.Config.RootCAs)
+			assert.Len(t, cluster.SslOpts.Config.Certificates, 1)
+			assert.False(t, cluster.SslOpts.Config.InsecureSkipVerify)
+		}
+	})
+
+	t.Run("missing CA file", func(t *testing.T) {
+		_, err := newCassandraCluster(config.Cassandra{
+			Hosts: "127.0.0.1",
+			TLS:   config.CassandraTLS{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseCassandraConsistency(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inLevel       string
+		expected      gocql.Consistency
+		expectedError bool
+	}{
+		{desc: "Empty level falls back to the default", inLevel: "", expected: gocql.One},
+		{desc: "Lowercase level", inLevel: "local_quorum", expected: gocql.LocalQuorum},
+		{desc: "Uppercase level", inLevel: "EACH_QUORUM", expected: gocql.EachQuorum},
+		{desc: "Unknown level", inLevel: "most", expected: gocql.One, expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		consistency, err := parseCassandraConsistency(tc.inLevel, gocql.One)
+
+		assert.Equal(t, tc.expected, consistency, tc.desc)
+		assert.Equal(t, tc.expectedError, err != nil, tc.desc)
+	}
+}
+
+func TestCassandraSchema(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		inCfg            config.Cassandra
+		expectedKeyspace string
+	}{
+		{
+			desc:             "Single data center",
+			inCfg:            config.Cassandra{Keyspace: "prebid", DefaultTTL: 2400, ReplicationFactor: 1},
+			expectedKeyspace: "CREATE KEYSPACE IF NOT EXISTS prebid WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1}",
+		},
+		{
+			desc:             "Local data center",
+			inCfg:            config.Cassandra{Keyspace: "prebid", DefaultTTL: 2400, ReplicationFactor: 3, LocalDC: "dc1"},
+			expectedKeyspace: "CREATE KEYSPACE IF NOT EXISTS prebid WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': 3}",
+		},
+	}
+
+	for _, tc := range testCases {
+		statements := cassandraSchema(tc.inCfg)
+
+		if assert.Len(t, statements, 2, tc.desc) {
+			assert.Equal(t, tc.expectedKeyspace, statements[0], tc.desc)
+			assert.Contains(t, statements[1], "CREATE TABLE IF NOT EXISTS prebid.cache", tc.desc)
+			assert.Contains(t, statements[1], "default_time_to_live = 2400", tc.desc)
+			assert.Contains(t, statements[1], "'class': 'TimeWindowCompactionStrategy'", tc.desc)
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 func NewBackend(cfg config.Configuration, appMetrics *metrics.Metrics) backends.Backend {
 	backend := newBaseBackend(cfg.Backend, appMetrics)
+	backend = applyShadow(cfg.Backend, appMetrics, backend)
 	backend = DecorateBackend(cfg, appMetrics, backend)
 
 	return backend
@@ -27,9 +28,13 @@ func DecorateBackend(cfg config.Configuration, appMetrics *metrics.Metrics, back
 		backend = decorators.EnforceSizeLimit(backend, cfg.RequestLimits.MaxSize)
 	}
 	// Metrics must be taken _before_ compression because it relies on the
-	// "json" or "xml" prefix on the payload. Compression might munge this.
+	// content type prefix on the payload. Compression might munge this.
 	// We should re-work this strategy at some point.
-	backend = decorators.LogMetrics(backend, appMetrics)
+	contentTypes, err := config.NewContentTypes(cfg.ContentTypes)
+	if err != nil {
+		log.Fatalf("Error creating the content types: %v", err)
+	}
+	backend = decorators.LogMetrics(backend, appMetrics, contentTypes)
 	backend = decorators.LimitTTLs(backend, getMaxTTLSeconds(cfg))
 
 	return backend
@@ -65,6 +70,20 @@ func newBaseBackend(cfg config.Backend, appMetrics *metrics.Metrics) backends.Ba
 		return backends.NewRedisBackend(cfg.Redis, ctx)
 	case config.BackendIgnite:
 		return backends.NewIgniteBackend(cfg.Ignite)
+	case config.BackendBolt:
+		return backends.NewBoltBackend(cfg.Bolt)
+	case config.BackendPostgres:
+		return backends.NewPostgresBackend(cfg.Postgres)
+	case config.BackendS3:
+		return backends.NewS3Backend(cfg.S3)
+	case config.BackendDynamoDB:
+		return backends.NewDynamoDBBackend(cfg.DynamoDB)
+	case config.BackendNATS:
+		return backends.NewNATSBackend(cfg.NATS)
+	case config.BackendMigrate:
+		return newMigrateBackend(cfg, appMetrics)
+	case config.BackendRouted:
+		return newRoutedBackend(cfg, appMetrics)
 	default:
 		log.Fatalf("Unknown backend type: %s", cfg.Type)
 	}
@@ -72,6 +91,39 @@ func newBaseBackend(cfg config.Backend, appMetrics *metrics.Metrics) backends.Ba
 	panic("Error creating backend. This shouldn't happen.")
 }
 
+// applyShadow replays a sample of the traffic to backend against the candidate one, if enabled. The
+// candidate gets the values as stored by the primary backend, compression included.
+func applyShadow(cfg config.Backend, appMetrics *metrics.Metrics, backend backends.Backend) backends.Backend {
+	if !cfg.Shadow.Enabled {
+		return backend
+	}
+
+	candidateCfg := cfg
+	candidateCfg.Type = cfg.Shadow.Type
+	return decorators.Shadow(backend, newBaseBackend(candidateCfg, appMetrics), cfg.Shadow, appMetrics)
+}
+
+// newMigrateBackend creates the backends to migrate from and to out of their respective sections of cfg
+func newMigrateBackend(cfg config.Backend, appMetrics *metrics.Metrics) backends.Backend {
+	oldCfg, newCfg := cfg, cfg
+	oldCfg.Type = cfg.Migrate.From
+	newCfg.Type = cfg.Migrate.To
+
+	return backends.NewMigrateBackend(cfg.Migrate, newBaseBackend(newCfg, appMetrics), newBaseBackend(oldCfg, appMetrics), appMetrics)
+}
+
+// newRoutedBackend creates each of the backends values get routed to, once, out of its section of cfg
+func newRoutedBackend(cfg config.Backend, appMetrics *metrics.Metrics) backends.Backend {
+	routedBackends := make(map[config.BackendType]backends.Backend)
+	for _, backendType := range cfg.Routed.BackendTypes() {
+		childCfg := cfg
+		childCfg.Type = backendType
+		routedBackends[backendType] = newBaseBackend(childCfg, appMetrics)
+	}
+
+	return backends.NewRoutedBackend(cfg.Routed, routedBackends)
+}
+
 // getMaxTTLSeconds was added for backards compatibility. This function will select either
 // config.backend.aerospike.default_ttl_seconds or backend.redis.expiration over
 // config.request_limits.max_ttl_seconds if they are not zero and hold a smaller TTL value
@@ -83,24 +135,41 @@ func newBaseBackend(cfg config.Backend, appMetrics *metrics.Metrics) backends.Ba
 func getMaxTTLSeconds(cfg config.Configuration) int {
 	maxTTLSeconds := cfg.RequestLimits.MaxTTLSeconds
 
+	backendTypes := []config.BackendType{cfg.Backend.Type}
 	switch cfg.Backend.Type {
-	case config.BackendCassandra:
-		// If config.request_limits.max_ttl_seconds was defined to be less than 2400 seconds, go
-		// with 2400 as it has been the TTL limit hardcoded in the Cassandra backend so far.
-		if maxTTLSeconds > utils.CASSANDRA_DEFAULT_TTL_SECONDS {
-			maxTTLSeconds = utils.CASSANDRA_DEFAULT_TTL_SECONDS
-		}
-	case config.BackendAerospike:
-		// If both config.request_limits.max_ttl_seconds and config.backend.aerospike.default_ttl_seconds
-		// were defined, the smallest value takes preference
-		if cfg.Backend.Aerospike.DefaultTTLSecs > 0 && maxTTLSeconds > cfg.Backend.Aerospike.DefaultTTLSecs {
-			maxTTLSeconds = cfg.Backend.Aerospike.DefaultTTLSecs
-		}
-	case config.BackendRedis:
-		// If both config.request_limits.max_ttl_seconds and backend.redis.expiration
-		// were defined, the smallest value takes preference
-		if cfg.Backend.Redis.ExpirationMinutes > 0 && maxTTLSeconds > cfg.Backend.Redis.ExpirationMinutes*60 {
-			maxTTLSeconds = cfg.Backend.Redis.ExpirationMinutes * 60
+	case config.BackendMigrate:
+		// Values get written to the backend being migrated to, so its limits apply
+		backendTypes = []config.BackendType{cfg.Backend.Migrate.To}
+	case config.BackendRouted:
+		// Values can get written to any of the backends routed to, so the smallest of their limits applies
+		backendTypes = cfg.Backend.Routed.BackendTypes()
+	}
+
+	for _, backendType := range backendTypes {
+		switch backendType {
+		case config.BackendCassandra:
+			// If config.request_limits.max_ttl_seconds was defined to be less than 2400 seconds, go
+			// with 2400 as it has been the TTL limit hardcoded in the Cassandra backend so far.
+			if maxTTLSeconds > utils.CASSANDRA_DEFAULT_TTL_SECONDS {
+				maxTTLSeconds = utils.CASSANDRA_DEFAULT_TTL_SECONDS
+			}
+		case config.BackendAerospike:
+			// If both config.request_limits.max_ttl_seconds and config.backend.aerospike.default_ttl_seconds
+			// were defined, the smallest value takes preference
+			if cfg.Backend.Aerospike.DefaultTTLSecs > 0 && maxTTLSeconds > cfg.Backend.Aerospike.DefaultTTLSecs {
+				maxTTLSeconds = cfg.Backend.Aerospike.DefaultTTLSecs
+			}
+		case config.BackendRedis:
+			// If both config.request_limits.max_ttl_seconds and backend.redis.expiration
+			// were defined, the smallest value takes preference
+			if cfg.Backend.Redis.ExpirationMinutes > 0 && maxTTLSeconds > cfg.Backend.Redis.ExpirationMinutes*60 {
+				maxTTLSeconds = cfg.Backend.Redis.ExpirationMinutes * 60
+			}
+		case config.BackendNATS:
+			// The bucket deletes values once they reach its max age, whatever their own TTL
+			if maxTTLSeconds > cfg.Backend.NATS.MaxAgeSeconds {
+				maxTTLSeconds = cfg.Backend.NATS.MaxAgeSeconds
+			}
 		}
 	}
 	return maxTTLSeconds
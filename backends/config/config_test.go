@@ -72,6 +72,32 @@ func TestApplyUnknownCompression(t *testing.T) {
 	assert.Equal(t, expectedLogLevel, hook.Entries[0].Level, "Unexpected log level")
 }
 
+func TestApplyShadow(t *testing.T) {
+	mockMetrics := metricstest.CreateMockMetrics()
+	m := &metrics.Metrics{MetricEngines: []metrics.CacheMetrics{&mockMetrics}}
+	primary := &fakeBackend{}
+
+	// Disabled, the primary backend is used as it is
+	actualBackend := applyShadow(config.Backend{Type: config.BackendMemcache}, m, primary)
+	assert.Equal(t, primary, actualBackend)
+
+	// Enabled, the primary backend gets decorated
+	cfg := config.Backend{
+		Type: config.BackendMemcache,
+		Shadow: config.Shadow{
+			Enabled:       true,
+			Type:          config.BackendMemory,
+			SamplingRate:  1,
+			QueueSize:     10,
+			Workers:       1,
+			TimeoutMillis: 100,
+		},
+	}
+	actualBackend = applyShadow(cfg, m, primary)
+	assert.NotEqual(t, primary, actualBackend)
+	assert.NoError(t, actualBackend.Put(context.Background(), "key", "value", 10))
+}
+
 func TestNewMemoryOrMemcacheBackend(t *testing.T) {
 	testCases := []struct {
 		desc            string
@@ -88,6 +114,25 @@ func TestNewMemoryOrMemcacheBackend(t *testing.T) {
 			inConfig:        config.Backend{Type: config.BackendMemcache},
 			expectedBackend: &backends.MemcacheBackend{},
 		},
+		{
+			desc: "Migrate from Memcache to Memory",
+			inConfig: config.Backend{
+				Type:    config.BackendMigrate,
+				Migrate: config.Migrate{From: config.BackendMemcache, To: config.BackendMemory},
+			},
+			expectedBackend: &backends.MigrateBackend{},
+		},
+		{
+			desc: "Routed between Memory and Memcache",
+			inConfig: config.Backend{
+				Type: config.BackendRouted,
+				Routed: config.Routed{
+					Pointers: config.BackendMemory,
+					Routes:   []config.Route{{Backend: config.BackendMemcache, MinSizeBytes: 1024}},
+				},
+			},
+			expectedBackend: &backends.RoutedBackend{},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -167,6 +212,46 @@ func TestNewBaseBackend(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:          "Bolt",
+			inConfig:      config.Backend{Type: config.BackendBolt},
+			inExpectPanic: true,
+			expectedLogEntries: []logEntry{
+				{msg: "Error creating Bolt backend: ", lvl: logrus.FatalLevel},
+			},
+		},
+		{
+			desc:          "Postgres",
+			inConfig:      config.Backend{Type: config.BackendPostgres},
+			inExpectPanic: true,
+			expectedLogEntries: []logEntry{
+				{msg: "Error creating Postgres backend: ", lvl: logrus.FatalLevel},
+			},
+		},
+		{
+			desc:          "S3",
+			inConfig:      config.Backend{Type: config.BackendS3},
+			inExpectPanic: true,
+			expectedLogEntries: []logEntry{
+				{msg: "Error creating S3 backend: the S3 bucket cannot be empty", lvl: logrus.FatalLevel},
+			},
+		},
+		{
+			desc:          "DynamoDB",
+			inConfig:      config.Backend{Type: config.BackendDynamoDB},
+			inExpectPanic: true,
+			expectedLogEntries: []logEntry{
+				{msg: "Error creating DynamoDB backend: the DynamoDB table cannot be empty", lvl: logrus.FatalLevel},
+			},
+		},
+		{
+			desc:          "NATS",
+			inConfig:      config.Backend{Type: config.BackendNATS},
+			inExpectPanic: true,
+			expectedLogEntries: []logEntry{
+				{msg: "Error creating NATS backend: the NATS servers cannot be empty", lvl: logrus.FatalLevel},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -343,6 +428,108 @@ func TestGetMaxTTLSeconds(t *testing.T) {
 				},
 			},
 		},
+		{
+			groupDesc: "NATS backend",
+			unitTests: []testCases{
+				{
+					desc: "maxTTLSeconds < cfg.Backend.NATS.MaxAgeSeconds",
+					inConfig: config.Configuration{
+						Backend: config.Backend{
+							Type: config.BackendNATS,
+							NATS: config.NATS{
+								MaxAgeSeconds: utils.REQUEST_MAX_TTL_SECONDS,
+							},
+						},
+						RequestLimits: config.RequestLimits{
+							MaxTTLSeconds: 10,
+						},
+					},
+					expectedMaxTTLSeconds: 10,
+				},
+				{
+					desc: "maxTTLSeconds > cfg.Backend.NATS.MaxAgeSeconds",
+					inConfig: config.Configuration{
+						Backend: config.Backend{
+							Type: config.BackendNATS,
+							NATS: config.NATS{
+								MaxAgeSeconds: SIXTY_SECONDS,
+							},
+						},
+						RequestLimits: config.RequestLimits{
+							MaxTTLSeconds: utils.REQUEST_MAX_TTL_SECONDS,
+						},
+					},
+					expectedMaxTTLSeconds: SIXTY_SECONDS,
+				},
+			},
+		},
+		{
+			groupDesc: "Migrate backend",
+			unitTests: []testCases{
+				{
+					desc: "The limits of the backend being migrated to apply",
+					inConfig: config.Configuration{
+						Backend: config.Backend{
+							Type: config.BackendMigrate,
+							Migrate: config.Migrate{
+								From: config.BackendMemcache,
+								To:   config.BackendRedis,
+							},
+							Redis: config.Redis{
+								ExpirationMinutes: 1,
+							},
+						},
+						RequestLimits: config.RequestLimits{
+							MaxTTLSeconds: utils.REQUEST_MAX_TTL_SECONDS,
+						},
+					},
+					expectedMaxTTLSeconds: SIXTY_SECONDS,
+				},
+				{
+					desc: "The limits of the backend being migrated from don't apply",
+					inConfig: config.Configuration{
+						Backend: config.Backend{
+							Type: config.BackendMigrate,
+							Migrate: config.Migrate{
+								From: config.BackendRedis,
+								To:   config.BackendMemcache,
+							},
+							Redis: config.Redis{
+								ExpirationMinutes: 1,
+							},
+						},
+						RequestLimits: config.RequestLimits{
+							MaxTTLSeconds: utils.REQUEST_MAX_TTL_SECONDS,
+						},
+					},
+					expectedMaxTTLSeconds: utils.REQUEST_MAX_TTL_SECONDS,
+				},
+			},
+		},
+		{
+			groupDesc: "Routed backend",
+			unitTests: []testCases{
+				{
+					desc: "The smallest limit of the backends routed to applies",
+					inConfig: config.Configuration{
+						Backend: config.Backend{
+							Type: config.BackendRouted,
+							Routed: config.Routed{
+								Pointers: config.BackendRedis,
+								Routes:   []config.Route{{Backend: config.BackendCassandra, MinSizeBytes: 1024}},
+							},
+							Redis: config.Redis{
+								ExpirationMinutes: 60,
+							},
+						},
+						RequestLimits: config.RequestLimits{
+							MaxTTLSeconds: utils.REQUEST_MAX_TTL_SECONDS,
+						},
+					},
+					expectedMaxTTLSeconds: utils.CASSANDRA_DEFAULT_TTL_SECONDS,
+				},
+			},
+		},
 	}
 
 	for _, tgroup := range tests {
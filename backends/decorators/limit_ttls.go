@@ -42,3 +42,8 @@ func (l ttlLimited) Put(ctx context.Context, key string, value string, requestTT
 func (l ttlLimited) Get(ctx context.Context, key string) (string, error) {
 	return l.Backend.Get(ctx, key)
 }
+
+// HealthCheck forwards the health check to the delegate
+func (l ttlLimited) HealthCheck(ctx context.Context) error {
+	return backends.CheckHealth(ctx, l.Backend)
+}
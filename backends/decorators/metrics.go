@@ -3,7 +3,6 @@ package decorators
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/prebid/prebid-cache/backends"
@@ -12,8 +11,9 @@ import (
 )
 
 type backendWithMetrics struct {
-	delegate backends.Backend
-	metrics  *metrics.Metrics
+	delegate     backends.Backend
+	metrics      *metrics.Metrics
+	contentTypes *utils.ContentTypes
 }
 
 func (b *backendWithMetrics) Get(ctx context.Context, key string) (string, error) {
@@ -41,10 +41,8 @@ func (b *backendWithMetrics) Get(ctx context.Context, key string) (string, error
 
 func (b *backendWithMetrics) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
 
-	if strings.HasPrefix(value, utils.XML_PREFIX) {
-		b.metrics.RecordPutBackendXml()
-	} else if strings.HasPrefix(value, utils.JSON_PREFIX) {
-		b.metrics.RecordPutBackendJson()
+	if contentType, ok := b.contentTypes.Match(value); ok {
+		b.metrics.RecordPutBackendType(contentType.Name)
 	} else {
 		b.metrics.RecordPutBackendInvalid() // Never gets called here. Unreachable
 	}
@@ -62,9 +60,16 @@ func (b *backendWithMetrics) Put(ctx context.Context, key string, value string,
 	return err
 }
 
-func LogMetrics(backend backends.Backend, m *metrics.Metrics) backends.Backend {
+func (b *backendWithMetrics) HealthCheck(ctx context.Context) error {
+	return backends.CheckHealth(ctx, b.delegate)
+}
+
+// LogMetrics records metrics of the calls to backend, labelling the puts with the content type
+// of their values
+func LogMetrics(backend backends.Backend, m *metrics.Metrics, contentTypes *utils.ContentTypes) backends.Backend {
 	return &backendWithMetrics{
-		delegate: backend,
-		metrics:  m,
+		delegate:     backend,
+		metrics:      m,
+		contentTypes: contentTypes,
 	}
 }
@@ -24,6 +24,11 @@ func (b *failedBackend) Put(ctx context.Context, key string, value string, ttlSe
 	return b.returnError
 }
 
+func builtInContentTypes() *utils.ContentTypes {
+	contentTypes, _ := utils.NewContentTypes()
+	return contentTypes
+}
+
 func TestGetBackendMetrics(t *testing.T) {
 	// Expected values
 	expectedMetrics := []string{
@@ -41,7 +46,7 @@ func TestGetBackendMetrics(t *testing.T) {
 
 	rawBackend := backends.NewMemoryBackend()
 	rawBackend.Put(context.Background(), "foo", "xml<vast></vast>", 0)
-	backendWithMetrics := LogMetrics(rawBackend, m)
+	backendWithMetrics := LogMetrics(rawBackend, m, builtInContentTypes())
 
 	// Run test
 	backendWithMetrics.Get(context.Background(), "foo")
@@ -109,7 +114,7 @@ func TestGetBackendErrorMetrics(t *testing.T) {
 				},
 			}
 			// Create backend with a mock storage that will fail and record metrics
-			backend := LogMetrics(&failedBackend{test.expectedError}, m)
+			backend := LogMetrics(&failedBackend{test.expectedError}, m, builtInContentTypes())
 
 			// Run test
 			retrievedValue, err := backend.Get(context.Background(), "foo")
@@ -126,7 +131,7 @@ func TestPutSuccessMetrics(t *testing.T) {
 	// Expected values
 	expectedMetrics := []string{
 		"RecordPutBackendDuration",
-		"RecordPutBackendXml",
+		"RecordPutBackendType",
 		"RecordPutBackendTTLSeconds",
 		"RecordPutBackendSize",
 	}
@@ -138,7 +143,7 @@ func TestPutSuccessMetrics(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	backend := LogMetrics(backends.NewMemoryBackend(), m)
+	backend := LogMetrics(backends.NewMemoryBackend(), m, builtInContentTypes())
 
 	// Run test
 	backend.Put(context.Background(), "foo", "xml<vast></vast>", 60)
@@ -151,7 +156,7 @@ func TestPutErrorMetrics(t *testing.T) {
 	// Expected values
 	expectedMetrics := []string{
 		"RecordPutBackendError",
-		"RecordPutBackendXml",
+		"RecordPutBackendType",
 		"RecordPutBackendSize",
 		"RecordPutBackendTTLSeconds",
 	}
@@ -163,7 +168,7 @@ func TestPutErrorMetrics(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	backend := LogMetrics(&failedBackend{errors.New("Failure")}, m)
+	backend := LogMetrics(&failedBackend{errors.New("Failure")}, m, builtInContentTypes())
 
 	// Run test
 	backend.Put(context.Background(), "foo", "xml<vast></vast>", 0)
@@ -175,7 +180,7 @@ func TestPutErrorMetrics(t *testing.T) {
 func TestJsonPayloadMetrics(t *testing.T) {
 	// Expected values
 	expectedMetrics := []string{
-		"RecordPutBackendJson",
+		"RecordPutBackendType",
 		"RecordPutBackendSize",
 		"RecordPutBackendTTLSeconds",
 		"RecordPutBackendDuration",
@@ -188,7 +193,7 @@ func TestJsonPayloadMetrics(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	backend := LogMetrics(backends.NewMemoryBackend(), m)
+	backend := LogMetrics(backends.NewMemoryBackend(), m, builtInContentTypes())
 
 	// Run test
 	backend.Put(context.Background(), "foo", "json{\"key\":\"value\"", 0)
@@ -197,6 +202,26 @@ func TestJsonPayloadMetrics(t *testing.T) {
 	metricstest.AssertMetrics(t, expectedMetrics, mockMetrics)
 }
 
+func TestConfiguredContentTypeMetrics(t *testing.T) {
+	// Test setup
+	mockMetrics := metricstest.CreateMockMetrics()
+	m := &metrics.Metrics{
+		MetricEngines: []metrics.CacheMetrics{
+			&mockMetrics,
+		},
+	}
+	contentTypes, err := utils.NewContentTypes(utils.ContentType{Name: "html", MediaType: "text/html"})
+	assert.NoError(t, err)
+	backend := LogMetrics(backends.NewMemoryBackend(), m, contentTypes)
+
+	// Run test
+	backend.Put(context.Background(), "foo", "html<p>Ad</p>", 0)
+
+	// Assert
+	mockMetrics.AssertCalled(t, "RecordPutBackendType")
+	mockMetrics.AssertNotCalled(t, "RecordPutBackendInvalid")
+}
+
 func TestInvalidPayloadMetrics(t *testing.T) {
 	// Expected values
 	expectedMetrics := []string{
@@ -213,7 +238,7 @@ func TestInvalidPayloadMetrics(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	backend := LogMetrics(backends.NewMemoryBackend(), m)
+	backend := LogMetrics(backends.NewMemoryBackend(), m, builtInContentTypes())
 
 	// Run test
 	backend.Put(context.Background(), "foo", "bar", 0)
@@ -221,3 +246,28 @@ func TestInvalidPayloadMetrics(t *testing.T) {
 	// Assert
 	metricstest.AssertMetrics(t, expectedMetrics, mockMetrics)
 }
+
+type unhealthyBackend struct {
+	failedBackend
+}
+
+func (b *unhealthyBackend) HealthCheck(ctx context.Context) error {
+	return b.returnError
+}
+
+func TestDecoratorsForwardHealthCheck(t *testing.T) {
+	m := &metrics.Metrics{}
+	decorate := map[string]func(backends.Backend) backends.Backend{
+		"LogMetrics":       func(b backends.Backend) backends.Backend { return LogMetrics(b, m, builtInContentTypes()) },
+		"LimitTTLs":        func(b backends.Backend) backends.Backend { return LimitTTLs(b, 10) },
+		"EnforceSizeLimit": func(b backends.Backend) backends.Backend { return EnforceSizeLimit(b, 10) },
+	}
+
+	for name, decorator := range decorate {
+		// Backends that don't implement HealthChecker are assumed healthy
+		assert.NoError(t, backends.CheckHealth(context.Background(), decorator(backends.NewMemoryBackend())), name)
+
+		unhealthy := &unhealthyBackend{failedBackend{returnError: errors.New("unreachable")}}
+		assert.EqualError(t, backends.CheckHealth(context.Background(), decorator(unhealthy)), "unreachable", name)
+	}
+}
@@ -0,0 +1,125 @@
+package decorators
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"github.com/prebid/prebid-cache/backends"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/metrics"
+	"github.com/prebid/prebid-cache/utils"
+)
+
+// Shadow wraps the primary backend and replays a sample of its traffic against a candidate backend
+// in order to evaluate it. Candidate calls run asynchronously out of a bounded queue and get dropped
+// when the queue is full, so the candidate never slows down nor changes the primary responses.
+func Shadow(primary backends.Backend, candidate backends.Backend, cfg config.Shadow, m *metrics.Metrics) backends.Backend {
+	s := &shadowed{
+		Backend:   primary,
+		candidate: candidate,
+		// Keys are sampled by comparing their hash against this threshold, so the puts and gets of
+		// a sampled key all get replayed
+		sampleThreshold: uint64(cfg.SamplingRate * (1 << 32)),
+		timeout:         time.Duration(cfg.TimeoutMillis) * time.Millisecond,
+		queue:           make(chan func(ctx context.Context), cfg.QueueSize),
+		metrics:         m,
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		go s.work()
+	}
+	return s
+}
+
+// shadowed implements the backends.Backend interface to serve as a decorator that replays
+// the calls to the primary backend against a candidate one
+type shadowed struct {
+	backends.Backend
+	candidate       backends.Backend
+	sampleThreshold uint64
+	timeout         time.Duration
+	queue           chan func(ctx context.Context)
+	metrics         *metrics.Metrics
+}
+
+// Put stores the value in the primary backend. If it succeeds and the key was sampled, the same
+// value gets stored in the candidate backend.
+func (s *shadowed) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
+	err := s.Backend.Put(ctx, key, value, ttlSeconds)
+	if err == nil && s.sampled(key) {
+		s.enqueue(func(ctx context.Context) {
+			start := time.Now()
+			err := s.candidate.Put(ctx, key, value, ttlSeconds)
+			s.metrics.RecordShadowPutDuration(time.Since(start))
+			if err != nil && !isPBCError(err, utils.RECORD_EXISTS) {
+				s.metrics.RecordShadowPutError()
+			}
+		})
+	}
+	return err
+}
+
+// Get retrieves the value from the primary backend. If the key was sampled, the candidate backend
+// gets queried as well and its result compared with the primary one. Candidate results are never
+// returned.
+func (s *shadowed) Get(ctx context.Context, key string) (string, error) {
+	value, err := s.Backend.Get(ctx, key)
+
+	// Nothing to compare against if the primary backend failed
+	if err != nil && !isPBCError(err, utils.KEY_NOT_FOUND) {
+		return value, err
+	}
+
+	if s.sampled(key) {
+		primaryFound := err == nil
+		s.enqueue(func(ctx context.Context) {
+			start := time.Now()
+			candidateValue, candidateErr := s.candidate.Get(ctx, key)
+			s.metrics.RecordShadowGetDuration(time.Since(start))
+
+			switch {
+			case candidateErr != nil && !isPBCError(candidateErr, utils.KEY_NOT_FOUND):
+				s.metrics.RecordShadowGetError()
+			case primaryFound == (candidateErr == nil) && value == candidateValue:
+				s.metrics.RecordShadowGetMatch()
+			default:
+				s.metrics.RecordShadowGetMismatch()
+			}
+		})
+	}
+	return value, err
+}
+
+// HealthCheck forwards the health check to the primary backend only, as the candidate one doesn't
+// serve any traffic
+func (s *shadowed) HealthCheck(ctx context.Context) error {
+	return backends.CheckHealth(ctx, s.Backend)
+}
+
+func (s *shadowed) sampled(key string) bool {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return uint64(h.Sum32()) < s.sampleThreshold
+}
+
+// enqueue schedules the candidate call, or drops it if the queue is full
+func (s *shadowed) enqueue(call func(ctx context.Context)) {
+	select {
+	case s.queue <- call:
+	default:
+		s.metrics.RecordShadowDropped()
+	}
+}
+
+func (s *shadowed) work() {
+	for call := range s.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		call(ctx)
+		cancel()
+	}
+}
+
+func isPBCError(err error, errType int) bool {
+	pbcErr, ok := err.(utils.PBCError)
+	return ok && pbcErr.Type == errType
+}
@@ -0,0 +1,254 @@
+package decorators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-cache/backends"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/metrics"
+	"github.com/prebid/prebid-cache/metrics/metricstest"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// shadowMetrics reports the shadow metrics recorded by the decorator workers to the recorded channel
+type shadowMetrics struct {
+	*metricstest.MockMetrics
+	recorded chan string
+}
+
+func newShadowMetrics() (*shadowMetrics, *metrics.Metrics) {
+	mockMetrics := metricstest.CreateMockMetrics()
+	engine := &shadowMetrics{MockMetrics: &mockMetrics, recorded: make(chan string, 10)}
+	return engine, &metrics.Metrics{MetricEngines: []metrics.CacheMetrics{engine}}
+}
+
+func (m *shadowMetrics) RecordShadowGetMatch()    { m.recorded <- "RecordShadowGetMatch" }
+func (m *shadowMetrics) RecordShadowGetMismatch() { m.recorded <- "RecordShadowGetMismatch" }
+func (m *shadowMetrics) RecordShadowGetError()    { m.recorded <- "RecordShadowGetError" }
+func (m *shadowMetrics) RecordShadowPutError()    { m.recorded <- "RecordShadowPutError" }
+func (m *shadowMetrics) RecordShadowDropped()     { m.recorded <- "RecordShadowDropped" }
+func (m *shadowMetrics) RecordShadowPutDuration(duration time.Duration) {
+	m.recorded <- "RecordShadowPutDuration"
+}
+
+// next returns the name of the next shadow metric recorded
+func (m *shadowMetrics) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case name := <-m.recorded:
+		return name
+	case <-time.After(time.Second):
+		t.Fatal("No shadow metric was recorded")
+	}
+	return ""
+}
+
+// blockingBackend blocks its Get calls until released
+type blockingBackend struct {
+	backends.Backend
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBackend) Get(ctx context.Context, key string) (string, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return b.Backend.Get(ctx, key)
+}
+
+func newShadowConfig() config.Shadow {
+	return config.Shadow{Enabled: true, SamplingRate: 1, QueueSize: 10, Workers: 1, TimeoutMillis: 100}
+}
+
+func TestShadowGet(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		inPrimaryData   map[string]string
+		inCandidateData map[string]string
+		inCandidateErr  error
+		expectedValue   string
+		expectedErr     error
+		expectedMetric  string
+	}{
+		{
+			desc:            "Both backends return the same value",
+			inPrimaryData:   map[string]string{"key": "value"},
+			inCandidateData: map[string]string{"key": "value"},
+			expectedValue:   "value",
+			expectedMetric:  "RecordShadowGetMatch",
+		},
+		{
+			desc:           "None of the backends finds the key",
+			expectedErr:    utils.NewPBCError(utils.KEY_NOT_FOUND),
+			expectedMetric: "RecordShadowGetMatch",
+		},
+		{
+			desc:            "Backends return different values",
+			inPrimaryData:   map[string]string{"key": "value"},
+			inCandidateData: map[string]string{"key": "other"},
+			expectedValue:   "value",
+			expectedMetric:  "RecordShadowGetMismatch",
+		},
+		{
+			desc:           "Candidate doesn't find the key",
+			inPrimaryData:  map[string]string{"key": "value"},
+			expectedValue:  "value",
+			expectedMetric: "RecordShadowGetMismatch",
+		},
+		{
+			desc:            "Primary doesn't find the key",
+			inCandidateData: map[string]string{"key": "value"},
+			expectedErr:     utils.NewPBCError(utils.KEY_NOT_FOUND),
+			expectedMetric:  "RecordShadowGetMismatch",
+		},
+		{
+			desc:           "Candidate fails",
+			inPrimaryData:  map[string]string{"key": "value"},
+			inCandidateErr: errors.New("candidate error"),
+			expectedValue:  "value",
+			expectedMetric: "RecordShadowGetError",
+		},
+	}
+
+	for _, tc := range testCases {
+		engine, m := newShadowMetrics()
+		primary, _ := backends.NewMemoryBackendWithValues(tc.inPrimaryData)
+		var candidate backends.Backend
+		candidate, _ = backends.NewMemoryBackendWithValues(tc.inCandidateData)
+		if tc.inCandidateErr != nil {
+			candidate = &failedBackend{returnError: tc.inCandidateErr}
+		}
+		backend := Shadow(primary, candidate, newShadowConfig(), m)
+
+		value, err := backend.Get(context.Background(), "key")
+
+		assert.Equal(t, tc.expectedValue, value, tc.desc)
+		assert.Equal(t, tc.expectedErr, err, tc.desc)
+		assert.Equal(t, tc.expectedMetric, engine.next(t), tc.desc)
+	}
+}
+
+// getErrorBackend is a memory backend whose Get calls fail
+type getErrorBackend struct {
+	*backends.MemoryBackend
+}
+
+func (b *getErrorBackend) Get(ctx context.Context, key string) (string, error) {
+	return "", errors.New("primary error")
+}
+
+func TestShadowGetPrimaryError(t *testing.T) {
+	engine, m := newShadowMetrics()
+	backend := Shadow(&getErrorBackend{backends.NewMemoryBackend()}, backends.NewMemoryBackend(), newShadowConfig(), m)
+
+	_, err := backend.Get(context.Background(), "key")
+	assert.EqualError(t, err, "primary error")
+
+	// Results can't be compared so nothing gets shadowed. The next call proves the queue was empty.
+	backend.Put(context.Background(), "key", "value", 10)
+	assert.Equal(t, "RecordShadowPutDuration", engine.next(t))
+	assert.Empty(t, engine.recorded)
+}
+
+func TestShadowPut(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		inCandidateData  map[string]string
+		inCandidateErr   error
+		expectedMetrics  []string
+		expectedInserted bool
+	}{
+		{
+			desc:             "Value gets stored in the candidate backend",
+			expectedMetrics:  []string{"RecordShadowPutDuration"},
+			expectedInserted: true,
+		},
+		{
+			desc:            "Key already in the candidate backend isn't an error",
+			inCandidateData: map[string]string{"key": "other"},
+			expectedMetrics: []string{"RecordShadowPutDuration"},
+		},
+		{
+			desc:            "Candidate fails",
+			inCandidateErr:  errors.New("candidate error"),
+			expectedMetrics: []string{"RecordShadowPutDuration", "RecordShadowPutError"},
+		},
+	}
+
+	for _, tc := range testCases {
+		engine, m := newShadowMetrics()
+		primary := backends.NewMemoryBackend()
+		candidateMemory, _ := backends.NewMemoryBackendWithValues(tc.inCandidateData)
+		var candidate backends.Backend = candidateMemory
+		if tc.inCandidateErr != nil {
+			candidate = &failedBackend{returnError: tc.inCandidateErr}
+		}
+		backend := Shadow(primary, candidate, newShadowConfig(), m)
+
+		err := backend.Put(context.Background(), "key", "value", 10)
+
+		assert.NoError(t, err, tc.desc)
+		for _, expected := range tc.expectedMetrics {
+			assert.Equal(t, expected, engine.next(t), tc.desc)
+		}
+		if tc.expectedInserted {
+			value, _ := candidateMemory.Get(context.Background(), "key")
+			assert.Equal(t, "value", value, tc.desc)
+		}
+	}
+}
+
+func TestShadowQueueFull(t *testing.T) {
+	engine, m := newShadowMetrics()
+	primary, _ := backends.NewMemoryBackendWithValues(map[string]string{"key": "value"})
+	candidate := &blockingBackend{Backend: primary, started: make(chan struct{}, 1), release: make(chan struct{})}
+	cfg := newShadowConfig()
+	cfg.QueueSize = 1
+	backend := Shadow(primary, candidate, cfg, m)
+
+	// The only worker gets stuck in the first call, and the second one fills the queue
+	backend.Get(context.Background(), "key")
+	<-candidate.started
+	backend.Get(context.Background(), "key")
+
+	// The third call can't get queued, but the primary response is unaffected
+	value, err := backend.Get(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, "RecordShadowDropped", engine.next(t))
+
+	close(candidate.release)
+	assert.Equal(t, "RecordShadowGetMatch", engine.next(t))
+	<-candidate.started
+	assert.Equal(t, "RecordShadowGetMatch", engine.next(t))
+}
+
+func TestShadowSampling(t *testing.T) {
+	_, m := newShadowMetrics()
+
+	cfg := newShadowConfig()
+	cfg.Workers = 0
+	cfg.SamplingRate = 0
+	none := Shadow(backends.NewMemoryBackend(), backends.NewMemoryBackend(), cfg, m).(*shadowed)
+	cfg.SamplingRate = 1
+	all := Shadow(backends.NewMemoryBackend(), backends.NewMemoryBackend(), cfg, m).(*shadowed)
+	cfg.SamplingRate = 0.5
+	half := Shadow(backends.NewMemoryBackend(), backends.NewMemoryBackend(), cfg, m).(*shadowed)
+
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		assert.False(t, none.sampled(key))
+		assert.True(t, all.sampled(key))
+		assert.Equal(t, half.sampled(key), half.sampled(key), "Sampling must be consistent for a given key")
+		if half.sampled(key) {
+			sampled++
+		}
+	}
+	assert.InDelta(t, 500, sampled, 100)
+}
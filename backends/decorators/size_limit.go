@@ -37,6 +37,10 @@ func (b *sizeCappedBackend) Put(ctx context.Context, key string, value string, t
 	return b.delegate.Put(ctx, key, value, ttlSeconds)
 }
 
+func (b *sizeCappedBackend) HealthCheck(ctx context.Context) error {
+	return backends.CheckHealth(ctx, b.delegate)
+}
+
 type BadPayloadSize struct {
 	Limit int
 	Size  int
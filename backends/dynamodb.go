@@ -0,0 +1,220 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Attributes of the items. The key is the partition key of the table, and expires_at its
+	// time-to-live attribute, holding the Unix time the value expires at.
+	dynamoDBKeyAttribute       = "key"
+	dynamoDBValueAttribute     = "value"
+	dynamoDBExpiresAtAttribute = "expires_at"
+
+	// dynamoDBTablePollInterval is how often the status of a table being created gets checked
+	dynamoDBTablePollInterval = time.Second
+)
+
+// DynamoDBBackend implements the Backend interface and stores values as items of an AWS DynamoDB
+// table. Expiration times are stored in the time-to-live attribute of the table, and checked on reads
+// and writes, because DynamoDB deletes expired items days later at best.
+type DynamoDBBackend struct {
+	client         *dynamodb.Client
+	table          string
+	consistentRead bool
+	now            func() time.Time
+}
+
+// NewDynamoDBBackend makes sure the table is reachable, after creating it if configured to
+func NewDynamoDBBackend(cfg config.DynamoDB) *DynamoDBBackend {
+	awsConfig, err := loadAWSConfig(context.Background(), cfg.Region, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken, cfg.TimeoutMillis, cfg.MaxIdleConns)
+	if err != nil {
+		log.Fatalf("Error creating DynamoDB backend: %v", err)
+		panic("DynamoDBBackend failure. This shouldn't happen.")
+	}
+	backend, err := newDynamoDBBackend(cfg, awsConfig, time.Now)
+	if err != nil {
+		log.Fatalf("Error creating DynamoDB backend: %v", err)
+		panic("DynamoDBBackend failure. This shouldn't happen.")
+	}
+	log.Infof("Prebid Cache will store items in the %s DynamoDB table", cfg.Table)
+	return backend
+}
+
+func newDynamoDBBackend(cfg config.DynamoDB, awsConfig aws.Config, now func() time.Time) (*DynamoDBBackend, error) {
+	if cfg.Table == "" {
+		return nil, errors.New("the DynamoDB table cannot be empty")
+	}
+
+	backend := &DynamoDBBackend{
+		client: dynamodb.NewFromConfig(awsConfig, func(o *dynamodb.Options) {
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+		}),
+		table:          cfg.Table,
+		consistentRead: cfg.ConsistentReads,
+		now:            now,
+	}
+
+	if cfg.CreateTableOnStart {
+		// Tables take a few seconds to get created
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if err := backend.createTable(ctx); err != nil {
+			return nil, fmt.Errorf("Failed to create the DynamoDB table: %v", err)
+		}
+		log.Infof("DynamoDB table %s is ready", cfg.Table)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := backend.HealthCheck(ctx); err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+func dynamoDBNumber(n int64) *types.AttributeValueMemberN {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+// Get returns the value stored under key, or a KEY_NOT_FOUND error if there's none or it expired
+func (b *DynamoDBBackend) Get(ctx context.Context, key string) (string, error) {
+	output, err := b.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(b.table),
+		Key:            map[string]types.AttributeValue{dynamoDBKeyAttribute: &types.AttributeValueMemberS{Value: key}},
+		ConsistentRead: aws.Bool(b.consistentRead),
+	})
+	if err != nil {
+		return "", classifyAWSTimeout(err)
+	}
+
+	value, ok := output.Item[dynamoDBValueAttribute].(*types.AttributeValueMemberS)
+	if !ok || b.expired(output.Item) {
+		return "", utils.NewPBCError(utils.KEY_NOT_FOUND)
+	}
+	return value.Value, nil
+}
+
+// Put stores value under key for ttlSeconds, or returns a RECORD_EXISTS error if key holds a value
+// that didn't expire yet. The write is conditional, so concurrent puts of the same key can't
+// overwrite each other.
+func (b *DynamoDBBackend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
+	now := b.now().Unix()
+	item := map[string]types.AttributeValue{
+		dynamoDBKeyAttribute:   &types.AttributeValueMemberS{Value: key},
+		dynamoDBValueAttribute: &types.AttributeValueMemberS{Value: value},
+	}
+	if ttlSeconds > 0 {
+		item[dynamoDBExpiresAtAttribute] = dynamoDBNumber(now + int64(ttlSeconds))
+	}
+
+	_, err := b.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(b.table),
+		Item:      item,
+		// Expired items that DynamoDB didn't delete yet can be replaced
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR #expires_at <= :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#key":        dynamoDBKeyAttribute,
+			"#expires_at": dynamoDBExpiresAtAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":now": dynamoDBNumber(now)},
+	})
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return utils.NewPBCError(utils.RECORD_EXISTS)
+	}
+	return classifyAWSTimeout(err)
+}
+
+// HealthCheck makes sure the table exists and the credentials grant access to it
+func (b *DynamoDBBackend) HealthCheck(ctx context.Context) error {
+	_, err := b.tableStatus(ctx)
+	return err
+}
+
+// expired tells whether the time-to-live attribute of item is past
+func (b *DynamoDBBackend) expired(item map[string]types.AttributeValue) bool {
+	expiresAt, ok := item[dynamoDBExpiresAtAttribute].(*types.AttributeValueMemberN)
+	if !ok {
+		return false
+	}
+	seconds, err := strconv.ParseInt(expiresAt.Value, 10, 64)
+	return err == nil && seconds <= b.now().Unix()
+}
+
+// tableStatus returns the status of the table, such as "CREATING" or "ACTIVE"
+func (b *DynamoDBBackend) tableStatus(ctx context.Context) (types.TableStatus, error) {
+	output, err := b.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(b.table)})
+	if err != nil {
+		return "", classifyAWSTimeout(err)
+	}
+	return output.Table.TableStatus, nil
+}
+
+// createTable creates the table with on-demand capacity if it doesn't exist, waits for it to be
+// active, and enables its time-to-live if it's not enabled yet
+func (b *DynamoDBBackend) createTable(ctx context.Context) error {
+	_, err := b.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:            aws.String(b.table),
+		AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String(dynamoDBKeyAttribute), AttributeType: types.ScalarAttributeTypeS}},
+		KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String(dynamoDBKeyAttribute), KeyType: types.KeyTypeHash}},
+		BillingMode:          types.BillingModePayPerRequest,
+	})
+	var inUseErr *types.ResourceInUseException
+	if err != nil && !errors.As(err, &inUseErr) {
+		return err
+	}
+
+	for {
+		status, err := b.tableStatus(ctx)
+		if err != nil {
+			return err
+		}
+		if status == types.TableStatusActive {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(dynamoDBTablePollInterval):
+		}
+	}
+
+	ttl, err := b.client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(b.table)})
+	if err != nil {
+		return err
+	}
+	if description := ttl.TimeToLiveDescription; description != nil {
+		switch description.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+			if attribute := aws.ToString(description.AttributeName); attribute != dynamoDBExpiresAtAttribute {
+				return fmt.Errorf("the time-to-live of the table is enabled on the %s attribute instead of %s", attribute, dynamoDBExpiresAtAttribute)
+			}
+			return nil
+		}
+	}
+
+	_, err = b.client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(b.table),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			Enabled:       aws.Bool(true),
+			AttributeName: aws.String(dynamoDBExpiresAtAttribute),
+		},
+	})
+	return err
+}
@@ -0,0 +1,264 @@
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDynamoDBAttribute is an attribute value of an item, either a string or a number
+type fakeDynamoDBAttribute struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+}
+
+// fakeDynamoDBServer stands in for DynamoDB over HTTP. It checks the requests are signed with its
+// access key, and supports the operations and the condition expression the DynamoDB backend relies on.
+type fakeDynamoDBServer struct {
+	*httptest.Server
+	accessKey string
+
+	mu          sync.Mutex
+	tables      map[string]map[string]map[string]fakeDynamoDBAttribute
+	ttlEnabled  map[string]string
+	pendingPoll int
+	operations  []string
+}
+
+func newFakeDynamoDBServer(t *testing.T) *fakeDynamoDBServer {
+	server := &fakeDynamoDBServer{
+		accessKey:  "access-key",
+		tables:     make(map[string]map[string]map[string]fakeDynamoDBAttribute),
+		ttlEnabled: make(map[string]string),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *fakeDynamoDBServer) config() config.DynamoDB {
+	return config.DynamoDB{
+		Endpoint:        s.URL,
+		Region:          "us-east-1",
+		Table:           "prebid-cache",
+		AccessKeyID:     s.accessKey,
+		SecretAccessKey: "secret-key",
+		TimeoutMillis:   1000,
+	}
+}
+
+func (s *fakeDynamoDBServer) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+s.accessKey+"/") {
+		s.writeError(w, "UnrecognizedClientException", "The security token included in the request is invalid")
+		return
+	}
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+
+	var input struct {
+		TableName                 string
+		Key                       map[string]fakeDynamoDBAttribute
+		Item                      map[string]fakeDynamoDBAttribute
+		ExpressionAttributeValues map[string]fakeDynamoDBAttribute
+		TimeToLiveSpecification   struct{ AttributeName string }
+	}
+	json.Unmarshal(body, &input)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.operations = append(s.operations, operation)
+
+	table, exists := s.tables[input.TableName]
+	if !exists && operation != "CreateTable" {
+		s.writeError(w, "ResourceNotFoundException", "Requested resource not found")
+		return
+	}
+
+	switch operation {
+	case "CreateTable":
+		if exists {
+			s.writeError(w, "ResourceInUseException", "Table already exists")
+			return
+		}
+		s.tables[input.TableName] = make(map[string]map[string]fakeDynamoDBAttribute)
+		s.pendingPoll = 1
+		io.WriteString(w, `{"TableDescription":{"TableStatus":"CREATING"}}`)
+	case "DescribeTable":
+		status := "ACTIVE"
+		if s.pendingPoll > 0 {
+			s.pendingPoll--
+			status = "CREATING"
+		}
+		io.WriteString(w, `{"Table":{"TableStatus":"`+status+`"}}`)
+	case "DescribeTimeToLive":
+		if attribute, ok := s.ttlEnabled[input.TableName]; ok {
+			io.WriteString(w, `{"TimeToLiveDescription":{"TimeToLiveStatus":"ENABLED","AttributeName":"`+attribute+`"}}`)
+		} else {
+			io.WriteString(w, `{"TimeToLiveDescription":{"TimeToLiveStatus":"DISABLED"}}`)
+		}
+	case "UpdateTimeToLive":
+		s.ttlEnabled[input.TableName] = input.TimeToLiveSpecification.AttributeName
+		io.WriteString(w, `{}`)
+	case "GetItem":
+		item, ok := table[*input.Key["key"].S]
+		if !ok {
+			io.WriteString(w, `{}`)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"Item": item})
+	case "PutItem":
+		// attribute_not_exists(#key) OR #expires_at <= :now
+		key := *input.Item["key"].S
+		if current, ok := table[key]; ok {
+			expiresAt := current["expires_at"]
+			now, _ := strconv.ParseInt(*input.ExpressionAttributeValues[":now"].N, 10, 64)
+			if expiresAt.N == nil || mustParseInt(*expiresAt.N) > now {
+				s.writeError(w, "ConditionalCheckFailedException", "The conditional request failed")
+				return
+			}
+		}
+		table[key] = input.Item
+		io.WriteString(w, `{}`)
+	}
+}
+
+func (s *fakeDynamoDBServer) writeError(w http.ResponseWriter, code string, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(http.StatusBadRequest)
+	io.WriteString(w, `{"__type":"com.amazonaws.dynamodb.v20120810#`+code+`","message":"`+message+`"}`)
+}
+
+func (s *fakeDynamoDBServer) calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.operations...)
+}
+
+func mustParseInt(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// newTestDynamoDBBackend creates a DynamoDB backend configured with cfg
+func newTestDynamoDBBackend(cfg config.DynamoDB, now func() time.Time) (*DynamoDBBackend, error) {
+	awsConfig, err := loadAWSConfig(context.Background(), cfg.Region, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken, cfg.TimeoutMillis, cfg.MaxIdleConns)
+	if err != nil {
+		return nil, err
+	}
+	return newDynamoDBBackend(cfg, awsConfig, now)
+}
+
+func TestNewDynamoDBBackend(t *testing.T) {
+	server := newFakeDynamoDBServer(t)
+
+	_, err := newTestDynamoDBBackend(server.config(), time.Now)
+	assert.ErrorContains(t, err, "ResourceNotFoundException: Requested resource not found", "The table must exist")
+
+	cfg := server.config()
+	cfg.CreateTableOnStart = true
+	_, err = newTestDynamoDBBackend(cfg, time.Now)
+	assert.NoError(t, err, "The table gets created")
+	assert.Equal(t, []string{"DescribeTable", "CreateTable", "DescribeTable", "DescribeTable", "DescribeTimeToLive", "UpdateTimeToLive", "DescribeTable"}, server.calls(), "The table gets created, waited for, and its time-to-live enabled")
+	assert.Equal(t, "expires_at", server.ttlEnabled["prebid-cache"])
+
+	_, err = newTestDynamoDBBackend(cfg, time.Now)
+	assert.NoError(t, err, "Tables that exist are left as they are")
+	assert.Equal(t, []string{"CreateTable", "DescribeTable", "DescribeTimeToLive", "DescribeTable"}, server.calls()[7:])
+
+	server.ttlEnabled["prebid-cache"] = "ttl"
+	_, err = newTestDynamoDBBackend(cfg, time.Now)
+	assert.EqualError(t, err, "Failed to create the DynamoDB table: the time-to-live of the table is enabled on the ttl attribute instead of expires_at", "Tables expiring items on another attribute")
+
+	cfg = server.config()
+	cfg.AccessKeyID = "wrong-key"
+	_, err = newTestDynamoDBBackend(cfg, time.Now)
+	assert.ErrorContains(t, err, "UnrecognizedClientException", "The credentials must be valid")
+
+	_, err = newTestDynamoDBBackend(config.DynamoDB{Region: "us-east-1"}, time.Now)
+	assert.EqualError(t, err, "the DynamoDB table cannot be empty")
+}
+
+func TestDynamoDBGetAndPut(t *testing.T) {
+	server := newFakeDynamoDBServer(t)
+	server.tables["prebid-cache"] = make(map[string]map[string]fakeDynamoDBAttribute)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	backend, err := newTestDynamoDBBackend(server.config(), clock.Now)
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx := context.Background()
+
+	_, err = backend.Get(ctx, "someKey")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of a missing key")
+
+	assert.NoError(t, backend.Put(ctx, "someKey", "someValue", 60), "Put of a new key")
+
+	value, err := backend.Get(ctx, "someKey")
+	assert.NoError(t, err, "Get of an existing key")
+	assert.Equal(t, "someValue", value, "Get of an existing key")
+
+	err = backend.Put(ctx, "someKey", "otherValue", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "Put of an existing key")
+
+	clock.now = clock.now.Add(time.Minute)
+
+	_, err = backend.Get(ctx, "someKey")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of an expired key that wasn't deleted yet")
+
+	assert.NoError(t, backend.Put(ctx, "someKey", "otherValue", 60), "Put of an expired key that wasn't deleted yet")
+	value, err = backend.Get(ctx, "someKey")
+	assert.NoError(t, err, "Get of a key put again after it expired")
+	assert.Equal(t, "otherValue", value, "Get of a key put again after it expired")
+
+	assert.NoError(t, backend.Put(ctx, "forever", "value", 0), "Put without expiration time")
+	clock.now = clock.now.Add(24 * time.Hour)
+	err = backend.Put(ctx, "forever", "otherValue", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "Values without expiration time never expire")
+
+	assert.NoError(t, backend.HealthCheck(ctx), "Health check")
+}
+
+func TestDynamoDBErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Amz-Target") {
+		case "DynamoDB_20120810.DescribeTable":
+			io.WriteString(w, `{"Table":{"TableStatus":"ACTIVE"}}`)
+		case "DynamoDB_20120810.GetItem":
+			time.Sleep(100 * time.Millisecond)
+		default:
+			w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"The level of configured provisioned throughput for the table was exceeded."}`)
+		}
+	}))
+	defer server.Close()
+
+	backend, err := newTestDynamoDBBackend(config.DynamoDB{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Table:           "prebid-cache",
+		AccessKeyID:     "access-key",
+		SecretAccessKey: "secret-key",
+		TimeoutMillis:   10,
+	}, time.Now)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = backend.Get(context.Background(), "someKey")
+	assert.Equal(t, context.DeadlineExceeded, err, "Client timeouts are reported as deadline errors")
+
+	err = backend.Put(context.Background(), "someKey", "someValue", 60)
+	assert.ErrorContains(t, err, "ProvisionedThroughputExceededException: The level of configured provisioned throughput for the table was exceeded.", "Errors of DynamoDB are reported")
+}
@@ -8,6 +8,9 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/utils"
@@ -16,13 +19,21 @@ import (
 
 // IgniteBackend implements Backend interface and communicates with the Apache Ignite storage
 // via its REST API as documented in https://ignite.apache.org/docs/2.11.1/restapi#rest-api-reference
+// or, if thinClient is set, via the binary protocol of the Ignite thin clients
 type IgniteBackend struct {
 	sender    requestSender
 	serverURL *url.URL
 	headers   http.Header
 	cacheName string
+	// session holds the credentials and session token of clusters with authentication enabled
+	session    *igniteSession
+	thinClient *igniteThinClient
 }
 
+// igniteAuthFailedStatus is the successStatus of the REST responses to requests that failed to
+// authenticate, which happens when session tokens expire
+const igniteAuthFailedStatus = 2
+
 // httpClientWrapper lets us mock the http.Client
 type httpClientWrapper interface {
 	Do(req *http.Request) (*http.Response, error)
@@ -31,7 +42,7 @@ type httpClientWrapper interface {
 // requestSender defines a DoRequest method that will let us send the request to the Ignite server
 // and handle it's response and error. Other implementations of it will let us mock errorscenarios.
 type requestSender interface {
-	DoRequest(ctx context.Context, url *url.URL, headers http.Header) ([]byte, error)
+	DoRequest(ctx context.Context, url *url.URL, params url.Values, headers http.Header) ([]byte, error)
 }
 
 // igniteSender implements the requestSender interface
@@ -39,16 +50,19 @@ type igniteSender struct {
 	httpClient httpClientWrapper
 }
 
-// DoRequest will hit the Ignite server specified in the url parameter and handle error responses
-func (c *igniteSender) DoRequest(ctx context.Context, url *url.URL, headers http.Header) ([]byte, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
+// DoRequest will POST the params as a form to the Ignite server specified in the url parameter and
+// handle error responses. Values are sent in the body so they don't run into URL length limits nor
+// show up in the logs of proxies.
+func (c *igniteSender) DoRequest(ctx context.Context, url *url.URL, params url.Values, headers http.Header) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url.String(), strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
 	if len(headers) > 0 {
-		httpReq.Header = headers
+		httpReq.Header = headers.Clone()
 	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	httpResp, httpErr := c.httpClient.Do(httpReq)
 	if httpErr != nil {
@@ -89,37 +103,31 @@ func NewIgniteBackend(cfg config.Ignite) *IgniteBackend {
 		panic(errMsg)
 	}
 
-	url, err := url.Parse(fmt.Sprintf("%s://%s:%d/ignite?cacheName=%s", cfg.Scheme, cfg.Host, cfg.Port, cfg.Cache.Name))
-	if err != nil {
-		errMsg := fmt.Sprintf("Error creating Ignite backend: error parsing Ignite host URL %s", err.Error())
-		log.Fatalf(errMsg)
-		panic(errMsg)
-	}
-
-	igb := &IgniteBackend{serverURL: url}
-	if cfg.VerifyCert {
-		igb.sender = &igniteSender{
-			httpClient: http.DefaultClient,
-		}
+	igb := &IgniteBackend{cacheName: cfg.Cache.Name}
+	if cfg.Protocol == config.IgniteThin {
+		igb.thinClient = newIgniteThinClient(cfg)
 	} else {
-		igb.sender = &igniteSender{
-			httpClient: &http.Client{
-				Transport: &http.Transport{
-					TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-				},
-			},
+		url, err := url.Parse(fmt.Sprintf("%s://%s:%d/ignite", cfg.Scheme, cfg.Host, cfg.Port))
+		if err != nil {
+			errMsg := fmt.Sprintf("Error creating Ignite backend: error parsing Ignite host URL %s", err.Error())
+			log.Fatalf(errMsg)
+			panic(errMsg)
 		}
-	}
-
-	if len(cfg.Headers) > 0 {
-		igb.headers = http.Header{}
-		for k, v := range cfg.Headers {
-			igb.headers.Add(k, v)
+		igb.serverURL = url
+		igb.sender = &igniteSender{httpClient: newIgniteHTTPClient(cfg)}
+
+		if len(cfg.Headers) > 0 {
+			igb.headers = http.Header{}
+			for k, v := range cfg.Headers {
+				igb.headers.Add(k, v)
+			}
+		}
+		if len(cfg.Username) > 0 {
+			igb.session = &igniteSession{username: cfg.Username, password: cfg.Password}
 		}
 	}
 
 	if cfg.Cache.CreateOnStart {
-		igb.cacheName = cfg.Cache.Name
 		if err := createCache(igb); err != nil {
 			errMsg := fmt.Sprintf("Error creating Ignite backend: %s", err.Error())
 			log.Fatalf(errMsg)
@@ -131,16 +139,116 @@ func NewIgniteBackend(cfg config.Ignite) *IgniteBackend {
 	return igb
 }
 
+// newIgniteHTTPClient returns an http.Client that keeps up to cfg.MaxIdleConns connections to the
+// Ignite server open and bounds each request with cfg.TimeoutMillis
+func newIgniteHTTPClient(cfg config.Ignite) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        cfg.MaxIdleConns,
+			MaxIdleConnsPerHost: cfg.MaxIdleConns,
+			IdleConnTimeout:     90 * time.Second,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: !cfg.VerifyCert},
+		},
+		Timeout: time.Duration(cfg.TimeoutMillis) * time.Millisecond,
+	}
+}
+
+// igniteSession authenticates against the Ignite REST API and keeps the session token it gets back,
+// so credentials don't need to be sent along with every request
+type igniteSession struct {
+	username string
+	password string
+
+	mu    sync.Mutex
+	token string
+}
+
+// authResponse is used to unmarshal the Ignite server's response to the "authenticate" command
+type authResponse struct {
+	Error        string `json:"error"`
+	Status       int    `json:"successStatus"`
+	SessionToken string `json:"sessionToken"`
+}
+
+// currentToken returns the session token, authenticating first if there isn't any yet. If the
+// session token is expired, it authenticates again and returns the new one.
+func (s *igniteSession) currentToken(ctx context.Context, ig *IgniteBackend, expired string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request might have renewed the session already
+	if len(s.token) > 0 && s.token != expired {
+		return s.token, nil
+	}
+
+	params := url.Values{}
+	params.Set("cmd", "authenticate")
+	params.Set("ignite.login", s.username)
+	params.Set("ignite.password", s.password)
+
+	responseBytes, err := ig.sender.DoRequest(ctx, ig.serverURL, params, ig.headers)
+	if err != nil {
+		return "", err
+	}
+
+	igniteResponse := authResponse{}
+	if unmarshalErr := json.Unmarshal(responseBytes, &igniteResponse); unmarshalErr != nil {
+		return "", fmt.Errorf("Ignite authentication response unmarshal error: %s", unmarshalErr.Error())
+	}
+	if len(igniteResponse.Error) > 0 {
+		return "", fmt.Errorf("Ignite authentication error. %s", igniteResponse.Error)
+	}
+	if igniteResponse.Status > 0 || len(igniteResponse.SessionToken) == 0 {
+		return "", fmt.Errorf("Ignite authentication error. successStatus: %d", igniteResponse.Status)
+	}
+
+	s.token = igniteResponse.SessionToken
+	return s.token, nil
+}
+
+// send sends the command in params to the Ignite REST API, along with the cache name and, when
+// authentication is enabled, the session token. If the session token expired, it gets renewed and
+// the command is sent once more.
+func (ig *IgniteBackend) send(ctx context.Context, params url.Values) ([]byte, error) {
+	params.Set("cacheName", ig.cacheName)
+	if ig.session == nil {
+		return ig.sender.DoRequest(ctx, ig.serverURL, params, ig.headers)
+	}
+
+	var responseBytes []byte
+	var token string
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if token, err = ig.session.currentToken(ctx, ig, token); err != nil {
+			return nil, err
+		}
+		params.Set("sessionToken", token)
+
+		if responseBytes, err = ig.sender.DoRequest(ctx, ig.serverURL, params, ig.headers); err != nil {
+			return nil, err
+		}
+
+		status := struct {
+			Status int `json:"successStatus"`
+		}{}
+		if json.Unmarshal(responseBytes, &status) != nil || status.Status != igniteAuthFailedStatus {
+			break
+		}
+	}
+	return responseBytes, nil
+}
+
 // createCache uses the Apache Ignite REST API "getorcreate" command to create a cache
 func createCache(igb *IgniteBackend) error {
+	if igb.thinClient != nil {
+		return igb.thinClient.CreateCache(context.Background())
+	}
 
-	urlCopy := *igb.serverURL
-	q := urlCopy.Query()
-	q.Set("cmd", "getorcreate")
-	q.Set("cacheName", igb.cacheName)
-	urlCopy.RawQuery = q.Encode()
+	params := url.Values{}
+	params.Set("cmd", "getorcreate")
 
-	responseBytes, err := igb.sender.DoRequest(context.Background(), &urlCopy, nil)
+	responseBytes, err := igb.send(context.Background(), params)
 	if err != nil {
 		return err
 	}
@@ -174,14 +282,15 @@ type getResponse struct {
 // when Ignite doesn't return an error, nor a 'Status' different than zero, but the 'Response' field is
 // empty. Get can also return Ignite server-side errors
 func (ig *IgniteBackend) Get(ctx context.Context, key string) (string, error) {
-	urlCopy := *ig.serverURL
-	q := urlCopy.Query()
-	q.Set("cmd", "get")
-	q.Set("key", key)
+	if ig.thinClient != nil {
+		return ig.thinClient.Get(ctx, key)
+	}
 
-	urlCopy.RawQuery = q.Encode()
+	params := url.Values{}
+	params.Set("cmd", "get")
+	params.Set("key", key)
 
-	responseBytes, err := ig.sender.DoRequest(ctx, &urlCopy, ig.headers)
+	responseBytes, err := ig.send(ctx, params)
 	if err != nil {
 		return "", err
 	}
@@ -218,17 +327,17 @@ type putResponse struct {
 // the storage already. Returns RecordExistsError or whatever PUT_INTERNAL_SERVER error we might
 // find in the storage side
 func (ig *IgniteBackend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
+	if ig.thinClient != nil {
+		return ig.thinClient.PutIfAbsent(ctx, key, value, ttlSeconds)
+	}
 
-	urlCopy := *ig.serverURL
-	q := urlCopy.Query()
-	q.Set("cmd", "putifabs")
-	q.Set("key", key)
-	q.Set("val", value)
-	q.Set("exp", fmt.Sprintf("%d", ttlSeconds*1000))
-
-	urlCopy.RawQuery = q.Encode()
+	params := url.Values{}
+	params.Set("cmd", "putifabs")
+	params.Set("key", key)
+	params.Set("val", value)
+	params.Set("exp", fmt.Sprintf("%d", ttlSeconds*1000))
 
-	responseBytes, err := ig.sender.DoRequest(ctx, &urlCopy, ig.headers)
+	responseBytes, err := ig.send(ctx, params)
 	if err != nil {
 		return err
 	}
@@ -254,3 +363,33 @@ func (ig *IgniteBackend) Put(ctx context.Context, key string, value string, ttlS
 
 	return nil
 }
+
+// HealthCheck uses the Apache Ignite REST API "version" command to make sure the Ignite server
+// is reachable and responsive
+func (ig *IgniteBackend) HealthCheck(ctx context.Context) error {
+	if ig.thinClient != nil {
+		return ig.thinClient.HealthCheck(ctx)
+	}
+
+	params := url.Values{}
+	params.Set("cmd", "version")
+
+	responseBytes, err := ig.send(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	igniteResponse := getResponse{}
+	if unmarshalErr := json.Unmarshal(responseBytes, &igniteResponse); unmarshalErr != nil {
+		return fmt.Errorf("Unmarshal response error: %s; Response body: %s", unmarshalErr.Error(), string(responseBytes))
+	}
+
+	if len(igniteResponse.Error) > 0 {
+		return fmt.Errorf("Ignite error. %s", igniteResponse.Error)
+	}
+	if igniteResponse.Status > 0 {
+		return fmt.Errorf("Ignite error. successStatus does not equal 0 %v", igniteResponse)
+	}
+
+	return nil
+}
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/utils"
@@ -47,13 +49,13 @@ func TestDoRequest(t *testing.T) {
 				ctx:      context.TODO(),
 				httpResp: nil,
 				httpErr: &url.Error{
-					Op:  "GET",
+					Op:  "POST",
 					Err: errors.New("fake http.Client error"),
 				},
 			},
 			expected: testOutput{
 				resp: nil,
-				err:  &url.Error{Op: "GET", Err: errors.New("fake http.Client error")},
+				err:  &url.Error{Op: "POST", Err: errors.New("fake http.Client error")},
 			},
 		},
 		{
@@ -137,13 +139,118 @@ func TestDoRequest(t *testing.T) {
 				},
 			},
 		}
-		actualResp, actualErr := fakeIgniteClient.DoRequest(tc.in.ctx, &url.URL{}, tc.in.headers)
+		actualResp, actualErr := fakeIgniteClient.DoRequest(tc.in.ctx, &url.URL{}, url.Values{}, tc.in.headers)
 
 		assert.Equal(t, tc.expected.resp, actualResp, tc.desc)
 		assert.Equal(t, tc.expected.err, actualErr, tc.desc)
 	}
 }
 
+func TestDoRequestPostsForm(t *testing.T) {
+	var receivedReq *http.Request
+	var receivedForm url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedReq = r
+		assert.NoError(t, r.ParseForm())
+		receivedForm = r.PostForm
+		w.Write([]byte(`{"successStatus":0}`))
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL + "/ignite")
+	assert.NoError(t, err)
+
+	headers := http.Header{"Header": []string{"value"}}
+	params := url.Values{"cmd": []string{"putifabs"}, "key": []string{"someKey"}, "val": []string{"<VAST version=\"4.0\"></VAST>"}}
+
+	sender := &igniteSender{httpClient: server.Client()}
+	resp, err := sender.DoRequest(context.Background(), serverURL, params, headers)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{"successStatus":0}`), resp)
+	if assert.NotNil(t, receivedReq) {
+		assert.Equal(t, http.MethodPost, receivedReq.Method)
+		assert.Empty(t, receivedReq.URL.RawQuery, "values must not be sent in the URL")
+		assert.Equal(t, "application/x-www-form-urlencoded", receivedReq.Header.Get("Content-Type"))
+		assert.Equal(t, "value", receivedReq.Header.Get("Header"))
+		assert.Equal(t, params, receivedForm)
+	}
+	assert.Len(t, headers, 1, "the configured headers must not be modified")
+}
+
+func TestIgniteSession(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		responses        []string
+		expectedErr      error
+		expectedCommands []string
+		expectedTokens   []string
+	}{
+		{
+			desc: "Authenticates before the first request",
+			responses: []string{
+				`{"successStatus":0,"sessionToken":"token1"}`,
+				`{"successStatus":0,"response":"value"}`,
+			},
+			expectedCommands: []string{"authenticate", "get"},
+			expectedTokens:   []string{"", "token1"},
+		},
+		{
+			desc: "Expired session gets renewed and the request sent again",
+			responses: []string{
+				`{"successStatus":0,"sessionToken":"token1"}`,
+				`{"successStatus":2,"error":"Failed to authenticate remote client (session token expired?)"}`,
+				`{"successStatus":0,"sessionToken":"token2"}`,
+				`{"successStatus":0,"response":"value"}`,
+			},
+			expectedCommands: []string{"authenticate", "get", "authenticate", "get"},
+			expectedTokens:   []string{"", "token1", "", "token2"},
+		},
+		{
+			desc: "Wrong credentials",
+			responses: []string{
+				`{"successStatus":2,"error":"The user name or password is incorrect"}`,
+			},
+			expectedErr:      errors.New("Ignite authentication error. The user name or password is incorrect"),
+			expectedCommands: []string{"authenticate"},
+			expectedTokens:   []string{""},
+		},
+	}
+
+	for _, tc := range testCases {
+		responses := tc.responses
+		client := &fakeIgniteClient{}
+		client.respond = func() ([]byte, error) {
+			resp := responses[0]
+			responses = responses[1:]
+			return []byte(resp), nil
+		}
+		back := &IgniteBackend{
+			sender:    client,
+			serverURL: &url.URL{},
+			cacheName: "myCache",
+			session:   &igniteSession{username: "ignite", password: "secret"},
+		}
+
+		v, err := back.Get(context.Background(), "someKey")
+
+		if tc.expectedErr != nil {
+			assert.Equal(t, tc.expectedErr, err, tc.desc)
+		} else {
+			assert.NoError(t, err, tc.desc)
+			assert.Equal(t, "value", v, tc.desc)
+		}
+		if assert.Len(t, client.requests, len(tc.expectedCommands), tc.desc) {
+			for i, req := range client.requests {
+				assert.Equal(t, tc.expectedCommands[i], req.Get("cmd"), tc.desc)
+				assert.Equal(t, tc.expectedTokens[i], req.Get("sessionToken"), tc.desc)
+			}
+		}
+		assert.Equal(t, "ignite", client.requests[0].Get("ignite.login"), tc.desc)
+		assert.Equal(t, "secret", client.requests[0].Get("ignite.password"), tc.desc)
+	}
+}
+
 func TestNewIgniteBackend(t *testing.T) {
 	type logEntry struct {
 		msg string
@@ -283,7 +390,7 @@ func TestNewIgniteBackend(t *testing.T) {
 						panicHappens: true,
 						logEntries: []logEntry{
 							{
-								msg: "Error creating Ignite backend: error parsing Ignite host URL parse \":invalid:://127.0.0.1:8080/ignite?cacheName=myCache\": missing protocol scheme",
+								msg: "Error creating Ignite backend: error parsing Ignite host URL parse \":invalid:://127.0.0.1:8080/ignite\": missing protocol scheme",
 								lvl: logrus.FatalLevel,
 							},
 						},
@@ -310,13 +417,20 @@ func TestNewIgniteBackend(t *testing.T) {
 					expected: testOut{
 						backend: &IgniteBackend{
 							serverURL: &url.URL{
-								Scheme:   "http",
-								Host:     "127.0.0.1:8080",
-								Path:     "/ignite",
-								RawQuery: "cacheName=myCache",
+								Scheme: "http",
+								Host:   "127.0.0.1:8080",
+								Path:   "/ignite",
 							},
-							sender:  &igniteSender{httpClient: http.DefaultClient},
-							headers: http.Header{"Header": []string{"Value"}},
+							sender: &igniteSender{
+								httpClient: &http.Client{
+									Transport: &http.Transport{
+										IdleConnTimeout: 90 * time.Second,
+										TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
+									},
+								},
+							},
+							headers:   http.Header{"Header": []string{"Value"}},
+							cacheName: "myCache",
 						},
 						panicHappens: false,
 						logEntries: []logEntry{
@@ -343,17 +457,65 @@ func TestNewIgniteBackend(t *testing.T) {
 					expected: testOut{
 						backend: &IgniteBackend{
 							serverURL: &url.URL{
-								Scheme:   "http",
-								Host:     "127.0.0.1:8080",
-								Path:     "/ignite",
-								RawQuery: "cacheName=myCache",
+								Scheme: "http",
+								Host:   "127.0.0.1:8080",
+								Path:   "/ignite",
 							},
 							sender: &igniteSender{
 								httpClient: &http.Client{
-									Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+									Transport: &http.Transport{
+										IdleConnTimeout: 90 * time.Second,
+										TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+									},
 								},
 							},
-							headers: http.Header{"Header": []string{"Value"}},
+							headers:   http.Header{"Header": []string{"Value"}},
+							cacheName: "myCache",
+						},
+						panicHappens: false,
+						logEntries: []logEntry{
+							{
+								msg: "Prebid Cache will write to Ignite cache name: myCache",
+								lvl: logrus.InfoLevel,
+							},
+						},
+					},
+				},
+				{
+					desc: "Expect a pooled http client with a timeout and a session to authenticate with",
+					in: config.Ignite{
+						Scheme:        "https",
+						Host:          "127.0.0.1",
+						Port:          8443,
+						VerifyCert:    true,
+						Username:      "ignite",
+						Password:      "secret",
+						TimeoutMillis: 500,
+						MaxIdleConns:  20,
+						Cache: config.IgniteCache{
+							Name: "myCache",
+						},
+					},
+					expected: testOut{
+						backend: &IgniteBackend{
+							serverURL: &url.URL{
+								Scheme: "https",
+								Host:   "127.0.0.1:8443",
+								Path:   "/ignite",
+							},
+							sender: &igniteSender{
+								httpClient: &http.Client{
+									Transport: &http.Transport{
+										MaxIdleConns:        20,
+										MaxIdleConnsPerHost: 20,
+										IdleConnTimeout:     90 * time.Second,
+										TLSClientConfig:     &tls.Config{InsecureSkipVerify: false},
+									},
+									Timeout: 500 * time.Millisecond,
+								},
+							},
+							cacheName: "myCache",
+							session:   &igniteSession{username: "ignite", password: "secret"},
 						},
 						panicHappens: false,
 						logEntries: []logEntry{
@@ -391,6 +553,15 @@ func TestNewIgniteBackend(t *testing.T) {
 				}
 			}
 
+			// Functions can't be compared, so make sure the http client honors the proxy environment
+			// variables before comparing the rest of the backend
+			if resultingBackend != nil {
+				if sender, ok := resultingBackend.sender.(*igniteSender); ok {
+					transport := sender.httpClient.(*http.Client).Transport.(*http.Transport)
+					assert.NotNilf(t, transport.Proxy, "%s - %s", group.desc, tc.desc)
+					transport.Proxy = nil
+				}
+			}
 			assert.Equalf(t, tc.expected.backend, resultingBackend, "%s - %s", group.desc, tc.desc)
 
 			//Reset log after every test and assert successful reset
@@ -402,12 +573,23 @@ func TestNewIgniteBackend(t *testing.T) {
 
 type fakeIgniteClient struct {
 	respond func() ([]byte, error)
+	// requests records the form parameters of every request
+	requests []url.Values
 }
 
-func (c *fakeIgniteClient) DoRequest(ctx context.Context, url *url.URL, headers http.Header) ([]byte, error) {
+func (c *fakeIgniteClient) DoRequest(ctx context.Context, url *url.URL, params url.Values, headers http.Header) ([]byte, error) {
+	c.requests = append(c.requests, cloneValues(params))
 	return c.respond()
 }
 
+func cloneValues(params url.Values) url.Values {
+	clone := url.Values{}
+	for k, v := range params {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
+
 func TestIgniteGet(t *testing.T) {
 	type testInput struct {
 		igniteResponse []byte
@@ -666,3 +848,37 @@ func TestCreateCache(t *testing.T) {
 		assert.Equal(t, tc.expected.err, createCache(back), tc.desc)
 	}
 }
+
+func TestIgniteHealthCheck(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		inIgniteResponse []byte
+		inServerError    error
+		expectedError    error
+	}{
+		{
+			desc:             "Ignite responds to the version command",
+			inIgniteResponse: []byte(`{"successStatus":0,"error":null,"response":"2.11.1","sessionToken":null}`),
+		},
+		{
+			desc:          "Ignite server is unreachable",
+			inServerError: errors.New("connection refused"),
+			expectedError: errors.New("connection refused"),
+		},
+		{
+			desc:             "Ignite responds with an error",
+			inIgniteResponse: []byte(`{"successStatus":1,"error":"Failed to handle request","response":null,"sessionToken":null}`),
+			expectedError:    errors.New("Ignite error. Failed to handle request"),
+		},
+		{
+			desc:             "Ignite responds with a malformed body",
+			inIgniteResponse: []byte(`malformed`),
+			expectedError:    errors.New("Unmarshal response error: invalid character 'm' looking for beginning of value; Response body: malformed"),
+		},
+	}
+
+	for _, tc := range testCases {
+		backend := NewFakeIgniteBackend(tc.inIgniteResponse, tc.inServerError)
+		assert.Equal(t, tc.expectedError, backend.HealthCheck(context.Background()), tc.desc)
+	}
+}
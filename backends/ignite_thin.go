@@ -0,0 +1,402 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"unicode/utf16"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+)
+
+// Binary protocol of the Ignite thin clients, as documented in
+// https://ignite.apache.org/docs/2.11.1/binary-client-protocol/binary-client-protocol
+// Version 1.6.0 is the first one to support expiry policies on cache operations, and ships with Ignite 2.8.
+const (
+	igniteThinVersionMajor int16 = 1
+	igniteThinVersionMinor int16 = 6
+	igniteThinVersionPatch int16 = 0
+
+	igniteThinHandshake  byte = 1
+	igniteThinClientCode byte = 2
+
+	igniteOpCacheGet                 int16 = 1000
+	igniteOpCachePutIfAbsent         int16 = 1002
+	igniteOpCacheGetNames            int16 = 1050
+	igniteOpCacheGetOrCreateWithName int16 = 1052
+
+	igniteTypeString byte = 9
+	igniteTypeNull   byte = 101
+
+	igniteFlagWithExpiryPolicy byte = 0x04
+	// igniteExpiryUnchanged leaves the expiration of an entry as it is when it gets updated or accessed
+	igniteExpiryUnchanged int64 = -2
+
+	igniteResponseFlagError           int16 = 0x01
+	igniteResponseFlagTopologyChanged int16 = 0x02
+)
+
+// igniteThinClient talks to an Ignite node over the binary protocol of the Ignite thin clients. It
+// keeps up to cfg.MaxIdleConns connections open, and bounds each request with cfg.TimeoutMillis.
+type igniteThinClient struct {
+	address   string
+	dial      func(ctx context.Context, network, address string) (net.Conn, error)
+	username  string
+	password  string
+	timeout   time.Duration
+	cacheName string
+	cacheID   int32
+	idle      chan net.Conn
+	requestID int64
+}
+
+// igniteThinError is an error the Ignite node responded with
+type igniteThinError struct {
+	status  int32
+	message string
+}
+
+func (e *igniteThinError) Error() string {
+	return fmt.Sprintf("Ignite error. Status %d: %s", e.status, e.message)
+}
+
+func newIgniteThinClient(cfg config.Ignite) *igniteThinClient {
+	c := &igniteThinClient{
+		address:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		dial:      (&net.Dialer{}).DialContext,
+		username:  cfg.Username,
+		password:  cfg.Password,
+		timeout:   time.Duration(cfg.TimeoutMillis) * time.Millisecond,
+		cacheName: cfg.Cache.Name,
+		cacheID:   igniteCacheID(cfg.Cache.Name),
+		idle:      make(chan net.Conn, cfg.MaxIdleConns),
+	}
+	if cfg.Scheme == "tls" {
+		c.dial = (&tls.Dialer{Config: &tls.Config{InsecureSkipVerify: !cfg.VerifyCert}}).DialContext
+	}
+	return c
+}
+
+// igniteCacheID returns the ID Ignite identifies the cache named name with, which is the Java hash
+// code of its name
+func igniteCacheID(name string) int32 {
+	var hash int32
+	for _, c := range utf16.Encode([]rune(name)) {
+		hash = 31*hash + int32(c)
+	}
+	if hash == 0 {
+		return 1
+	}
+	return hash
+}
+
+// Get retrieves the value stored under key, or returns a KEY_NOT_FOUND error if there's none
+func (c *igniteThinClient) Get(ctx context.Context, key string) (string, error) {
+	payload := c.cacheHeader(0)
+	writeIgniteString(payload, key)
+
+	resp, err := c.do(ctx, igniteOpCacheGet, payload.Bytes())
+	if err != nil {
+		var igniteErr *igniteThinError
+		if errors.As(err, &igniteErr) {
+			return "", utils.NewPBCError(utils.GET_INTERNAL_SERVER, igniteErr.Error())
+		}
+		return "", err
+	}
+
+	value, err := readIgniteString(bytes.NewReader(resp))
+	if err != nil {
+		return "", utils.NewPBCError(utils.GET_INTERNAL_SERVER, fmt.Sprintf("Ignite response error: %s", err.Error()))
+	}
+	if value == nil {
+		return "", utils.NewPBCError(utils.KEY_NOT_FOUND)
+	}
+	return *value, nil
+}
+
+// PutIfAbsent stores value under key for ttlSeconds, unless key already exists in which case it
+// returns a RECORD_EXISTS error
+func (c *igniteThinClient) PutIfAbsent(ctx context.Context, key string, value string, ttlSeconds int) error {
+	payload := c.cacheHeader(igniteFlagWithExpiryPolicy)
+	binary.Write(payload, binary.LittleEndian, int64(ttlSeconds)*1000)
+	binary.Write(payload, binary.LittleEndian, igniteExpiryUnchanged)
+	binary.Write(payload, binary.LittleEndian, igniteExpiryUnchanged)
+	writeIgniteString(payload, key)
+	writeIgniteString(payload, value)
+
+	resp, err := c.do(ctx, igniteOpCachePutIfAbsent, payload.Bytes())
+	if err != nil {
+		var igniteErr *igniteThinError
+		if errors.As(err, &igniteErr) {
+			return utils.NewPBCError(utils.PUT_INTERNAL_SERVER, igniteErr.Error())
+		}
+		return err
+	}
+
+	if len(resp) < 1 {
+		return utils.NewPBCError(utils.PUT_INTERNAL_SERVER, "Ignite response error: empty response")
+	}
+	if resp[0] == 0 {
+		return utils.NewPBCError(utils.RECORD_EXISTS)
+	}
+	return nil
+}
+
+// CreateCache creates the cache if it doesn't exist yet
+func (c *igniteThinClient) CreateCache(ctx context.Context) error {
+	payload := &bytes.Buffer{}
+	writeIgniteString(payload, c.cacheName)
+
+	_, err := c.do(ctx, igniteOpCacheGetOrCreateWithName, payload.Bytes())
+	return err
+}
+
+// HealthCheck makes sure the Ignite node is reachable and responsive by listing its caches
+func (c *igniteThinClient) HealthCheck(ctx context.Context) error {
+	_, err := c.do(ctx, igniteOpCacheGetNames, nil)
+	return err
+}
+
+// cacheHeader returns a buffer that starts with the ID of the cache and the flags of the operation
+func (c *igniteThinClient) cacheHeader(flags byte) *bytes.Buffer {
+	payload := &bytes.Buffer{}
+	binary.Write(payload, binary.LittleEndian, c.cacheID)
+	payload.WriteByte(flags)
+	return payload
+}
+
+// do sends the request of operation op to the Ignite node and returns the payload of its response.
+// Connections are put back into the idle pool unless an I/O error makes them unusable.
+func (c *igniteThinClient) do(ctx context.Context, op int16, payload []byte) ([]byte, error) {
+	conn, err := c.getConn(ctx)
+	if err != nil {
+		return nil, classifyIgniteTimeout(err)
+	}
+
+	conn.SetDeadline(c.deadline(ctx))
+	resp, err := c.roundTrip(conn, op, payload)
+	if err != nil {
+		var igniteErr *igniteThinError
+		if !errors.As(err, &igniteErr) {
+			conn.Close()
+			return nil, classifyIgniteTimeout(err)
+		}
+	}
+
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return resp, err
+}
+
+// deadline returns the earliest of the context deadline and the client timeout, or the zero time
+// if none of them is set
+func (c *igniteThinClient) deadline(ctx context.Context) time.Time {
+	var deadline time.Time
+	if c.timeout > 0 {
+		deadline = time.Now().Add(c.timeout)
+	}
+	if ctxDeadline, ok := ctx.Deadline(); ok && (deadline.IsZero() || ctxDeadline.Before(deadline)) {
+		deadline = ctxDeadline
+	}
+	return deadline
+}
+
+// getConn returns an idle connection, or opens a new one and performs the protocol handshake on it
+func (c *igniteThinClient) getConn(ctx context.Context) (net.Conn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	deadline := c.deadline(ctx)
+	dialCtx := ctx
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	conn, err := c.dial(dialCtx, "tcp", c.address)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(deadline)
+	if err := c.handshake(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// handshake negotiates the protocol version and authenticates, if credentials are set
+func (c *igniteThinClient) handshake(conn net.Conn) error {
+	msg := &bytes.Buffer{}
+	msg.WriteByte(igniteThinHandshake)
+	binary.Write(msg, binary.LittleEndian, igniteThinVersionMajor)
+	binary.Write(msg, binary.LittleEndian, igniteThinVersionMinor)
+	binary.Write(msg, binary.LittleEndian, igniteThinVersionPatch)
+	msg.WriteByte(igniteThinClientCode)
+	if len(c.username) > 0 {
+		writeIgniteString(msg, c.username)
+		writeIgniteString(msg, c.password)
+	}
+
+	if err := writeIgniteMessage(conn, msg.Bytes()); err != nil {
+		return err
+	}
+	resp, err := readIgniteMessage(conn)
+	if err != nil {
+		return err
+	}
+	if len(resp) < 1 {
+		return errors.New("Ignite handshake error: empty response")
+	}
+	if resp[0] == 1 {
+		return nil
+	}
+
+	// The node responds with the protocol version it supports and the reason of the failure
+	r := bytes.NewReader(resp[1:])
+	var version [3]int16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return errors.New("Ignite handshake error")
+	}
+	reason, _ := readIgniteString(r)
+	if reason == nil {
+		reason = new(string)
+	}
+	return fmt.Errorf("Ignite handshake error. Node supports protocol version %d.%d.%d: %s", version[0], version[1], version[2], *reason)
+}
+
+// roundTrip writes the request of operation op and reads its response, making sure it's the one
+// that answers the request
+func (c *igniteThinClient) roundTrip(conn net.Conn, op int16, payload []byte) ([]byte, error) {
+	requestID := atomic.AddInt64(&c.requestID, 1)
+
+	msg := &bytes.Buffer{}
+	binary.Write(msg, binary.LittleEndian, op)
+	binary.Write(msg, binary.LittleEndian, requestID)
+	msg.Write(payload)
+	if err := writeIgniteMessage(conn, msg.Bytes()); err != nil {
+		return nil, err
+	}
+
+	resp, err := readIgniteMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(resp)
+	header := struct {
+		RequestID int64
+		Flags     int16
+	}{}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("Ignite response error: %s", err.Error())
+	}
+	if header.RequestID != requestID {
+		return nil, fmt.Errorf("Ignite response error: expected a response to request %d, got %d", requestID, header.RequestID)
+	}
+	if header.Flags&igniteResponseFlagTopologyChanged != 0 {
+		// Topology version, which only matters to clients that route requests to the primary nodes
+		if _, err := r.Seek(8+4, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+	if header.Flags&igniteResponseFlagError != 0 {
+		igniteErr := &igniteThinError{}
+		if err := binary.Read(r, binary.LittleEndian, &igniteErr.status); err != nil {
+			return nil, fmt.Errorf("Ignite response error: %s", err.Error())
+		}
+		if message, _ := readIgniteString(r); message != nil {
+			igniteErr.message = *message
+		}
+		return nil, igniteErr
+	}
+
+	return resp[len(resp)-r.Len():], nil
+}
+
+// writeIgniteMessage writes msg prefixed by its length
+func writeIgniteMessage(w io.Writer, msg []byte) error {
+	buf := make([]byte, 4+len(msg))
+	binary.LittleEndian.PutUint32(buf, uint32(len(msg)))
+	copy(buf[4:], msg)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readIgniteMessage reads a message prefixed by its length
+func readIgniteMessage(r io.Reader) ([]byte, error) {
+	var length int32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("Ignite response error: invalid message length %d", length)
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// writeIgniteString writes s as a string object
+func writeIgniteString(buf *bytes.Buffer, s string) {
+	buf.WriteByte(igniteTypeString)
+	binary.Write(buf, binary.LittleEndian, int32(len(s)))
+	buf.WriteString(s)
+}
+
+// readIgniteString reads a string object, or returns nil if the object is null
+func readIgniteString(r *bytes.Reader) (*string, error) {
+	typeCode, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch typeCode {
+	case igniteTypeNull:
+		return nil, nil
+	case igniteTypeString:
+	default:
+		return nil, fmt.Errorf("unexpected object type %d, expected a string", typeCode)
+	}
+
+	var length int32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	if length < 0 || int(length) > r.Len() {
+		return nil, fmt.Errorf("invalid string length %d", length)
+	}
+	s := make([]byte, length)
+	if _, err := io.ReadFull(r, s); err != nil {
+		return nil, err
+	}
+	str := string(s)
+	return &str, nil
+}
+
+// classifyIgniteTimeout turns network timeouts into context.DeadlineExceeded, so a slow node gets
+// reported as such regardless of which of the client timeout and the request deadline expired first
+func classifyIgniteTimeout(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
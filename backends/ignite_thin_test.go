@@ -0,0 +1,268 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIgniteNode speaks enough of the thin client protocol to serve gets and puts from memory
+type fakeIgniteNode struct {
+	listener net.Listener
+	username string
+	password string
+	// stall makes the node read requests without ever responding to them
+	stall bool
+
+	mu          sync.Mutex
+	values      map[string]string
+	ttls        map[string]int64
+	caches      []int32
+	connections int
+}
+
+func newFakeIgniteNode(t *testing.T) *fakeIgniteNode {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	node := &fakeIgniteNode{listener: listener, values: map[string]string{}, ttls: map[string]int64{}}
+	go node.serve()
+	t.Cleanup(func() { listener.Close() })
+	return node
+}
+
+func (n *fakeIgniteNode) config() config.Ignite {
+	host, port, _ := net.SplitHostPort(n.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return config.Ignite{
+		Protocol:      config.IgniteThin,
+		Scheme:        "tcp",
+		Host:          host,
+		Port:          portNumber,
+		TimeoutMillis: 1000,
+		MaxIdleConns:  2,
+		Cache:         config.IgniteCache{Name: "prebid"},
+	}
+}
+
+func (n *fakeIgniteNode) serve() {
+	for {
+		conn, err := n.listener.Accept()
+		if err != nil {
+			return
+		}
+		n.mu.Lock()
+		n.connections++
+		n.mu.Unlock()
+		go n.handle(conn)
+	}
+}
+
+func (n *fakeIgniteNode) handle(conn net.Conn) {
+	defer conn.Close()
+
+	msg, err := readIgniteMessage(conn)
+	if err != nil {
+		return
+	}
+	r := bytes.NewReader(msg)
+	r.Seek(1+2+2+2+1, 0)
+	username, _ := readIgniteString(r)
+	password, _ := readIgniteString(r)
+	if n.username != "" && (username == nil || password == nil || *username != n.username || *password != n.password) {
+		resp := &bytes.Buffer{}
+		resp.WriteByte(0)
+		binary.Write(resp, binary.LittleEndian, [3]int16{1, 7, 0})
+		writeIgniteString(resp, "The user name or password is incorrect")
+		writeIgniteMessage(conn, resp.Bytes())
+		return
+	}
+	writeIgniteMessage(conn, []byte{1})
+
+	for {
+		msg, err := readIgniteMessage(conn)
+		if err != nil {
+			return
+		}
+		if n.stall {
+			io.Copy(io.Discard, conn)
+			return
+		}
+		r := bytes.NewReader(msg)
+		var op int16
+		var requestID int64
+		binary.Read(r, binary.LittleEndian, &op)
+		binary.Read(r, binary.LittleEndian, &requestID)
+
+		resp := &bytes.Buffer{}
+		binary.Write(resp, binary.LittleEndian, requestID)
+		payload := n.execute(op, r)
+		if payload == nil {
+			binary.Write(resp, binary.LittleEndian, igniteResponseFlagError)
+			binary.Write(resp, binary.LittleEndian, int32(1))
+			writeIgniteString(resp, "Cache does not exist")
+		} else {
+			binary.Write(resp, binary.LittleEndian, int16(0))
+			resp.Write(payload)
+		}
+		if writeIgniteMessage(conn, resp.Bytes()) != nil {
+			return
+		}
+	}
+}
+
+// execute runs the operation and returns its payload, or nil if it failed
+func (n *fakeIgniteNode) execute(op int16, r *bytes.Reader) []byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	payload := &bytes.Buffer{}
+	switch op {
+	case igniteOpCacheGetNames:
+		binary.Write(payload, binary.LittleEndian, int32(0))
+	case igniteOpCacheGetOrCreateWithName:
+		name, _ := readIgniteString(r)
+		n.caches = append(n.caches, igniteCacheID(*name))
+	case igniteOpCacheGet, igniteOpCachePutIfAbsent:
+		var cacheID int32
+		binary.Read(r, binary.LittleEndian, &cacheID)
+		flags, _ := r.ReadByte()
+		var ttl [3]int64
+		if flags&igniteFlagWithExpiryPolicy != 0 {
+			binary.Read(r, binary.LittleEndian, &ttl)
+		}
+		if cacheID != igniteCacheID("prebid") {
+			return nil
+		}
+		key, _ := readIgniteString(r)
+		if op == igniteOpCacheGet {
+			if value, ok := n.values[*key]; ok {
+				writeIgniteString(payload, value)
+			} else {
+				payload.WriteByte(igniteTypeNull)
+			}
+			break
+		}
+		value, _ := readIgniteString(r)
+		if _, exists := n.values[*key]; exists {
+			payload.WriteByte(0)
+		} else {
+			n.values[*key] = *value
+			n.ttls[*key] = ttl[0]
+			payload.WriteByte(1)
+		}
+	}
+	return append([]byte{}, payload.Bytes()...)
+}
+
+// stats returns the number of connections accepted and the time-to-live key was stored with
+func (n *fakeIgniteNode) stats(key string) (int, int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.connections, n.ttls[key]
+}
+
+func TestIgniteCacheID(t *testing.T) {
+	assert.Equal(t, int32(1544803905), igniteCacheID("default"))
+	assert.Equal(t, int32(-980114566), igniteCacheID("prebid"))
+	assert.Equal(t, int32(1), igniteCacheID(""))
+}
+
+func TestIgniteThinClient(t *testing.T) {
+	node := newFakeIgniteNode(t)
+	backend := NewIgniteBackend(node.config())
+	ctx := context.Background()
+
+	assert.NoError(t, backend.HealthCheck(ctx), "Health check")
+
+	_, err := backend.Get(ctx, "someKey")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of a missing key")
+
+	assert.NoError(t, backend.Put(ctx, "someKey", "someValue", 60), "Put of a new key")
+	_, ttl := node.stats("someKey")
+	assert.Equal(t, int64(60000), ttl, "The time-to-live gets sent in milliseconds")
+
+	value, err := backend.Get(ctx, "someKey")
+	assert.NoError(t, err, "Get of an existing key")
+	assert.Equal(t, "someValue", value, "Get of an existing key")
+
+	err = backend.Put(ctx, "someKey", "otherValue", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "Put of an existing key")
+
+	connections, _ := node.stats("someKey")
+	assert.Equal(t, 1, connections, "Connections get reused")
+}
+
+func TestIgniteThinClientCreateCache(t *testing.T) {
+	node := newFakeIgniteNode(t)
+	cfg := node.config()
+	cfg.Cache.CreateOnStart = true
+
+	NewIgniteBackend(cfg)
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	assert.Equal(t, []int32{igniteCacheID("prebid")}, node.caches)
+}
+
+func TestIgniteThinClientErrors(t *testing.T) {
+	t.Run("Node responds with an error", func(t *testing.T) {
+		node := newFakeIgniteNode(t)
+		cfg := node.config()
+		cfg.Cache.Name = "unknown"
+		backend := NewIgniteBackend(cfg)
+
+		_, err := backend.Get(context.Background(), "someKey")
+		assert.Equal(t, utils.NewPBCError(utils.GET_INTERNAL_SERVER, "Ignite error. Status 1: Cache does not exist"), err)
+
+		err = backend.Put(context.Background(), "someKey", "someValue", 60)
+		assert.Equal(t, utils.NewPBCError(utils.PUT_INTERNAL_SERVER, "Ignite error. Status 1: Cache does not exist"), err)
+
+		connections, _ := node.stats("someKey")
+		assert.Equal(t, 1, connections, "Connections are kept after errors responded by the node")
+	})
+
+	t.Run("Authentication", func(t *testing.T) {
+		node := newFakeIgniteNode(t)
+		node.username = "ignite"
+		node.password = "secret"
+		cfg := node.config()
+
+		cfg.Username, cfg.Password = "ignite", "wrong"
+		err := NewIgniteBackend(cfg).HealthCheck(context.Background())
+		assert.EqualError(t, err, "Ignite handshake error. Node supports protocol version 1.7.0: The user name or password is incorrect")
+
+		cfg.Username, cfg.Password = "ignite", "secret"
+		assert.NoError(t, NewIgniteBackend(cfg).HealthCheck(context.Background()))
+	})
+
+	t.Run("Node doesn't respond in time", func(t *testing.T) {
+		node := newFakeIgniteNode(t)
+		node.stall = true
+		cfg := node.config()
+		cfg.TimeoutMillis = 50
+		backend := NewIgniteBackend(cfg)
+
+		start := time.Now()
+		err := backend.Put(context.Background(), "someKey", "someValue", 60)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Less(t, time.Since(start), time.Second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		cfg.TimeoutMillis = 0
+		_, err = NewIgniteBackend(cfg).Get(ctx, "someKey")
+		assert.Equal(t, context.DeadlineExceeded, err, "Requests are bounded by their context too")
+	})
+}
@@ -2,9 +2,13 @@ package backends
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"time"
 
-	"github.com/google/gomemcache/memcache"
+	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/utils"
 	log "github.com/sirupsen/logrus"
@@ -16,6 +20,7 @@ import (
 type MemcacheDataStore interface {
 	Get(key string) (*memcache.Item, error)
 	Put(key string, value string, ttlSeconds int) error
+	Ping() error
 }
 
 // Memcache Object use to implement MemcacheDataStore interface
@@ -41,6 +46,12 @@ func (mc *Memcache) Put(key string, value string, ttlSeconds int) error {
 	})
 }
 
+// Ping uses the github.com/bradfitz/gomemcache/memcache library to make sure
+// every memcache server is reachable
+func (mc *Memcache) Ping() error {
+	return mc.client.Ping()
+}
+
 // MemcacheBackend implements the Backend interface
 type MemcacheBackend struct {
 	memcache MemcacheDataStore
@@ -49,28 +60,122 @@ type MemcacheBackend struct {
 // NewMemcacheBackend creates a new memcache backend and expects a valid
 // 'cfg config.Memcache' argument
 func NewMemcacheBackend(cfg config.Memcache) *MemcacheBackend {
+	mc, err := newMemcacheClient(cfg)
+	if err != nil {
+		log.Fatalf("Error creating Memcache backend: %v", err)
+		panic("Memcache failure. This shouldn't happen.")
+	}
+
+	return &MemcacheBackend{
+		memcache: &Memcache{mc},
+	}
+}
+
+// newMemcacheClient returns a client that connects to the configured nodes, or to the ones the
+// configuration endpoint lists in auto discovery mode, over TLS if enabled
+func newMemcacheClient(cfg config.Memcache) (*memcache.Client, error) {
+	timeout := time.Duration(cfg.TimeoutMillis) * time.Millisecond
+	if timeout == 0 {
+		timeout = utils.MEMCACHE_TIMEOUT_MS * time.Millisecond
+	}
+
+	var dialContext func(ctx context.Context, network, address string) (net.Conn, error)
+	if cfg.TLS.Enabled {
+		tlsConfig, err := newMemcacheTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		// The client bounds each dial with its timeout. Certificates get verified against the host
+		// connected to unless a server name is set.
+		dialContext = (&tls.Dialer{Config: tlsConfig}).DialContext
+	} else {
+		dialContext = (&net.Dialer{}).DialContext
+	}
+
 	var mc *memcache.Client
 	if cfg.ConfigHost != "" {
-		var err error
-		mc, err = memcache.NewDiscoveryClient(cfg.ConfigHost, time.Duration(cfg.PollIntervalSeconds)*time.Second)
+		servers, err := newMemcacheDiscovery(cfg.ConfigHost, time.Duration(cfg.PollIntervalSeconds)*time.Second, timeout, dialContext)
 		if err != nil {
-			log.Fatalf("%v", err)
-			panic("Memcache failure. This shouldn't happen.")
+			return nil, err
 		}
+		mc = memcache.NewFromSelector(servers)
 	} else {
 		mc = memcache.New(cfg.Hosts...)
 	}
+	mc.Timeout = timeout
+	mc.MaxIdleConns = cfg.MaxIdleConns
+	mc.DialContext = dialContext
+	return mc, nil
+}
 
-	return &MemcacheBackend{
-		memcache: &Memcache{mc},
+// newMemcacheTLSConfig builds the TLS configuration of the connections to the memcache nodes
+func newMemcacheTLSConfig(cfg config.MemcacheTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		ServerName:         cfg.ServerName,
+	}
+	if cfg.CAFile != "" {
+		pool, err := utils.LoadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load key pair %s, %s: %v", cfg.CertFile, cfg.KeyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// withContext runs op unless ctx is already done, and returns as soon as either op completes or ctx
+// is done, whichever comes first. The memcache client isn't aware of contexts, so op keeps running in
+// the background until the client timeout, which is reported the same way as an expired context.
+func withContext(ctx context.Context, op func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+	}()
+
+	select {
+	case err := <-done:
+		return classifyMemcacheTimeout(err)
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
+// classifyMemcacheTimeout turns the client timeouts into context.DeadlineExceeded, so a slow node
+// gets reported the same way as a request whose deadline expired
+func classifyMemcacheTimeout(err error) error {
+	var connectTimeout *memcache.ConnectTimeoutError
+	if errors.As(err, &connectTimeout) {
+		return context.DeadlineExceeded
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
 // Get makes the MemcacheDataStore client to retrieve the value that has been previously
 // stored under 'key'. If unseuccessful, returns an empty value and a KeyNotFoundError
 // or other, memcache-related error
 func (mc *MemcacheBackend) Get(ctx context.Context, key string) (string, error) {
-	res, err := mc.memcache.Get(key)
+	var res *memcache.Item
+	err := withContext(ctx, func() error {
+		var err error
+		res, err = mc.memcache.Get(key)
+		return err
+	})
 
 	if err != nil {
 		if err == memcache.ErrCacheMiss {
@@ -85,9 +190,16 @@ func (mc *MemcacheBackend) Get(ctx context.Context, key string) (string, error)
 // Put makes the MemcacheDataStore client to store `value` only if `key` doesn't exist
 // in the storage already. If it does, no operation is performed and Put returns RecordExistsError
 func (mc *MemcacheBackend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
-	err := mc.memcache.Put(key, value, ttlSeconds)
+	err := withContext(ctx, func() error {
+		return mc.memcache.Put(key, value, ttlSeconds)
+	})
 	if err != nil && err == memcache.ErrNotStored {
 		return utils.NewPBCError(utils.RECORD_EXISTS)
 	}
 	return err
 }
+
+// HealthCheck pings every memcache server
+func (mc *MemcacheBackend) HealthCheck(ctx context.Context) error {
+	return withContext(ctx, mc.memcache.Ping)
+}
@@ -0,0 +1,145 @@
+package backends
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	log "github.com/sirupsen/logrus"
+)
+
+// memcacheDiscovery keeps the nodes of an ElastiCache memcached cluster up to date. It polls the
+// configuration endpoint of the cluster with the "config get cluster" command and hands the nodes
+// it lists to the client through a memcache.ServerList.
+type memcacheDiscovery struct {
+	configHost  string
+	timeout     time.Duration
+	dialContext func(ctx context.Context, network, address string) (net.Conn, error)
+	servers     *memcache.ServerList
+	configID    int64
+}
+
+// newMemcacheDiscovery polls the configuration endpoint at configHost once before returning the
+// list of nodes it found, which then gets refreshed every pollInterval. A failed poll is logged and
+// the nodes that were last found keep being used.
+func newMemcacheDiscovery(configHost string, pollInterval time.Duration, timeout time.Duration, dialContext func(ctx context.Context, network, address string) (net.Conn, error)) (*memcache.ServerList, error) {
+	if pollInterval < time.Second {
+		return nil, errors.New("Discovery polling interval must be at least one second")
+	}
+
+	d := &memcacheDiscovery{
+		configHost:  configHost,
+		timeout:     timeout,
+		dialContext: dialContext,
+		servers:     new(memcache.ServerList),
+		configID:    -1,
+	}
+	if err := d.poll(); err != nil {
+		log.Warnf("First poll of the memcache configuration endpoint %s failed: %v", configHost, err)
+	}
+	go d.pollEvery(pollInterval)
+
+	return d.servers, nil
+}
+
+func (d *memcacheDiscovery) pollEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := d.poll(); err != nil {
+			log.Warnf("Poll of the memcache configuration endpoint %s failed: %v", d.configHost, err)
+		}
+	}
+}
+
+// poll fetches the cluster configuration and updates the list of nodes if its version is newer
+// than the one of the configuration that was last applied
+func (d *memcacheDiscovery) poll() error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	conn, err := d.dialContext(ctx, "tcp", d.configHost)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(d.timeout))
+
+	if _, err := io.WriteString(conn, "config get cluster\r\n"); err != nil {
+		return err
+	}
+	configID, nodes, err := parseMemcacheClusterConfig(bufio.NewReader(conn))
+	if err != nil {
+		return err
+	}
+	if configID <= d.configID {
+		return nil
+	}
+
+	if err := d.servers.SetServers(nodes...); err != nil {
+		return err
+	}
+	d.configID = configID
+	log.Infof("Memcache cluster configuration %d lists the nodes %v", configID, nodes)
+	return nil
+}
+
+// parseMemcacheClusterConfig reads the response to "config get cluster", which looks like
+//
+//	CONFIG cluster 0 <length of the next two lines>\r\n
+//	<version>\n
+//	<hostname>|<ip address>|<port> <hostname>|<ip address>|<port>\n
+//	\r\n
+//	END\r\n
+//
+// and returns the version of the configuration along with the host:port address of every node.
+// Nodes are addressed by host name, or by IP address if they have none.
+func parseMemcacheClusterConfig(r *bufio.Reader) (int64, []string, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return 0, nil, err
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 4 || fields[0] != "CONFIG" {
+		return 0, nil, fmt.Errorf("unexpected response to config get cluster: %q", strings.TrimSpace(header))
+	}
+	length, err := strconv.Atoi(fields[3])
+	if err != nil || length < 0 {
+		return 0, nil, fmt.Errorf("invalid cluster config length: %s", fields[3])
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	lines := strings.SplitN(string(body), "\n", 3)
+	if len(lines) < 2 {
+		return 0, nil, fmt.Errorf("cluster config must hold a version and a list of nodes: %q", body)
+	}
+
+	configID, err := strconv.ParseInt(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid cluster config version: %s", lines[0])
+	}
+	var nodes []string
+	for _, node := range strings.Fields(lines[1]) {
+		parts := strings.Split(node, "|")
+		if len(parts) != 3 {
+			return 0, nil, fmt.Errorf("cluster node %s is not in the hostname|ip|port format", node)
+		}
+		host := parts[0]
+		if host == "" {
+			host = parts[1]
+		}
+		nodes = append(nodes, net.JoinHostPort(host, parts[2]))
+	}
+	return configID, nodes, nil
+}
@@ -1,16 +1,26 @@
 package backends
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
-	"github.com/google/gomemcache/memcache"
+	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/utils"
+	"github.com/prebid/prebid-cache/utils/certtest"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMemcacheGet(t *testing.T) {
@@ -171,26 +181,31 @@ func TestNewMemcacheBackend(t *testing.T) {
 		expectedLogEntries []logEntry
 	}{
 		{
-			desc: "PollIntervalSeconds is less than 1, memcache.NewDiscoveryClient will throw an error, expect Panic",
+			desc: "PollIntervalSeconds is less than 1, the discovery client can't be created, expect Panic",
 			inCfg: config.Memcache{
-				ConfigHost:          "somehost.com:0000",
+				ConfigHost:          "127.0.0.1:1",
 				PollIntervalSeconds: 0,
 			},
 			expectPanic: true,
 			expectedLogEntries: []logEntry{
 				{
-					msg: "Discovery polling duration is invalid",
+					msg: "Error creating Memcache backend: Discovery polling interval must be at least one second",
 					lvl: logrus.FatalLevel,
 				},
 			},
 		},
 		{
-			desc: "PollIntervalSeconds is greater than 1, memcache.NewDiscoveryClient will return a valid memcache client",
+			desc: "PollIntervalSeconds is greater than 1, an unreachable configuration endpoint doesn't prevent the client from being created",
 			inCfg: config.Memcache{
-				ConfigHost:          "somehost.com:0000",
+				ConfigHost:          "127.0.0.1:1",
 				PollIntervalSeconds: 2,
 			},
-			expectedLogEntries: []logEntry{},
+			expectedLogEntries: []logEntry{
+				{
+					msg: "First poll of the memcache configuration endpoint 127.0.0.1:1 failed",
+					lvl: logrus.WarnLevel,
+				},
+			},
 		},
 		{
 			desc: "ConfigHost is an empty string, memcache client gets created calling memcache.New(cfg.Hosts...)",
@@ -217,7 +232,7 @@ func TestNewMemcacheBackend(t *testing.T) {
 
 		if assert.Len(t, hook.Entries, len(test.expectedLogEntries), test.desc) {
 			for i := 0; i < len(test.expectedLogEntries); i++ {
-				assert.Equal(t, test.expectedLogEntries[i].msg, hook.Entries[i].Message, test.desc)
+				assert.Contains(t, hook.Entries[i].Message, test.expectedLogEntries[i].msg, test.desc)
 				assert.Equal(t, test.expectedLogEntries[i].lvl, hook.Entries[i].Level, test.desc)
 			}
 		}
@@ -227,3 +242,283 @@ func TestNewMemcacheBackend(t *testing.T) {
 		assert.Nil(t, hook.LastEntry())
 	}
 }
+
+func TestMemcacheHealthCheck(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inClient      MemcacheDataStore
+		expectedError error
+	}{
+		{
+			desc:     "Every memcache server responds",
+			inClient: &GoodMemcache{},
+		},
+		{
+			desc:          "A memcache server is unreachable",
+			inClient:      &ErrorProneMemcache{ServerError: memcache.ErrNoServers},
+			expectedError: memcache.ErrNoServers,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := NewMockMemcacheBackend(tc.inClient).HealthCheck(context.Background())
+		assert.Equal(t, tc.expectedError, err, tc.desc)
+	}
+}
+
+func TestMemcacheExpiredContext(t *testing.T) {
+	client := &GoodMemcache{StoredData: map[string]string{"key": "value"}}
+	backend := NewMockMemcacheBackend(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := backend.Get(ctx, "key")
+	assert.Equal(t, context.Canceled, err, "Get")
+	assert.Equal(t, context.Canceled, backend.Put(ctx, "other", "value", 10), "Put")
+	assert.Equal(t, context.Canceled, backend.HealthCheck(ctx), "HealthCheck")
+	assert.NotContains(t, client.StoredData, "other", "Expired requests shouldn't reach memcache")
+}
+
+func TestMemcacheContextDeadline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	// The node stalls longer than the request deadline, but not as long as the client timeout
+	serveFakeMemcache(ln, func(command string) string {
+		time.Sleep(time.Second)
+		return "END\r\n"
+	})
+	client, err := newMemcacheClient(config.Memcache{Hosts: []string{ln.Addr().String()}, TimeoutMillis: 5000})
+	require.NoError(t, err)
+	backend := NewMockMemcacheBackend(&Memcache{client})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = backend.Get(ctx, "key")
+
+	assert.Equal(t, context.DeadlineExceeded, err, "Requests are bounded by their deadline")
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "Requests return as soon as their deadline expires")
+}
+
+// timeoutError is a net.Error that reports a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyMemcacheTimeout(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 11211}
+	testCases := []struct {
+		desc     string
+		inErr    error
+		expected error
+	}{
+		{desc: "No error", inErr: nil, expected: nil},
+		{desc: "Connect timeout", inErr: &memcache.ConnectTimeoutError{Addr: addr}, expected: context.DeadlineExceeded},
+		{desc: "Read timeout", inErr: &net.OpError{Op: "read", Err: timeoutError{}}, expected: context.DeadlineExceeded},
+		{desc: "Cache miss", inErr: memcache.ErrCacheMiss, expected: memcache.ErrCacheMiss},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, classifyMemcacheTimeout(tc.inErr), tc.desc)
+	}
+}
+
+func TestNewMemcacheBackendSettings(t *testing.T) {
+	backend := NewMemcacheBackend(config.Memcache{Hosts: []string{"127.0.0.1:11211"}, TimeoutMillis: 50, MaxIdleConns: 16})
+
+	if assert.IsType(t, &Memcache{}, backend.memcache) {
+		client := backend.memcache.(*Memcache).client
+		assert.Equal(t, 50*time.Millisecond, client.Timeout)
+		assert.Equal(t, 16, client.MaxIdleConns)
+	}
+
+	backend = NewMemcacheBackend(config.Memcache{Hosts: []string{"127.0.0.1:11211"}})
+	assert.Equal(t, 100*time.Millisecond, backend.memcache.(*Memcache).client.Timeout, "Default timeout")
+}
+
+func TestTLSMemcache(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	caFile := ca.WriteCA(t, dir)
+	pair := ca.Issue(t, dir, "server")
+
+	// Fake memcache server that doesn't find any key
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{pair.Load(t)}})
+	require.NoError(t, err)
+	defer ln.Close()
+	serveFakeMemcache(ln, func(string) string { return "END\r\n" })
+
+	testCases := []struct {
+		desc          string
+		inTLS         config.MemcacheTLS
+		expectedError error
+	}{
+		{
+			desc:          "Server certificate signed by the configured CA",
+			inTLS:         config.MemcacheTLS{Enabled: true, CAFile: caFile, ServerName: "localhost"},
+			expectedError: utils.NewPBCError(utils.KEY_NOT_FOUND),
+		},
+		{
+			desc:          "Server certificate signed by an unknown CA",
+			inTLS:         config.MemcacheTLS{Enabled: true, ServerName: "localhost"},
+			expectedError: errors.New("x509: certificate signed by unknown authority"),
+		},
+	}
+
+	for _, tc := range testCases {
+		client, err := newMemcacheClient(config.Memcache{Hosts: []string{ln.Addr().String()}, TimeoutMillis: 1000, TLS: tc.inTLS})
+		require.NoError(t, err, tc.desc)
+		backend := NewMockMemcacheBackend(&Memcache{client})
+
+		_, err = backend.Get(context.Background(), "key")
+		if pbcErr, ok := tc.expectedError.(utils.PBCError); ok {
+			assert.Equal(t, pbcErr, err, tc.desc)
+		} else if assert.Error(t, err, tc.desc) {
+			assert.Contains(t, err.Error(), tc.expectedError.Error(), tc.desc)
+		}
+	}
+}
+
+func TestNewMemcacheClientErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := newMemcacheClient(config.Memcache{TLS: config.MemcacheTLS{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")}})
+	assert.Error(t, err, "Missing CA file")
+
+	_, err = newMemcacheClient(config.Memcache{TLS: config.MemcacheTLS{Enabled: true, CertFile: filepath.Join(dir, "missing.pem"), KeyFile: filepath.Join(dir, "missing-key.pem")}})
+	assert.Error(t, err, "Missing client certificate")
+
+	_, err = newMemcacheClient(config.Memcache{ConfigHost: "127.0.0.1:1", PollIntervalSeconds: 0})
+	assert.Error(t, err, "Poll interval below one second")
+}
+
+func TestMemcacheDiscovery(t *testing.T) {
+	// Fake memcache node that doesn't find any key
+	node, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer node.Close()
+	serveFakeMemcache(node, func(string) string { return "END\r\n" })
+	nodeHost, nodePort, _ := net.SplitHostPort(node.Addr().String())
+
+	// Fake configuration endpoint listing the node above. The version of the config is bumped by
+	// every test step.
+	var clusterConfig atomic.Value
+	clusterConfig.Store("1\n|" + nodeHost + "|" + nodePort + "\n")
+	configEndpoint, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer configEndpoint.Close()
+	serveFakeMemcache(configEndpoint, func(command string) string {
+		if command != "config get cluster" {
+			return "ERROR\r\n"
+		}
+		return clusterConfigResponse(clusterConfig.Load().(string))
+	})
+
+	client, err := newMemcacheClient(config.Memcache{ConfigHost: configEndpoint.Addr().String(), PollIntervalSeconds: 60, TimeoutMillis: 1000})
+	require.NoError(t, err)
+	backend := NewMockMemcacheBackend(&Memcache{client})
+
+	_, err = backend.Get(context.Background(), "key")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Node found by the first poll")
+
+	// A newer config without nodes leaves the client without servers
+	servers := new(memcache.ServerList)
+	discovery := &memcacheDiscovery{configHost: configEndpoint.Addr().String(), timeout: time.Second, dialContext: (&net.Dialer{}).DialContext, servers: servers, configID: -1}
+	require.NoError(t, discovery.poll())
+	assert.Equal(t, int64(1), discovery.configID)
+
+	clusterConfig.Store("2\n\n")
+	require.NoError(t, discovery.poll())
+	assert.Equal(t, int64(2), discovery.configID)
+	_, err = memcache.NewFromSelector(servers).Get("key")
+	assert.Equal(t, memcache.ErrNoServers, err, "Nodes removed by a newer config")
+
+	// An older config gets ignored
+	clusterConfig.Store("1\n|" + nodeHost + "|" + nodePort + "\n")
+	require.NoError(t, discovery.poll())
+	assert.Equal(t, int64(2), discovery.configID)
+	_, err = memcache.NewFromSelector(servers).Get("key")
+	assert.Equal(t, memcache.ErrNoServers, err, "Older config")
+}
+
+func TestParseMemcacheClusterConfig(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		inResponse       string
+		expectedConfigID int64
+		expectedNodes    []string
+		expectedError    bool
+	}{
+		{
+			desc:             "Nodes with host names",
+			inResponse:       clusterConfigResponse("12\nnode-1.cache.amazonaws.com|10.0.0.1|11211 node-2.cache.amazonaws.com|10.0.0.2|11211\n"),
+			expectedConfigID: 12,
+			expectedNodes:    []string{"node-1.cache.amazonaws.com:11211", "node-2.cache.amazonaws.com:11211"},
+		},
+		{
+			desc:             "Node without host name",
+			inResponse:       clusterConfigResponse("3\n|10.0.0.1|11211\n"),
+			expectedConfigID: 3,
+			expectedNodes:    []string{"10.0.0.1:11211"},
+		},
+		{
+			desc:          "Server without auto discovery",
+			inResponse:    "ERROR\r\n",
+			expectedError: true,
+		},
+		{
+			desc:          "Malformed node",
+			inResponse:    clusterConfigResponse("3\n10.0.0.1:11211\n"),
+			expectedError: true,
+		},
+		{
+			desc:          "Truncated response",
+			inResponse:    "CONFIG cluster 0 90\r\n12\n",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		configID, nodes, err := parseMemcacheClusterConfig(bufio.NewReader(strings.NewReader(tc.inResponse)))
+		if tc.expectedError {
+			assert.Error(t, err, tc.desc)
+			continue
+		}
+		if assert.NoError(t, err, tc.desc) {
+			assert.Equal(t, tc.expectedConfigID, configID, tc.desc)
+			assert.Equal(t, tc.expectedNodes, nodes, tc.desc)
+		}
+	}
+}
+
+// clusterConfigResponse returns the response of a configuration endpoint to "config get cluster"
+func clusterConfigResponse(body string) string {
+	return fmt.Sprintf("CONFIG cluster 0 %d\r\n%s\r\nEND\r\n", len(body), body)
+}
+
+// serveFakeMemcache answers every command line received by ln with the response respond returns
+func serveFakeMemcache(ln net.Listener, respond func(command string) string) {
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					conn.Write([]byte(respond(strings.TrimSpace(line))))
+				}
+			}()
+		}
+	}()
+}
@@ -0,0 +1,111 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/metrics"
+	"github.com/prebid/prebid-cache/utils"
+)
+
+// MigrateBackend moves the entries of a running Prebid Cache from an old storage backend to a new one.
+// Writes go to the new backend and, for a transition window, get mirrored to the old one. Reads are
+// served by the new backend and fall back to the old one if the key is not found.
+type MigrateBackend struct {
+	newBackend     Backend
+	oldBackend     Backend
+	mirrorUntil    time.Time
+	copyOnRead     bool
+	copyTTLSeconds int
+	metrics        *metrics.Metrics
+	now            func() time.Time
+}
+
+// NewMigrateBackend returns a MigrateBackend that moves entries from oldBackend to newBackend
+func NewMigrateBackend(cfg config.Migrate, newBackend Backend, oldBackend Backend, appMetrics *metrics.Metrics) *MigrateBackend {
+	return &MigrateBackend{
+		newBackend:     newBackend,
+		oldBackend:     oldBackend,
+		mirrorUntil:    time.Now().Add(time.Duration(cfg.MirrorWritesSeconds) * time.Second),
+		copyOnRead:     cfg.CopyOnRead,
+		copyTTLSeconds: cfg.CopyOnReadTTLSeconds,
+		metrics:        appMetrics,
+		now:            time.Now,
+	}
+}
+
+// Put writes the value to the new backend. During the transition window, successful writes are also
+// mirrored to the old backend. Failing to mirror a value doesn't fail the request.
+func (b *MigrateBackend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
+	if err := b.newBackend.Put(ctx, key, value, ttlSeconds); err != nil {
+		return err
+	}
+
+	if b.now().Before(b.mirrorUntil) {
+		if err := b.oldBackend.Put(ctx, key, value, ttlSeconds); err != nil && !isRecordExists(err) {
+			log.Debugf("Failed to mirror key %s to the old backend: %v", key, err)
+			b.metrics.RecordMigrationMirrorError()
+		}
+	}
+	return nil
+}
+
+// Get reads the value from the new backend. If it's not there, the old backend gets queried and, if
+// copy-on-read is enabled, the value found gets copied to the new backend.
+func (b *MigrateBackend) Get(ctx context.Context, key string) (string, error) {
+	value, err := b.newBackend.Get(ctx, key)
+	if err == nil {
+		b.metrics.RecordMigrationReadNew()
+		return value, nil
+	}
+	if !isKeyNotFound(err) {
+		return "", err
+	}
+
+	value, oldErr := b.oldBackend.Get(ctx, key)
+	if oldErr != nil {
+		if isKeyNotFound(oldErr) {
+			b.metrics.RecordMigrationReadMiss()
+			return "", err
+		}
+		return "", oldErr
+	}
+	b.metrics.RecordMigrationReadOld()
+
+	if b.copyOnRead {
+		// A record that already exists was written to the new backend in the meantime, keep that one
+		switch err := b.newBackend.Put(ctx, key, value, b.copyTTLSeconds); {
+		case err == nil:
+			b.metrics.RecordMigrationCopy()
+		case !isRecordExists(err):
+			log.Debugf("Failed to copy key %s to the new backend: %v", key, err)
+			b.metrics.RecordMigrationCopyError()
+		}
+	}
+	return value, nil
+}
+
+// HealthCheck reports the backend as unhealthy if any of the two backends is, as both of them serve reads
+func (b *MigrateBackend) HealthCheck(ctx context.Context) error {
+	if err := CheckHealth(ctx, b.newBackend); err != nil {
+		return fmt.Errorf("new backend: %v", err)
+	}
+	if err := CheckHealth(ctx, b.oldBackend); err != nil {
+		return fmt.Errorf("old backend: %v", err)
+	}
+	return nil
+}
+
+func isKeyNotFound(err error) bool {
+	pbcErr, ok := err.(utils.PBCError)
+	return ok && pbcErr.Type == utils.KEY_NOT_FOUND
+}
+
+func isRecordExists(err error) bool {
+	pbcErr, ok := err.(utils.PBCError)
+	return ok && pbcErr.Type == utils.RECORD_EXISTS
+}
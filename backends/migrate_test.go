@@ -0,0 +1,194 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/metrics"
+	"github.com/prebid/prebid-cache/metrics/metricstest"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateBackendGet(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		inNewData       map[string]string
+		inOldData       map[string]string
+		inNewBackendErr bool
+		inCopyOnRead    bool
+		expectedValue   string
+		expectedErr     error
+		expectedNewData map[string]string
+		expectedMetrics []string
+	}{
+		{
+			desc:            "Key found in the new backend",
+			inNewData:       map[string]string{"key": "new"},
+			inOldData:       map[string]string{"key": "old"},
+			inCopyOnRead:    true,
+			expectedValue:   "new",
+			expectedNewData: map[string]string{"key": "new"},
+			expectedMetrics: []string{"RecordMigrationReadNew"},
+		},
+		{
+			desc:            "Key only found in the old backend, no copy on read",
+			inOldData:       map[string]string{"key": "old"},
+			expectedValue:   "old",
+			expectedNewData: map[string]string{},
+			expectedMetrics: []string{"RecordMigrationReadOld"},
+		},
+		{
+			desc:            "Key only found in the old backend, copy on read",
+			inOldData:       map[string]string{"key": "old"},
+			inCopyOnRead:    true,
+			expectedValue:   "old",
+			expectedNewData: map[string]string{"key": "old"},
+			expectedMetrics: []string{"RecordMigrationReadOld", "RecordMigrationCopy"},
+		},
+		{
+			desc:            "Key not found in any backend",
+			inCopyOnRead:    true,
+			expectedErr:     utils.NewPBCError(utils.KEY_NOT_FOUND),
+			expectedNewData: map[string]string{},
+			expectedMetrics: []string{"RecordMigrationReadMiss"},
+		},
+		{
+			desc:            "New backend errors other than key not found don't fall back to the old backend",
+			inOldData:       map[string]string{"key": "old"},
+			inNewBackendErr: true,
+			expectedErr:     errors.New("Backend error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		mockMetrics := metricstest.CreateMockMetrics()
+		m := &metrics.Metrics{MetricEngines: []metrics.CacheMetrics{&mockMetrics}}
+
+		oldBackend, _ := NewMemoryBackendWithValues(tc.inOldData)
+		newMemoryBackend, _ := NewMemoryBackendWithValues(tc.inNewData)
+		var newBackend Backend = newMemoryBackend
+		if tc.inNewBackendErr {
+			newBackend = NewErrorResponseMemoryBackend()
+		}
+		backend := NewMigrateBackend(config.Migrate{CopyOnRead: tc.inCopyOnRead, CopyOnReadTTLSeconds: 60}, newBackend, oldBackend, m)
+
+		value, err := backend.Get(context.Background(), "key")
+
+		assert.Equal(t, tc.expectedValue, value, tc.desc)
+		assert.Equal(t, tc.expectedErr, err, tc.desc)
+		if tc.expectedNewData != nil {
+			assert.Equal(t, tc.expectedNewData, newMemoryBackend.db, tc.desc)
+		}
+		assertMigrationMetrics(t, tc.expectedMetrics, &mockMetrics)
+	}
+}
+
+func TestMigrateBackendPut(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		inOldData           map[string]string
+		inMirrorSeconds     int
+		inElapsed           time.Duration
+		inOldBackendErr     bool
+		expectedOldData     map[string]string
+		expectedMirrorError bool
+	}{
+		{
+			desc:            "Mirroring disabled",
+			expectedOldData: map[string]string{},
+		},
+		{
+			desc:            "Within the mirroring window, the value gets written to both backends",
+			inMirrorSeconds: 60,
+			inElapsed:       30 * time.Second,
+			expectedOldData: map[string]string{"key": "value"},
+		},
+		{
+			desc:            "Past the mirroring window, the value only gets written to the new backend",
+			inMirrorSeconds: 60,
+			inElapsed:       90 * time.Second,
+			expectedOldData: map[string]string{},
+		},
+		{
+			desc:            "Key already in the old backend isn't a mirroring error",
+			inOldData:       map[string]string{"key": "old"},
+			inMirrorSeconds: 60,
+			expectedOldData: map[string]string{"key": "old"},
+		},
+		{
+			desc:                "Failing to mirror doesn't fail the put",
+			inMirrorSeconds:     60,
+			inOldBackendErr:     true,
+			expectedMirrorError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		mockMetrics := metricstest.CreateMockMetrics()
+		m := &metrics.Metrics{MetricEngines: []metrics.CacheMetrics{&mockMetrics}}
+
+		newBackend := NewMemoryBackend()
+		oldMemoryBackend, _ := NewMemoryBackendWithValues(tc.inOldData)
+		var oldBackend Backend = oldMemoryBackend
+		if tc.inOldBackendErr {
+			oldBackend = NewErrorResponseMemoryBackend()
+		}
+		backend := NewMigrateBackend(config.Migrate{MirrorWritesSeconds: tc.inMirrorSeconds}, newBackend, oldBackend, m)
+		start := time.Now()
+		backend.now = func() time.Time { return start.Add(tc.inElapsed) }
+
+		err := backend.Put(context.Background(), "key", "value", 60)
+
+		assert.NoError(t, err, tc.desc)
+		assert.Equal(t, map[string]string{"key": "value"}, newBackend.db, tc.desc)
+		if !tc.inOldBackendErr {
+			assert.Equal(t, tc.expectedOldData, oldMemoryBackend.db, tc.desc)
+		}
+		if tc.expectedMirrorError {
+			assertMigrationMetrics(t, []string{"RecordMigrationMirrorError"}, &mockMetrics)
+		} else {
+			assertMigrationMetrics(t, nil, &mockMetrics)
+		}
+	}
+}
+
+func TestMigrateBackendPutNewBackendError(t *testing.T) {
+	mockMetrics := metricstest.CreateMockMetrics()
+	m := &metrics.Metrics{MetricEngines: []metrics.CacheMetrics{&mockMetrics}}
+	oldBackend := NewMemoryBackend()
+	backend := NewMigrateBackend(config.Migrate{MirrorWritesSeconds: 60}, NewErrorResponseMemoryBackend(), oldBackend, m)
+
+	err := backend.Put(context.Background(), "key", "value", 60)
+
+	assert.Error(t, err)
+	assert.Empty(t, oldBackend.db, "Values that failed to be written to the new backend must not be mirrored")
+}
+
+// assertMigrationMetrics asserts that, out of the migration metrics, only the expected ones were recorded
+func assertMigrationMetrics(t *testing.T, expected []string, m *metricstest.MockMetrics) {
+	t.Helper()
+
+	all := []string{
+		"RecordMigrationReadNew",
+		"RecordMigrationReadOld",
+		"RecordMigrationReadMiss",
+		"RecordMigrationCopy",
+		"RecordMigrationCopyError",
+		"RecordMigrationMirrorError",
+	}
+	for _, name := range all {
+		wasExpected := false
+		for _, e := range expected {
+			wasExpected = wasExpected || e == name
+		}
+		if wasExpected {
+			m.AssertCalled(t, name)
+		} else {
+			m.AssertNotCalled(t, name)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// NATSBackend implements the Backend interface and stores values in a key-value bucket of NATS
+// JetStream. JetStream only expires values once they reach the max age of their bucket, so each value
+// is stored after an expiry header holding the time its TTL ends, and is reported missing once it's
+// past.
+type NATSBackend struct {
+	conn    *nats.Conn
+	kv      jetstream.KeyValue
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewNATSBackend makes sure the bucket is reachable, after creating it if configured to
+func NewNATSBackend(cfg config.NATS) *NATSBackend {
+	backend, err := newNATSBackend(cfg)
+	if err != nil {
+		log.Fatalf("Error creating NATS backend: %v", err)
+		panic("NATSBackend failure. This shouldn't happen.")
+	}
+	log.Infof("Prebid Cache will store values in the %s NATS bucket", cfg.Bucket)
+	return backend
+}
+
+func newNATSBackend(cfg config.NATS) (*NATSBackend, error) {
+	if len(cfg.Servers) == 0 {
+		return nil, errors.New("the NATS servers cannot be empty")
+	}
+
+	conn, err := nats.Connect(strings.Join(cfg.Servers, ","), natsOptions(cfg)...)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := openNATSBucket(conn, cfg, time.Now)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return backend, nil
+}
+
+// natsOptions returns the options of the connection to the servers, which are tried in order and
+// reconnected to for as long as Prebid Cache runs
+func natsOptions(cfg config.NATS) []nats.Option {
+	options := []nats.Option{
+		nats.Name("prebid-cache"),
+		nats.DontRandomize(),
+		nats.MaxReconnects(-1),
+	}
+	if cfg.TimeoutMillis > 0 {
+		options = append(options, nats.Timeout(time.Duration(cfg.TimeoutMillis)*time.Millisecond))
+	}
+	switch {
+	case cfg.CredsFile != "":
+		options = append(options, nats.UserCredentials(cfg.CredsFile))
+	case cfg.Token != "":
+		options = append(options, nats.Token(cfg.Token))
+	case cfg.Username != "":
+		options = append(options, nats.UserInfo(cfg.Username, cfg.Password))
+	}
+	if cfg.TLS.CAFile != "" {
+		options = append(options, nats.RootCAs(cfg.TLS.CAFile))
+	}
+	if cfg.TLS.CertFile != "" {
+		options = append(options, nats.ClientCert(cfg.TLS.CertFile, cfg.TLS.KeyFile))
+	}
+	return options
+}
+
+// openNATSBucket opens the bucket, after creating it if configured to
+func openNATSBucket(conn *nats.Conn, cfg config.NATS, now func() time.Time) (*NATSBackend, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	maxAge := time.Duration(cfg.MaxAgeSeconds) * time.Second
+	if cfg.CreateBucketOnStart {
+		_, err := js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+			Bucket:   cfg.Bucket,
+			Replicas: cfg.Replicas,
+			TTL:      maxAge,
+		})
+		if errors.Is(err, jetstream.ErrBucketExists) {
+			log.Infof("The %s NATS bucket exists already", cfg.Bucket)
+		} else if err != nil {
+			return nil, fmt.Errorf("Failed to create the NATS bucket: %v", err)
+		}
+	}
+
+	kv, err := js.KeyValue(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	status, err := kv.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.TTL() != maxAge {
+		log.Warnf("The %s NATS bucket expires values after %v instead of the %v set in config.backend.nats.max_age_seconds", cfg.Bucket, status.TTL(), maxAge)
+	}
+
+	return &NATSBackend{
+		conn:    conn,
+		kv:      kv,
+		timeout: time.Duration(cfg.TimeoutMillis) * time.Millisecond,
+		now:     now,
+	}, nil
+}
+
+// Get returns the value stored under key, or a KEY_NOT_FOUND error if there's none or it expired
+func (b *NATSBackend) Get(ctx context.Context, key string) (string, error) {
+	ctx, cancel := b.withTimeout(ctx)
+	defer cancel()
+
+	entry, err := b.kv.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) || errors.Is(err, jetstream.ErrInvalidKey) {
+		return "", utils.NewPBCError(utils.KEY_NOT_FOUND)
+	}
+	if err != nil {
+		return "", classifyNATSTimeout(err)
+	}
+
+	value, expired, err := b.decodeValue(key, entry.Value())
+	if err != nil {
+		return "", err
+	}
+	if expired {
+		return "", utils.NewPBCError(utils.KEY_NOT_FOUND)
+	}
+	return value, nil
+}
+
+// Put stores value under key for ttlSeconds, or returns a RECORD_EXISTS error if key holds a value
+// that didn't expire yet. Writes are conditioned on the last revision of key, so concurrent puts of
+// the same key can't overwrite each other, and expired values only get replaced by a single put.
+func (b *NATSBackend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
+	ctx, cancel := b.withTimeout(ctx)
+	defer cancel()
+
+	data := b.encodeValue(value, ttlSeconds)
+	_, err := b.kv.Create(ctx, key, data)
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		err = b.replaceExpired(ctx, key, data)
+	}
+	switch {
+	case errors.Is(err, jetstream.ErrKeyExists):
+		return utils.NewPBCError(utils.RECORD_EXISTS)
+	case errors.Is(err, jetstream.ErrInvalidKey):
+		return utils.NewPBCError(utils.PUT_BAD_REQUEST, fmt.Sprintf("Key %s is not a valid NATS key. Keys can only contain letters, digits and the -/_=. characters.", key))
+	}
+	return classifyNATSTimeout(err)
+}
+
+// replaceExpired overwrites the value of key with data if it expired, or returns ErrKeyExists if it
+// didn't or if another put replaced it first
+func (b *NATSBackend) replaceExpired(ctx context.Context, key string, data []byte) error {
+	entry, err := b.kv.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		// The value got deleted after the put found it, which leaves no revision to update
+		return jetstream.ErrKeyExists
+	}
+	if err != nil {
+		return err
+	}
+	if _, expired, err := b.decodeValue(key, entry.Value()); err != nil || !expired {
+		return jetstream.ErrKeyExists
+	}
+	_, err = b.kv.Update(ctx, key, data, entry.Revision())
+	return err
+}
+
+// encodeValue prepends value with its expiry header: the Unix time in milliseconds the value expires
+// at, or 0 if it only expires with the max age of the bucket, followed by a line feed
+func (b *NATSBackend) encodeValue(value string, ttlSeconds int) []byte {
+	var expiresAt int64
+	if ttlSeconds > 0 {
+		expiresAt = b.now().Add(time.Duration(ttlSeconds) * time.Second).UnixMilli()
+	}
+	data := strconv.AppendInt(nil, expiresAt, 10)
+	data = append(data, '\n')
+	return append(data, value...)
+}
+
+// decodeValue splits the data stored under key into the value it holds and whether its expiry header
+// says it expired
+func (b *NATSBackend) decodeValue(key string, data []byte) (string, bool, error) {
+	header, value, found := bytes.Cut(data, []byte{'\n'})
+	expiresAt, err := strconv.ParseInt(string(header), 10, 64)
+	if !found || err != nil {
+		return "", false, fmt.Errorf("the value of key %s has no valid expiry header", key)
+	}
+	expired := expiresAt > 0 && b.now().UnixMilli() >= expiresAt
+	return string(value), expired, nil
+}
+
+// HealthCheck makes sure the bucket exists and the NATS servers are reachable
+func (b *NATSBackend) HealthCheck(ctx context.Context) error {
+	ctx, cancel := b.withTimeout(ctx)
+	defer cancel()
+
+	_, err := b.kv.Status(ctx)
+	return classifyNATSTimeout(err)
+}
+
+// withTimeout bounds ctx with the configured timeout, if any
+func (b *NATSBackend) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, b.timeout)
+}
+
+// classifyNATSTimeout turns the client timeouts into context.DeadlineExceeded, so a slow server gets
+// reported the same way as a request whose deadline expired
+func classifyNATSTimeout(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, nats.ErrTimeout) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
@@ -0,0 +1,230 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/prebid/prebid-cache/utils/certtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runNATSServer starts an in-process NATS server with JetStream enabled, customized by configure
+func runNATSServer(t *testing.T, configure func(opts *server.Options)) *server.Server {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	configure(&opts)
+
+	natsServer := natstest.RunServer(&opts)
+	t.Cleanup(natsServer.Shutdown)
+	return natsServer
+}
+
+func natsTestConfig(natsServer *server.Server) config.NATS {
+	return config.NATS{
+		Servers:       []string{natsServer.ClientURL()},
+		Bucket:        "prebid-cache",
+		Replicas:      1,
+		MaxAgeSeconds: 3600,
+		TimeoutMillis: 1000,
+	}
+}
+
+// createNATSBucket creates a bucket with kvConfig directly, the way an operator would
+func createNATSBucket(t *testing.T, natsServer *server.Server, kvConfig jetstream.KeyValueConfig) jetstream.KeyValue {
+	conn, err := nats.Connect(natsServer.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+	kv, err := js.CreateKeyValue(context.Background(), kvConfig)
+	require.NoError(t, err)
+	return kv
+}
+
+func TestNewNATSBackend(t *testing.T) {
+	natsServer := runNATSServer(t, func(opts *server.Options) {
+		opts.Username = "user"
+		opts.Password = "password"
+	})
+	validCfg := natsTestConfig(natsServer)
+	validCfg.Username = "user"
+	validCfg.Password = "password"
+
+	_, err := newNATSBackend(validCfg)
+	assert.ErrorIs(t, err, jetstream.ErrBucketNotFound, "The bucket must exist")
+
+	cfg := validCfg
+	cfg.CreateBucketOnStart = true
+	backend, err := newNATSBackend(cfg)
+	if assert.NoError(t, err, "The bucket gets created") {
+		status, err := backend.kv.Status(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, status.TTL(), "The max age of the bucket is configured")
+	}
+
+	_, err = newNATSBackend(cfg)
+	assert.NoError(t, err, "Buckets that exist are left as they are")
+
+	cfg = validCfg
+	cfg.Servers = append([]string{"nats://127.0.0.1:1"}, cfg.Servers...)
+	_, err = newNATSBackend(cfg)
+	assert.NoError(t, err, "Servers are tried in order")
+
+	cfg = validCfg
+	cfg.Password = "wrong-password"
+	_, err = newNATSBackend(cfg)
+	assert.ErrorIs(t, err, nats.ErrAuthorization, "The credentials must be valid")
+
+	_, err = newNATSBackend(config.NATS{Bucket: "prebid-cache"})
+	assert.EqualError(t, err, "the NATS servers cannot be empty")
+}
+
+func TestNATSGetAndPut(t *testing.T) {
+	natsServer := runNATSServer(t, func(opts *server.Options) {})
+	kv := createNATSBucket(t, natsServer, jetstream.KeyValueConfig{Bucket: "prebid-cache", TTL: time.Hour})
+	backend, err := newNATSBackend(natsTestConfig(natsServer))
+	require.NoError(t, err)
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err = backend.Get(ctx, "someKey")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of a missing key")
+
+	assert.NoError(t, backend.Put(ctx, "someKey", "someValue", 10), "Put of a new key")
+
+	value, err := backend.Get(ctx, "someKey")
+	assert.NoError(t, err, "Get of an existing key")
+	assert.Equal(t, "someValue", value, "Get of an existing key")
+	entry, err := kv.Get(ctx, "someKey")
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%d\nsomeValue", now.Add(10*time.Second).UnixMilli()), string(entry.Value()), "Values are stored after their expiry header")
+
+	err = backend.Put(ctx, "someKey", "otherValue", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "Put of an existing key")
+
+	now = now.Add(10 * time.Second)
+	_, err = backend.Get(ctx, "someKey")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of an expired key")
+
+	assert.NoError(t, backend.Put(ctx, "someKey", "otherValue", 60), "Put of an expired key")
+	value, err = backend.Get(ctx, "someKey")
+	assert.NoError(t, err, "Get of a key put again after it expired")
+	assert.Equal(t, "otherValue", value, "Get of a key put again after it expired")
+
+	assert.NoError(t, backend.Put(ctx, "forever", "value", 0), "Put without expiration time")
+	now = now.Add(24 * time.Hour)
+	err = backend.Put(ctx, "forever", "otherValue", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "Values without expiration time last as long as the bucket keeps them")
+
+	_, err = kv.Put(ctx, "deleted", []byte("0\nvalue"))
+	require.NoError(t, err)
+	require.NoError(t, kv.Delete(ctx, "deleted"))
+	_, err = backend.Get(ctx, "deleted")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of a deleted key")
+	assert.NoError(t, backend.Put(ctx, "deleted", "value", 60), "Put of a deleted key")
+
+	_, err = kv.Put(ctx, "malformed", []byte("value"))
+	require.NoError(t, err)
+	_, err = backend.Get(ctx, "malformed")
+	assert.EqualError(t, err, "the value of key malformed has no valid expiry header", "Get of a value without expiry header")
+	err = backend.Put(ctx, "malformed", "value", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "Values without expiry header never get replaced")
+
+	err = backend.Put(ctx, "some key", "value", 60)
+	assert.Equal(t, utils.NewPBCError(utils.PUT_BAD_REQUEST, "Key some key is not a valid NATS key. Keys can only contain letters, digits and the -/_=. characters."), err, "Put of an invalid key")
+	_, err = backend.Get(ctx, "some key")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of an invalid key")
+
+	assert.NoError(t, backend.HealthCheck(ctx), "Health check")
+}
+
+func TestNATSPutReplacesExpiredValuesOnce(t *testing.T) {
+	natsServer := runNATSServer(t, func(opts *server.Options) {})
+	createNATSBucket(t, natsServer, jetstream.KeyValueConfig{Bucket: "prebid-cache", TTL: time.Hour})
+	backend, err := newNATSBackend(natsTestConfig(natsServer))
+	require.NoError(t, err)
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, backend.Put(ctx, "someKey", "someValue", 10))
+	entry, err := backend.kv.Get(ctx, "someKey")
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+
+	// Another put replaces the expired value between the read of its revision and the update
+	require.NoError(t, backend.Put(ctx, "someKey", "firstValue", 60))
+	_, err = backend.kv.Update(ctx, "someKey", backend.encodeValue("secondValue", 60), entry.Revision())
+	assert.ErrorIs(t, err, jetstream.ErrKeyExists, "Updates are conditioned on the revision that expired")
+
+	value, err := backend.Get(ctx, "someKey")
+	assert.NoError(t, err)
+	assert.Equal(t, "firstValue", value, "The first put of an expired key wins")
+}
+
+func TestNATSTLS(t *testing.T) {
+	dir := t.TempDir()
+	authority := certtest.NewAuthority(t, "NATS CA")
+	caFile := authority.WriteCA(t, dir)
+	serverPair := authority.Issue(t, dir, "server")
+	clientPair := authority.Issue(t, dir, "client")
+
+	natsServer := runNATSServer(t, func(opts *server.Options) {
+		tlsConfig, err := server.GenTLSConfig(&server.TLSConfigOpts{
+			CertFile: serverPair.CertFile,
+			KeyFile:  serverPair.KeyFile,
+			CaFile:   caFile,
+			Verify:   true,
+		})
+		require.NoError(t, err)
+		opts.TLSConfig = tlsConfig
+		opts.TLSVerify = true
+	})
+
+	cfg := natsTestConfig(natsServer)
+	cfg.Servers = []string{strings.Replace(natsServer.ClientURL(), "nats://", "tls://", 1)}
+	cfg.CreateBucketOnStart = true
+	cfg.TLS = config.NATSTLS{CAFile: caFile, CertFile: clientPair.CertFile, KeyFile: clientPair.KeyFile}
+	backend, err := newNATSBackend(cfg)
+	if assert.NoError(t, err, "Mutual TLS") {
+		assert.NoError(t, backend.Put(context.Background(), "someKey", "someValue", 60))
+	}
+
+	cfg.TLS = config.NATSTLS{CAFile: caFile}
+	_, err = newNATSBackend(cfg)
+	assert.Error(t, err, "Servers that require mutual TLS reject clients without a certificate")
+
+	cfg.TLS = config.NATSTLS{}
+	_, err = newNATSBackend(cfg)
+	assert.Error(t, err, "Server certificates are verified against the system roots by default")
+}
+
+func TestNATSErrors(t *testing.T) {
+	natsServer := runNATSServer(t, func(opts *server.Options) {})
+	cfg := natsTestConfig(natsServer)
+	cfg.CreateBucketOnStart = true
+	cfg.TimeoutMillis = 10
+	backend, err := newNATSBackend(cfg)
+	require.NoError(t, err)
+
+	natsServer.Shutdown()
+
+	_, err = backend.Get(context.Background(), "someKey")
+	assert.Equal(t, context.DeadlineExceeded, err, "Client timeouts are reported as deadline errors")
+	err = backend.Put(context.Background(), "someKey", "someValue", 60)
+	assert.Equal(t, context.DeadlineExceeded, err, "Client timeouts are reported as deadline errors")
+	assert.Equal(t, context.DeadlineExceeded, backend.HealthCheck(context.Background()), "Client timeouts are reported as deadline errors")
+}
@@ -0,0 +1,193 @@
+package backends
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// PostgresBackend implements the Backend interface and stores values in a PostgreSQL table along
+// with their expiration time. Expired rows are filtered out of reads right away, and deleted by a
+// background reaper.
+type PostgresBackend struct {
+	db        *sql.DB
+	table     string
+	batchSize int
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewPostgresBackend connects to the database, creates the schema if configured to, and starts the
+// reaper of expired rows
+func NewPostgresBackend(cfg config.Postgres) *PostgresBackend {
+	db, err := sql.Open("postgres", postgresDSN(cfg))
+	if err != nil {
+		log.Fatalf("Error creating Postgres backend: %v", err)
+		panic("PostgresBackend failure. This shouldn't happen.")
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second)
+
+	backend, err := newPostgresBackend(cfg, db)
+	if err != nil {
+		log.Fatalf("Error creating Postgres backend: %v", err)
+		panic("PostgresBackend failure. This shouldn't happen.")
+	}
+	log.Infof("Connected to Postgres at %s:%d", cfg.Host, cfg.Port)
+
+	go backend.runReaper(time.Duration(cfg.ReaperIntervalSeconds) * time.Second)
+	return backend
+}
+
+func newPostgresBackend(cfg config.Postgres, db *sql.DB) (*PostgresBackend, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+	if cfg.CreateSchemaOnStart {
+		for _, statement := range postgresSchema(cfg.Table) {
+			if _, err := db.ExecContext(ctx, statement); err != nil {
+				return nil, fmt.Errorf("Failed to create the Postgres schema: %v", err)
+			}
+		}
+		log.Infof("Postgres table %s is ready", cfg.Table)
+	}
+
+	return &PostgresBackend{
+		db:        db,
+		table:     cfg.Table,
+		batchSize: cfg.ReaperBatchSize,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// postgresDSN returns the connection string of the database in the key/value format of libpq
+func postgresDSN(cfg config.Postgres) string {
+	settings := []struct{ key, value string }{
+		{"host", cfg.Host},
+		{"port", strconv.Itoa(cfg.Port)},
+		{"dbname", cfg.Database},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"sslmode", cfg.SSLMode},
+	}
+
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	dsn := make([]string, 0, len(settings))
+	for _, setting := range settings {
+		if setting.value != "" {
+			dsn = append(dsn, fmt.Sprintf("%s='%s'", setting.key, quote.Replace(setting.value)))
+		}
+	}
+	return strings.Join(dsn, " ")
+}
+
+// postgresSchema returns the statements that create the table and the index the reaper uses to
+// find the expired rows, as described in postgres_schema.sql
+func postgresSchema(table string) []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    key text PRIMARY KEY,
+    value text NOT NULL,
+    expires_at timestamptz NOT NULL
+)`, table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_expires_at_idx ON %s (expires_at)`, table, table),
+	}
+}
+
+// Get returns the value stored under key, or a KEY_NOT_FOUND error if there's none or it expired
+func (b *PostgresBackend) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := b.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT value FROM %s WHERE key = $1 AND expires_at > now()`, b.table), key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", utils.NewPBCError(utils.KEY_NOT_FOUND)
+	}
+	return value, err
+}
+
+// Put stores value under key for ttlSeconds, or returns a RECORD_EXISTS error if key is taken. Keys
+// of expired rows are only freed once the reaper deletes them.
+func (b *PostgresBackend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
+	result, err := b.db.ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO %s (key, value, expires_at) VALUES ($1, $2, now() + $3 * interval '1 second') ON CONFLICT (key) DO NOTHING`, b.table),
+		key, value, ttlSeconds)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return utils.NewPBCError(utils.RECORD_EXISTS)
+	}
+	return nil
+}
+
+// HealthCheck makes sure the database is reachable
+func (b *PostgresBackend) HealthCheck(ctx context.Context) error {
+	return b.db.PingContext(ctx)
+}
+
+// Close stops the reaper and closes the connection pool
+func (b *PostgresBackend) Close() error {
+	close(b.stop)
+	<-b.done
+	return b.db.Close()
+}
+
+// runReaper deletes the expired rows every interval until the backend gets closed
+func (b *PostgresBackend) runReaper(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			if deleted, err := b.reap(context.Background()); err != nil {
+				log.Errorf("Postgres reaper error after deleting %d expired rows: %v", deleted, err)
+			} else if deleted > 0 {
+				log.Debugf("Postgres reaper deleted %d expired rows", deleted)
+			}
+		}
+	}
+}
+
+// reap deletes the expired rows, batchSize rows per statement so locks are held briefly, and returns
+// how many it deleted. Rows locked by the reaper of another Prebid Cache instance are skipped.
+func (b *PostgresBackend) reap(ctx context.Context) (int64, error) {
+	statement := fmt.Sprintf(`DELETE FROM %s WHERE key IN (SELECT key FROM %s WHERE expires_at <= now() LIMIT $1 FOR UPDATE SKIP LOCKED)`, b.table, b.table)
+
+	var deleted int64
+	for {
+		result, err := b.db.ExecContext(ctx, statement, b.batchSize)
+		if err != nil {
+			return deleted, err
+		}
+		batch, err := result.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += batch
+		if batch < int64(b.batchSize) {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPostgresBackend(t *testing.T) (*PostgresBackend, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &PostgresBackend{db: db, table: "cache", batchSize: 2}, mock
+}
+
+func TestPostgresDSN(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		inCfg       config.Postgres
+		expectedDSN string
+	}{
+		{
+			desc:        "Empty settings are left out",
+			inCfg:       config.Postgres{Host: "127.0.0.1", Port: 5432, Database: "prebid", SSLMode: "require"},
+			expectedDSN: `host='127.0.0.1' port='5432' dbname='prebid' sslmode='require'`,
+		},
+		{
+			desc:        "Quotes and backslashes get escaped",
+			inCfg:       config.Postgres{Host: "db", Port: 5432, Database: "prebid", User: "prebid", Password: `it's a \secret`, SSLMode: "disable"},
+			expectedDSN: `host='db' port='5432' dbname='prebid' user='prebid' password='it\'s a \\secret' sslmode='disable'`,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expectedDSN, postgresDSN(tc.inCfg), tc.desc)
+	}
+}
+
+func TestNewPostgresBackend(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inCfg         config.Postgres
+		setup         func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			desc:  "Database is reachable",
+			inCfg: config.Postgres{Table: "cache"},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+			},
+		},
+		{
+			desc:  "Database is unreachable",
+			inCfg: config.Postgres{Table: "cache"},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+			},
+			expectedError: errors.New("connection refused"),
+		},
+		{
+			desc:  "Schema gets created on start",
+			inCfg: config.Postgres{Table: "prebid_cache", CreateSchemaOnStart: true},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS prebid_cache (")).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX IF NOT EXISTS prebid_cache_expires_at_idx ON prebid_cache (expires_at)")).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			desc:  "Schema creation fails",
+			inCfg: config.Postgres{Table: "cache", CreateSchemaOnStart: true},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				mock.ExpectExec("CREATE TABLE").WillReturnError(errors.New("permission denied"))
+			},
+			expectedError: errors.New("Failed to create the Postgres schema: permission denied"),
+		},
+	}
+
+	for _, tc := range testCases {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if !assert.NoError(t, err, tc.desc) {
+			continue
+		}
+		tc.setup(mock)
+
+		backend, err := newPostgresBackend(tc.inCfg, db)
+
+		if tc.expectedError != nil {
+			assert.Equal(t, tc.expectedError, err, tc.desc)
+			assert.Nil(t, backend, tc.desc)
+		} else {
+			assert.NoError(t, err, tc.desc)
+			assert.Equal(t, tc.inCfg.Table, backend.table, tc.desc)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet(), tc.desc)
+		db.Close()
+	}
+}
+
+func TestPostgresGet(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT value FROM cache WHERE key = $1 AND expires_at > now()")
+
+	testCases := []struct {
+		desc          string
+		setup         func(mock sqlmock.Sqlmock)
+		expectedValue string
+		expectedError error
+	}{
+		{
+			desc: "Key holds a value that didn't expire",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WithArgs("someKey").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("someValue"))
+			},
+			expectedValue: "someValue",
+		},
+		{
+			desc: "Key is missing or expired",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WithArgs("someKey").WillReturnRows(sqlmock.NewRows([]string{"value"}))
+			},
+			expectedError: utils.NewPBCError(utils.KEY_NOT_FOUND),
+		},
+		{
+			desc: "Query fails",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WithArgs("someKey").WillReturnError(context.DeadlineExceeded)
+			},
+			expectedError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		backend, mock := newTestPostgresBackend(t)
+		tc.setup(mock)
+
+		value, err := backend.Get(context.Background(), "someKey")
+
+		assert.Equal(t, tc.expectedValue, value, tc.desc)
+		assert.Equal(t, tc.expectedError, err, tc.desc)
+		assert.NoError(t, mock.ExpectationsWereMet(), tc.desc)
+	}
+}
+
+func TestPostgresPut(t *testing.T) {
+	statement := regexp.QuoteMeta("INSERT INTO cache (key, value, expires_at) VALUES ($1, $2, now() + $3 * interval '1 second') ON CONFLICT (key) DO NOTHING")
+
+	testCases := []struct {
+		desc          string
+		setup         func(mock sqlmock.Sqlmock)
+		expectedError error
+	}{
+		{
+			desc: "New key gets inserted",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(statement).WithArgs("someKey", "someValue", 60).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			desc: "Existing key is left untouched",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(statement).WithArgs("someKey", "someValue", 60).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: utils.NewPBCError(utils.RECORD_EXISTS),
+		},
+		{
+			desc: "Statement fails",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(statement).WithArgs("someKey", "someValue", 60).WillReturnError(errors.New("connection reset"))
+			},
+			expectedError: errors.New("connection reset"),
+		},
+	}
+
+	for _, tc := range testCases {
+		backend, mock := newTestPostgresBackend(t)
+		tc.setup(mock)
+
+		err := backend.Put(context.Background(), "someKey", "someValue", 60)
+
+		assert.Equal(t, tc.expectedError, err, tc.desc)
+		assert.NoError(t, mock.ExpectationsWereMet(), tc.desc)
+	}
+}
+
+func TestPostgresReap(t *testing.T) {
+	statement := regexp.QuoteMeta("DELETE FROM cache WHERE key IN (SELECT key FROM cache WHERE expires_at <= now() LIMIT $1 FOR UPDATE SKIP LOCKED)")
+
+	testCases := []struct {
+		desc            string
+		setup           func(mock sqlmock.Sqlmock)
+		expectedDeleted int64
+		expectedError   error
+	}{
+		{
+			desc: "Nothing expired",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(statement).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			desc: "Full batches are followed by another one",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(statement).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(statement).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(statement).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedDeleted: 5,
+		},
+		{
+			desc: "Statement fails",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(statement).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(statement).WithArgs(2).WillReturnError(errors.New("connection reset"))
+			},
+			expectedDeleted: 2,
+			expectedError:   errors.New("connection reset"),
+		},
+	}
+
+	for _, tc := range testCases {
+		backend, mock := newTestPostgresBackend(t)
+		tc.setup(mock)
+
+		deleted, err := backend.reap(context.Background())
+
+		assert.Equal(t, tc.expectedDeleted, deleted, tc.desc)
+		assert.Equal(t, tc.expectedError, err, tc.desc)
+		assert.NoError(t, mock.ExpectationsWereMet(), tc.desc)
+	}
+}
+
+func TestPostgresHealthCheck(t *testing.T) {
+	backend, mock := newTestPostgresBackend(t)
+
+	mock.ExpectPing()
+	assert.NoError(t, backend.HealthCheck(context.Background()))
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	assert.EqualError(t, backend.HealthCheck(context.Background()), "connection refused")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -18,12 +18,13 @@ import (
 type RedisDB interface {
 	Get(ctx context.Context, key string) (string, error)
 	Put(ctx context.Context, key string, value string, ttlSeconds int) (bool, error)
+	Ping(ctx context.Context) error
 }
 
 // RedisDBClient is a wrapper for the Redis client that implements
-// the RedisDB interface
+// the RedisDB interface. The client may be a single node, cluster or sentinel-backed client.
 type RedisDBClient struct {
-	client *redis.Client
+	client redis.UniversalClient
 }
 
 // Get returns the value associated with the provided `key` parameter
@@ -38,6 +39,11 @@ func (db RedisDBClient) Put(ctx context.Context, key, value string, ttlSeconds i
 	return db.client.SetNX(ctx, key, value, time.Duration(ttlSeconds)*time.Second).Result()
 }
 
+// Ping sends a PING command to the Redis server
+func (db RedisDBClient) Ping(ctx context.Context) error {
+	return db.client.Ping(ctx).Err()
+}
+
 // RedisBackend when initialized will instantiate and configure the Redis client. It implements
 // the Backend interface.
 type RedisBackend struct {
@@ -47,35 +53,32 @@ type RedisBackend struct {
 
 // NewRedisBackend initializes the redis client and pings to make sure connection was successful
 func NewRedisBackend(cfg config.Redis, ctx context.Context) *RedisBackend {
-	constr := cfg.Host + ":" + strconv.Itoa(cfg.Port)
-
-	options := &redis.Options{
-		Addr:     constr,
-		Password: cfg.Password,
-		DB:       cfg.Db,
+	tlsConfig, reloaders, err := newRedisTLSConfig(cfg.TLS)
+	if err != nil {
+		log.Fatalf("Error creating Redis backend: %v", err)
+		panic("RedisBackend failure. This shouldn't happen.")
 	}
-
-	if cfg.TLS.Enabled {
-		options = &redis.Options{
-			Addr:     constr,
-			Password: cfg.Password,
-			DB:       cfg.Db,
-			TLSConfig: &tls.Config{
-				InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
-			},
-		}
+	for _, reloader := range reloaders {
+		go reloader.Watch(cfg.TLS.ReloadInterval(), nil)
 	}
 
-	redisClient := RedisDBClient{client: redis.NewClient(options)}
+	redisClient := RedisDBClient{client: newRedisClient(cfg, tlsConfig)}
 
-	_, err := redisClient.client.Ping(ctx).Result()
+	_, err = redisClient.client.Ping(ctx).Result()
 
 	if err != nil {
 		log.Fatalf("Error creating Redis backend: %v", err)
 		panic("RedisBackend failure. This shouldn't happen.")
 	}
 
-	log.Infof("Connected to Redis at %s:%d", cfg.Host, cfg.Port)
+	switch cfg.Mode {
+	case config.RedisCluster:
+		log.Infof("Connected to Redis cluster at %v", cfg.Addresses)
+	case config.RedisSentinel:
+		log.Infof("Connected to Redis master %s through the sentinels at %v", cfg.MasterName, cfg.Addresses)
+	default:
+		log.Infof("Connected to Redis at %s:%d", cfg.Host, cfg.Port)
+	}
 
 	return &RedisBackend{
 		cfg:    cfg,
@@ -83,6 +86,90 @@ func NewRedisBackend(cfg config.Redis, ctx context.Context) *RedisBackend {
 	}
 }
 
+// newRedisTLSConfig builds the TLS configuration shared by the connections to every Redis node,
+// or returns nil if TLS is disabled. The CA file and the client certificate, if configured, are
+// served by the returned reloaders, which must be watched for the rotated files to be picked up.
+func newRedisTLSConfig(cfg config.RedisTLS) (*tls.Config, []utils.Reloader, error) {
+	if !cfg.Enabled {
+		return nil, nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		ServerName:         cfg.ServerName,
+	}
+
+	var reloaders []utils.Reloader
+	if cfg.CAFile != "" {
+		caReloader, err := utils.NewCertPoolReloader(cfg.CAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !cfg.InsecureSkipVerify {
+			// RootCAs can't change once set, so server certificates get verified against the CAs
+			// that were last loaded instead of going through the default verification
+			tlsConfig.InsecureSkipVerify = true
+			tlsConfig.VerifyConnection = caReloader.VerifyConnection
+		}
+		reloaders = append(reloaders, caReloader)
+	}
+
+	if cfg.CertFile != "" {
+		certReloader, err := utils.NewCertificateReloader(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig.GetClientCertificate = certReloader.GetClientCertificate
+		reloaders = append(reloaders, certReloader)
+	}
+	return tlsConfig, reloaders, nil
+}
+
+// newRedisClient builds the go-redis client that corresponds to the configured mode. Clients connect
+// lazily, so no connection is attempted here.
+func newRedisClient(cfg config.Redis, tlsConfig *tls.Config) redis.UniversalClient {
+	switch cfg.Mode {
+	case config.RedisCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:          cfg.Addresses,
+			Username:       cfg.Username,
+			Password:       cfg.Password,
+			PoolSize:       cfg.PoolSize,
+			ReadOnly:       cfg.ReadOnly,
+			RouteByLatency: cfg.RouteByLatency,
+			TLSConfig:      tlsConfig,
+		})
+	case config.RedisSentinel:
+		options := &redis.FailoverOptions{
+			MasterName:    cfg.MasterName,
+			SentinelAddrs: cfg.Addresses,
+			Username:      cfg.Username,
+			Password:      cfg.Password,
+			DB:            cfg.Db,
+			PoolSize:      cfg.PoolSize,
+			TLSConfig:     tlsConfig,
+		}
+		// Only the cluster flavor of the failover client can send read commands to the replicas. It
+		// ignores DB and always uses database 0, which config validation requires for replica reads,
+		// so any other database is read from the master.
+		if (cfg.ReadOnly || cfg.RouteByLatency) && cfg.Db == 0 {
+			options.RouteByLatency = cfg.RouteByLatency
+			options.RouteRandomly = !cfg.RouteByLatency
+			return redis.NewFailoverClusterClient(options)
+		}
+		return redis.NewFailoverClient(options)
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:      cfg.Host + ":" + strconv.Itoa(cfg.Port),
+			Username:  cfg.Username,
+			Password:  cfg.Password,
+			DB:        cfg.Db,
+			PoolSize:  cfg.PoolSize,
+			TLSConfig: tlsConfig,
+		})
+	}
+}
+
 // Get calls the Redis client to return the value associated with the provided `key`
 // parameter and interprets its response. A `Nil` error reply of the Redis client means
 // the `key` does not exist.
@@ -110,3 +197,8 @@ func (b *RedisBackend) Put(ctx context.Context, key string, value string, ttlSec
 	}
 	return nil
 }
+
+// HealthCheck pings the Redis server
+func (b *RedisBackend) HealthCheck(ctx context.Context) error {
+	return b.client.Ping(ctx)
+}
@@ -2,12 +2,19 @@ package backends
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/utils"
+	"github.com/prebid/prebid-cache/utils/certtest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRedisClientGet(t *testing.T) {
@@ -193,3 +200,229 @@ func TestRedisClientPut(t *testing.T) {
 		}
 	}
 }
+
+func TestRedisHealthCheck(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inRedisClient RedisDB
+		expectedError error
+	}{
+		{
+			desc:          "Redis server responds to PING",
+			inRedisClient: FakeRedisClient{},
+			expectedError: nil,
+		},
+		{
+			desc:          "Redis server is unreachable",
+			inRedisClient: FakeRedisClient{ServerError: errors.New("dial tcp: connection refused")},
+			expectedError: errors.New("dial tcp: connection refused"),
+		},
+	}
+
+	for _, tc := range testCases {
+		redisBackend := NewFakeRedisBackend(tc.inRedisClient)
+		assert.Equal(t, tc.expectedError, redisBackend.HealthCheck(context.Background()), tc.desc)
+	}
+}
+
+func TestNewRedisClient(t *testing.T) {
+	t.Run("standalone", func(t *testing.T) {
+		client := newRedisClient(config.Redis{Mode: config.RedisStandalone, Host: "127.0.0.1", Port: 6379, Db: 1, PoolSize: 5}, nil)
+		defer client.Close()
+
+		if assert.IsType(t, &redis.Client{}, client) {
+			options := client.(*redis.Client).Options()
+			assert.Equal(t, "127.0.0.1:6379", options.Addr)
+			assert.Equal(t, 1, options.DB)
+			assert.Equal(t, 5, options.PoolSize)
+			assert.Nil(t, options.TLSConfig)
+		}
+	})
+
+	t.Run("standalone with ACL user and TLS", func(t *testing.T) {
+		tlsConfig := &tls.Config{ServerName: "redis.internal"}
+		client := newRedisClient(config.Redis{Mode: config.RedisStandalone, Username: "user", Password: "secret"}, tlsConfig)
+		defer client.Close()
+
+		if assert.IsType(t, &redis.Client{}, client) {
+			options := client.(*redis.Client).Options()
+			assert.Equal(t, "user", options.Username)
+			assert.Equal(t, "secret", options.Password)
+			assert.Same(t, tlsConfig, options.TLSConfig)
+		}
+	})
+
+	t.Run("cluster", func(t *testing.T) {
+		addresses := []string{"10.0.0.1:6379", "10.0.0.2:6379"}
+		client := newRedisClient(config.Redis{Mode: config.RedisCluster, Addresses: addresses, ReadOnly: true, RouteByLatency: true, PoolSize: 5}, nil)
+		defer client.Close()
+
+		if assert.IsType(t, &redis.ClusterClient{}, client) {
+			options := client.(*redis.ClusterClient).Options()
+			assert.Equal(t, addresses, options.Addrs)
+			assert.True(t, options.ReadOnly)
+			assert.True(t, options.RouteByLatency)
+			assert.Equal(t, 5, options.PoolSize)
+		}
+	})
+
+	t.Run("sentinel, reads from the master", func(t *testing.T) {
+		client := newRedisClient(config.Redis{Mode: config.RedisSentinel, Addresses: []string{"10.0.0.1:26379"}, MasterName: "mymaster", Db: 1}, nil)
+		defer client.Close()
+
+		if assert.IsType(t, &redis.Client{}, client) {
+			options := client.(*redis.Client).Options()
+			assert.Equal(t, "FailoverClient", options.Addr)
+			assert.Equal(t, 1, options.DB)
+		}
+	})
+
+	t.Run("sentinel, reads from the replicas", func(t *testing.T) {
+		client := newRedisClient(config.Redis{Mode: config.RedisSentinel, Addresses: []string{"10.0.0.1:26379"}, MasterName: "mymaster", ReadOnly: true}, nil)
+		defer client.Close()
+
+		if assert.IsType(t, &redis.ClusterClient{}, client) {
+			options := client.(*redis.ClusterClient).Options()
+			assert.True(t, options.ReadOnly)
+			assert.True(t, options.RouteRandomly)
+		}
+	})
+
+	t.Run("sentinel, replica reads never switch to database 0", func(t *testing.T) {
+		client := newRedisClient(config.Redis{Mode: config.RedisSentinel, Addresses: []string{"10.0.0.1:26379"}, MasterName: "mymaster", Db: 1, RouteByLatency: true}, nil)
+		defer client.Close()
+
+		if assert.IsType(t, &redis.Client{}, client) {
+			assert.Equal(t, 1, client.(*redis.Client).Options().DB)
+		}
+	})
+}
+
+func TestNewRedisTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	caFile := ca.WriteCA(t, dir)
+	pair := ca.Issue(t, dir, "client")
+
+	testCases := []struct {
+		desc                 string
+		inCfg                config.RedisTLS
+		expectedNil          bool
+		expectedError        bool
+		expectedVerifyCAs    bool
+		expectedClientCert   bool
+		expectedServerName   string
+		expectedInsecureSkip bool
+	}{
+		{
+			desc:        "TLS disabled",
+			inCfg:       config.RedisTLS{Enabled: false},
+			expectedNil: true,
+		},
+		{
+			desc:                 "System roots, no client certificate",
+			inCfg:                config.RedisTLS{Enabled: true, InsecureSkipVerify: true},
+			expectedInsecureSkip: true,
+		},
+		{
+			desc:                 "Private CA and client certificate",
+			inCfg:                config.RedisTLS{Enabled: true, CAFile: caFile, CertFile: pair.CertFile, KeyFile: pair.KeyFile, ServerName: "redis.internal"},
+			expectedVerifyCAs:    true,
+			expectedClientCert:   true,
+			expectedServerName:   "redis.internal",
+			expectedInsecureSkip: true,
+		},
+		{
+			desc:                 "Private CA with verification skipped",
+			inCfg:                config.RedisTLS{Enabled: true, CAFile: caFile, InsecureSkipVerify: true},
+			expectedInsecureSkip: true,
+		},
+		{
+			desc:          "CA file doesn't exist",
+			inCfg:         config.RedisTLS{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")},
+			expectedError: true,
+		},
+		{
+			desc:          "Client certificate file doesn't exist",
+			inCfg:         config.RedisTLS{Enabled: true, CertFile: filepath.Join(dir, "missing.pem"), KeyFile: pair.KeyFile},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tlsConfig, reloaders, err := newRedisTLSConfig(tc.inCfg)
+		if tc.expectedError {
+			assert.Error(t, err, tc.desc)
+			continue
+		}
+		if !assert.NoError(t, err, tc.desc) {
+			continue
+		}
+		if tc.expectedNil {
+			assert.Nil(t, tlsConfig, tc.desc)
+			assert.Empty(t, reloaders, tc.desc)
+			continue
+		}
+		assert.Equal(t, tc.expectedServerName, tlsConfig.ServerName, tc.desc)
+		assert.Equal(t, tc.expectedInsecureSkip, tlsConfig.InsecureSkipVerify, tc.desc)
+		assert.Nil(t, tlsConfig.RootCAs, tc.desc)
+		assert.Equal(t, tc.expectedVerifyCAs, tlsConfig.VerifyConnection != nil, tc.desc)
+		if tc.expectedClientCert {
+			cert, err := tlsConfig.GetClientCertificate(nil)
+			assert.NoError(t, err, tc.desc)
+			assert.Equal(t, pair.Load(t).Certificate, cert.Certificate, tc.desc)
+		} else {
+			assert.Nil(t, tlsConfig.GetClientCertificate, tc.desc)
+		}
+
+		expectedReloaders := 0
+		if tc.inCfg.CAFile != "" {
+			expectedReloaders++
+		}
+		if tc.inCfg.CertFile != "" {
+			expectedReloaders++
+		}
+		assert.Len(t, reloaders, expectedReloaders, tc.desc)
+	}
+}
+
+func TestRedisTLSConfigReloadsCAFile(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	caFile := ca.WriteCA(t, dir)
+	server := ca.Issue(t, dir, "server").Load(t)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{server}})
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			// Complete the handshake before closing, so the client gets to verify the certificate
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	tlsConfig, reloaders, err := newRedisTLSConfig(config.RedisTLS{Enabled: true, CAFile: caFile})
+	require.NoError(t, err)
+	require.Len(t, reloaders, 1)
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), tlsConfig)
+	if assert.NoError(t, err, "Server certificate signed by the CA") {
+		conn.Close()
+	}
+
+	// Replace the CA, the server certificate isn't trusted anymore
+	certtest.NewAuthority(t, "rotated-ca").WriteCA(t, dir)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(caFile, future, future))
+	_, err = reloaders[0].(*utils.CertPoolReloader).Reload()
+	require.NoError(t, err)
+
+	_, err = tls.Dial("tcp", listener.Addr().String(), tlsConfig)
+	assert.Error(t, err, "Server certificate signed by the previous CA")
+}
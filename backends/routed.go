@@ -0,0 +1,95 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+)
+
+// routedPointerPrefix starts the pointers stored in the pointers backend, followed by the type of the
+// backend holding the value. Values always start with their "xml" or "json" type, and compressed
+// ones with their non-zero length, so they can't be mistaken for pointers.
+const routedPointerPrefix = "\x00route:"
+
+// RoutedBackend stores each value in one of several backends, picked by the size and the type of the
+// value. The backend a key was routed to is recorded in a pointer stored in the pointers backend, so
+// reads don't have to query every backend. Values routed to the pointers backend are stored in place
+// of the pointer.
+type RoutedBackend struct {
+	pointers     Backend
+	pointersType config.BackendType
+	backends     map[config.BackendType]Backend
+	routes       []config.Route
+	defaultType  config.BackendType
+}
+
+// NewRoutedBackend returns a RoutedBackend routing values to backends, which must hold a backend of
+// each of the types cfg.BackendTypes lists
+func NewRoutedBackend(cfg config.Routed, backends map[config.BackendType]Backend) *RoutedBackend {
+	return &RoutedBackend{
+		pointers:     backends[cfg.Pointers],
+		pointersType: cfg.Pointers,
+		backends:     backends,
+		routes:       cfg.Routes,
+		defaultType:  cfg.DefaultBackend(),
+	}
+}
+
+// Put stores value in the backend it gets routed to, then the pointer to that backend. Writing the
+// value first means a pointer is never left dangling when that write fails, and the pointer remains
+// the record that decides whether key is taken. If it is, the value stored for nothing expires along
+// with its TTL.
+func (b *RoutedBackend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
+	backendType := b.route(value)
+	if backendType == b.pointersType {
+		return b.pointers.Put(ctx, key, value, ttlSeconds)
+	}
+
+	if err := b.backends[backendType].Put(ctx, key, value, ttlSeconds); err != nil {
+		return err
+	}
+	return b.pointers.Put(ctx, key, routedPointerPrefix+string(backendType), ttlSeconds)
+}
+
+// Get reads key from the pointers backend, and follows the pointer found there if any
+func (b *RoutedBackend) Get(ctx context.Context, key string) (string, error) {
+	value, err := b.pointers.Get(ctx, key)
+	if err != nil || !strings.HasPrefix(value, routedPointerPrefix) {
+		return value, err
+	}
+
+	backendType := config.BackendType(strings.TrimPrefix(value, routedPointerPrefix))
+	backend, ok := b.backends[backendType]
+	if !ok {
+		// The routes changed since the value got stored
+		return "", utils.NewPBCError(utils.GET_INTERNAL_SERVER, fmt.Sprintf("Key %s was routed to the %s backend, which is no longer configured", key, backendType))
+	}
+	return backend.Get(ctx, key)
+}
+
+// HealthCheck reports the backend as unhealthy if any of the backends values get routed to is
+func (b *RoutedBackend) HealthCheck(ctx context.Context) error {
+	for backendType, backend := range b.backends {
+		if err := CheckHealth(ctx, backend); err != nil {
+			return fmt.Errorf("%s backend: %v", backendType, err)
+		}
+	}
+	return nil
+}
+
+// route returns the type of the backend of the first route value matches, or of the default backend
+func (b *RoutedBackend) route(value string) config.BackendType {
+	for _, route := range b.routes {
+		if route.Type != "" && !strings.HasPrefix(value, route.Type) {
+			continue
+		}
+		if len(value) < route.MinSizeBytes || (route.MaxSizeBytes > 0 && len(value) > route.MaxSizeBytes) {
+			continue
+		}
+		return route.Backend
+	}
+	return b.defaultType
+}
@@ -0,0 +1,155 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRoutedBackend() (*RoutedBackend, map[config.BackendType]*MemoryBackend) {
+	memories := map[config.BackendType]*MemoryBackend{
+		config.BackendRedis:    NewMemoryBackend(),
+		config.BackendS3:       NewMemoryBackend(),
+		config.BackendPostgres: NewMemoryBackend(),
+	}
+	routedBackends := make(map[config.BackendType]Backend, len(memories))
+	for backendType, memory := range memories {
+		routedBackends[backendType] = memory
+	}
+
+	cfg := config.Routed{
+		Pointers: config.BackendRedis,
+		Routes: []config.Route{
+			{Backend: config.BackendS3, Type: utils.XML_PREFIX, MinSizeBytes: 100},
+			{Backend: config.BackendPostgres, MinSizeBytes: 100, MaxSizeBytes: 1000},
+		},
+	}
+	return NewRoutedBackend(cfg, routedBackends), memories
+}
+
+func TestRoutedBackendPut(t *testing.T) {
+	largeXML := "xml" + strings.Repeat("a", 200)
+	largeJSON := "json" + strings.Repeat("a", 200)
+	hugeJSON := "json" + strings.Repeat("a", 2000)
+
+	testCases := []struct {
+		desc             string
+		inValue          string
+		expectedPointer  string
+		expectedLocation config.BackendType
+	}{
+		{
+			desc:             "Small values get stored in place of the pointer",
+			inValue:          "json{}",
+			expectedPointer:  "json{}",
+			expectedLocation: config.BackendRedis,
+		},
+		{
+			desc:             "Large values match the route of their type",
+			inValue:          largeXML,
+			expectedPointer:  "\x00route:s3",
+			expectedLocation: config.BackendS3,
+		},
+		{
+			desc:             "Large values of other types match the next route",
+			inValue:          largeJSON,
+			expectedPointer:  "\x00route:postgres",
+			expectedLocation: config.BackendPostgres,
+		},
+		{
+			desc:             "Values matching no route go to the default backend",
+			inValue:          hugeJSON,
+			expectedPointer:  hugeJSON,
+			expectedLocation: config.BackendRedis,
+		},
+	}
+
+	for _, tc := range testCases {
+		backend, memories := newTestRoutedBackend()
+
+		assert.NoError(t, backend.Put(context.Background(), "key", tc.inValue, 60), tc.desc)
+
+		pointer, err := memories[config.BackendRedis].Get(context.Background(), "key")
+		assert.NoError(t, err, tc.desc)
+		assert.Equal(t, tc.expectedPointer, pointer, tc.desc)
+		for backendType, memory := range memories {
+			if backendType == config.BackendRedis {
+				continue
+			}
+			value, err := memory.Get(context.Background(), "key")
+			if backendType == tc.expectedLocation {
+				assert.Equal(t, tc.inValue, value, tc.desc)
+			} else {
+				assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, tc.desc)
+			}
+		}
+
+		value, err := backend.Get(context.Background(), "key")
+		assert.NoError(t, err, tc.desc)
+		assert.Equal(t, tc.inValue, value, "Get follows the pointer: "+tc.desc)
+	}
+}
+
+func TestRoutedBackendPutExistingKey(t *testing.T) {
+	backend, _ := newTestRoutedBackend()
+	largeXML := "xml" + strings.Repeat("a", 200)
+
+	assert.NoError(t, backend.Put(context.Background(), "key", "json{}", 60))
+
+	err := backend.Put(context.Background(), "key", largeXML, 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "The pointer decides whether key is taken")
+	value, err := backend.Get(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "json{}", value, "The value of a taken key doesn't change")
+}
+
+func TestRoutedBackendPutFailedChild(t *testing.T) {
+	backend, memories := newTestRoutedBackend()
+	backend.backends[config.BackendS3] = NewErrorResponseMemoryBackend()
+	largeXML := "xml" + strings.Repeat("a", 200)
+
+	err := backend.Put(context.Background(), "key", largeXML, 60)
+	assert.Error(t, err, "Errors of the backend the value is routed to are returned")
+
+	_, err = memories[config.BackendRedis].Get(context.Background(), "key")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "No pointer is left to a value that wasn't stored")
+
+	backend.backends[config.BackendS3] = memories[config.BackendS3]
+	assert.NoError(t, backend.Put(context.Background(), "key", largeXML, 60), "The key can be put again")
+}
+
+func TestRoutedBackendGet(t *testing.T) {
+	backend, memories := newTestRoutedBackend()
+
+	_, err := backend.Get(context.Background(), "missing")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Missing pointer")
+
+	memories[config.BackendRedis].Put(context.Background(), "dangling", "\x00route:s3", 60)
+	_, err = backend.Get(context.Background(), "dangling")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Pointer to a value that's missing")
+
+	memories[config.BackendRedis].Put(context.Background(), "removed", "\x00route:cassandra", 60)
+	_, err = backend.Get(context.Background(), "removed")
+	assert.Equal(t, utils.NewPBCError(utils.GET_INTERNAL_SERVER, "Key removed was routed to the cassandra backend, which is no longer configured"), err, "Pointer to a backend that's no longer configured")
+}
+
+func TestRoutedBackendHealthCheck(t *testing.T) {
+	backend, _ := newTestRoutedBackend()
+	assert.NoError(t, backend.HealthCheck(context.Background()))
+
+	backend.backends[config.BackendS3] = &unhealthyBackend{NewMemoryBackend()}
+	assert.EqualError(t, backend.HealthCheck(context.Background()), "s3 backend: connection refused")
+}
+
+type unhealthyBackend struct {
+	Backend
+}
+
+func (b *unhealthyBackend) HealthCheck(ctx context.Context) error {
+	return errors.New("connection refused")
+}
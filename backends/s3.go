@@ -0,0 +1,216 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// s3ExpiresAtMetadata is the object metadata holding the Unix time the value expires at, sent
+	// in the X-Amz-Meta-Expires-At header
+	s3ExpiresAtMetadata = "expires-at"
+	// s3LifecycleRuleID identifies the lifecycle rule Prebid Cache adds to the bucket
+	s3LifecycleRuleID = "prebid-cache-expiration"
+)
+
+// S3Backend implements the Backend interface and stores values as objects of an AWS S3 bucket, or of
+// a bucket of an S3-compatible object store. Expiration times are stored in the metadata of the
+// objects and checked on reads, because lifecycle rules only delete objects with a granularity of
+// days.
+type S3Backend struct {
+	client *s3.Client
+	bucket string
+	prefix string
+	now    func() time.Time
+}
+
+// NewS3Backend makes sure the bucket is reachable, and adds the expiration rule to its lifecycle
+// configuration if configured to
+func NewS3Backend(cfg config.S3) *S3Backend {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	awsConfig, err := loadAWSConfig(ctx, cfg.Region, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken, cfg.TimeoutMillis, cfg.MaxIdleConns)
+	if err != nil {
+		log.Fatalf("Error creating S3 backend: %v", err)
+		panic("S3Backend failure. This shouldn't happen.")
+	}
+	backend, err := newS3Backend(ctx, cfg, awsConfig, time.Now)
+	if err != nil {
+		log.Fatalf("Error creating S3 backend: %v", err)
+		panic("S3Backend failure. This shouldn't happen.")
+	}
+	log.Infof("Prebid Cache will store objects in the S3 bucket %s", cfg.Bucket)
+	return backend
+}
+
+func newS3Backend(ctx context.Context, cfg config.S3, awsConfig aws.Config, now func() time.Time) (*S3Backend, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("the S3 bucket cannot be empty")
+	}
+
+	client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.ForcePathStyle
+		// Checksums only get sent where S3 requires them, as not every S3-compatible object store
+		// supports the ones newer SDK versions send by default
+		o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+	})
+	backend := &S3Backend{
+		client: client,
+		bucket: cfg.Bucket,
+		prefix: cfg.Prefix,
+		now:    now,
+	}
+
+	if err := backend.HealthCheck(ctx); err != nil {
+		return nil, err
+	}
+	if cfg.ConfigureLifecycle {
+		if err := backend.configureLifecycle(ctx, cfg.LifecycleExpirationDays); err != nil {
+			return nil, fmt.Errorf("Failed to configure the S3 bucket lifecycle: %v", err)
+		}
+		log.Infof("S3 objects under %q expire after %d days", cfg.Prefix, cfg.LifecycleExpirationDays)
+	}
+	return backend, nil
+}
+
+// Get returns the value stored under key, or a KEY_NOT_FOUND error if there's none or it expired
+func (b *S3Backend) Get(ctx context.Context, key string) (string, error) {
+	output, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.prefix + key),
+	})
+	if err != nil {
+		if awsStatusCode(err) == http.StatusNotFound {
+			return "", utils.NewPBCError(utils.KEY_NOT_FOUND)
+		}
+		return "", classifyAWSTimeout(err)
+	}
+	defer output.Body.Close()
+
+	// The object store deletes expired objects days later at best
+	if b.expired(output.Metadata) {
+		return "", utils.NewPBCError(utils.KEY_NOT_FOUND)
+	}
+
+	value, err := io.ReadAll(output.Body)
+	if err != nil {
+		return "", classifyAWSTimeout(err)
+	}
+	return string(value), nil
+}
+
+// Put stores value under key for ttlSeconds, or returns a RECORD_EXISTS error if key is taken. The
+// write is conditional, so concurrent puts of the same key can't overwrite each other. An object
+// holding an expired value that wasn't deleted yet gets overwritten.
+func (b *S3Backend) Put(ctx context.Context, key string, value string, ttlSeconds int) error {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(b.bucket),
+		Key:         aws.String(b.prefix + key),
+		Body:        strings.NewReader(value),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+		IfNoneMatch: aws.String("*"),
+	}
+	if ttlSeconds > 0 {
+		expiresAt := b.now().Add(time.Duration(ttlSeconds) * time.Second)
+		input.Metadata = map[string]string{s3ExpiresAtMetadata: strconv.FormatInt(expiresAt.Unix(), 10)}
+		input.Expires = aws.Time(expiresAt)
+	}
+
+	_, err := b.client.PutObject(ctx, input)
+	switch awsStatusCode(err) {
+	case http.StatusPreconditionFailed:
+		return b.replaceExpired(ctx, input, value)
+	case http.StatusConflict:
+		// A concurrent conditional write of the same key is in progress
+		return utils.NewPBCError(utils.RECORD_EXISTS)
+	}
+	return classifyAWSTimeout(err)
+}
+
+// replaceExpired overwrites the object input is meant to write if the value it holds expired. The
+// write is conditioned on the ETag of the expired object, so only one of the concurrent puts of the
+// same key succeeds.
+func (b *S3Backend) replaceExpired(ctx context.Context, input *s3.PutObjectInput, value string) error {
+	head, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: input.Bucket, Key: input.Key})
+	switch {
+	case awsStatusCode(err) == http.StatusNotFound:
+		// The lifecycle rule deleted the object in the meantime, so it can be created again
+	case err != nil:
+		return classifyAWSTimeout(err)
+	case !b.expired(head.Metadata):
+		return utils.NewPBCError(utils.RECORD_EXISTS)
+	default:
+		input.IfNoneMatch = nil
+		input.IfMatch = head.ETag
+	}
+
+	input.Body = strings.NewReader(value)
+	_, err = b.client.PutObject(ctx, input)
+	switch awsStatusCode(err) {
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return utils.NewPBCError(utils.RECORD_EXISTS)
+	}
+	return classifyAWSTimeout(err)
+}
+
+// expired tells whether the expiration time found in the metadata of an object has passed
+func (b *S3Backend) expired(metadata map[string]string) bool {
+	expiresAt, err := strconv.ParseInt(metadata[s3ExpiresAtMetadata], 10, 64)
+	return err == nil && expiresAt <= b.now().Unix()
+}
+
+// HealthCheck makes sure the bucket exists and the credentials grant access to it
+func (b *S3Backend) HealthCheck(ctx context.Context) error {
+	_, err := b.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(b.bucket)})
+	return classifyAWSTimeout(err)
+}
+
+// configureLifecycle adds a rule deleting the objects under the prefix expirationDays after they got
+// written to the lifecycle configuration of the bucket, or updates the rule if it's there already.
+// The other rules are written back as they were.
+func (b *S3Backend) configureLifecycle(ctx context.Context, expirationDays int) error {
+	var rules []types.LifecycleRule
+
+	current, err := b.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(b.bucket)})
+	switch {
+	case err == nil:
+		for _, rule := range current.Rules {
+			if aws.ToString(rule.ID) != s3LifecycleRuleID {
+				rules = append(rules, rule)
+			}
+		}
+	case awsStatusCode(err) == http.StatusNotFound:
+		// The bucket has no lifecycle configuration yet
+	default:
+		return err
+	}
+
+	rules = append(rules, types.LifecycleRule{
+		ID:         aws.String(s3LifecycleRuleID),
+		Filter:     &types.LifecycleRuleFilter{Prefix: aws.String(b.prefix)},
+		Status:     types.ExpirationStatusEnabled,
+		Expiration: &types.LifecycleExpiration{Days: aws.Int32(int32(expirationDays))},
+	})
+	_, err = b.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(b.bucket),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: rules},
+	})
+	return err
+}
@@ -0,0 +1,303 @@
+package backends
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3Object is an object of the fake S3 server, along with its metadata
+type fakeS3Object struct {
+	value  []byte
+	header http.Header
+}
+
+// fakeS3Server is an in-process S3-compatible object store with path-style addressing. It checks the
+// requests are signed with its access key, and supports the conditional writes and bucket lifecycle
+// configurations the S3 backend relies on.
+type fakeS3Server struct {
+	*httptest.Server
+	bucket    string
+	accessKey string
+
+	mu        sync.Mutex
+	objects   map[string]fakeS3Object
+	lifecycle string
+	// ifMatch holds the If-Match header of the last object put
+	ifMatch string
+	// afterHead, if set, runs after an object is read with a HEAD request
+	afterHead func(key string)
+}
+
+func newFakeS3Server(t *testing.T) *fakeS3Server {
+	server := &fakeS3Server{
+		bucket:    "prebid-cache",
+		accessKey: "access-key",
+		objects:   make(map[string]fakeS3Object),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *fakeS3Server) config() config.S3 {
+	return config.S3{
+		Endpoint:        s.URL,
+		Region:          "us-east-1",
+		Bucket:          s.bucket,
+		Prefix:          "creatives/",
+		ForcePathStyle:  true,
+		AccessKeyID:     s.accessKey,
+		SecretAccessKey: "secret-key",
+		TimeoutMillis:   1000,
+	}
+}
+
+func (s *fakeS3Server) object(name string) fakeS3Object {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.objects[name]
+}
+
+func (s *fakeS3Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+s.accessKey+"/") {
+		s.writeError(w, http.StatusForbidden, "InvalidAccessKeyId")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+s.bucket)
+	if len(path) == len(r.URL.Path) {
+		s.writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	key := strings.TrimPrefix(path, "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case key == "" && r.URL.Query().Has("lifecycle"):
+		s.handleLifecycle(w, r, body)
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := s.objects[key]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		for name, values := range object.header {
+			w.Header()[name] = values
+		}
+		if r.Method == http.MethodGet {
+			w.Write(object.value)
+		} else if s.afterHead != nil {
+			s.afterHead(key)
+		}
+	case r.Method == http.MethodPut:
+		object, exists := s.objects[key]
+		if exists && r.Header.Get("If-None-Match") == "*" || r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != object.header.Get("ETag") {
+			s.writeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		s.ifMatch = r.Header.Get("If-Match")
+
+		checksum := md5.Sum(body)
+		header := http.Header{}
+		header.Set("ETag", `"`+hex.EncodeToString(checksum[:])+`"`)
+		for name, values := range r.Header {
+			if strings.HasPrefix(name, "X-Amz-Meta-") || name == "Expires" || name == "Content-Type" {
+				header[name] = values
+			}
+		}
+		s.objects[key] = fakeS3Object{value: body, header: header}
+		w.Header().Set("ETag", header.Get("ETag"))
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *fakeS3Server) handleLifecycle(w http.ResponseWriter, r *http.Request, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		if s.lifecycle == "" {
+			s.writeError(w, http.StatusNotFound, "NoSuchLifecycleConfiguration")
+			return
+		}
+		io.WriteString(w, s.lifecycle)
+	case http.MethodPut:
+		s.lifecycle = string(body)
+	}
+}
+
+func (s *fakeS3Server) writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>`+code+`</Code><Message>fake S3 error</Message></Error>`)
+}
+
+// newTestS3Backend creates an S3 backend configured with cfg
+func newTestS3Backend(cfg config.S3, now func() time.Time) (*S3Backend, error) {
+	awsConfig, err := loadAWSConfig(context.Background(), cfg.Region, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken, cfg.TimeoutMillis, cfg.MaxIdleConns)
+	if err != nil {
+		return nil, err
+	}
+	return newS3Backend(context.Background(), cfg, awsConfig, now)
+}
+
+func TestNewS3Backend(t *testing.T) {
+	server := newFakeS3Server(t)
+
+	cfg := server.config()
+	cfg.Bucket = "missing"
+	_, err := newTestS3Backend(cfg, time.Now)
+	assert.Equal(t, http.StatusNotFound, awsStatusCode(err), "The bucket must exist")
+
+	cfg = server.config()
+	cfg.AccessKeyID = "wrong-key"
+	_, err = newTestS3Backend(cfg, time.Now)
+	assert.Equal(t, http.StatusForbidden, awsStatusCode(err), "The credentials must be valid")
+
+	cfg = server.config()
+	cfg.Bucket = ""
+	_, err = newTestS3Backend(cfg, time.Now)
+	assert.EqualError(t, err, "the S3 bucket cannot be empty", "The bucket must be set")
+
+	server.lifecycle = `<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+		`<Rule><ID>logs</ID><Filter><Prefix>logs/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule>` +
+		`<Rule><ID>prebid-cache-expiration</ID><Filter><Prefix>creatives/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>7</Days></Expiration></Rule>` +
+		`</LifecycleConfiguration>`
+	cfg = server.config()
+	cfg.ConfigureLifecycle = true
+	cfg.LifecycleExpirationDays = 1
+	_, err = newTestS3Backend(cfg, time.Now)
+	require.NoError(t, err)
+
+	var lifecycle struct {
+		Rules []struct {
+			ID     string `xml:"ID"`
+			Prefix string `xml:"Filter>Prefix"`
+			Status string `xml:"Status"`
+			Days   int    `xml:"Expiration>Days"`
+		} `xml:"Rule"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(server.lifecycle), &lifecycle))
+	if assert.Len(t, lifecycle.Rules, 2, "Other rules are kept and the expiration rule gets updated") {
+		assert.Equal(t, "logs", lifecycle.Rules[0].ID)
+		assert.Equal(t, 30, lifecycle.Rules[0].Days)
+		assert.Equal(t, "prebid-cache-expiration", lifecycle.Rules[1].ID)
+		assert.Equal(t, "creatives/", lifecycle.Rules[1].Prefix)
+		assert.Equal(t, "Enabled", lifecycle.Rules[1].Status)
+		assert.Equal(t, 1, lifecycle.Rules[1].Days)
+	}
+}
+
+func TestS3GetAndPut(t *testing.T) {
+	server := newFakeS3Server(t)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	backend, err := newTestS3Backend(server.config(), clock.Now)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = backend.Get(ctx, "someKey")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of a missing key")
+
+	assert.NoError(t, backend.Put(ctx, "someKey", "<VAST version=\"3.0\"></VAST>", 60), "Put of a new key")
+	assert.Equal(t, "1700000060", server.object("creatives/someKey").header.Get("X-Amz-Meta-Expires-At"), "The expiration time is stored in the object metadata")
+
+	value, err := backend.Get(ctx, "someKey")
+	assert.NoError(t, err, "Get of an existing key")
+	assert.Equal(t, "<VAST version=\"3.0\"></VAST>", value, "Get of an existing key")
+
+	err = backend.Put(ctx, "someKey", "otherValue", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "Put of an existing key")
+
+	clock.now = clock.now.Add(time.Minute)
+	_, err = backend.Get(ctx, "someKey")
+	assert.Equal(t, utils.NewPBCError(utils.KEY_NOT_FOUND), err, "Get of an expired key that wasn't deleted yet")
+
+	assert.NoError(t, backend.Put(ctx, "key with spaces+signs", "value", 0), "Keys get escaped")
+	value, err = backend.Get(ctx, "key with spaces+signs")
+	assert.NoError(t, err, "Values without expiration time never expire")
+	assert.Equal(t, "value", value, "Values without expiration time never expire")
+
+	assert.NoError(t, backend.HealthCheck(ctx), "Health check")
+}
+
+func TestS3PutOverwritesExpiredObjects(t *testing.T) {
+	server := newFakeS3Server(t)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	backend, err := newTestS3Backend(server.config(), clock.Now)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, backend.Put(ctx, "someKey", "expiredValue", 60))
+	expiredETag := server.object("creatives/someKey").header.Get("ETag")
+	clock.now = clock.now.Add(time.Minute)
+
+	assert.NoError(t, backend.Put(ctx, "someKey", "newValue", 60), "Put of a key whose value expired")
+	assert.Equal(t, expiredETag, server.ifMatch, "The overwrite is conditioned on the expired object")
+	value, err := backend.Get(ctx, "someKey")
+	assert.NoError(t, err)
+	assert.Equal(t, "newValue", value)
+
+	err = backend.Put(ctx, "someKey", "otherValue", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "The new value doesn't get overwritten")
+
+	// Another put replaces the expired object between the check of its expiration and the overwrite
+	clock.now = clock.now.Add(time.Minute)
+	server.afterHead = func(key string) {
+		server.objects[key].header.Set("ETag", `"replaced"`)
+	}
+	err = backend.Put(ctx, "someKey", "racingValue", 60)
+	assert.Equal(t, utils.NewPBCError(utils.RECORD_EXISTS), err, "Concurrent overwrite of an expired key")
+}
+
+func TestS3Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		if r.Method == http.MethodGet {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`)
+	}))
+	defer server.Close()
+
+	backend, err := newTestS3Backend(config.S3{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "prebid-cache",
+		ForcePathStyle:  true,
+		AccessKeyID:     "access-key",
+		SecretAccessKey: "secret-key",
+		TimeoutMillis:   10,
+	}, time.Now)
+	require.NoError(t, err)
+
+	_, err = backend.Get(context.Background(), "someKey")
+	assert.Equal(t, context.DeadlineExceeded, err, "Client timeouts are reported as deadline errors")
+
+	err = backend.Put(context.Background(), "someKey", "someValue", 60)
+	if assert.Error(t, err, "Errors of the object store are reported") {
+		assert.Equal(t, http.StatusServiceUnavailable, awsStatusCode(err))
+		assert.Contains(t, err.Error(), "SlowDown: Please reduce your request rate.")
+	}
+}
@@ -9,7 +9,7 @@ import (
 
 	as "github.com/aerospike/aerospike-client-go/v6"
 	as_types "github.com/aerospike/aerospike-client-go/v6/types"
-	"github.com/google/gomemcache/memcache"
+	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/prebid/prebid-cache/utils"
 )
 
@@ -17,27 +17,33 @@ import (
 // Aerospike client mocks
 // ------------------------------------------
 func NewMockAerospikeBackend(mockClient AerospikeDB) *AerospikeBackend {
-	return &AerospikeBackend{client: mockClient}
+	return &AerospikeBackend{
+		setName:     defaultSetName,
+		binName:     defaultBinName,
+		readPolicy:  as.NewPolicy(),
+		writePolicy: as.NewWritePolicy(0, 0),
+		client:      mockClient,
+	}
 }
 
 type ErrorProneAerospikeClient struct {
 	ServerError string
 }
 
-func (c *ErrorProneAerospikeClient) NewUUIDKey(namespace string, key string) (*as.Key, error) {
+func (c *ErrorProneAerospikeClient) NewUUIDKey(namespace string, setName string, key string) (*as.Key, error) {
 	if c.ServerError == "TEST_KEY_GEN_ERROR" {
 		return nil, &as.AerospikeError{ResultCode: as_types.NOT_AUTHENTICATED}
 	}
 	return nil, nil
 }
 
-func (c *ErrorProneAerospikeClient) Get(key *as.Key) (*as.Record, error) {
+func (c *ErrorProneAerospikeClient) Get(policy *as.BasePolicy, key *as.Key, binNames ...string) (*as.Record, error) {
 	if c.ServerError == "TEST_GET_ERROR" {
 		return nil, &as.AerospikeError{ResultCode: as_types.KEY_NOT_FOUND_ERROR}
 	} else if c.ServerError == "TEST_NO_BUCKET_ERROR" {
 		return &as.Record{Bins: as.BinMap{"AnyKey": "any_value"}}, nil
 	} else if c.ServerError == "TEST_NON_STRING_VALUE_ERROR" {
-		return &as.Record{Bins: as.BinMap{binValue: 0.0}}, nil
+		return &as.Record{Bins: as.BinMap{binNames[0]: 0.0}}, nil
 	}
 	return nil, nil
 }
@@ -49,18 +55,22 @@ func (c *ErrorProneAerospikeClient) Put(policy *as.WritePolicy, key *as.Key, bin
 	return nil
 }
 
+func (c *ErrorProneAerospikeClient) IsConnected() bool {
+	return c.ServerError != "TEST_DISCONNECTED"
+}
+
 // Aerospike client that does not throw errors
 type GoodAerospikeClient struct {
 	StoredData map[string]string
 }
 
-func (c *GoodAerospikeClient) Get(aeKey *as.Key) (*as.Record, error) {
+func (c *GoodAerospikeClient) Get(policy *as.BasePolicy, aeKey *as.Key, binNames ...string) (*as.Record, error) {
 	if aeKey != nil && aeKey.Value() != nil {
 		key := aeKey.Value().String()
 
 		if value, found := c.StoredData[key]; found {
 			rec := &as.Record{
-				Bins: as.BinMap{binValue: value},
+				Bins: as.BinMap{binNames[0]: value},
 			}
 			return rec, nil
 		}
@@ -71,7 +81,7 @@ func (c *GoodAerospikeClient) Get(aeKey *as.Key) (*as.Record, error) {
 func (c *GoodAerospikeClient) Put(policy *as.WritePolicy, aeKey *as.Key, binMap as.BinMap) error {
 	if aeKey != nil && aeKey.Value() != nil {
 		key := aeKey.Value().String()
-		if interfaceValue, found := binMap[binValue]; found {
+		if interfaceValue, found := binMap[defaultBinName]; found {
 			if str, asserted := interfaceValue.(string); asserted {
 				c.StoredData[key] = str
 			}
@@ -82,7 +92,11 @@ func (c *GoodAerospikeClient) Put(policy *as.WritePolicy, aeKey *as.Key, binMap
 	return &as.AerospikeError{ResultCode: as_types.KEY_MISMATCH}
 }
 
-func (c *GoodAerospikeClient) NewUUIDKey(namespace string, key string) (*as.Key, error) {
+func (c *GoodAerospikeClient) IsConnected() bool {
+	return true
+}
+
+func (c *GoodAerospikeClient) NewUUIDKey(namespace string, setName string, key string) (*as.Key, error) {
 	return as.NewKey(namespace, setName, key)
 }
 
@@ -113,6 +127,10 @@ func (ec *ErrorProneCassandraClient) Put(ctx context.Context, key string, value
 	return ec.Applied, ec.ServerError
 }
 
+func (ec *ErrorProneCassandraClient) Closed() bool {
+	return true
+}
+
 // Cassandra client client that does not throw errors
 type GoodCassandraClient struct {
 	StoredData map[string]string
@@ -136,6 +154,10 @@ func (gc *GoodCassandraClient) Put(ctx context.Context, key string, value string
 	return true, nil
 }
 
+func (gc *GoodCassandraClient) Closed() bool {
+	return false
+}
+
 // ------------------------------------------
 // Memcache client mocks
 // ------------------------------------------
@@ -157,6 +179,10 @@ func (ec *ErrorProneMemcache) Put(key string, value string, ttlSeconds int) erro
 	return ec.ServerError
 }
 
+func (ec *ErrorProneMemcache) Ping() error {
+	return ec.ServerError
+}
+
 // Memcache client that does not throw errors
 type GoodMemcache struct {
 	StoredData map[string]string
@@ -176,6 +202,10 @@ func (gm *GoodMemcache) Put(key string, value string, ttlSeconds int) error {
 	return nil
 }
 
+func (gm *GoodMemcache) Ping() error {
+	return nil
+}
+
 // ------------------------------------------
 // Redis client mocks
 // ------------------------------------------
@@ -209,6 +239,10 @@ func (r FakeRedisClient) Put(ctx context.Context, key string, value string, ttlS
 	return r.Success, r.ServerError
 }
 
+func (r FakeRedisClient) Ping(ctx context.Context) error {
+	return r.ServerError
+}
+
 // ------------------------------------------
 // Memory client mocks
 // ------------------------------------------
@@ -36,3 +36,7 @@ func (s *snappyCompressor) Get(ctx context.Context, key string) (string, error)
 
 	return string(decompressed), nil
 }
+
+func (s *snappyCompressor) HealthCheck(ctx context.Context) error {
+	return backends.CheckHealth(ctx, s.delegate)
+}
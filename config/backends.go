@@ -3,6 +3,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -14,12 +18,36 @@ type Backend struct {
 	Memcache  Memcache    `mapstructure:"memcache"`
 	Redis     Redis       `mapstructure:"redis"`
 	Ignite    Ignite      `mapstructure:"ignite"`
+	Bolt      Bolt        `mapstructure:"bolt"`
+	Postgres  Postgres    `mapstructure:"postgres"`
+	S3        S3          `mapstructure:"s3"`
+	DynamoDB  DynamoDB    `mapstructure:"dynamodb"`
+	NATS      NATS        `mapstructure:"nats"`
+	Routed    Routed      `mapstructure:"routed"`
+	Migrate   Migrate     `mapstructure:"migrate"`
+	Shadow    Shadow      `mapstructure:"shadow"`
 }
 
 func (cfg *Backend) validateAndLog() error {
+	var errs ValidationErrors
 
 	log.Infof("config.backend.type: %s", cfg.Type)
-	switch cfg.Type {
+	if cfg.Type == BackendMigrate {
+		errs.add(cfg.validateAndLogMigrate())
+	} else if cfg.Type == BackendRouted {
+		errs.add(cfg.validateAndLogRouted())
+	} else if !isStorageBackend(cfg.Type) {
+		errs.add(fmt.Errorf(`invalid config.backend.type: %s. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats", "memory", "migrate" or "routed".`, cfg.Type))
+	} else {
+		errs.add(cfg.validateAndLogStorage(cfg.Type))
+	}
+	errs.add(cfg.validateAndLogShadow())
+	return errs.toError()
+}
+
+// validateAndLogStorage validates the settings of the storage backend of type backendType
+func (cfg *Backend) validateAndLogStorage(backendType BackendType) error {
+	switch backendType {
 	case BackendAerospike:
 		return cfg.Aerospike.validateAndLog()
 	case BackendCassandra:
@@ -30,14 +58,161 @@ func (cfg *Backend) validateAndLog() error {
 		return cfg.Redis.validateAndLog()
 	case BackendIgnite:
 		return cfg.Ignite.validateAndLog()
-	case BackendMemory:
-		return nil
-	default:
-		return fmt.Errorf(`invalid config.backend.type: %s. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", or "memory".`, cfg.Type)
+	case BackendBolt:
+		return cfg.Bolt.validateAndLog()
+	case BackendPostgres:
+		return cfg.Postgres.validateAndLog()
+	case BackendS3:
+		return cfg.S3.validateAndLog()
+	case BackendDynamoDB:
+		return cfg.DynamoDB.validateAndLog()
+	case BackendNATS:
+		return cfg.NATS.validateAndLog()
 	}
 	return nil
 }
 
+// validateAndLogMigrate makes sure the backends to migrate from and to are two different storage
+// backends, and validates the settings of both
+func (cfg *Backend) validateAndLogMigrate() error {
+	var errs ValidationErrors
+
+	fromValid := isStorageBackend(cfg.Migrate.From)
+	if !fromValid {
+		errs.add(fmt.Errorf(`invalid config.backend.migrate.from: %s. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`, cfg.Migrate.From))
+	}
+	toValid := isStorageBackend(cfg.Migrate.To)
+	if !toValid {
+		errs.add(fmt.Errorf(`invalid config.backend.migrate.to: %s. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`, cfg.Migrate.To))
+	}
+	if fromValid && cfg.Migrate.From == cfg.Migrate.To {
+		errs.add(fmt.Errorf("invalid config.backend.migrate: from and to must be different backends, both are %s.", cfg.Migrate.From))
+	}
+	if cfg.Migrate.MirrorWritesSeconds < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.migrate.mirror_writes_seconds: %d. Value cannot be negative.", cfg.Migrate.MirrorWritesSeconds))
+	}
+	if cfg.Migrate.CopyOnRead && cfg.Migrate.CopyOnReadTTLSeconds <= 0 {
+		errs.add(fmt.Errorf("invalid config.backend.migrate.copy_on_read_ttl_seconds: %d. Value must be positive when copy_on_read is enabled.", cfg.Migrate.CopyOnReadTTLSeconds))
+	}
+
+	if len(errs) == 0 {
+		log.Infof("config.backend.migrate.from: %s", cfg.Migrate.From)
+		log.Infof("config.backend.migrate.to: %s", cfg.Migrate.To)
+		log.Infof("config.backend.migrate.mirror_writes_seconds: %d", cfg.Migrate.MirrorWritesSeconds)
+		log.Infof("config.backend.migrate.copy_on_read: %t", cfg.Migrate.CopyOnRead)
+		if cfg.Migrate.CopyOnRead {
+			log.Infof("config.backend.migrate.copy_on_read_ttl_seconds: %d", cfg.Migrate.CopyOnReadTTLSeconds)
+		}
+	}
+
+	if fromValid {
+		errs.add(cfg.validateAndLogStorage(cfg.Migrate.From))
+	}
+	if toValid && cfg.Migrate.To != cfg.Migrate.From {
+		errs.add(cfg.validateAndLogStorage(cfg.Migrate.To))
+	}
+	return errs.toError()
+}
+
+// validateAndLogRouted makes sure the routes of the routed backend lead to storage backends, and
+// validates the settings of each of them once
+func (cfg *Backend) validateAndLogRouted() error {
+	var errs ValidationErrors
+
+	if !isStorageBackend(cfg.Routed.Pointers) {
+		errs.add(fmt.Errorf(`invalid config.backend.routed.pointers: %s. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`, cfg.Routed.Pointers))
+	}
+	if cfg.Routed.Default != "" && !isStorageBackend(cfg.Routed.Default) {
+		errs.add(fmt.Errorf(`invalid config.backend.routed.default: %s. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`, cfg.Routed.Default))
+	}
+	if len(cfg.Routed.Routes) == 0 {
+		errs.add(fmt.Errorf("invalid config.backend.routed.routes: at least one route is required."))
+	}
+	for i, route := range cfg.Routed.Routes {
+		if !isStorageBackend(route.Backend) {
+			errs.add(fmt.Errorf(`invalid config.backend.routed.routes[%d].backend: %s. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`, i, route.Backend))
+		}
+		if route.MinSizeBytes < 0 {
+			errs.add(fmt.Errorf("invalid config.backend.routed.routes[%d].min_size_bytes: %d. Value cannot be negative.", i, route.MinSizeBytes))
+		}
+		if route.MaxSizeBytes < 0 {
+			errs.add(fmt.Errorf("invalid config.backend.routed.routes[%d].max_size_bytes: %d. Value cannot be negative.", i, route.MaxSizeBytes))
+		}
+		if route.MaxSizeBytes > 0 && route.MaxSizeBytes < route.MinSizeBytes {
+			errs.add(fmt.Errorf("invalid config.backend.routed.routes[%d].max_size_bytes: %d. Value cannot be less than min_size_bytes.", i, route.MaxSizeBytes))
+		}
+	}
+
+	if len(errs) == 0 {
+		log.Infof("config.backend.routed.pointers: %s", cfg.Routed.Pointers)
+		log.Infof("config.backend.routed.default: %s", cfg.Routed.DefaultBackend())
+		for i, route := range cfg.Routed.Routes {
+			log.Infof("config.backend.routed.routes[%d]: backend=%s type=%s min_size_bytes=%d max_size_bytes=%d", i, route.Backend, route.Type, route.MinSizeBytes, route.MaxSizeBytes)
+		}
+	}
+
+	// The storage backends that are valid get validated too, each of them once
+	for _, backendType := range cfg.Routed.BackendTypes() {
+		if isStorageBackend(backendType) {
+			errs.add(cfg.validateAndLogStorage(backendType))
+		}
+	}
+	return errs.toError()
+}
+
+// validateAndLogShadow makes sure the candidate backend shadowing the configured one is a different
+// storage backend, and validates its settings
+func (cfg *Backend) validateAndLogShadow() error {
+	if !cfg.Shadow.Enabled {
+		return nil
+	}
+
+	var errs ValidationErrors
+
+	typeValid := isStorageBackend(cfg.Shadow.Type)
+	if !typeValid {
+		errs.add(fmt.Errorf(`invalid config.backend.shadow.type: %s. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`, cfg.Shadow.Type))
+	} else if cfg.Shadow.Type == cfg.Type {
+		errs.add(fmt.Errorf("invalid config.backend.shadow.type: %s. The candidate backend must differ from config.backend.type.", cfg.Shadow.Type))
+	}
+	if cfg.Shadow.SamplingRate < 0 || cfg.Shadow.SamplingRate > 1 {
+		errs.add(fmt.Errorf("invalid config.backend.shadow.sampling_rate: %v. Value must be between 0 and 1.", cfg.Shadow.SamplingRate))
+	}
+	if cfg.Shadow.QueueSize <= 0 {
+		errs.add(fmt.Errorf("invalid config.backend.shadow.queue_size: %d. Value must be positive.", cfg.Shadow.QueueSize))
+	}
+	if cfg.Shadow.Workers <= 0 {
+		errs.add(fmt.Errorf("invalid config.backend.shadow.workers: %d. Value must be positive.", cfg.Shadow.Workers))
+	}
+	if cfg.Shadow.TimeoutMillis <= 0 {
+		errs.add(fmt.Errorf("invalid config.backend.shadow.timeout_ms: %d. Value must be positive.", cfg.Shadow.TimeoutMillis))
+	}
+
+	if len(errs) == 0 {
+		log.Infof("config.backend.shadow.enabled: %t", cfg.Shadow.Enabled)
+		log.Infof("config.backend.shadow.type: %s", cfg.Shadow.Type)
+		log.Infof("config.backend.shadow.sampling_rate: %v", cfg.Shadow.SamplingRate)
+		log.Infof("config.backend.shadow.queue_size: %d", cfg.Shadow.QueueSize)
+		log.Infof("config.backend.shadow.workers: %d", cfg.Shadow.Workers)
+		log.Infof("config.backend.shadow.timeout_ms: %d", cfg.Shadow.TimeoutMillis)
+	}
+
+	// A candidate of the same type as the main backend shares its settings, which got validated already
+	if typeValid && cfg.Shadow.Type != cfg.Type {
+		errs.add(cfg.validateAndLogStorage(cfg.Shadow.Type))
+	}
+	return errs.toError()
+}
+
+// isStorageBackend tells whether backendType is a backend that stores data by itself
+func isStorageBackend(backendType BackendType) bool {
+	switch backendType {
+	case BackendAerospike, BackendCassandra, BackendMemcache, BackendMemory, BackendRedis, BackendIgnite, BackendBolt, BackendPostgres, BackendS3, BackendDynamoDB, BackendNATS:
+		return true
+	}
+	return false
+}
+
 type BackendType string
 
 const (
@@ -47,8 +222,92 @@ const (
 	BackendMemory    BackendType = "memory"
 	BackendRedis     BackendType = "redis"
 	BackendIgnite    BackendType = "ignite"
+	BackendBolt      BackendType = "bolt"
+	BackendPostgres  BackendType = "postgres"
+	BackendS3        BackendType = "s3"
+	BackendDynamoDB  BackendType = "dynamodb"
+	BackendNATS      BackendType = "nats"
+	BackendMigrate   BackendType = "migrate"
+	BackendRouted    BackendType = "routed"
 )
 
+// Routed holds the settings of the "routed" backend type, which stores each value in one of several
+// storage backends depending on its size and type. The backend a key was routed to is recorded in the
+// Pointers backend, so reads don't have to query every backend.
+type Routed struct {
+	// Pointers is the backend recording where each key was routed to. Values routed to it get stored
+	// in place of the pointer.
+	Pointers BackendType `mapstructure:"pointers"`
+	// Routes are matched in order against the values being put. Values matching none of them go to the
+	// Default backend, which is the Pointers backend if empty.
+	Routes  []Route     `mapstructure:"routes"`
+	Default BackendType `mapstructure:"default"`
+}
+
+// Route matches the values of Type, "xml" or "json", whose size is between MinSizeBytes and
+// MaxSizeBytes. An empty Type matches values of any type, and a MaxSizeBytes of zero means no upper
+// bound.
+type Route struct {
+	Backend      BackendType `mapstructure:"backend"`
+	Type         string      `mapstructure:"type"`
+	MinSizeBytes int         `mapstructure:"min_size_bytes"`
+	MaxSizeBytes int         `mapstructure:"max_size_bytes"`
+}
+
+// DefaultBackend returns the backend the values matching none of the routes go to
+func (cfg *Routed) DefaultBackend() BackendType {
+	if cfg.Default == "" {
+		return cfg.Pointers
+	}
+	return cfg.Default
+}
+
+// BackendTypes returns the storage backends the routed backend uses, each of them once
+func (cfg *Routed) BackendTypes() []BackendType {
+	backendTypes := []BackendType{cfg.Pointers}
+	seen := map[BackendType]bool{cfg.Pointers: true}
+	candidates := []BackendType{cfg.DefaultBackend()}
+	for _, route := range cfg.Routes {
+		candidates = append(candidates, route.Backend)
+	}
+	for _, backendType := range candidates {
+		if !seen[backendType] {
+			seen[backendType] = true
+			backendTypes = append(backendTypes, backendType)
+		}
+	}
+	return backendTypes
+}
+
+// Migrate holds the settings of the "migrate" backend type, used to move the entries of a running
+// Prebid Cache from one storage backend to another without losing them at cutover. Writes go to the
+// "to" backend and reads fall back to the "from" backend on misses.
+type Migrate struct {
+	From BackendType `mapstructure:"from"`
+	To   BackendType `mapstructure:"to"`
+	// MirrorWritesSeconds is how long after startup writes keep getting mirrored to the "from" backend,
+	// so rolling back stays possible during the transition. Zero disables mirroring.
+	MirrorWritesSeconds int `mapstructure:"mirror_writes_seconds"`
+	// CopyOnRead backfills the "to" backend with the entries read from the "from" backend, stored with
+	// a time-to-live of CopyOnReadTTLSeconds as their remaining one is unknown. It can't exceed
+	// RequestLimits.MaxTTLSeconds, so copies don't outlive any value put in the "from" backend.
+	CopyOnRead           bool `mapstructure:"copy_on_read"`
+	CopyOnReadTTLSeconds int  `mapstructure:"copy_on_read_ttl_seconds"`
+}
+
+// Shadow holds the settings to replay a sample of the backend traffic against a candidate backend,
+// in order to evaluate it before moving to it. The candidate gets configured in its usual section.
+type Shadow struct {
+	Enabled bool        `mapstructure:"enabled"`
+	Type    BackendType `mapstructure:"type"`
+	// SamplingRate is the share of keys, between 0 and 1, whose puts and gets get replayed
+	SamplingRate float64 `mapstructure:"sampling_rate"`
+	// QueueSize bounds the number of calls waiting to be replayed. Calls get dropped when it's full.
+	QueueSize     int `mapstructure:"queue_size"`
+	Workers       int `mapstructure:"workers"`
+	TimeoutMillis int `mapstructure:"timeout_ms"`
+}
+
 type Aerospike struct {
 	DefaultTTLSecs  int      `mapstructure:"default_ttl_seconds"`
 	Host            string   `mapstructure:"host"`
@@ -67,15 +326,91 @@ type Aerospike struct {
 	ConnIdleTimeoutSecs int `mapstructure:"connection_idle_timeout_seconds"`
 	// Specifies the size of the connection queue per node.
 	ConnQueueSize int `mapstructure:"connection_queue_size"`
+	// SetName is the set the cached values are stored in. Defaults to "uuid" if empty.
+	SetName string `mapstructure:"set_name"`
+	// BinName is the bin that holds the cached values. Defaults to "value" if empty.
+	BinName string `mapstructure:"bin_name"`
+	// AuthMode is either "internal" or "external". It only applies when User is set.
+	AuthMode AerospikeAuthMode `mapstructure:"auth_mode"`
+	// Socket and total timeouts of each read and write operation. The Aerospike client's default
+	// read timeouts apply if zero, while writes are not bounded.
+	ReadSocketTimeoutMillis  int `mapstructure:"read_socket_timeout_ms"`
+	ReadTotalTimeoutMillis   int `mapstructure:"read_total_timeout_ms"`
+	WriteSocketTimeoutMillis int `mapstructure:"write_socket_timeout_ms"`
+	WriteTotalTimeoutMillis  int `mapstructure:"write_total_timeout_ms"`
+	// RackAware makes reads prefer the replicas found in the rack identified by RackID
+	RackAware bool         `mapstructure:"rack_aware"`
+	RackID    int          `mapstructure:"rack_id"`
+	TLS       AerospikeTLS `mapstructure:"tls"`
+}
+
+type AerospikeAuthMode string
+
+const (
+	AerospikeAuthInternal AerospikeAuthMode = "internal"
+	AerospikeAuthExternal AerospikeAuthMode = "external"
+)
+
+type AerospikeTLS struct {
+	Enabled bool `mapstructure:"enabled"`
+	// CAFile is the PEM bundle of the authorities that sign the node certificates. The system
+	// roots are used if empty.
+	CAFile string `mapstructure:"ca_file"`
+	// CertFile and KeyFile hold the client certificate presented to nodes that require mutual TLS
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// Name is the TLS name the node certificates are verified against
+	Name string `mapstructure:"name"`
 }
 
 func (cfg *Aerospike) validateAndLog() error {
+	var errs ValidationErrors
+
 	if len(cfg.Host) < 1 && len(cfg.Hosts) < 1 {
-		return fmt.Errorf("Cannot connect to empty Aerospike host(s)")
+		errs.add(fmt.Errorf("Cannot connect to empty Aerospike host(s)"))
 	}
 
 	if cfg.Port <= 0 {
-		return fmt.Errorf("Cannot connect to Aerospike host at port %d", cfg.Port)
+		errs.add(fmt.Errorf("Cannot connect to Aerospike host at port %d", cfg.Port))
+	}
+
+	// Aerospike limits set names to 63 characters and bin names to 15
+	if len(cfg.SetName) > 63 {
+		errs.add(fmt.Errorf("invalid config.backend.aerospike.set_name: %s. Set names cannot be longer than 63 characters.", cfg.SetName))
+	}
+	if len(cfg.BinName) > 15 {
+		errs.add(fmt.Errorf("invalid config.backend.aerospike.bin_name: %s. Bin names cannot be longer than 15 characters.", cfg.BinName))
+	}
+
+	switch cfg.AuthMode {
+	case "", AerospikeAuthInternal, AerospikeAuthExternal:
+	default:
+		errs.add(fmt.Errorf(`invalid config.backend.aerospike.auth_mode: %s. It must be "internal" or "external".`, cfg.AuthMode))
+	}
+
+	timeouts := []struct {
+		name   string
+		millis int
+	}{
+		{"read_socket_timeout_ms", cfg.ReadSocketTimeoutMillis},
+		{"read_total_timeout_ms", cfg.ReadTotalTimeoutMillis},
+		{"write_socket_timeout_ms", cfg.WriteSocketTimeoutMillis},
+		{"write_total_timeout_ms", cfg.WriteTotalTimeoutMillis},
+	}
+	for _, timeout := range timeouts {
+		if timeout.millis < 0 {
+			errs.add(fmt.Errorf("invalid config.backend.aerospike.%s: %d. Value cannot be negative.", timeout.name, timeout.millis))
+		}
+	}
+
+	if !cfg.TLS.Enabled && (cfg.TLS.CAFile != "" || cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" || cfg.TLS.Name != "") {
+		errs.add(fmt.Errorf("invalid config.backend.aerospike.tls: ca_file, cert_file, key_file and name require tls.enabled."))
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs.add(fmt.Errorf("invalid config.backend.aerospike.tls: cert_file and key_file must be set together."))
+	}
+	if len(errs) > 0 {
+		return errs
 	}
 
 	log.Infof("config.backend.aerospike.host: %s", cfg.Host)
@@ -83,6 +418,15 @@ func (cfg *Aerospike) validateAndLog() error {
 	log.Infof("config.backend.aerospike.port: %d", cfg.Port)
 	log.Infof("config.backend.aerospike.namespace: %s", cfg.Namespace)
 	log.Infof("config.backend.aerospike.user: %s", cfg.User)
+	if cfg.AuthMode != "" {
+		log.Infof("config.backend.aerospike.auth_mode: %s", cfg.AuthMode)
+	}
+	if cfg.SetName != "" {
+		log.Infof("config.backend.aerospike.set_name: %s", cfg.SetName)
+	}
+	if cfg.BinName != "" {
+		log.Infof("config.backend.aerospike.bin_name: %s", cfg.BinName)
+	}
 
 	if cfg.DefaultTTLSecs > 0 {
 		log.Infof("config.backend.aerospike.default_ttl_seconds: %d. Note that this configuration option is being deprecated in favor of config.request_limits.max_ttl_seconds", cfg.DefaultTTLSecs)
@@ -112,6 +456,25 @@ func (cfg *Aerospike) validateAndLog() error {
 		log.Infof("config.backend.aerospike.connection_queue_size value will default to 256")
 	}
 
+	for _, timeout := range timeouts {
+		if timeout.millis > 0 {
+			log.Infof("config.backend.aerospike.%s: %d", timeout.name, timeout.millis)
+		}
+	}
+
+	if cfg.RackAware {
+		log.Infof("config.backend.aerospike.rack_aware: %t", cfg.RackAware)
+		log.Infof("config.backend.aerospike.rack_id: %d", cfg.RackID)
+	}
+
+	if cfg.TLS.Enabled {
+		log.Infof("config.backend.aerospike.tls.enabled: %t", cfg.TLS.Enabled)
+		log.Infof("config.backend.aerospike.tls.ca_file: %s", cfg.TLS.CAFile)
+		log.Infof("config.backend.aerospike.tls.cert_file: %s", cfg.TLS.CertFile)
+		log.Infof("config.backend.aerospike.tls.key_file: %s", cfg.TLS.KeyFile)
+		log.Infof("config.backend.aerospike.tls.name: %s", cfg.TLS.Name)
+	}
+
 	return nil
 }
 
@@ -119,9 +482,92 @@ type Cassandra struct {
 	Hosts      string `mapstructure:"hosts"`
 	Keyspace   string `mapstructure:"keyspace"`
 	DefaultTTL int    `mapstructure:"default_ttl_seconds"`
+	// Username and Password authenticate against clusters that use the PasswordAuthenticator
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// LocalDC routes the queries to the nodes of this data center first. Any node may be picked if empty.
+	LocalDC string `mapstructure:"local_dc"`
+	// ReadConsistency and WriteConsistency are consistency level names such as "one", "local_one"
+	// or "local_quorum"
+	ReadConsistency  string `mapstructure:"read_consistency"`
+	WriteConsistency string `mapstructure:"write_consistency"`
+	// ProtocolVersion is the version of the native protocol. It gets negotiated with the cluster if zero.
+	ProtocolVersion int `mapstructure:"protocol_version"`
+	// TimeoutMillis bounds every query and ConnectTimeoutMillis the initial dial to each node.
+	// The client defaults of 600 milliseconds apply if zero.
+	TimeoutMillis        int `mapstructure:"timeout_ms"`
+	ConnectTimeoutMillis int `mapstructure:"connect_timeout_ms"`
+	// CreateSchemaOnStart creates the keyspace and the cache table if they don't exist yet
+	CreateSchemaOnStart bool `mapstructure:"create_schema_on_start"`
+	// ReplicationFactor of the keyspace created on start. It applies to LocalDC if set, or to
+	// the whole cluster otherwise.
+	ReplicationFactor int          `mapstructure:"replication_factor"`
+	TLS               CassandraTLS `mapstructure:"tls"`
+}
+
+type CassandraTLS struct {
+	Enabled            bool `mapstructure:"enabled"`
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+	// CAFile is the PEM bundle of the authorities that sign the node certificates. The system
+	// roots are used if empty.
+	CAFile string `mapstructure:"ca_file"`
+	// CertFile and KeyFile hold the client certificate presented to nodes that require mutual TLS
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+// cassandraConsistencies are the consistency levels Prebid Cache accepts for reads and writes
+var cassandraConsistencies = map[string]bool{
+	"any":          true,
+	"one":          true,
+	"two":          true,
+	"three":        true,
+	"quorum":       true,
+	"all":          true,
+	"local_quorum": true,
+	"each_quorum":  true,
+	"local_one":    true,
 }
 
+// cassandraIdentifier matches the unquoted keyspace names Cassandra accepts
+var cassandraIdentifier = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,47}$`)
+
 func (cfg *Cassandra) validateAndLog() error {
+	var errs ValidationErrors
+
+	if cfg.ReadConsistency != "" && !cassandraConsistencies[strings.ToLower(cfg.ReadConsistency)] {
+		errs.add(fmt.Errorf("invalid config.backend.cassandra.read_consistency: %s", cfg.ReadConsistency))
+	}
+	if cfg.WriteConsistency != "" && !cassandraConsistencies[strings.ToLower(cfg.WriteConsistency)] {
+		errs.add(fmt.Errorf("invalid config.backend.cassandra.write_consistency: %s", cfg.WriteConsistency))
+	}
+	if cfg.ProtocolVersion < 0 || cfg.ProtocolVersion > 5 {
+		errs.add(fmt.Errorf("invalid config.backend.cassandra.protocol_version: %d. It must be between 1 and 5, or 0 to negotiate it.", cfg.ProtocolVersion))
+	}
+	if cfg.TimeoutMillis < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.cassandra.timeout_ms: %d. Value cannot be negative.", cfg.TimeoutMillis))
+	}
+	if cfg.ConnectTimeoutMillis < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.cassandra.connect_timeout_ms: %d. Value cannot be negative.", cfg.ConnectTimeoutMillis))
+	}
+	if cfg.CreateSchemaOnStart {
+		if !cassandraIdentifier.MatchString(cfg.Keyspace) {
+			errs.add(fmt.Errorf("invalid config.backend.cassandra.keyspace: %s. Keyspaces created on start must be alphanumeric identifiers.", cfg.Keyspace))
+		}
+		if cfg.ReplicationFactor < 1 {
+			errs.add(fmt.Errorf("invalid config.backend.cassandra.replication_factor: %d. Value must be positive when create_schema_on_start is enabled.", cfg.ReplicationFactor))
+		}
+	}
+	if !cfg.TLS.Enabled && (cfg.TLS.CAFile != "" || cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "") {
+		errs.add(fmt.Errorf("invalid config.backend.cassandra.tls: ca_file, cert_file and key_file require tls.enabled."))
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs.add(fmt.Errorf("invalid config.backend.cassandra.tls: cert_file and key_file must be set together."))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
 	log.Infof("config.backend.cassandra.hosts: %s", cfg.Hosts)
 	log.Infof("config.backend.cassandra.keyspace: %s", cfg.Keyspace)
 	if cfg.DefaultTTL < 0 {
@@ -129,6 +575,38 @@ func (cfg *Cassandra) validateAndLog() error {
 		cfg.DefaultTTL = 2400
 	}
 	log.Infof("config.backend.cassandra.default_ttl_seconds: %d. Note that this configuration option is being deprecated in favor of config.request_limits.max_ttl_seconds", cfg.DefaultTTL)
+	if cfg.Username != "" {
+		log.Infof("config.backend.cassandra.username: %s", cfg.Username)
+	}
+	if cfg.LocalDC != "" {
+		log.Infof("config.backend.cassandra.local_dc: %s", cfg.LocalDC)
+	}
+	if cfg.ReadConsistency != "" {
+		log.Infof("config.backend.cassandra.read_consistency: %s", cfg.ReadConsistency)
+	}
+	if cfg.WriteConsistency != "" {
+		log.Infof("config.backend.cassandra.write_consistency: %s", cfg.WriteConsistency)
+	}
+	if cfg.ProtocolVersion > 0 {
+		log.Infof("config.backend.cassandra.protocol_version: %d", cfg.ProtocolVersion)
+	}
+	if cfg.TimeoutMillis > 0 {
+		log.Infof("config.backend.cassandra.timeout_ms: %d", cfg.TimeoutMillis)
+	}
+	if cfg.ConnectTimeoutMillis > 0 {
+		log.Infof("config.backend.cassandra.connect_timeout_ms: %d", cfg.ConnectTimeoutMillis)
+	}
+	if cfg.CreateSchemaOnStart {
+		log.Infof("config.backend.cassandra.create_schema_on_start: %t", cfg.CreateSchemaOnStart)
+		log.Infof("config.backend.cassandra.replication_factor: %d", cfg.ReplicationFactor)
+	}
+	if cfg.TLS.Enabled {
+		log.Infof("config.backend.cassandra.tls.enabled: %t", cfg.TLS.Enabled)
+		log.Infof("config.backend.cassandra.tls.insecure_skip_verify: %t", cfg.TLS.InsecureSkipVerify)
+		log.Infof("config.backend.cassandra.tls.ca_file: %s", cfg.TLS.CAFile)
+		log.Infof("config.backend.cassandra.tls.cert_file: %s", cfg.TLS.CertFile)
+		log.Infof("config.backend.cassandra.tls.key_file: %s", cfg.TLS.KeyFile)
+	}
 
 	return nil
 }
@@ -137,9 +615,50 @@ type Memcache struct {
 	ConfigHost          string   `mapstructure:"config_host"`
 	PollIntervalSeconds int      `mapstructure:"poll_interval_seconds"`
 	Hosts               []string `mapstructure:"hosts"`
+	// TimeoutMillis is the socket read and write timeout. The client default of 100 milliseconds
+	// applies if zero.
+	TimeoutMillis int `mapstructure:"timeout_ms"`
+	// MaxIdleConns is the maximum number of idle connections kept per node. The client default
+	// of 2 applies if zero.
+	MaxIdleConns int         `mapstructure:"max_idle_conns"`
+	TLS          MemcacheTLS `mapstructure:"tls"`
+}
+
+type MemcacheTLS struct {
+	Enabled            bool `mapstructure:"enabled"`
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+	// CAFile is the PEM bundle of the authorities that sign the node certificates. The system
+	// roots are used if empty.
+	CAFile string `mapstructure:"ca_file"`
+	// CertFile and KeyFile hold the client certificate presented to nodes that require mutual TLS
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ServerName overrides the host name the node certificates are verified against
+	ServerName string `mapstructure:"server_name"`
 }
 
 func (cfg *Memcache) validateAndLog() error {
+	var errs ValidationErrors
+
+	if cfg.TimeoutMillis < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.memcache.timeout_ms: %d. Value cannot be negative.", cfg.TimeoutMillis))
+	}
+	if cfg.MaxIdleConns < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.memcache.max_idle_conns: %d. Value cannot be negative.", cfg.MaxIdleConns))
+	}
+	if cfg.ConfigHost != "" && cfg.PollIntervalSeconds < 1 {
+		errs.add(fmt.Errorf("invalid config.backend.memcache.poll_interval_seconds: %d. Value must be at least 1 in auto discovery mode.", cfg.PollIntervalSeconds))
+	}
+	if !cfg.TLS.Enabled && (cfg.TLS.CAFile != "" || cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" || cfg.TLS.ServerName != "") {
+		errs.add(fmt.Errorf("invalid config.backend.memcache.tls: ca_file, cert_file, key_file and server_name require tls.enabled."))
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs.add(fmt.Errorf("invalid config.backend.memcache.tls: cert_file and key_file must be set together."))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
 	if cfg.ConfigHost != "" {
 		log.Infof("Memcache client will run in auto discovery mode")
 		log.Infof("config.backend.memcache.config_host: %s", cfg.ConfigHost)
@@ -147,59 +666,230 @@ func (cfg *Memcache) validateAndLog() error {
 	} else {
 		log.Infof("config.backend.memcache.hosts: %v", cfg.Hosts)
 	}
+	if cfg.TimeoutMillis > 0 {
+		log.Infof("config.backend.memcache.timeout_ms: %d", cfg.TimeoutMillis)
+	}
+	if cfg.MaxIdleConns > 0 {
+		log.Infof("config.backend.memcache.max_idle_conns: %d", cfg.MaxIdleConns)
+	}
+	if cfg.TLS.Enabled {
+		log.Infof("config.backend.memcache.tls.enabled: %t", cfg.TLS.Enabled)
+		log.Infof("config.backend.memcache.tls.insecure_skip_verify: %t", cfg.TLS.InsecureSkipVerify)
+		log.Infof("config.backend.memcache.tls.ca_file: %s", cfg.TLS.CAFile)
+		log.Infof("config.backend.memcache.tls.cert_file: %s", cfg.TLS.CertFile)
+		log.Infof("config.backend.memcache.tls.key_file: %s", cfg.TLS.KeyFile)
+		log.Infof("config.backend.memcache.tls.server_name: %s", cfg.TLS.ServerName)
+	}
 	return nil
 }
 
 type Redis struct {
-	Host              string   `mapstructure:"host"`
-	Port              int      `mapstructure:"port"`
-	Password          string   `mapstructure:"password"`
-	Db                int      `mapstructure:"db"`
+	// Mode is either "standalone", "cluster" or "sentinel"
+	Mode RedisMode `mapstructure:"mode"`
+	Host string    `mapstructure:"host"`
+	Port int       `mapstructure:"port"`
+	// Username is the ACL user to authenticate as. Password-only authentication is used if empty.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	Db       int    `mapstructure:"db"`
+	// Addresses is the seed list of host:port addresses of the cluster nodes in cluster mode,
+	// or of the sentinel nodes in sentinel mode
+	Addresses []string `mapstructure:"addresses"`
+	// MasterName is the name of the master monitored by the sentinel nodes
+	MasterName string `mapstructure:"master_name"`
+	// PoolSize is the maximum number of connections per node. The Redis client default applies if zero.
+	PoolSize int `mapstructure:"pool_size"`
+	// ReadOnly sends read commands to replica nodes too. Cluster and sentinel modes only.
+	ReadOnly bool `mapstructure:"read_only"`
+	// RouteByLatency sends read commands to the node with the lowest latency. Cluster and sentinel modes only.
+	RouteByLatency    bool     `mapstructure:"route_by_latency"`
 	ExpirationMinutes int      `mapstructure:"expiration"`
 	TLS               RedisTLS `mapstructure:"tls"`
 }
 
+type RedisMode string
+
+const (
+	RedisStandalone RedisMode = "standalone"
+	RedisCluster    RedisMode = "cluster"
+	RedisSentinel   RedisMode = "sentinel"
+)
+
 type RedisTLS struct {
 	Enabled            bool `mapstructure:"enabled"`
 	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+	// CAFile is the PEM bundle of the authorities that sign the server certificates. The system
+	// roots are used if empty.
+	CAFile string `mapstructure:"ca_file"`
+	// CertFile and KeyFile hold the client certificate presented to servers that require mutual TLS
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ServerName overrides the host name the server certificates are verified against
+	ServerName string `mapstructure:"server_name"`
+	// ReloadIntervalSeconds is how often the CA, client certificate and key files are checked for
+	// changes. Zero disables reloading.
+	ReloadIntervalSeconds int `mapstructure:"reload_interval_seconds"`
+}
+
+// ReloadInterval returns ReloadIntervalSeconds as a time.Duration
+func (cfg *RedisTLS) ReloadInterval() time.Duration {
+	return time.Duration(cfg.ReloadIntervalSeconds) * time.Second
+}
+
+func (cfg *RedisTLS) validate() error {
+	if !cfg.Enabled {
+		if cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != "" || cfg.ServerName != "" {
+			return fmt.Errorf("invalid config.backend.redis.tls: ca_file, cert_file, key_file and server_name require tls.enabled.")
+		}
+		return nil
+	}
+
+	var errs ValidationErrors
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		errs.add(fmt.Errorf("invalid config.backend.redis.tls: cert_file and key_file must be set together."))
+	}
+	if cfg.ReloadIntervalSeconds < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.redis.tls.reload_interval_seconds: %d. Value cannot be negative.", cfg.ReloadIntervalSeconds))
+	}
+	return errs.toError()
 }
 
 func (cfg *Redis) validateAndLog() error {
-	log.Infof("config.backend.redis.host: %s", cfg.Host)
-	log.Infof("config.backend.redis.port: %d", cfg.Port)
+	var errs ValidationErrors
+
+	switch cfg.Mode {
+	case RedisStandalone:
+		if cfg.ReadOnly || cfg.RouteByLatency {
+			errs.add(fmt.Errorf("invalid config.backend.redis: read_only and route_by_latency are only supported in cluster and sentinel modes."))
+		}
+	case RedisCluster:
+		if len(cfg.Addresses) == 0 {
+			errs.add(fmt.Errorf("invalid config.backend.redis.addresses: the seed list of cluster nodes cannot be empty in cluster mode."))
+		}
+		if cfg.Db != 0 {
+			errs.add(fmt.Errorf("invalid config.backend.redis.db: %d. Redis Cluster only supports database 0.", cfg.Db))
+		}
+	case RedisSentinel:
+		if len(cfg.Addresses) == 0 {
+			errs.add(fmt.Errorf("invalid config.backend.redis.addresses: the list of sentinel nodes cannot be empty in sentinel mode."))
+		}
+		if cfg.MasterName == "" {
+			errs.add(fmt.Errorf("invalid config.backend.redis.master_name: the master name cannot be empty in sentinel mode."))
+		}
+		if (cfg.ReadOnly || cfg.RouteByLatency) && cfg.Db != 0 {
+			errs.add(fmt.Errorf("invalid config.backend.redis.db: %d. Only database 0 can be read from replicas in sentinel mode.", cfg.Db))
+		}
+	default:
+		errs.add(fmt.Errorf(`invalid config.backend.redis.mode: %s. It must be "standalone", "cluster" or "sentinel".`, cfg.Mode))
+	}
+	if cfg.PoolSize < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.redis.pool_size: %d. Value cannot be negative.", cfg.PoolSize))
+	}
+	errs.add(cfg.TLS.validate())
+	if len(errs) > 0 {
+		return errs
+	}
+
+	log.Infof("config.backend.redis.mode: %s", cfg.Mode)
+	if cfg.Mode == RedisStandalone {
+		log.Infof("config.backend.redis.host: %s", cfg.Host)
+		log.Infof("config.backend.redis.port: %d", cfg.Port)
+	} else {
+		log.Infof("config.backend.redis.addresses: %v", cfg.Addresses)
+		if cfg.Mode == RedisSentinel {
+			log.Infof("config.backend.redis.master_name: %s", cfg.MasterName)
+		}
+		log.Infof("config.backend.redis.read_only: %t", cfg.ReadOnly)
+		log.Infof("config.backend.redis.route_by_latency: %t", cfg.RouteByLatency)
+	}
 	log.Infof("config.backend.redis.db: %d", cfg.Db)
+	if cfg.Username != "" {
+		log.Infof("config.backend.redis.username: %s", cfg.Username)
+	}
+	if cfg.PoolSize > 0 {
+		log.Infof("config.backend.redis.pool_size: %d", cfg.PoolSize)
+	}
 	if cfg.ExpirationMinutes > 0 {
 		log.Infof("config.backend.redis.expiration: %d. Note that this configuration option is being deprecated in favor of config.request_limits.max_ttl_seconds", cfg.ExpirationMinutes)
 	}
 	log.Infof("config.backend.redis.tls.enabled: %t", cfg.TLS.Enabled)
 	log.Infof("config.backend.redis.tls.insecure_skip_verify: %t", cfg.TLS.InsecureSkipVerify)
+	if cfg.TLS.Enabled {
+		log.Infof("config.backend.redis.tls.ca_file: %s", cfg.TLS.CAFile)
+		log.Infof("config.backend.redis.tls.cert_file: %s", cfg.TLS.CertFile)
+		log.Infof("config.backend.redis.tls.key_file: %s", cfg.TLS.KeyFile)
+		log.Infof("config.backend.redis.tls.server_name: %s", cfg.TLS.ServerName)
+		log.Infof("config.backend.redis.tls.reload_interval_seconds: %d", cfg.TLS.ReloadIntervalSeconds)
+	}
 	return nil
 }
 
 type Ignite struct {
+	// Protocol is either "rest", to talk to the Ignite REST API, or "thin", to use the binary protocol
+	// of the Ignite thin clients
+	Protocol IgniteProtocol `mapstructure:"protocol"`
+	// Scheme is "http" or "https" with the REST protocol, and "tcp" or "tls" with the thin protocol
 	Scheme string `mapstructure:"scheme"`
 	Host   string `mapstructure:"host"`
 	Port   int    `mapstructure:"port"`
 	// If VerifyCert is set to true, Prebid Cache verifies the SSL certificate on the Ignite server
 	VerifyCert bool              `mapstructure:"secure"`
 	Headers    map[string]string `mapstructure:"headers"`
-	Cache      IgniteCache       `mapstructure:"cache"`
+	// Username and Password authenticate Prebid Cache against clusters with authentication enabled.
+	// The REST protocol exchanges them for a session token that gets renewed when it expires.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// TimeoutMillis bounds each request to the Ignite server. Zero means no timeout.
+	TimeoutMillis int `mapstructure:"timeout_ms"`
+	// MaxIdleConns is the number of idle connections kept open to the Ignite server
+	MaxIdleConns int         `mapstructure:"max_idle_conns"`
+	Cache        IgniteCache `mapstructure:"cache"`
 }
 
+type IgniteProtocol string
+
+const (
+	IgniteREST IgniteProtocol = "rest"
+	IgniteThin IgniteProtocol = "thin"
+)
+
 type IgniteCache struct {
 	Name          string `mapstructure:"name"`
 	CreateOnStart bool   `mapstructure:"create_on_start"`
 }
 
 func (cfg *Ignite) validateAndLog() error {
+	var errs ValidationErrors
+
 	if len(cfg.Scheme) == 0 {
-		return errors.New("Cannot connect to Ignite: empty config.ignite.scheme")
+		errs.add(errors.New("Cannot connect to Ignite: empty config.ignite.scheme"))
 	}
 	if len(cfg.Host) == 0 {
-		return errors.New("Cannot connect to Ignite: empty config.ignite.host")
+		errs.add(errors.New("Cannot connect to Ignite: empty config.ignite.host"))
 	}
 	if len(cfg.Cache.Name) == 0 {
-		return errors.New("Cannot write nor read from Ignite: empty config.ignite.cachename")
+		errs.add(errors.New("Cannot write nor read from Ignite: empty config.ignite.cachename"))
+	}
+	switch cfg.Protocol {
+	case IgniteREST, "":
+	case IgniteThin:
+		if cfg.Scheme != "tcp" && cfg.Scheme != "tls" {
+			errs.add(fmt.Errorf(`invalid config.backend.ignite.scheme: %s. It must be "tcp" or "tls" with the thin protocol.`, cfg.Scheme))
+		}
+	default:
+		errs.add(fmt.Errorf(`invalid config.backend.ignite.protocol: %s. It must be "rest" or "thin".`, cfg.Protocol))
+	}
+	if cfg.Password != "" && cfg.Username == "" {
+		errs.add(fmt.Errorf("invalid config.backend.ignite.username: a username is required along with the password."))
+	}
+	if cfg.TimeoutMillis < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.ignite.timeout_ms: %d. Value cannot be negative.", cfg.TimeoutMillis))
+	}
+	if cfg.MaxIdleConns < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.ignite.max_idle_conns: %d. Value cannot be negative.", cfg.MaxIdleConns))
+	}
+	if cfg.Protocol == IgniteThin {
+		log.Infof("config.backend.ignite.protocol: %s", cfg.Protocol)
 	}
 	log.Infof("config.backend.ignite.scheme: %s", cfg.Scheme)
 	log.Infof("config.backend.ignite.host: %s", cfg.Host)
@@ -208,6 +898,357 @@ func (cfg *Ignite) validateAndLog() error {
 	log.Infof("config.backend.ignite.cache.create_on_start: %v", cfg.Headers)
 	log.Infof("config.backend.ignite.cache.name: %s", cfg.Cache.Name)
 	log.Infof("config.backend.ignite.cache.create_on_start: %t", cfg.Cache.CreateOnStart)
+	if cfg.Username != "" {
+		log.Infof("config.backend.ignite.username: %s", cfg.Username)
+	}
+	if cfg.TimeoutMillis > 0 {
+		log.Infof("config.backend.ignite.timeout_ms: %d", cfg.TimeoutMillis)
+	}
+	if cfg.MaxIdleConns > 0 {
+		log.Infof("config.backend.ignite.max_idle_conns: %d", cfg.MaxIdleConns)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Bolt holds the settings of the embedded backend, which stores values in a bbolt database file on
+// the local disk. Values survive restarts, but are only available to a single Prebid Cache node.
+type Bolt struct {
+	// DataDir is the directory of the database file. It gets created if it doesn't exist.
+	DataDir string `mapstructure:"data_dir"`
+	// CompactionIntervalSeconds is how often expired values get deleted from the database file
+	CompactionIntervalSeconds int `mapstructure:"compaction_interval_seconds"`
+	// MaxSizeMB bounds the size of the stored keys and values. Puts are rejected once it's reached,
+	// until expired values get deleted. Zero means no limit.
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+}
+
+func (cfg *Bolt) validateAndLog() error {
+	var errs ValidationErrors
+
+	if len(cfg.DataDir) == 0 {
+		errs.add(fmt.Errorf("invalid config.backend.bolt.data_dir: the data directory cannot be empty."))
+	}
+	if cfg.CompactionIntervalSeconds <= 0 {
+		errs.add(fmt.Errorf("invalid config.backend.bolt.compaction_interval_seconds: %d. Value must be positive.", cfg.CompactionIntervalSeconds))
+	}
+	if cfg.MaxSizeMB < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.bolt.max_size_mb: %d. Value cannot be negative.", cfg.MaxSizeMB))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	log.Infof("config.backend.bolt.data_dir: %s", cfg.DataDir)
+	log.Infof("config.backend.bolt.compaction_interval_seconds: %d", cfg.CompactionIntervalSeconds)
+	log.Infof("config.backend.bolt.max_size_mb: %d", cfg.MaxSizeMB)
+	return nil
+}
+
+type Postgres struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Database string `mapstructure:"dbname"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	// SSLMode is one of the libpq modes "disable", "require", "verify-ca" or "verify-full"
+	SSLMode string `mapstructure:"sslmode"`
+	// Table is the name of the table values are stored in
+	Table string `mapstructure:"table"`
+	// MaxOpenConns and MaxIdleConns bound the connection pool. Zero means no limit on open connections,
+	// and the database/sql default of 2 idle connections.
+	MaxOpenConns int `mapstructure:"max_open_conns"`
+	MaxIdleConns int `mapstructure:"max_idle_conns"`
+	// ConnMaxLifetimeSeconds is how long connections get reused for. Zero means forever.
+	ConnMaxLifetimeSeconds int `mapstructure:"conn_max_lifetime_seconds"`
+	// CreateSchemaOnStart creates the table and its expiration index if they don't exist yet
+	CreateSchemaOnStart bool `mapstructure:"create_schema_on_start"`
+	// ReaperIntervalSeconds is how often expired rows get deleted, ReaperBatchSize rows per statement
+	ReaperIntervalSeconds int `mapstructure:"reaper_interval_seconds"`
+	ReaperBatchSize       int `mapstructure:"reaper_batch_size"`
+}
+
+// postgresSSLModes are the SSL modes supported by the Postgres driver
+var postgresSSLModes = map[string]bool{
+	"disable":     true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// postgresIdentifier matches the table names that don't need quoting
+var postgresIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+func (cfg *Postgres) validateAndLog() error {
+	var errs ValidationErrors
+
+	if len(cfg.Host) == 0 {
+		errs.add(fmt.Errorf("invalid config.backend.postgres.host: the host cannot be empty."))
+	}
+	if len(cfg.Database) == 0 {
+		errs.add(fmt.Errorf("invalid config.backend.postgres.dbname: the database name cannot be empty."))
+	}
+	if !postgresSSLModes[cfg.SSLMode] {
+		errs.add(fmt.Errorf(`invalid config.backend.postgres.sslmode: %s. It must be "disable", "require", "verify-ca" or "verify-full".`, cfg.SSLMode))
+	}
+	if !postgresIdentifier.MatchString(cfg.Table) {
+		errs.add(fmt.Errorf("invalid config.backend.postgres.table: %s. It must be a lowercase identifier.", cfg.Table))
+	}
+	if cfg.MaxOpenConns < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.postgres.max_open_conns: %d. Value cannot be negative.", cfg.MaxOpenConns))
+	}
+	if cfg.MaxIdleConns < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.postgres.max_idle_conns: %d. Value cannot be negative.", cfg.MaxIdleConns))
+	}
+	if cfg.ConnMaxLifetimeSeconds < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.postgres.conn_max_lifetime_seconds: %d. Value cannot be negative.", cfg.ConnMaxLifetimeSeconds))
+	}
+	if cfg.ReaperIntervalSeconds <= 0 {
+		errs.add(fmt.Errorf("invalid config.backend.postgres.reaper_interval_seconds: %d. Value must be positive.", cfg.ReaperIntervalSeconds))
+	}
+	if cfg.ReaperBatchSize <= 0 {
+		errs.add(fmt.Errorf("invalid config.backend.postgres.reaper_batch_size: %d. Value must be positive.", cfg.ReaperBatchSize))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	log.Infof("config.backend.postgres.host: %s", cfg.Host)
+	log.Infof("config.backend.postgres.port: %d", cfg.Port)
+	log.Infof("config.backend.postgres.dbname: %s", cfg.Database)
+	if cfg.User != "" {
+		log.Infof("config.backend.postgres.user: %s", cfg.User)
+	}
+	log.Infof("config.backend.postgres.sslmode: %s", cfg.SSLMode)
+	log.Infof("config.backend.postgres.table: %s", cfg.Table)
+	log.Infof("config.backend.postgres.max_open_conns: %d", cfg.MaxOpenConns)
+	log.Infof("config.backend.postgres.max_idle_conns: %d", cfg.MaxIdleConns)
+	log.Infof("config.backend.postgres.conn_max_lifetime_seconds: %d", cfg.ConnMaxLifetimeSeconds)
+	log.Infof("config.backend.postgres.create_schema_on_start: %t", cfg.CreateSchemaOnStart)
+	log.Infof("config.backend.postgres.reaper_interval_seconds: %d", cfg.ReaperIntervalSeconds)
+	log.Infof("config.backend.postgres.reaper_batch_size: %d", cfg.ReaperBatchSize)
+	return nil
+}
+
+// S3 holds the settings of the backend storing values as objects in an AWS S3 bucket, or in a bucket
+// of any S3-compatible object store such as MinIO
+type S3 struct {
+	// Endpoint is the URL of the object store. Empty means the AWS S3 endpoint of Region.
+	Endpoint string `mapstructure:"endpoint"`
+	Region   string `mapstructure:"region"`
+	Bucket   string `mapstructure:"bucket"`
+	// Prefix is prepended to the keys to get the object names
+	Prefix string `mapstructure:"prefix"`
+	// ForcePathStyle addresses the bucket in the path of the URLs rather than in the host name, as
+	// most S3-compatible object stores expect
+	ForcePathStyle bool `mapstructure:"force_path_style"`
+	// AccessKeyID, SecretAccessKey and SessionToken default to the credentials of the default chain
+	// of the AWS SDK: environment variables, shared profiles, web identity, ECS and EC2 metadata
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	SessionToken    string `mapstructure:"session_token"`
+	TimeoutMillis   int    `mapstructure:"timeout_ms"`
+	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
+	// ConfigureLifecycle adds a rule to the lifecycle configuration of the bucket on start, so the
+	// object store deletes the objects under Prefix LifecycleExpirationDays after they were written
+	ConfigureLifecycle      bool `mapstructure:"configure_lifecycle"`
+	LifecycleExpirationDays int  `mapstructure:"lifecycle_expiration_days"`
+}
+
+func (cfg *S3) validateAndLog() error {
+	var errs ValidationErrors
+
+	if len(cfg.Bucket) == 0 {
+		errs.add(fmt.Errorf("invalid config.backend.s3.bucket: the bucket cannot be empty."))
+	}
+	if len(cfg.Region) == 0 {
+		errs.add(fmt.Errorf("invalid config.backend.s3.region: the region cannot be empty."))
+	}
+	if cfg.Endpoint != "" {
+		if endpoint, err := url.Parse(cfg.Endpoint); err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			errs.add(fmt.Errorf("invalid config.backend.s3.endpoint: %s. It must be an http or https URL.", cfg.Endpoint))
+		}
+	}
+	if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+		errs.add(fmt.Errorf("invalid config.backend.s3.access_key_id: access_key_id and secret_access_key must be set together."))
+	}
+	if cfg.TimeoutMillis < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.s3.timeout_ms: %d. Value cannot be negative.", cfg.TimeoutMillis))
+	}
+	if cfg.MaxIdleConns < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.s3.max_idle_conns: %d. Value cannot be negative.", cfg.MaxIdleConns))
+	}
+	if cfg.ConfigureLifecycle && cfg.LifecycleExpirationDays <= 0 {
+		errs.add(fmt.Errorf("invalid config.backend.s3.lifecycle_expiration_days: %d. Value must be positive when configure_lifecycle is enabled.", cfg.LifecycleExpirationDays))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if cfg.Endpoint != "" {
+		log.Infof("config.backend.s3.endpoint: %s", cfg.Endpoint)
+	}
+	log.Infof("config.backend.s3.region: %s", cfg.Region)
+	log.Infof("config.backend.s3.bucket: %s", cfg.Bucket)
+	log.Infof("config.backend.s3.prefix: %s", cfg.Prefix)
+	log.Infof("config.backend.s3.force_path_style: %t", cfg.ForcePathStyle)
+	log.Infof("config.backend.s3.timeout_ms: %d", cfg.TimeoutMillis)
+	log.Infof("config.backend.s3.max_idle_conns: %d", cfg.MaxIdleConns)
+	log.Infof("config.backend.s3.configure_lifecycle: %t", cfg.ConfigureLifecycle)
+	if cfg.ConfigureLifecycle {
+		log.Infof("config.backend.s3.lifecycle_expiration_days: %d", cfg.LifecycleExpirationDays)
+	}
+	return nil
+}
+
+// DynamoDB holds the settings of the backend storing values as items of an AWS DynamoDB table, or of a
+// table of a service compatible with DynamoDB such as DynamoDB Local
+type DynamoDB struct {
+	// Endpoint is the URL of the service. Empty means the AWS DynamoDB endpoint of Region.
+	Endpoint string `mapstructure:"endpoint"`
+	Region   string `mapstructure:"region"`
+	Table    string `mapstructure:"table"`
+	// AccessKeyID, SecretAccessKey and SessionToken default to the credentials of the default chain
+	// of the AWS SDK: environment variables, shared profiles, web identity, ECS and EC2 metadata
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	SessionToken    string `mapstructure:"session_token"`
+	TimeoutMillis   int    `mapstructure:"timeout_ms"`
+	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
+	// ConsistentReads makes reads see the writes that completed before them, at twice the cost
+	ConsistentReads bool `mapstructure:"consistent_reads"`
+	// CreateTableOnStart creates the table with on-demand capacity if it doesn't exist yet, and enables
+	// its time-to-live
+	CreateTableOnStart bool `mapstructure:"create_table_on_start"`
+}
+
+// dynamoDBTableName matches the names DynamoDB allows for tables
+var dynamoDBTableName = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+
+func (cfg *DynamoDB) validateAndLog() error {
+	var errs ValidationErrors
+
+	if !dynamoDBTableName.MatchString(cfg.Table) {
+		errs.add(fmt.Errorf("invalid config.backend.dynamodb.table: %s. It must be 3 to 255 letters, digits, underscores, dashes or dots.", cfg.Table))
+	}
+	if len(cfg.Region) == 0 {
+		errs.add(fmt.Errorf("invalid config.backend.dynamodb.region: the region cannot be empty."))
+	}
+	if cfg.Endpoint != "" {
+		if endpoint, err := url.Parse(cfg.Endpoint); err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			errs.add(fmt.Errorf("invalid config.backend.dynamodb.endpoint: %s. It must be an http or https URL.", cfg.Endpoint))
+		}
+	}
+	if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+		errs.add(fmt.Errorf("invalid config.backend.dynamodb.access_key_id: access_key_id and secret_access_key must be set together."))
+	}
+	if cfg.TimeoutMillis < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.dynamodb.timeout_ms: %d. Value cannot be negative.", cfg.TimeoutMillis))
+	}
+	if cfg.MaxIdleConns < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.dynamodb.max_idle_conns: %d. Value cannot be negative.", cfg.MaxIdleConns))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if cfg.Endpoint != "" {
+		log.Infof("config.backend.dynamodb.endpoint: %s", cfg.Endpoint)
+	}
+	log.Infof("config.backend.dynamodb.region: %s", cfg.Region)
+	log.Infof("config.backend.dynamodb.table: %s", cfg.Table)
+	log.Infof("config.backend.dynamodb.timeout_ms: %d", cfg.TimeoutMillis)
+	log.Infof("config.backend.dynamodb.max_idle_conns: %d", cfg.MaxIdleConns)
+	log.Infof("config.backend.dynamodb.consistent_reads: %t", cfg.ConsistentReads)
+	log.Infof("config.backend.dynamodb.create_table_on_start: %t", cfg.CreateTableOnStart)
+	return nil
+}
+
+// NATS holds the settings of the backend storing values in a key-value bucket of NATS JetStream
+type NATS struct {
+	// Servers are the URLs of the NATS servers, as in nats://localhost:4222, or tls://localhost:4222 to
+	// require TLS. They're tried in order until one of them accepts the connection.
+	Servers  []string `mapstructure:"servers"`
+	Bucket   string   `mapstructure:"bucket"`
+	Replicas int      `mapstructure:"replicas"`
+	// MaxAgeSeconds is the time-to-live of the whole bucket, which caps the time-to-live of the values.
+	// Values that expire sooner are reported missing once expired, until the bucket deletes them.
+	MaxAgeSeconds int `mapstructure:"max_age_seconds"`
+	// Username and Password, Token, or the user JWT and seed of CredsFile authenticate Prebid Cache
+	// against servers that require it
+	Username  string  `mapstructure:"username"`
+	Password  string  `mapstructure:"password"`
+	Token     string  `mapstructure:"token"`
+	CredsFile string  `mapstructure:"creds_file"`
+	TLS       NATSTLS `mapstructure:"tls"`
+	// TimeoutMillis bounds the connection to the servers and each request sent to them
+	TimeoutMillis int `mapstructure:"timeout_ms"`
+	// CreateBucketOnStart creates the bucket if it doesn't exist yet
+	CreateBucketOnStart bool `mapstructure:"create_bucket_on_start"`
+}
+
+// NATSTLS configures the TLS connections to servers with tls URLs. Setting any of its files requires
+// TLS from every server.
+type NATSTLS struct {
+	// CAFile is the PEM bundle of the authorities that sign the server certificates. The system
+	// roots are used if empty.
+	CAFile string `mapstructure:"ca_file"`
+	// CertFile and KeyFile hold the client certificate presented to servers that require mutual TLS
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+// natsBucketName matches the names NATS allows for key-value buckets
+var natsBucketName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func (cfg *NATS) validateAndLog() error {
+	var errs ValidationErrors
+
+	if len(cfg.Servers) == 0 {
+		errs.add(fmt.Errorf("invalid config.backend.nats.servers: at least one server is required."))
+	}
+	for _, server := range cfg.Servers {
+		if serverURL, err := url.Parse(server); err != nil || (serverURL.Scheme != "nats" && serverURL.Scheme != "tls") || serverURL.Host == "" {
+			errs.add(fmt.Errorf("invalid config.backend.nats.servers: %s. It must be a nats or tls URL.", server))
+		}
+	}
+	if !natsBucketName.MatchString(cfg.Bucket) {
+		errs.add(fmt.Errorf("invalid config.backend.nats.bucket: %s. It must be made of letters, digits, underscores or dashes.", cfg.Bucket))
+	}
+	if cfg.Replicas < 1 || cfg.Replicas > 5 {
+		errs.add(fmt.Errorf("invalid config.backend.nats.replicas: %d. It must be between 1 and 5.", cfg.Replicas))
+	}
+	if cfg.MaxAgeSeconds <= 0 {
+		errs.add(fmt.Errorf("invalid config.backend.nats.max_age_seconds: %d. Value must be positive.", cfg.MaxAgeSeconds))
+	}
+	if cfg.Token != "" && cfg.Username != "" {
+		errs.add(fmt.Errorf("invalid config.backend.nats.token: token and username cannot be set together."))
+	}
+	if cfg.TimeoutMillis < 0 {
+		errs.add(fmt.Errorf("invalid config.backend.nats.timeout_ms: %d. Value cannot be negative.", cfg.TimeoutMillis))
+	}
+	if cfg.CredsFile != "" && (cfg.Token != "" || cfg.Username != "") {
+		errs.add(fmt.Errorf("invalid config.backend.nats.creds_file: creds_file cannot be set along with username or token."))
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs.add(fmt.Errorf("invalid config.backend.nats.tls: cert_file and key_file must be set together."))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 
+	log.Infof("config.backend.nats.servers: %v", cfg.Servers)
+	log.Infof("config.backend.nats.bucket: %s", cfg.Bucket)
+	log.Infof("config.backend.nats.replicas: %d", cfg.Replicas)
+	log.Infof("config.backend.nats.max_age_seconds: %d", cfg.MaxAgeSeconds)
+	log.Infof("config.backend.nats.timeout_ms: %d", cfg.TimeoutMillis)
+	log.Infof("config.backend.nats.creds_file: %s", cfg.CredsFile)
+	log.Infof("config.backend.nats.tls.ca_file: %s", cfg.TLS.CAFile)
+	log.Infof("config.backend.nats.tls.cert_file: %s", cfg.TLS.CertFile)
+	log.Infof("config.backend.nats.tls.key_file: %s", cfg.TLS.KeyFile)
+	log.Infof("config.backend.nats.create_bucket_on_start: %t", cfg.CreateBucketOnStart)
 	return nil
 }
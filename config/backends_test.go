@@ -232,6 +232,42 @@ func TestAerospikeValidateAndLog(t *testing.T) {
 						{msg: "config.backend.aerospike.connection_queue_size value will default to 256", lvl: logrus.InfoLevel},
 					},
 				},
+				{
+					desc: "set and bin names, auth mode, timeouts, rack awareness and TLS",
+					inCfg: Aerospike{
+						Host:                   "foo.com",
+						Port:                   8888,
+						MaxReadRetries:         2,
+						ConnQueueSize:          64,
+						SetName:                "cache",
+						BinName:                "payload",
+						AuthMode:               AerospikeAuthExternal,
+						ReadTotalTimeoutMillis: 100,
+						RackAware:              true,
+						RackID:                 2,
+						TLS:                    AerospikeTLS{Enabled: true, CAFile: "ca.pem", Name: "aerospike.internal"},
+					},
+					hasError: false,
+					logEntries: []logComponents{
+						{msg: "config.backend.aerospike.host: foo.com", lvl: logrus.InfoLevel},
+						{msg: fmt.Sprintf("config.backend.aerospike.hosts: %v", []string{}), lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.port: 8888", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.namespace: ", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.user: ", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.auth_mode: external", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.set_name: cache", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.bin_name: payload", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.connection_queue_size: 64", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.read_total_timeout_ms: 100", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.rack_aware: true", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.rack_id: 2", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.tls.enabled: true", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.tls.ca_file: ca.pem", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.tls.cert_file: ", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.tls.key_file: ", lvl: logrus.InfoLevel},
+						{msg: "config.backend.aerospike.tls.name: aerospike.internal", lvl: logrus.InfoLevel},
+					},
+				},
 				{
 					desc: "config.backend.aerospike.connection_queue_size valid value found in config",
 					inCfg: Aerospike{
@@ -280,6 +316,36 @@ func TestAerospikeValidateAndLog(t *testing.T) {
 					hasError:      true,
 					expectedError: fmt.Errorf("Cannot connect to Aerospike host at port 0"),
 				},
+				{
+					desc:          "aerospike.bin_name too long",
+					inCfg:         Aerospike{Host: "foo.com", Port: 8888, BinName: "a_very_long_bin_name"},
+					hasError:      true,
+					expectedError: fmt.Errorf("invalid config.backend.aerospike.bin_name: a_very_long_bin_name. Bin names cannot be longer than 15 characters."),
+				},
+				{
+					desc:          "aerospike.auth_mode unknown",
+					inCfg:         Aerospike{Host: "foo.com", Port: 8888, AuthMode: "pki"},
+					hasError:      true,
+					expectedError: fmt.Errorf(`invalid config.backend.aerospike.auth_mode: pki. It must be "internal" or "external".`),
+				},
+				{
+					desc:          "aerospike.read_total_timeout_ms negative",
+					inCfg:         Aerospike{Host: "foo.com", Port: 8888, ReadTotalTimeoutMillis: -1},
+					hasError:      true,
+					expectedError: fmt.Errorf("invalid config.backend.aerospike.read_total_timeout_ms: -1. Value cannot be negative."),
+				},
+				{
+					desc:          "aerospike.tls files without TLS enabled",
+					inCfg:         Aerospike{Host: "foo.com", Port: 8888, TLS: AerospikeTLS{CAFile: "ca.pem"}},
+					hasError:      true,
+					expectedError: fmt.Errorf("invalid config.backend.aerospike.tls: ca_file, cert_file, key_file and name require tls.enabled."),
+				},
+				{
+					desc:          "aerospike.tls client certificate without key",
+					inCfg:         Aerospike{Host: "foo.com", Port: 8888, TLS: AerospikeTLS{Enabled: true, CertFile: "cert.pem"}},
+					hasError:      true,
+					expectedError: fmt.Errorf("invalid config.backend.aerospike.tls: cert_file and key_file must be set together."),
+				},
 			},
 		},
 	}
@@ -298,7 +364,7 @@ func TestAerospikeValidateAndLog(t *testing.T) {
 
 			//run test
 			if test.hasError {
-				assert.Equal(t, test.inCfg.validateAndLog(), test.expectedError, group.desc+" : "+test.desc)
+				assert.EqualError(t, test.inCfg.validateAndLog(), test.expectedError.Error(), group.desc+" : "+test.desc)
 			} else {
 				assert.Nil(t, test.inCfg.validateAndLog(), group.desc+" : "+test.desc)
 			}
@@ -318,3 +384,982 @@ func TestAerospikeValidateAndLog(t *testing.T) {
 		}
 	}
 }
+
+func TestMigrateValidateAndLog(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inCfg         Backend
+		expectedError string
+	}{
+		{
+			desc: "Valid migration from memcache to memory",
+			inCfg: Backend{
+				Type:    BackendMigrate,
+				Migrate: Migrate{From: BackendMemcache, To: BackendMemory, MirrorWritesSeconds: 60, CopyOnRead: true, CopyOnReadTTLSeconds: 300},
+			},
+		},
+		{
+			desc: "Unknown from backend",
+			inCfg: Backend{
+				Type:    BackendMigrate,
+				Migrate: Migrate{From: "", To: BackendMemory},
+			},
+			expectedError: `invalid config.backend.migrate.from: . It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`,
+		},
+		{
+			desc: "Can't migrate to another migrate backend",
+			inCfg: Backend{
+				Type:    BackendMigrate,
+				Migrate: Migrate{From: BackendMemory, To: BackendMigrate},
+			},
+			expectedError: `invalid config.backend.migrate.to: migrate. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`,
+		},
+		{
+			desc: "Same backend",
+			inCfg: Backend{
+				Type:    BackendMigrate,
+				Migrate: Migrate{From: BackendMemory, To: BackendMemory},
+			},
+			expectedError: "invalid config.backend.migrate: from and to must be different backends, both are memory.",
+		},
+		{
+			desc: "Negative mirroring window",
+			inCfg: Backend{
+				Type:    BackendMigrate,
+				Migrate: Migrate{From: BackendMemcache, To: BackendMemory, MirrorWritesSeconds: -1},
+			},
+			expectedError: "invalid config.backend.migrate.mirror_writes_seconds: -1. Value cannot be negative.",
+		},
+		{
+			desc: "Copy on read without TTL",
+			inCfg: Backend{
+				Type:    BackendMigrate,
+				Migrate: Migrate{From: BackendMemcache, To: BackendMemory, CopyOnRead: true},
+			},
+			expectedError: "invalid config.backend.migrate.copy_on_read_ttl_seconds: 0. Value must be positive when copy_on_read is enabled.",
+		},
+		{
+			desc: "The settings of the backends being migrated get validated",
+			inCfg: Backend{
+				Type:    BackendMigrate,
+				Migrate: Migrate{From: BackendIgnite, To: BackendMemory},
+			},
+			expectedError: "Cannot connect to Ignite: empty config.ignite.scheme\nCannot connect to Ignite: empty config.ignite.host\nCannot write nor read from Ignite: empty config.ignite.cachename",
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.inCfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestRoutedValidateAndLog(t *testing.T) {
+	validRouted := Routed{
+		Pointers: BackendMemory,
+		Routes:   []Route{{Backend: BackendMemcache, Type: "xml", MinSizeBytes: 1024}},
+	}
+
+	testCases := []struct {
+		desc          string
+		inCfg         func(routed *Routed)
+		expectedError string
+	}{
+		{
+			desc:  "Valid configuration",
+			inCfg: func(routed *Routed) {},
+		},
+		{
+			desc:          "Pointers backend isn't a storage backend",
+			inCfg:         func(routed *Routed) { routed.Pointers = BackendRouted },
+			expectedError: `invalid config.backend.routed.pointers: routed. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`,
+		},
+		{
+			desc:          "Default backend isn't a storage backend",
+			inCfg:         func(routed *Routed) { routed.Default = BackendMigrate },
+			expectedError: `invalid config.backend.routed.default: migrate. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`,
+		},
+		{
+			desc:          "No routes",
+			inCfg:         func(routed *Routed) { routed.Routes = nil },
+			expectedError: "invalid config.backend.routed.routes: at least one route is required.",
+		},
+		{
+			desc:          "Route to an unknown backend",
+			inCfg:         func(routed *Routed) { routed.Routes = append(routed.Routes, Route{Backend: "unknown"}) },
+			expectedError: `invalid config.backend.routed.routes[1].backend: unknown. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`,
+		},
+		{
+			desc:          "Negative minimum size",
+			inCfg:         func(routed *Routed) { routed.Routes[0].MinSizeBytes = -1 },
+			expectedError: "invalid config.backend.routed.routes[0].min_size_bytes: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Negative maximum size",
+			inCfg:         func(routed *Routed) { routed.Routes[0].MaxSizeBytes = -1 },
+			expectedError: "invalid config.backend.routed.routes[0].max_size_bytes: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Maximum size below the minimum size",
+			inCfg:         func(routed *Routed) { routed.Routes[0].MaxSizeBytes = 512 },
+			expectedError: "invalid config.backend.routed.routes[0].max_size_bytes: 512. Value cannot be less than min_size_bytes.",
+		},
+		{
+			desc:          "The settings of the backends routed to get validated",
+			inCfg:         func(routed *Routed) { routed.Default = BackendIgnite },
+			expectedError: "Cannot connect to Ignite: empty config.ignite.scheme\nCannot connect to Ignite: empty config.ignite.host\nCannot write nor read from Ignite: empty config.ignite.cachename",
+		},
+	}
+
+	for _, tc := range testCases {
+		routed := validRouted
+		routed.Routes = append([]Route(nil), validRouted.Routes...)
+		tc.inCfg(&routed)
+		cfg := Backend{Type: BackendRouted, Routed: routed, Memcache: Memcache{Hosts: []string{"127.0.0.1:11211"}}}
+
+		err := cfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestRoutedBackendTypes(t *testing.T) {
+	routed := Routed{
+		Pointers: BackendRedis,
+		Routes:   []Route{{Backend: BackendS3}, {Backend: BackendRedis}, {Backend: BackendS3}, {Backend: BackendPostgres}},
+	}
+	assert.Equal(t, BackendRedis, routed.DefaultBackend(), "The default backend defaults to the pointers backend")
+	assert.Equal(t, []BackendType{BackendRedis, BackendS3, BackendPostgres}, routed.BackendTypes(), "Backends are listed once")
+
+	routed.Default = BackendMemory
+	assert.Equal(t, []BackendType{BackendRedis, BackendMemory, BackendS3, BackendPostgres}, routed.BackendTypes())
+}
+
+func TestShadowValidateAndLog(t *testing.T) {
+	validShadow := Shadow{Enabled: true, Type: BackendMemory, SamplingRate: 0.5, QueueSize: 10, Workers: 1, TimeoutMillis: 100}
+
+	testCases := []struct {
+		desc          string
+		inCfg         func(shadow *Shadow)
+		expectedError string
+	}{
+		{
+			desc:  "Valid shadow configuration",
+			inCfg: func(shadow *Shadow) {},
+		},
+		{
+			desc:  "Disabled shadow configurations don't get validated",
+			inCfg: func(shadow *Shadow) { *shadow = Shadow{} },
+		},
+		{
+			desc:          "Unknown candidate backend",
+			inCfg:         func(shadow *Shadow) { shadow.Type = "unknown" },
+			expectedError: `invalid config.backend.shadow.type: unknown. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`,
+		},
+		{
+			desc:          "Candidate backend same as the primary one",
+			inCfg:         func(shadow *Shadow) { shadow.Type = BackendMemcache },
+			expectedError: "invalid config.backend.shadow.type: memcache. The candidate backend must differ from config.backend.type.",
+		},
+		{
+			desc:          "Sampling rate out of bounds",
+			inCfg:         func(shadow *Shadow) { shadow.SamplingRate = 1.5 },
+			expectedError: "invalid config.backend.shadow.sampling_rate: 1.5. Value must be between 0 and 1.",
+		},
+		{
+			desc:          "Zero queue size",
+			inCfg:         func(shadow *Shadow) { shadow.QueueSize = 0 },
+			expectedError: "invalid config.backend.shadow.queue_size: 0. Value must be positive.",
+		},
+		{
+			desc:          "Zero workers",
+			inCfg:         func(shadow *Shadow) { shadow.Workers = 0 },
+			expectedError: "invalid config.backend.shadow.workers: 0. Value must be positive.",
+		},
+		{
+			desc:          "Negative timeout",
+			inCfg:         func(shadow *Shadow) { shadow.TimeoutMillis = -1 },
+			expectedError: "invalid config.backend.shadow.timeout_ms: -1. Value must be positive.",
+		},
+		{
+			desc:          "The settings of the candidate backend get validated",
+			inCfg:         func(shadow *Shadow) { shadow.Type = BackendIgnite },
+			expectedError: "Cannot connect to Ignite: empty config.ignite.scheme\nCannot connect to Ignite: empty config.ignite.host\nCannot write nor read from Ignite: empty config.ignite.cachename",
+		},
+	}
+
+	for _, tc := range testCases {
+		cfg := Backend{Type: BackendMemcache, Shadow: validShadow}
+		tc.inCfg(&cfg.Shadow)
+
+		err := cfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestBackendValidateAndLogCollectsErrors(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		inCfg          Backend
+		expectedErrors []string
+	}{
+		{
+			desc: "Invalid main and candidate backends",
+			inCfg: Backend{
+				Type:     BackendMemcache,
+				Memcache: Memcache{TimeoutMillis: -1, MaxIdleConns: -1},
+				S3:       S3{MaxIdleConns: -1},
+				Shadow:   Shadow{Enabled: true, Type: BackendS3, SamplingRate: 2, QueueSize: 1, Workers: 1, TimeoutMillis: 1},
+			},
+			expectedErrors: []string{
+				"invalid config.backend.memcache.timeout_ms: -1. Value cannot be negative.",
+				"invalid config.backend.memcache.max_idle_conns: -1. Value cannot be negative.",
+				"invalid config.backend.shadow.sampling_rate: 2. Value must be between 0 and 1.",
+				"invalid config.backend.s3.bucket: the bucket cannot be empty.",
+				"invalid config.backend.s3.region: the region cannot be empty.",
+				"invalid config.backend.s3.max_idle_conns: -1. Value cannot be negative.",
+			},
+		},
+		{
+			desc: "Invalid main backend type and candidate backend",
+			inCfg: Backend{
+				Type:   "unknown",
+				Shadow: Shadow{Enabled: true, Type: BackendMemory},
+			},
+			expectedErrors: []string{
+				`invalid config.backend.type: unknown. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats", "memory", "migrate" or "routed".`,
+				"invalid config.backend.shadow.queue_size: 0. Value must be positive.",
+				"invalid config.backend.shadow.workers: 0. Value must be positive.",
+				"invalid config.backend.shadow.timeout_ms: 0. Value must be positive.",
+			},
+		},
+		{
+			desc: "Invalid migration and backends migrated from and to",
+			inCfg: Backend{
+				Type:     BackendMigrate,
+				Migrate:  Migrate{From: BackendBolt, To: BackendPostgres, MirrorWritesSeconds: -1},
+				Bolt:     Bolt{DataDir: "/var/lib/prebid-cache", CompactionIntervalSeconds: 0},
+				Postgres: Postgres{Host: "127.0.0.1", Database: "prebid", SSLMode: "disable", Table: "prebid_cache", ReaperIntervalSeconds: 30, ReaperBatchSize: 0},
+			},
+			expectedErrors: []string{
+				"invalid config.backend.migrate.mirror_writes_seconds: -1. Value cannot be negative.",
+				"invalid config.backend.bolt.compaction_interval_seconds: 0. Value must be positive.",
+				"invalid config.backend.postgres.reaper_batch_size: 0. Value must be positive.",
+			},
+		},
+		{
+			desc: "Invalid routes and routed backends",
+			inCfg: Backend{
+				Type: BackendRouted,
+				Routed: Routed{
+					Pointers: BackendRedis,
+					Routes:   []Route{{Backend: BackendNATS, MinSizeBytes: -1}, {Backend: "unknown"}},
+				},
+				Redis: Redis{Mode: "unknown"},
+				NATS:  NATS{Bucket: "prebid_cache", Replicas: 1, MaxAgeSeconds: 3600},
+			},
+			expectedErrors: []string{
+				"invalid config.backend.routed.routes[0].min_size_bytes: -1. Value cannot be negative.",
+				`invalid config.backend.routed.routes[1].backend: unknown. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats" or "memory".`,
+				`invalid config.backend.redis.mode: unknown. It must be "standalone", "cluster" or "sentinel".`,
+				"invalid config.backend.nats.servers: at least one server is required.",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.inCfg.validateAndLog()
+
+		if assert.IsType(t, ValidationErrors{}, err, tc.desc) {
+			var msgs []string
+			for _, e := range err.(ValidationErrors) {
+				msgs = append(msgs, e.Error())
+			}
+			assert.Equal(t, tc.expectedErrors, msgs, tc.desc)
+		}
+	}
+}
+
+func TestCassandraValidateAndLog(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inCfg         Cassandra
+		expectedError string
+	}{
+		{
+			desc:  "Defaults",
+			inCfg: Cassandra{Hosts: "127.0.0.1", Keyspace: "prebid", ReadConsistency: "one", WriteConsistency: "local_one"},
+		},
+		{
+			desc: "Authentication, TLS, data center and schema creation",
+			inCfg: Cassandra{
+				Hosts:               "127.0.0.1",
+				Keyspace:            "prebid",
+				Username:            "user",
+				Password:            "secret",
+				LocalDC:             "dc1",
+				ReadConsistency:     "LOCAL_QUORUM",
+				WriteConsistency:    "local_quorum",
+				ProtocolVersion:     4,
+				CreateSchemaOnStart: true,
+				ReplicationFactor:   3,
+				TLS:                 CassandraTLS{Enabled: true, CAFile: "ca.pem", CertFile: "cert.pem", KeyFile: "key.pem"},
+			},
+		},
+		{
+			desc:          "Unknown read consistency",
+			inCfg:         Cassandra{ReadConsistency: "most"},
+			expectedError: "invalid config.backend.cassandra.read_consistency: most",
+		},
+		{
+			desc:          "Unknown write consistency",
+			inCfg:         Cassandra{WriteConsistency: "most"},
+			expectedError: "invalid config.backend.cassandra.write_consistency: most",
+		},
+		{
+			desc:          "Unsupported protocol version",
+			inCfg:         Cassandra{ProtocolVersion: 6},
+			expectedError: "invalid config.backend.cassandra.protocol_version: 6. It must be between 1 and 5, or 0 to negotiate it.",
+		},
+		{
+			desc:          "Negative timeout",
+			inCfg:         Cassandra{TimeoutMillis: -1},
+			expectedError: "invalid config.backend.cassandra.timeout_ms: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Keyspace that can't be created",
+			inCfg:         Cassandra{Keyspace: "prebid; DROP TABLE cache", CreateSchemaOnStart: true, ReplicationFactor: 1},
+			expectedError: "invalid config.backend.cassandra.keyspace: prebid; DROP TABLE cache. Keyspaces created on start must be alphanumeric identifiers.",
+		},
+		{
+			desc:          "Schema creation without replication factor",
+			inCfg:         Cassandra{Keyspace: "prebid", CreateSchemaOnStart: true},
+			expectedError: "invalid config.backend.cassandra.replication_factor: 0. Value must be positive when create_schema_on_start is enabled.",
+		},
+		{
+			desc:          "TLS files without TLS enabled",
+			inCfg:         Cassandra{TLS: CassandraTLS{CAFile: "ca.pem"}},
+			expectedError: "invalid config.backend.cassandra.tls: ca_file, cert_file and key_file require tls.enabled.",
+		},
+		{
+			desc:          "Client certificate without key",
+			inCfg:         Cassandra{TLS: CassandraTLS{Enabled: true, CertFile: "cert.pem"}},
+			expectedError: "invalid config.backend.cassandra.tls: cert_file and key_file must be set together.",
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.inCfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestMemcacheValidateAndLog(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inCfg         Memcache
+		expectedError string
+	}{
+		{
+			desc:  "Static hosts with timeouts and TLS",
+			inCfg: Memcache{Hosts: []string{"10.0.0.1:11211"}, TimeoutMillis: 50, MaxIdleConns: 16, TLS: MemcacheTLS{Enabled: true, CAFile: "ca.pem"}},
+		},
+		{
+			desc:  "Auto discovery",
+			inCfg: Memcache{ConfigHost: "cluster.cfg.cache.amazonaws.com:11211", PollIntervalSeconds: 60},
+		},
+		{
+			desc:          "Negative timeout",
+			inCfg:         Memcache{TimeoutMillis: -1},
+			expectedError: "invalid config.backend.memcache.timeout_ms: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Negative max idle connections",
+			inCfg:         Memcache{MaxIdleConns: -1},
+			expectedError: "invalid config.backend.memcache.max_idle_conns: -1. Value cannot be negative.",
+		},
+		{
+			desc:  "TLS in auto discovery mode",
+			inCfg: Memcache{ConfigHost: "cluster.cfg.cache.amazonaws.com:11211", PollIntervalSeconds: 60, TLS: MemcacheTLS{Enabled: true}},
+		},
+		{
+			desc:          "Auto discovery without poll interval",
+			inCfg:         Memcache{ConfigHost: "cluster.cfg.cache.amazonaws.com:11211"},
+			expectedError: "invalid config.backend.memcache.poll_interval_seconds: 0. Value must be at least 1 in auto discovery mode.",
+		},
+		{
+			desc:          "TLS files without TLS enabled",
+			inCfg:         Memcache{TLS: MemcacheTLS{ServerName: "memcache.internal"}},
+			expectedError: "invalid config.backend.memcache.tls: ca_file, cert_file, key_file and server_name require tls.enabled.",
+		},
+		{
+			desc:          "Client certificate without key",
+			inCfg:         Memcache{TLS: MemcacheTLS{Enabled: true, KeyFile: "key.pem"}},
+			expectedError: "invalid config.backend.memcache.tls: cert_file and key_file must be set together.",
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.inCfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestIgniteValidateAndLog(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inCfg         Ignite
+		expectedError string
+	}{
+		{
+			desc:  "REST protocol",
+			inCfg: Ignite{Protocol: IgniteREST, Scheme: "http", Host: "127.0.0.1", Port: 8080, Cache: IgniteCache{Name: "prebid"}},
+		},
+		{
+			desc: "Thin protocol with authentication",
+			inCfg: Ignite{
+				Protocol:      IgniteThin,
+				Scheme:        "tls",
+				Host:          "127.0.0.1",
+				Port:          10800,
+				Username:      "ignite",
+				Password:      "ignite",
+				TimeoutMillis: 100,
+				MaxIdleConns:  5,
+				Cache:         IgniteCache{Name: "prebid"},
+			},
+		},
+		{
+			desc:          "Unknown protocol",
+			inCfg:         Ignite{Protocol: "grpc", Scheme: "http", Host: "127.0.0.1", Cache: IgniteCache{Name: "prebid"}},
+			expectedError: `invalid config.backend.ignite.protocol: grpc. It must be "rest" or "thin".`,
+		},
+		{
+			desc:          "HTTP scheme with the thin protocol",
+			inCfg:         Ignite{Protocol: IgniteThin, Scheme: "http", Host: "127.0.0.1", Cache: IgniteCache{Name: "prebid"}},
+			expectedError: `invalid config.backend.ignite.scheme: http. It must be "tcp" or "tls" with the thin protocol.`,
+		},
+		{
+			desc:          "Password without username",
+			inCfg:         Ignite{Scheme: "http", Host: "127.0.0.1", Password: "ignite", Cache: IgniteCache{Name: "prebid"}},
+			expectedError: "invalid config.backend.ignite.username: a username is required along with the password.",
+		},
+		{
+			desc:          "Negative timeout",
+			inCfg:         Ignite{Scheme: "http", Host: "127.0.0.1", TimeoutMillis: -1, Cache: IgniteCache{Name: "prebid"}},
+			expectedError: "invalid config.backend.ignite.timeout_ms: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Negative idle connections",
+			inCfg:         Ignite{Scheme: "http", Host: "127.0.0.1", MaxIdleConns: -1, Cache: IgniteCache{Name: "prebid"}},
+			expectedError: "invalid config.backend.ignite.max_idle_conns: -1. Value cannot be negative.",
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.inCfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestBoltValidateAndLog(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inCfg         Bolt
+		expectedError string
+	}{
+		{
+			desc:  "Valid configuration",
+			inCfg: Bolt{DataDir: "/var/lib/prebid-cache", CompactionIntervalSeconds: 60, MaxSizeMB: 512},
+		},
+		{
+			desc:          "Empty data directory",
+			inCfg:         Bolt{CompactionIntervalSeconds: 60},
+			expectedError: "invalid config.backend.bolt.data_dir: the data directory cannot be empty.",
+		},
+		{
+			desc:          "Zero compaction interval",
+			inCfg:         Bolt{DataDir: "/var/lib/prebid-cache"},
+			expectedError: "invalid config.backend.bolt.compaction_interval_seconds: 0. Value must be positive.",
+		},
+		{
+			desc:          "Negative size limit",
+			inCfg:         Bolt{DataDir: "/var/lib/prebid-cache", CompactionIntervalSeconds: 60, MaxSizeMB: -1},
+			expectedError: "invalid config.backend.bolt.max_size_mb: -1. Value cannot be negative.",
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.inCfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestPostgresValidateAndLog(t *testing.T) {
+	validPostgres := Postgres{
+		Host:                  "127.0.0.1",
+		Port:                  5432,
+		Database:              "prebid",
+		SSLMode:               "require",
+		Table:                 "cache",
+		ReaperIntervalSeconds: 60,
+		ReaperBatchSize:       1000,
+	}
+
+	testCases := []struct {
+		desc          string
+		inCfg         func(cfg *Postgres)
+		expectedError string
+	}{
+		{
+			desc:  "Valid configuration",
+			inCfg: func(cfg *Postgres) {},
+		},
+		{
+			desc:          "Empty host",
+			inCfg:         func(cfg *Postgres) { cfg.Host = "" },
+			expectedError: "invalid config.backend.postgres.host: the host cannot be empty.",
+		},
+		{
+			desc:          "Empty database",
+			inCfg:         func(cfg *Postgres) { cfg.Database = "" },
+			expectedError: "invalid config.backend.postgres.dbname: the database name cannot be empty.",
+		},
+		{
+			desc:          "Unsupported SSL mode",
+			inCfg:         func(cfg *Postgres) { cfg.SSLMode = "prefer" },
+			expectedError: `invalid config.backend.postgres.sslmode: prefer. It must be "disable", "require", "verify-ca" or "verify-full".`,
+		},
+		{
+			desc:          "Table name that would need quoting",
+			inCfg:         func(cfg *Postgres) { cfg.Table = "cache; DROP TABLE users" },
+			expectedError: "invalid config.backend.postgres.table: cache; DROP TABLE users. It must be a lowercase identifier.",
+		},
+		{
+			desc:          "Negative pool size",
+			inCfg:         func(cfg *Postgres) { cfg.MaxOpenConns = -1 },
+			expectedError: "invalid config.backend.postgres.max_open_conns: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Negative idle connections",
+			inCfg:         func(cfg *Postgres) { cfg.MaxIdleConns = -1 },
+			expectedError: "invalid config.backend.postgres.max_idle_conns: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Negative connection lifetime",
+			inCfg:         func(cfg *Postgres) { cfg.ConnMaxLifetimeSeconds = -1 },
+			expectedError: "invalid config.backend.postgres.conn_max_lifetime_seconds: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Zero reaper interval",
+			inCfg:         func(cfg *Postgres) { cfg.ReaperIntervalSeconds = 0 },
+			expectedError: "invalid config.backend.postgres.reaper_interval_seconds: 0. Value must be positive.",
+		},
+		{
+			desc:          "Zero reaper batch size",
+			inCfg:         func(cfg *Postgres) { cfg.ReaperBatchSize = 0 },
+			expectedError: "invalid config.backend.postgres.reaper_batch_size: 0. Value must be positive.",
+		},
+	}
+
+	for _, tc := range testCases {
+		cfg := validPostgres
+		tc.inCfg(&cfg)
+
+		err := cfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestS3ValidateAndLog(t *testing.T) {
+	validS3 := S3{
+		Region:                  "us-east-1",
+		Bucket:                  "prebid-cache",
+		TimeoutMillis:           2000,
+		MaxIdleConns:            100,
+		LifecycleExpirationDays: 1,
+	}
+
+	testCases := []struct {
+		desc          string
+		inCfg         func(cfg *S3)
+		expectedError string
+	}{
+		{
+			desc:  "Valid configuration",
+			inCfg: func(cfg *S3) {},
+		},
+		{
+			desc: "S3-compatible object store",
+			inCfg: func(cfg *S3) {
+				cfg.Endpoint = "http://minio:9000"
+				cfg.ForcePathStyle = true
+				cfg.AccessKeyID = "access-key"
+				cfg.SecretAccessKey = "secret-key"
+			},
+		},
+		{
+			desc:          "Empty bucket",
+			inCfg:         func(cfg *S3) { cfg.Bucket = "" },
+			expectedError: "invalid config.backend.s3.bucket: the bucket cannot be empty.",
+		},
+		{
+			desc:          "Empty region",
+			inCfg:         func(cfg *S3) { cfg.Region = "" },
+			expectedError: "invalid config.backend.s3.region: the region cannot be empty.",
+		},
+		{
+			desc:          "Endpoint without scheme",
+			inCfg:         func(cfg *S3) { cfg.Endpoint = "minio:9000" },
+			expectedError: "invalid config.backend.s3.endpoint: minio:9000. It must be an http or https URL.",
+		},
+		{
+			desc:          "Access key without secret",
+			inCfg:         func(cfg *S3) { cfg.AccessKeyID = "access-key" },
+			expectedError: "invalid config.backend.s3.access_key_id: access_key_id and secret_access_key must be set together.",
+		},
+		{
+			desc:          "Negative timeout",
+			inCfg:         func(cfg *S3) { cfg.TimeoutMillis = -1 },
+			expectedError: "invalid config.backend.s3.timeout_ms: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Negative idle connections",
+			inCfg:         func(cfg *S3) { cfg.MaxIdleConns = -1 },
+			expectedError: "invalid config.backend.s3.max_idle_conns: -1. Value cannot be negative.",
+		},
+		{
+			desc: "Lifecycle rule that expires nothing",
+			inCfg: func(cfg *S3) {
+				cfg.ConfigureLifecycle = true
+				cfg.LifecycleExpirationDays = 0
+			},
+			expectedError: "invalid config.backend.s3.lifecycle_expiration_days: 0. Value must be positive when configure_lifecycle is enabled.",
+		},
+	}
+
+	for _, tc := range testCases {
+		cfg := validS3
+		tc.inCfg(&cfg)
+
+		err := cfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestDynamoDBValidateAndLog(t *testing.T) {
+	validDynamoDB := DynamoDB{
+		Region:        "us-east-1",
+		Table:         "prebid-cache",
+		TimeoutMillis: 1000,
+		MaxIdleConns:  100,
+	}
+
+	testCases := []struct {
+		desc          string
+		inCfg         func(cfg *DynamoDB)
+		expectedError string
+	}{
+		{
+			desc:  "Valid configuration",
+			inCfg: func(cfg *DynamoDB) {},
+		},
+		{
+			desc: "DynamoDB Local",
+			inCfg: func(cfg *DynamoDB) {
+				cfg.Endpoint = "http://127.0.0.1:8000"
+				cfg.AccessKeyID = "access-key"
+				cfg.SecretAccessKey = "secret-key"
+				cfg.CreateTableOnStart = true
+			},
+		},
+		{
+			desc:          "Table name that's too short",
+			inCfg:         func(cfg *DynamoDB) { cfg.Table = "pc" },
+			expectedError: "invalid config.backend.dynamodb.table: pc. It must be 3 to 255 letters, digits, underscores, dashes or dots.",
+		},
+		{
+			desc:          "Table name with forbidden characters",
+			inCfg:         func(cfg *DynamoDB) { cfg.Table = "prebid cache" },
+			expectedError: "invalid config.backend.dynamodb.table: prebid cache. It must be 3 to 255 letters, digits, underscores, dashes or dots.",
+		},
+		{
+			desc:          "Empty region",
+			inCfg:         func(cfg *DynamoDB) { cfg.Region = "" },
+			expectedError: "invalid config.backend.dynamodb.region: the region cannot be empty.",
+		},
+		{
+			desc:          "Endpoint without scheme",
+			inCfg:         func(cfg *DynamoDB) { cfg.Endpoint = "127.0.0.1:8000" },
+			expectedError: "invalid config.backend.dynamodb.endpoint: 127.0.0.1:8000. It must be an http or https URL.",
+		},
+		{
+			desc:          "Secret without access key",
+			inCfg:         func(cfg *DynamoDB) { cfg.SecretAccessKey = "secret-key" },
+			expectedError: "invalid config.backend.dynamodb.access_key_id: access_key_id and secret_access_key must be set together.",
+		},
+		{
+			desc:          "Negative timeout",
+			inCfg:         func(cfg *DynamoDB) { cfg.TimeoutMillis = -1 },
+			expectedError: "invalid config.backend.dynamodb.timeout_ms: -1. Value cannot be negative.",
+		},
+		{
+			desc:          "Negative idle connections",
+			inCfg:         func(cfg *DynamoDB) { cfg.MaxIdleConns = -1 },
+			expectedError: "invalid config.backend.dynamodb.max_idle_conns: -1. Value cannot be negative.",
+		},
+	}
+
+	for _, tc := range testCases {
+		cfg := validDynamoDB
+		tc.inCfg(&cfg)
+
+		err := cfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestNATSValidateAndLog(t *testing.T) {
+	validNATS := NATS{
+		Servers:       []string{"nats://127.0.0.1:4222"},
+		Bucket:        "prebid-cache",
+		Replicas:      1,
+		MaxAgeSeconds: 3600,
+		TimeoutMillis: 1000,
+	}
+
+	testCases := []struct {
+		desc          string
+		inCfg         func(cfg *NATS)
+		expectedError string
+	}{
+		{
+			desc:  "Valid configuration",
+			inCfg: func(cfg *NATS) {},
+		},
+		{
+			desc: "Cluster with TLS and credentials",
+			inCfg: func(cfg *NATS) {
+				cfg.Servers = []string{"tls://nats-1:4222", "tls://nats-2:4222"}
+				cfg.Replicas = 3
+				cfg.Username = "user"
+				cfg.Password = "password"
+				cfg.TLS = NATSTLS{CAFile: "/etc/nats/ca.pem", CertFile: "/etc/nats/client.pem", KeyFile: "/etc/nats/client-key.pem"}
+				cfg.CreateBucketOnStart = true
+			},
+		},
+		{
+			desc:          "No servers",
+			inCfg:         func(cfg *NATS) { cfg.Servers = nil },
+			expectedError: "invalid config.backend.nats.servers: at least one server is required.",
+		},
+		{
+			desc:          "Server without scheme",
+			inCfg:         func(cfg *NATS) { cfg.Servers = []string{"127.0.0.1:4222"} },
+			expectedError: "invalid config.backend.nats.servers: 127.0.0.1:4222. It must be a nats or tls URL.",
+		},
+		{
+			desc:          "Bucket name with forbidden characters",
+			inCfg:         func(cfg *NATS) { cfg.Bucket = "prebid.cache" },
+			expectedError: "invalid config.backend.nats.bucket: prebid.cache. It must be made of letters, digits, underscores or dashes.",
+		},
+		{
+			desc:          "No replicas",
+			inCfg:         func(cfg *NATS) { cfg.Replicas = 0 },
+			expectedError: "invalid config.backend.nats.replicas: 0. It must be between 1 and 5.",
+		},
+		{
+			desc:          "Too many replicas",
+			inCfg:         func(cfg *NATS) { cfg.Replicas = 6 },
+			expectedError: "invalid config.backend.nats.replicas: 6. It must be between 1 and 5.",
+		},
+		{
+			desc:          "Bucket that never expires values",
+			inCfg:         func(cfg *NATS) { cfg.MaxAgeSeconds = 0 },
+			expectedError: "invalid config.backend.nats.max_age_seconds: 0. Value must be positive.",
+		},
+		{
+			desc: "Token and username",
+			inCfg: func(cfg *NATS) {
+				cfg.Token = "token"
+				cfg.Username = "user"
+			},
+			expectedError: "invalid config.backend.nats.token: token and username cannot be set together.",
+		},
+		{
+			desc:          "Negative timeout",
+			inCfg:         func(cfg *NATS) { cfg.TimeoutMillis = -1 },
+			expectedError: "invalid config.backend.nats.timeout_ms: -1. Value cannot be negative.",
+		},
+		{
+			desc: "Creds file and token",
+			inCfg: func(cfg *NATS) {
+				cfg.CredsFile = "/etc/nats/user.creds"
+				cfg.Token = "token"
+			},
+			expectedError: "invalid config.backend.nats.creds_file: creds_file cannot be set along with username or token.",
+		},
+		{
+			desc:          "Client certificate without its key",
+			inCfg:         func(cfg *NATS) { cfg.TLS.CertFile = "/etc/nats/client.pem" },
+			expectedError: "invalid config.backend.nats.tls: cert_file and key_file must be set together.",
+		},
+	}
+
+	for _, tc := range testCases {
+		cfg := validNATS
+		tc.inCfg(&cfg)
+
+		err := cfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestRedisValidateAndLog(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inCfg         Redis
+		expectedError string
+	}{
+		{
+			desc:  "Standalone",
+			inCfg: Redis{Mode: RedisStandalone, Host: "127.0.0.1", Port: 6379},
+		},
+		{
+			desc:          "Standalone, replica reads",
+			inCfg:         Redis{Mode: RedisStandalone, ReadOnly: true},
+			expectedError: "invalid config.backend.redis: read_only and route_by_latency are only supported in cluster and sentinel modes.",
+		},
+		{
+			desc:  "Cluster",
+			inCfg: Redis{Mode: RedisCluster, Addresses: []string{"10.0.0.1:6379"}, ReadOnly: true, RouteByLatency: true, PoolSize: 20},
+		},
+		{
+			desc:          "Cluster without seed addresses",
+			inCfg:         Redis{Mode: RedisCluster},
+			expectedError: "invalid config.backend.redis.addresses: the seed list of cluster nodes cannot be empty in cluster mode.",
+		},
+		{
+			desc:          "Cluster with a database other than 0",
+			inCfg:         Redis{Mode: RedisCluster, Addresses: []string{"10.0.0.1:6379"}, Db: 1},
+			expectedError: "invalid config.backend.redis.db: 1. Redis Cluster only supports database 0.",
+		},
+		{
+			desc:  "Sentinel",
+			inCfg: Redis{Mode: RedisSentinel, Addresses: []string{"10.0.0.1:26379"}, MasterName: "mymaster", Db: 1},
+		},
+		{
+			desc:          "Sentinel without master name",
+			inCfg:         Redis{Mode: RedisSentinel, Addresses: []string{"10.0.0.1:26379"}},
+			expectedError: "invalid config.backend.redis.master_name: the master name cannot be empty in sentinel mode.",
+		},
+		{
+			desc:          "Sentinel without sentinel addresses",
+			inCfg:         Redis{Mode: RedisSentinel, MasterName: "mymaster"},
+			expectedError: "invalid config.backend.redis.addresses: the list of sentinel nodes cannot be empty in sentinel mode.",
+		},
+		{
+			desc:          "Sentinel replica reads with a database other than 0",
+			inCfg:         Redis{Mode: RedisSentinel, Addresses: []string{"10.0.0.1:26379"}, MasterName: "mymaster", Db: 1, ReadOnly: true},
+			expectedError: "invalid config.backend.redis.db: 1. Only database 0 can be read from replicas in sentinel mode.",
+		},
+		{
+			desc:          "Sentinel latency routing with a database other than 0",
+			inCfg:         Redis{Mode: RedisSentinel, Addresses: []string{"10.0.0.1:26379"}, MasterName: "mymaster", Db: 2, RouteByLatency: true},
+			expectedError: "invalid config.backend.redis.db: 2. Only database 0 can be read from replicas in sentinel mode.",
+		},
+		{
+			desc:          "Unknown mode",
+			inCfg:         Redis{Mode: "ring"},
+			expectedError: `invalid config.backend.redis.mode: ring. It must be "standalone", "cluster" or "sentinel".`,
+		},
+		{
+			desc:          "Negative pool size",
+			inCfg:         Redis{Mode: RedisStandalone, PoolSize: -1},
+			expectedError: "invalid config.backend.redis.pool_size: -1. Value cannot be negative.",
+		},
+		{
+			desc:  "ACL user with mutual TLS",
+			inCfg: Redis{Mode: RedisStandalone, Username: "user", TLS: RedisTLS{Enabled: true, CAFile: "ca.pem", CertFile: "cert.pem", KeyFile: "key.pem", ServerName: "redis.internal"}},
+		},
+		{
+			desc:          "TLS files without TLS enabled",
+			inCfg:         Redis{Mode: RedisStandalone, TLS: RedisTLS{CAFile: "ca.pem"}},
+			expectedError: "invalid config.backend.redis.tls: ca_file, cert_file, key_file and server_name require tls.enabled.",
+		},
+		{
+			desc:          "Client certificate without key",
+			inCfg:         Redis{Mode: RedisStandalone, TLS: RedisTLS{Enabled: true, CertFile: "cert.pem"}},
+			expectedError: "invalid config.backend.redis.tls: cert_file and key_file must be set together.",
+		},
+		{
+			desc:          "Negative certificate reload interval",
+			inCfg:         Redis{Mode: RedisStandalone, TLS: RedisTLS{Enabled: true, ReloadIntervalSeconds: -1}},
+			expectedError: "invalid config.backend.redis.tls.reload_interval_seconds: -1. Value cannot be negative.",
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.inCfg.validateAndLog()
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
@@ -1,7 +1,14 @@
 package config
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -13,40 +20,102 @@ import (
 )
 
 func NewConfig(filename string) Configuration {
+	v := newViper()
+	setConfigFilePath(v, filename)
+
+	cfg, err := readConfig(v)
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+	return cfg
+}
+
+// LoadConfigFile reads the configuration file found at path with defaults and environment variable
+// overrides applied. Unlike NewConfig, it returns an error if the file doesn't exist or can't be read.
+func LoadConfigFile(path string) (Configuration, error) {
+	v := newViper()
+	v.SetConfigFile(path)
+
+	return readConfig(v)
+}
+
+// FindConfigFile returns the path of the configuration file NewConfig reads for filename, or an empty
+// string if there's none
+func FindConfigFile(filename string) string {
+	v := viper.New()
+	setConfigFilePath(v, filename)
+	if err := v.ReadInConfig(); err != nil {
+		if _, fileNotFound := err.(viper.ConfigFileNotFoundError); fileNotFound {
+			return ""
+		}
+	}
+	return v.ConfigFileUsed()
+}
+
+func newViper() *viper.Viper {
 	v := viper.New()
 
 	setConfigDefaults(v)
 
 	setEnvVarsLookup(v)
 
-	setConfigFilePath(v, filename)
+	return v
+}
+
+// readConfig reads the configuration file v points to. If the file is defective, the default values and
+// environment variable overrides get returned along with the error.
+func readConfig(v *viper.Viper) (Configuration, error) {
+	var readErr error
 
 	// Read configuration file
-	err := v.ReadInConfig()
-	if err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		// Make sure the configuration file was not defective
 		if _, fileNotFound := err.(viper.ConfigFileNotFoundError); fileNotFound {
 			// Config file not found. Just log at info level and start Prebid Cache with default values
 			log.Info("Configuration file not detected. Initializing with default values and environment variable overrides.")
 		} else {
 			// Config file was found but was defective, Either `UnsupportedConfigError` or `ConfigParseError` was thrown
-			log.Fatalf("Configuration file could not be read: %v", err)
+			readErr = fmt.Errorf("Configuration file could not be read: %v", err)
 		}
 	}
 
 	cfg := Configuration{}
 	if err := v.Unmarshal(&cfg); err != nil {
-		log.Fatalf("Failed to unmarshal config: %v", err)
+		return cfg, fmt.Errorf("Failed to unmarshal config: %v", err)
 	}
 
-	return cfg
+	return cfg, readErr
 }
 
 func setConfigDefaults(v *viper.Viper) {
 	v.SetDefault("port", 2424)
 	v.SetDefault("admin_port", 2525)
+	v.SetDefault("tls.enabled", false)
+	v.SetDefault("tls.cert_file", "")
+	v.SetDefault("tls.key_file", "")
+	v.SetDefault("tls.min_version", "")
+	v.SetDefault("tls.cipher_suites", []string{})
+	v.SetDefault("tls.client_ca_file", "")
+	v.SetDefault("tls.reload_interval_seconds", utils.TLS_RELOAD_INTERVAL_SECONDS)
+	v.SetDefault("admin_tls.enabled", false)
+	v.SetDefault("admin_tls.cert_file", "")
+	v.SetDefault("admin_tls.key_file", "")
+	v.SetDefault("admin_tls.min_version", "")
+	v.SetDefault("admin_tls.cipher_suites", []string{})
+	v.SetDefault("admin_tls.client_ca_file", "")
+	v.SetDefault("admin_tls.reload_interval_seconds", utils.TLS_RELOAD_INTERVAL_SECONDS)
+	v.SetDefault("listener.unix_socket_path", "")
+	v.SetDefault("listener.unix_socket_permissions", utils.UNIX_SOCKET_PERMISSIONS)
+	v.SetDefault("listener.h2c", false)
+	v.SetDefault("admin_listener.unix_socket_path", "")
+	v.SetDefault("admin_listener.unix_socket_permissions", utils.UNIX_SOCKET_PERMISSIONS)
+	v.SetDefault("admin_listener.h2c", false)
 	v.SetDefault("index_response", "This application stores short-term data for use in Prebid.")
 	v.SetDefault("status_response", "")
+	v.SetDefault("health_check.enabled", false)
+	v.SetDefault("health_check.interval_ms", utils.HEALTH_CHECK_INTERVAL_MS)
+	v.SetDefault("health_check.timeout_ms", utils.HEALTH_CHECK_TIMEOUT_MS)
+	v.SetDefault("health_check.drain_delay_ms", 0)
 	v.SetDefault("log.level", "info")
 	v.SetDefault("backend.type", "memory")
 	v.SetDefault("backend.aerospike.host", "")
@@ -60,25 +129,145 @@ func setConfigDefaults(v *viper.Viper) {
 	v.SetDefault("backend.aerospike.max_write_retries", 0)
 	v.SetDefault("backend.aerospike.connection_idle_timeout_seconds", 0)
 	v.SetDefault("backend.aerospike.connection_queue_size", 0)
+	v.SetDefault("backend.aerospike.set_name", "uuid")
+	v.SetDefault("backend.aerospike.bin_name", "value")
+	v.SetDefault("backend.aerospike.auth_mode", "internal")
+	v.SetDefault("backend.aerospike.read_socket_timeout_ms", 0)
+	v.SetDefault("backend.aerospike.read_total_timeout_ms", 0)
+	v.SetDefault("backend.aerospike.write_socket_timeout_ms", 0)
+	v.SetDefault("backend.aerospike.write_total_timeout_ms", 0)
+	v.SetDefault("backend.aerospike.rack_aware", false)
+	v.SetDefault("backend.aerospike.rack_id", 0)
+	v.SetDefault("backend.aerospike.tls.enabled", false)
+	v.SetDefault("backend.aerospike.tls.ca_file", "")
+	v.SetDefault("backend.aerospike.tls.cert_file", "")
+	v.SetDefault("backend.aerospike.tls.key_file", "")
+	v.SetDefault("backend.aerospike.tls.name", "")
 	v.SetDefault("backend.cassandra.hosts", "")
 	v.SetDefault("backend.cassandra.keyspace", "")
 	v.SetDefault("backend.cassandra.default_ttl_seconds", utils.CASSANDRA_DEFAULT_TTL_SECONDS)
+	v.SetDefault("backend.cassandra.username", "")
+	v.SetDefault("backend.cassandra.password", "")
+	v.SetDefault("backend.cassandra.local_dc", "")
+	v.SetDefault("backend.cassandra.read_consistency", "one")
+	v.SetDefault("backend.cassandra.write_consistency", "local_one")
+	v.SetDefault("backend.cassandra.protocol_version", 0)
+	v.SetDefault("backend.cassandra.timeout_ms", 0)
+	v.SetDefault("backend.cassandra.connect_timeout_ms", 0)
+	v.SetDefault("backend.cassandra.create_schema_on_start", false)
+	v.SetDefault("backend.cassandra.replication_factor", 1)
+	v.SetDefault("backend.cassandra.tls.enabled", false)
+	v.SetDefault("backend.cassandra.tls.insecure_skip_verify", false)
+	v.SetDefault("backend.cassandra.tls.ca_file", "")
+	v.SetDefault("backend.cassandra.tls.cert_file", "")
+	v.SetDefault("backend.cassandra.tls.key_file", "")
 	v.SetDefault("backend.memcache.hosts", []string{})
+	v.SetDefault("backend.memcache.timeout_ms", 0)
+	v.SetDefault("backend.memcache.max_idle_conns", 0)
+	v.SetDefault("backend.memcache.tls.enabled", false)
+	v.SetDefault("backend.memcache.tls.insecure_skip_verify", false)
+	v.SetDefault("backend.memcache.tls.ca_file", "")
+	v.SetDefault("backend.memcache.tls.cert_file", "")
+	v.SetDefault("backend.memcache.tls.key_file", "")
+	v.SetDefault("backend.memcache.tls.server_name", "")
+	v.SetDefault("backend.redis.mode", "standalone")
 	v.SetDefault("backend.redis.host", "")
 	v.SetDefault("backend.redis.port", 0)
+	v.SetDefault("backend.redis.username", "")
 	v.SetDefault("backend.redis.password", "")
 	v.SetDefault("backend.redis.db", 0)
+	v.SetDefault("backend.redis.addresses", []string{})
+	v.SetDefault("backend.redis.master_name", "")
+	v.SetDefault("backend.redis.pool_size", 0)
+	v.SetDefault("backend.redis.read_only", false)
+	v.SetDefault("backend.redis.route_by_latency", false)
 	v.SetDefault("backend.redis.expiration", utils.REDIS_DEFAULT_EXPIRATION_MINUTES)
 	v.SetDefault("backend.redis.tls.enabled", false)
 	v.SetDefault("backend.redis.tls.insecure_skip_verify", false)
+	v.SetDefault("backend.redis.tls.ca_file", "")
+	v.SetDefault("backend.redis.tls.cert_file", "")
+	v.SetDefault("backend.redis.tls.key_file", "")
+	v.SetDefault("backend.redis.tls.server_name", "")
+	v.SetDefault("backend.redis.tls.reload_interval_seconds", utils.TLS_RELOAD_INTERVAL_SECONDS)
+	v.SetDefault("backend.ignite.protocol", "rest")
 	v.SetDefault("backend.ignite.scheme", "")
 	v.SetDefault("backend.ignite.host", "")
 	v.SetDefault("backend.ignite.port", 0)
 	v.SetDefault("backend.ignite.secure", false)
 	v.SetDefault("backend.ignite.headers", map[string]string{})
+	v.SetDefault("backend.ignite.username", "")
+	v.SetDefault("backend.ignite.password", "")
+	v.SetDefault("backend.ignite.timeout_ms", 1000)
+	v.SetDefault("backend.ignite.max_idle_conns", 10)
 	v.SetDefault("backend.ignite.cache.name", "")
 	v.SetDefault("backend.ignite.cache.create_on_start", false)
+	v.SetDefault("backend.bolt.data_dir", "")
+	v.SetDefault("backend.bolt.compaction_interval_seconds", utils.BOLT_COMPACTION_INTERVAL_SECONDS)
+	v.SetDefault("backend.bolt.max_size_mb", 0)
+	v.SetDefault("backend.postgres.host", "")
+	v.SetDefault("backend.postgres.port", 5432)
+	v.SetDefault("backend.postgres.dbname", "")
+	v.SetDefault("backend.postgres.user", "")
+	v.SetDefault("backend.postgres.password", "")
+	v.SetDefault("backend.postgres.sslmode", "require")
+	v.SetDefault("backend.postgres.table", "cache")
+	v.SetDefault("backend.postgres.max_open_conns", 10)
+	v.SetDefault("backend.postgres.max_idle_conns", 5)
+	v.SetDefault("backend.postgres.conn_max_lifetime_seconds", 300)
+	v.SetDefault("backend.postgres.create_schema_on_start", false)
+	v.SetDefault("backend.postgres.reaper_interval_seconds", 60)
+	v.SetDefault("backend.postgres.reaper_batch_size", 1000)
+	v.SetDefault("backend.s3.endpoint", "")
+	v.SetDefault("backend.s3.region", "us-east-1")
+	v.SetDefault("backend.s3.bucket", "")
+	v.SetDefault("backend.s3.prefix", "")
+	v.SetDefault("backend.s3.force_path_style", false)
+	v.SetDefault("backend.s3.access_key_id", "")
+	v.SetDefault("backend.s3.secret_access_key", "")
+	v.SetDefault("backend.s3.session_token", "")
+	v.SetDefault("backend.s3.timeout_ms", 2000)
+	v.SetDefault("backend.s3.max_idle_conns", 100)
+	v.SetDefault("backend.s3.configure_lifecycle", false)
+	v.SetDefault("backend.s3.lifecycle_expiration_days", 1)
+	v.SetDefault("backend.dynamodb.endpoint", "")
+	v.SetDefault("backend.dynamodb.region", "us-east-1")
+	v.SetDefault("backend.dynamodb.table", "prebid-cache")
+	v.SetDefault("backend.dynamodb.access_key_id", "")
+	v.SetDefault("backend.dynamodb.secret_access_key", "")
+	v.SetDefault("backend.dynamodb.session_token", "")
+	v.SetDefault("backend.dynamodb.timeout_ms", 1000)
+	v.SetDefault("backend.dynamodb.max_idle_conns", 100)
+	v.SetDefault("backend.dynamodb.consistent_reads", false)
+	v.SetDefault("backend.dynamodb.create_table_on_start", false)
+	v.SetDefault("backend.nats.servers", []string{})
+	v.SetDefault("backend.nats.bucket", "prebid-cache")
+	v.SetDefault("backend.nats.replicas", 1)
+	v.SetDefault("backend.nats.max_age_seconds", utils.REQUEST_MAX_TTL_SECONDS)
+	v.SetDefault("backend.nats.username", "")
+	v.SetDefault("backend.nats.password", "")
+	v.SetDefault("backend.nats.token", "")
+	v.SetDefault("backend.nats.timeout_ms", 1000)
+	v.SetDefault("backend.nats.creds_file", "")
+	v.SetDefault("backend.nats.tls.ca_file", "")
+	v.SetDefault("backend.nats.tls.cert_file", "")
+	v.SetDefault("backend.nats.tls.key_file", "")
+	v.SetDefault("backend.nats.create_bucket_on_start", false)
+	v.SetDefault("backend.routed.pointers", "")
+	v.SetDefault("backend.routed.default", "")
+	v.SetDefault("backend.migrate.from", "")
+	v.SetDefault("backend.migrate.to", "")
+	v.SetDefault("backend.migrate.mirror_writes_seconds", 0)
+	v.SetDefault("backend.migrate.copy_on_read", false)
+	v.SetDefault("backend.migrate.copy_on_read_ttl_seconds", utils.REQUEST_MAX_TTL_SECONDS)
+	v.SetDefault("backend.shadow.enabled", false)
+	v.SetDefault("backend.shadow.type", "")
+	v.SetDefault("backend.shadow.sampling_rate", 1.0)
+	v.SetDefault("backend.shadow.queue_size", utils.SHADOW_QUEUE_SIZE)
+	v.SetDefault("backend.shadow.workers", utils.SHADOW_WORKERS)
+	v.SetDefault("backend.shadow.timeout_ms", utils.SHADOW_TIMEOUT_MS)
 	v.SetDefault("compression.type", "snappy")
+	v.SetDefault("content_types", []ContentType{})
+	v.SetDefault("json_schemas", []JSONSchema{})
 	v.SetDefault("metrics.influx.enabled", false)
 	v.SetDefault("metrics.influx.host", "")
 	v.SetDefault("metrics.influx.database", "")
@@ -98,7 +287,13 @@ func setConfigDefaults(v *viper.Viper) {
 	v.SetDefault("request_limits.max_num_values", utils.REQUEST_MAX_NUM_VALUES)
 	v.SetDefault("request_limits.max_ttl_seconds", utils.REQUEST_MAX_TTL_SECONDS)
 	v.SetDefault("request_limits.max_header_size_bytes", http.DefaultMaxHeaderBytes)
+	v.SetDefault("request_limits.validate_xml", ValidateXMLOff)
 	v.SetDefault("request_logging.referer_sampling_rate", 0.0)
+	v.SetDefault("cors.allowed_origins", []string{})
+	v.SetDefault("vast_wrapper.enabled", false)
+	v.SetDefault("vast_wrapper.base_url", "")
+	v.SetDefault("vast_wrapper.impression_urls", []string{})
+	v.SetDefault("vast_wrapper.error_urls", []string{})
 	v.SetDefault("routes.allow_public_write", true)
 }
 
@@ -118,45 +313,209 @@ func setEnvVarsLookup(v *viper.Viper) {
 type Configuration struct {
 	Port           int            `mapstructure:"port"`
 	AdminPort      int            `mapstructure:"admin_port"`
+	TLS            TLS            `mapstructure:"tls"`
+	AdminTLS       TLS            `mapstructure:"admin_tls"`
+	Listener       Listener       `mapstructure:"listener"`
+	AdminListener  Listener       `mapstructure:"admin_listener"`
 	IndexResponse  string         `mapstructure:"index_response"`
 	Log            Log            `mapstructure:"log"`
 	RateLimiting   RateLimiting   `mapstructure:"rate_limiter"`
 	RequestLimits  RequestLimits  `mapstructure:"request_limits"`
 	RequestLogging RequestLogging `mapstructure:"request_logging"`
+	CORS           CORS           `mapstructure:"cors"`
+	VASTWrapper    VASTWrapper    `mapstructure:"vast_wrapper"`
 
-	StatusResponse string      `mapstructure:"status_response"`
-	Backend        Backend     `mapstructure:"backend"`
-	Compression    Compression `mapstructure:"compression"`
-	Metrics        Metrics     `mapstructure:"metrics"`
-	Routes         Routes      `mapstructure:"routes"`
+	StatusResponse string        `mapstructure:"status_response"`
+	HealthCheck    HealthCheck   `mapstructure:"health_check"`
+	Backend        Backend       `mapstructure:"backend"`
+	Compression    Compression   `mapstructure:"compression"`
+	ContentTypes   []ContentType `mapstructure:"content_types"`
+	JSONSchemas    []JSONSchema  `mapstructure:"json_schemas"`
+	Metrics        Metrics       `mapstructure:"metrics"`
+	Routes         Routes        `mapstructure:"routes"`
 }
 
 // ValidateAndLog validates the config, terminating the program on any errors.
 // It also logs the config values that it used.
 func (cfg *Configuration) ValidateAndLog() {
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%s", err.Error())
+	}
+}
+
+// Validate logs the config values that it used and returns a ValidationErrors holding every problem
+// found in the config, or nil if it's valid.
+func (cfg *Configuration) Validate() error {
+	var errs ValidationErrors
 
 	log.Infof("config.port: %d", cfg.Port)
 	log.Infof("config.admin_port: %d", cfg.AdminPort)
-	cfg.Log.validateAndLog()
+	errs.add(cfg.TLS.validateAndLog("tls"))
+	errs.add(cfg.AdminTLS.validateAndLog("admin_tls"))
+	errs.add(cfg.Listener.validateAndLog("listener"))
+	errs.add(cfg.AdminListener.validateAndLog("admin_listener"))
+	errs.add(cfg.Log.validateAndLog())
 	cfg.RateLimiting.validateAndLog()
-	cfg.RequestLimits.validateAndLog()
-	cfg.RequestLogging.validateAndLog()
+	errs.add(cfg.RequestLimits.validateAndLog())
+	errs.add(cfg.RequestLogging.validateAndLog())
+	cfg.CORS.validateAndLog()
+	errs.add(cfg.VASTWrapper.validateAndLog())
+	errs.add(cfg.HealthCheck.validateAndLog())
+	errs.add(cfg.Backend.validateAndLog())
+	errs.add(cfg.Compression.validateAndLog())
+	errs.add(validateAndLogContentTypes(cfg.ContentTypes))
+	errs.add(cfg.validateAndLogJSONSchemas())
+	errs.add(cfg.validateRoutingByType())
+	errs.add(cfg.validateMigrateCopyTTL())
+	errs.add(cfg.Metrics.validateAndLog())
+	cfg.Routes.validateAndLog()
 
-	if err := cfg.Backend.validateAndLog(); err != nil {
-		log.Fatalf("%s", err.Error())
+	return errs.toError()
+}
+
+// TLS holds the settings Prebid Cache uses to terminate TLS on one of its listeners. When
+// ClientCAFile is set, clients must present a certificate signed by that CA (mutual TLS).
+type TLS struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	CertFile     string   `mapstructure:"cert_file"`
+	KeyFile      string   `mapstructure:"key_file"`
+	MinVersion   string   `mapstructure:"min_version"`
+	CipherSuites []string `mapstructure:"cipher_suites"`
+	ClientCAFile string   `mapstructure:"client_ca_file"`
+	// ReloadIntervalSeconds is how often the certificate and key files are checked for changes.
+	// A value of zero or less disables certificate hot-reload.
+	ReloadIntervalSeconds int `mapstructure:"reload_interval_seconds"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func (cfg *TLS) validateAndLog(name string) error {
+	log.Infof("config.%s.enabled: %t", name, cfg.Enabled)
+	if !cfg.Enabled {
+		return nil
 	}
 
-	cfg.Compression.validateAndLog()
-	cfg.Metrics.validateAndLog()
-	cfg.Routes.validateAndLog()
+	var errs ValidationErrors
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		errs.add(fmt.Errorf("invalid config.%s: cert_file and key_file are required when TLS is enabled.", name))
+	}
+	if _, ok := tlsVersions[cfg.MinVersion]; cfg.MinVersion != "" && !ok {
+		errs.add(fmt.Errorf(`invalid config.%s.min_version: %s. It must be "1.0", "1.1", "1.2" or "1.3"`, name, cfg.MinVersion))
+	}
+	for _, suite := range cfg.CipherSuites {
+		if _, ok := cipherSuiteID(suite); !ok {
+			errs.add(fmt.Errorf("invalid config.%s.cipher_suites: %s is not a supported cipher suite", name, suite))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	log.Infof("config.%s.cert_file: %s", name, cfg.CertFile)
+	log.Infof("config.%s.key_file: %s", name, cfg.KeyFile)
+	log.Infof("config.%s.min_version: %s", name, cfg.MinVersion)
+	log.Infof("config.%s.cipher_suites: %v", name, cfg.CipherSuites)
+	if cfg.ClientCAFile != "" {
+		log.Infof("config.%s.client_ca_file: %s. Clients must present a certificate signed by this CA", name, cfg.ClientCAFile)
+	}
+	log.Infof("config.%s.reload_interval_seconds: %d", name, cfg.ReloadIntervalSeconds)
+	return nil
+}
+
+// MinTLSVersion returns the crypto/tls constant matching MinVersion. If MinVersion was left
+// blank, TLS 1.2 is assumed.
+func (cfg *TLS) MinTLSVersion() uint16 {
+	if version, ok := tlsVersions[cfg.MinVersion]; ok {
+		return version
+	}
+	return tls.VersionTLS12
+}
+
+// CipherSuiteIDs returns the crypto/tls IDs of the configured cipher suites. A nil slice means
+// the crypto/tls defaults will be used.
+func (cfg *TLS) CipherSuiteIDs() []uint16 {
+	if len(cfg.CipherSuites) == 0 {
+		return nil
+	}
+	ids := make([]uint16, 0, len(cfg.CipherSuites))
+	for _, suite := range cfg.CipherSuites {
+		if id, ok := cipherSuiteID(suite); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ReloadInterval returns ReloadIntervalSeconds as a time.Duration
+func (cfg *TLS) ReloadInterval() time.Duration {
+	return time.Duration(cfg.ReloadIntervalSeconds) * time.Second
+}
+
+func cipherSuiteID(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+// Listener holds the settings of the socket a server accepts connections from.
+type Listener struct {
+	// UnixSocketPath, when set, makes the server listen on a Unix domain socket instead of its TCP port
+	UnixSocketPath string `mapstructure:"unix_socket_path"`
+	// UnixSocketPermissions are the octal file permissions applied to the Unix domain socket file
+	UnixSocketPermissions string `mapstructure:"unix_socket_permissions"`
+	// H2C enables HTTP/2 over cleartext connections
+	H2C bool `mapstructure:"h2c"`
+}
+
+func (cfg *Listener) validateAndLog(name string) error {
+	if cfg.UnixSocketPath != "" {
+		if _, err := cfg.SocketFileMode(); err != nil {
+			return fmt.Errorf("invalid config.%s.unix_socket_permissions: %s. It must be an octal file mode no greater than 0777", name, cfg.UnixSocketPermissions)
+		}
+		log.Infof("config.%s.unix_socket_path: %s", name, cfg.UnixSocketPath)
+		log.Infof("config.%s.unix_socket_permissions: %s", name, cfg.UnixSocketPermissions)
+	}
+	if cfg.H2C {
+		log.Infof("config.%s.h2c: %t", name, cfg.H2C)
+	}
+	return nil
+}
+
+// SocketFileMode parses UnixSocketPermissions as an octal file mode
+func (cfg *Listener) SocketFileMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(cfg.UnixSocketPermissions, 8, 32)
+	if err != nil {
+		return 0, err
+	}
+	if mode > 0777 {
+		return 0, fmt.Errorf("file mode %s exceeds 0777", cfg.UnixSocketPermissions)
+	}
+	return os.FileMode(mode), nil
 }
 
 type Log struct {
 	Level LogLevel `mapstructure:"level"`
 }
 
-func (cfg *Log) validateAndLog() {
+func (cfg *Log) validateAndLog() error {
+	if _, err := log.ParseLevel(string(cfg.Level)); err != nil {
+		return fmt.Errorf(`invalid config.log.level: %s. It must be "trace", "debug", "info", "warning", "error", "fatal" or "panic"`, cfg.Level)
+	}
 	log.Infof("config.log.level: %s", cfg.Level)
+	return nil
 }
 
 type LogLevel string
@@ -188,12 +547,12 @@ type RequestLogging struct {
 	RefererSamplingRate float64 `mapstructure:"referer_sampling_rate"`
 }
 
-func (cfg *RequestLogging) validateAndLog() {
-	if cfg.RefererSamplingRate >= 0.0 && cfg.RefererSamplingRate <= 1.0 {
-		log.Infof("config.request_logging.referer_sampling_rate: %s", strconv.FormatFloat(cfg.RefererSamplingRate, 'f', -1, 64))
-	} else {
-		log.Fatalf("invalid config.request_logging.referer_sampling_rate: value must be positive and not greater than 1.0. Got %s", strconv.FormatFloat(cfg.RefererSamplingRate, 'f', -1, 64))
+func (cfg *RequestLogging) validateAndLog() error {
+	if cfg.RefererSamplingRate < 0.0 || cfg.RefererSamplingRate > 1.0 {
+		return fmt.Errorf("invalid config.request_logging.referer_sampling_rate: value must be positive and not greater than 1.0. Got %s", strconv.FormatFloat(cfg.RefererSamplingRate, 'f', -1, 64))
 	}
+	log.Infof("config.request_logging.referer_sampling_rate: %s", strconv.FormatFloat(cfg.RefererSamplingRate, 'f', -1, 64))
+	return nil
 }
 
 type RequestLimits struct {
@@ -202,51 +561,370 @@ type RequestLimits struct {
 	MaxTTLSeconds    int  `mapstructure:"max_ttl_seconds"`
 	AllowSettingKeys bool `mapstructure:"allow_setting_keys"`
 	MaxHeaderSize    int  `mapstructure:"max_header_size_bytes"`
+	// ValidateXML tells whether XML values are checked to be well-formed VAST documents when
+	// they're put, and what happens to the invalid ones
+	ValidateXML string `mapstructure:"validate_xml"`
 }
 
-func (cfg *RequestLimits) validateAndLog() {
+// Modes of XML values validation
+const (
+	// ValidateXMLOff stores XML values without checking them
+	ValidateXMLOff = "off"
+	// ValidateXMLLog logs and counts invalid XML values, but still stores them
+	ValidateXMLLog = "log"
+	// ValidateXMLReject logs, counts and rejects invalid XML values
+	ValidateXMLReject = "reject"
+)
+
+func (cfg *RequestLimits) validateAndLog() error {
+	var errs ValidationErrors
+
 	log.Infof("config.request_limits.allow_setting_keys: %v", cfg.AllowSettingKeys)
 
 	if cfg.MaxTTLSeconds >= 0 {
 		log.Infof("config.request_limits.max_ttl_seconds: %d", cfg.MaxTTLSeconds)
 	} else {
-		log.Fatalf("invalid config.request_limits.max_ttl_seconds: %d. Value cannot be negative.", cfg.MaxTTLSeconds)
+		errs.add(fmt.Errorf("invalid config.request_limits.max_ttl_seconds: %d. Value cannot be negative.", cfg.MaxTTLSeconds))
 	}
 
 	if cfg.MaxSize >= 0 {
 		log.Infof("config.request_limits.max_size_bytes: %d", cfg.MaxSize)
 	} else {
-		log.Fatalf("invalid config.request_limits.max_size_bytes: %d. Value cannot be negative.", cfg.MaxSize)
+		errs.add(fmt.Errorf("invalid config.request_limits.max_size_bytes: %d. Value cannot be negative.", cfg.MaxSize))
 	}
 
 	if cfg.MaxNumValues >= 0 {
 		log.Infof("config.request_limits.max_num_values: %d", cfg.MaxNumValues)
 	} else {
-		log.Fatalf("invalid config.request_limits.max_num_values: %d. Value cannot be negative.", cfg.MaxNumValues)
+		errs.add(fmt.Errorf("invalid config.request_limits.max_num_values: %d. Value cannot be negative.", cfg.MaxNumValues))
 	}
 
 	if cfg.MaxHeaderSize >= 0 {
 		log.Infof("config.request_limits.max_header_size_bytes: %d", cfg.MaxHeaderSize)
 	} else {
-		log.Fatalf("invalid config.request_limits.max_header_size_bytes: %d. Value cannot be negative.", cfg.MaxHeaderSize)
+		errs.add(fmt.Errorf("invalid config.request_limits.max_header_size_bytes: %d. Value cannot be negative.", cfg.MaxHeaderSize))
+	}
+
+	switch cfg.ValidateXML {
+	case "", ValidateXMLOff:
+		log.Infof("config.request_limits.validate_xml: %s", ValidateXMLOff)
+	case ValidateXMLLog, ValidateXMLReject:
+		log.Infof("config.request_limits.validate_xml: %s", cfg.ValidateXML)
+	default:
+		errs.add(fmt.Errorf(`invalid config.request_limits.validate_xml: %s. It must be "off", "log" or "reject".`, cfg.ValidateXML))
+	}
+
+	return errs.toError()
+}
+
+// CORS configures the cross-origin requests the public endpoints accept
+type CORS struct {
+	// AllowedOrigins lists the origins allowed to make cross-origin requests. If empty, any origin is allowed.
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+}
+
+func (cfg *CORS) validateAndLog() {
+	if len(cfg.AllowedOrigins) > 0 {
+		log.Infof("config.cors.allowed_origins: %v", cfg.AllowedOrigins)
+	}
+}
+
+// VASTWrapper configures the Wrapper VAST documents "GET /cache?uuid={id}&wrapper=true" generates around
+// stored XML values
+type VASTWrapper struct {
+	// Enabled lets requests ask for wrappers. Requests asking for one are rejected otherwise.
+	Enabled bool `mapstructure:"enabled"`
+	// BaseURL is the public URL Prebid Cache is reached at, which the wrappers' VASTAdTagURI points to.
+	// It's required when wrappers are enabled.
+	BaseURL string `mapstructure:"base_url"`
+	// ImpressionURLs and ErrorURLs get added to every wrapper as Impression and Error tracking URLs
+	ImpressionURLs []string `mapstructure:"impression_urls"`
+	ErrorURLs      []string `mapstructure:"error_urls"`
+}
+
+func (cfg *VASTWrapper) validateAndLog() error {
+	var errs ValidationErrors
+
+	if cfg.Enabled {
+		log.Infof("config.vast_wrapper.enabled: %t", cfg.Enabled)
+		if cfg.BaseURL == "" {
+			errs.add(fmt.Errorf("invalid config.vast_wrapper.base_url: the base URL cannot be empty when VAST wrappers are enabled."))
+		}
+	}
+	if cfg.BaseURL != "" {
+		if u, err := url.Parse(cfg.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" {
+			errs.add(fmt.Errorf("invalid config.vast_wrapper.base_url: %s. It must be an absolute http or https URL without a query.", cfg.BaseURL))
+		} else {
+			log.Infof("config.vast_wrapper.base_url: %s", cfg.BaseURL)
+		}
+	}
+	errs.add(validateAndLogTrackingURLs("impression_urls", cfg.ImpressionURLs))
+	errs.add(validateAndLogTrackingURLs("error_urls", cfg.ErrorURLs))
+
+	return errs.toError()
+}
+
+func validateAndLogTrackingURLs(key string, urls []string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	var errs ValidationErrors
+	for i, trackingURL := range urls {
+		if u, err := url.Parse(trackingURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add(fmt.Errorf("invalid config.vast_wrapper.%s[%d]: %s. It must be an absolute http or https URL.", key, i, trackingURL))
+		}
+	}
+	if len(errs) == 0 {
+		log.Infof("config.vast_wrapper.%s: %v", key, urls)
+	}
+	return errs.toError()
+}
+
+// HealthCheck configures how "GET /status" determines whether Prebid Cache is ready to serve traffic
+type HealthCheck struct {
+	// Enabled makes "GET /status" reflect the health of the backend storage service
+	Enabled        bool `mapstructure:"enabled"`
+	IntervalMillis int  `mapstructure:"interval_ms"`
+	TimeoutMillis  int  `mapstructure:"timeout_ms"`
+	// DrainDelayMillis is how long the servers keep serving requests after a shutdown signal
+	// flips "GET /status" to 503, so load balancers can stop routing traffic to this instance.
+	DrainDelayMillis int `mapstructure:"drain_delay_ms"`
+}
+
+func (cfg *HealthCheck) validateAndLog() error {
+	var errs ValidationErrors
+
+	log.Infof("config.health_check.enabled: %t", cfg.Enabled)
+	if cfg.Enabled {
+		if cfg.IntervalMillis > 0 {
+			log.Infof("config.health_check.interval_ms: %d", cfg.IntervalMillis)
+		} else {
+			errs.add(fmt.Errorf("invalid config.health_check.interval_ms: %d. Value must be greater than zero.", cfg.IntervalMillis))
+		}
+		if cfg.TimeoutMillis > 0 {
+			log.Infof("config.health_check.timeout_ms: %d", cfg.TimeoutMillis)
+		} else {
+			errs.add(fmt.Errorf("invalid config.health_check.timeout_ms: %d. Value must be greater than zero.", cfg.TimeoutMillis))
+		}
+	}
+
+	if cfg.DrainDelayMillis >= 0 {
+		log.Infof("config.health_check.drain_delay_ms: %d", cfg.DrainDelayMillis)
+	} else {
+		errs.add(fmt.Errorf("invalid config.health_check.drain_delay_ms: %d. Value cannot be negative.", cfg.DrainDelayMillis))
 	}
+
+	return errs.toError()
+}
+
+func (cfg *HealthCheck) Interval() time.Duration {
+	return time.Duration(cfg.IntervalMillis) * time.Millisecond
+}
+
+func (cfg *HealthCheck) Timeout() time.Duration {
+	return time.Duration(cfg.TimeoutMillis) * time.Millisecond
+}
+
+func (cfg *HealthCheck) DrainDelay() time.Duration {
+	return time.Duration(cfg.DrainDelayMillis) * time.Millisecond
 }
 
 type Compression struct {
 	Type CompressionType `mapstructure:"type"`
 }
 
-func (cfg *Compression) validateAndLog() {
+func (cfg *Compression) validateAndLog() error {
 	switch cfg.Type {
 	case CompressionNone:
 		fallthrough
 	case CompressionSnappy:
 		log.Infof("config.compression.type: %s", cfg.Type)
+		return nil
 	default:
-		log.Fatalf(`invalid config.compression.type: %s. It must be "none" or "snappy"`, cfg.Type)
+		return fmt.Errorf(`invalid config.compression.type: %s. It must be "none" or "snappy"`, cfg.Type)
 	}
 }
 
+// validateRoutingByType makes sure the routed backend only routes values by the types of content
+// Prebid Cache accepts, and doesn't route them by type when they get compressed, as compression hides
+// the type prefix of the values from the backend
+func (cfg *Configuration) validateRoutingByType() error {
+	if cfg.Backend.Type != BackendRouted {
+		return nil
+	}
+	contentTypes, err := NewContentTypes(cfg.ContentTypes)
+	if err != nil {
+		// Reported by validateAndLogContentTypes
+		return nil
+	}
+	for i, route := range cfg.Backend.Routed.Routes {
+		if route.Type == "" {
+			continue
+		}
+		if _, ok := contentTypes.Get(route.Type); !ok {
+			return fmt.Errorf(`invalid config.backend.routed.routes[%d].type: %s. It must be one of the content types ["%s"] or empty to match any type.`, i, route.Type, strings.Join(contentTypes.Names(), `", "`))
+		}
+		if cfg.Compression.Type != CompressionNone {
+			return fmt.Errorf("invalid config.backend.routed.routes[%d].type: %s. Values can't be routed by type when config.compression.type is %s.", i, route.Type, cfg.Compression.Type)
+		}
+	}
+	return nil
+}
+
+// validateMigrateCopyTTL makes sure the values copied on read by the migrate backend don't outlive the
+// longest time-to-live a put can ask for. Their remaining time-to-live in the old backend is unknown,
+// so a longer one could keep an expired value around.
+func (cfg *Configuration) validateMigrateCopyTTL() error {
+	if cfg.Backend.Type != BackendMigrate || !cfg.Backend.Migrate.CopyOnRead || cfg.RequestLimits.MaxTTLSeconds <= 0 {
+		return nil
+	}
+	if cfg.Backend.Migrate.CopyOnReadTTLSeconds > cfg.RequestLimits.MaxTTLSeconds {
+		return fmt.Errorf("invalid config.backend.migrate.copy_on_read_ttl_seconds: %d. Value cannot exceed config.request_limits.max_ttl_seconds: %d.", cfg.Backend.Migrate.CopyOnReadTTLSeconds, cfg.RequestLimits.MaxTTLSeconds)
+	}
+	return nil
+}
+
+// ContentType holds the settings of a type of content Prebid Cache accepts besides "json" and "xml".
+// Values are stored prefixed by the name of their type, so names can't start with the name of another
+// type.
+type ContentType struct {
+	Name string `mapstructure:"name"`
+	// ContentType is the Content-Type header values get served with
+	ContentType string `mapstructure:"content_type"`
+	// Encoding is "raw" for values stored and served as they're put, or "base64" for binary values
+	// put and stored base64-encoded, and served decoded. Defaults to "raw".
+	Encoding string `mapstructure:"encoding"`
+	// Validator is "json", "xml" or "utf8" to reject the values that aren't valid JSON, well-formed
+	// XML or UTF-8 text. Values aren't validated if empty.
+	Validator string `mapstructure:"validator"`
+}
+
+// contentTypeName matches the names allowed for content types, which are used as metric labels
+var contentTypeName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func validateAndLogContentTypes(contentTypes []ContentType) error {
+	for i, contentType := range contentTypes {
+		if !contentTypeName.MatchString(contentType.Name) {
+			return fmt.Errorf("invalid config.content_types[%d].name: %s. It must be lowercase letters, digits or underscores, starting with a letter.", i, contentType.Name)
+		}
+		if _, _, err := mime.ParseMediaType(contentType.ContentType); err != nil {
+			return fmt.Errorf("invalid config.content_types[%d].content_type: %s. It must be a media type such as text/html.", i, contentType.ContentType)
+		}
+		switch contentType.Encoding {
+		case "", utils.ENCODING_RAW, utils.ENCODING_BASE64:
+		default:
+			return fmt.Errorf(`invalid config.content_types[%d].encoding: %s. It must be "raw" or "base64".`, i, contentType.Encoding)
+		}
+		if _, ok := utils.ContentValidators[contentType.Validator]; contentType.Validator != "" && !ok {
+			return fmt.Errorf(`invalid config.content_types[%d].validator: %s. It must be "json", "xml", "utf8" or empty.`, i, contentType.Validator)
+		}
+	}
+	if _, err := NewContentTypes(contentTypes); err != nil {
+		return fmt.Errorf("invalid config.content_types: %v.", err)
+	}
+
+	for i, contentType := range contentTypes {
+		log.Infof("config.content_types[%d]: %s served as %s, %s encoding, validator: %q", i, contentType.Name, contentType.ContentType, contentType.encoding(), contentType.Validator)
+	}
+	return nil
+}
+
+func (cfg ContentType) encoding() string {
+	if cfg.Encoding == "" {
+		return utils.ENCODING_RAW
+	}
+	return cfg.Encoding
+}
+
+// NewContentTypes returns the built-in content types along with the ones of contentTypes
+func NewContentTypes(contentTypes []ContentType) (*utils.ContentTypes, error) {
+	types := make([]utils.ContentType, 0, len(contentTypes))
+	for _, contentType := range contentTypes {
+		types = append(types, utils.ContentType{
+			Name:      contentType.Name,
+			MediaType: contentType.ContentType,
+			Encoding:  contentType.encoding(),
+			Validate:  utils.ContentValidators[contentType.Validator],
+		})
+	}
+	return utils.NewContentTypes(types...)
+}
+
+// JSONSchema holds the settings of a JSON Schema the values of some content types must match, such
+// as an OpenRTB bid object
+type JSONSchema struct {
+	Name string `mapstructure:"name"`
+	// File is the path of the schema. The references it makes to other schema files are resolved
+	// relative to it.
+	File string `mapstructure:"file"`
+	// Types are the content types whose values must match the schema: "json", or content types with
+	// the "json" validator and raw encoding
+	Types []string `mapstructure:"types"`
+}
+
+func (cfg *Configuration) validateAndLogJSONSchemas() error {
+	var errs ValidationErrors
+
+	for i, schema := range cfg.JSONSchemas {
+		if schema.Name == "" {
+			errs.add(fmt.Errorf("invalid config.json_schemas[%d].name: %s. It cannot be empty.", i, schema.Name))
+		}
+		if len(schema.Types) == 0 {
+			errs.add(fmt.Errorf("invalid config.json_schemas[%d].types: %v. It must list at least one content type.", i, schema.Types))
+		}
+		for _, contentType := range schema.Types {
+			if !cfg.hasJSONContentType(contentType) {
+				errs.add(fmt.Errorf(`invalid config.json_schemas[%d].types: %s. It must be "json" or a content type with the "json" validator and raw encoding.`, i, contentType))
+			}
+		}
+		if _, err := utils.NewJSONSchema(schema.Name, schema.File); err != nil {
+			errs.add(fmt.Errorf("invalid config.json_schemas[%d].file: %s. %v.", i, schema.File, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// The entries are valid, so what's left to check is that no content type matches two schemas
+	if _, err := NewJSONSchemas(cfg.JSONSchemas); err != nil {
+		return fmt.Errorf("invalid config.json_schemas: %v.", err)
+	}
+
+	for i, schema := range cfg.JSONSchemas {
+		log.Infof("config.json_schemas[%d]: %s from %s, for types %v", i, schema.Name, schema.File, schema.Types)
+	}
+	return nil
+}
+
+// hasJSONContentType returns whether the values of contentType are raw JSON values
+func (cfg *Configuration) hasJSONContentType(contentType string) bool {
+	if contentType == utils.JSON_PREFIX {
+		return true
+	}
+	for _, configured := range cfg.ContentTypes {
+		if configured.Name == contentType {
+			return configured.Validator == "json" && configured.encoding() == utils.ENCODING_RAW
+		}
+	}
+	return false
+}
+
+// NewJSONSchemas compiles the JSON schemas and returns them by content type
+func NewJSONSchemas(schemas []JSONSchema) (*utils.JSONSchemas, error) {
+	jsonSchemas := utils.NewJSONSchemas()
+	for _, schema := range schemas {
+		compiled, err := utils.NewJSONSchema(schema.Name, schema.File)
+		if err != nil {
+			return nil, err
+		}
+		if err := jsonSchemas.Add(compiled, schema.Types...); err != nil {
+			return nil, err
+		}
+	}
+	return jsonSchemas, nil
+}
+
 type CompressionType string
 
 const (
@@ -260,15 +938,16 @@ type Metrics struct {
 	Prometheus PrometheusMetrics `mapstructure:"prometheus"`
 }
 
-func (cfg *Metrics) validateAndLog() {
+func (cfg *Metrics) validateAndLog() error {
+	var errs ValidationErrors
 
 	if cfg.Type == MetricsInflux || cfg.Influx.Enabled {
-		cfg.Influx.validateAndLog()
+		errs.add(cfg.Influx.validateAndLog())
 		cfg.Influx.Enabled = true
 	}
 
 	if cfg.Prometheus.Enabled {
-		cfg.Prometheus.validateAndLog()
+		errs.add(cfg.Prometheus.validateAndLog())
 		cfg.Prometheus.Enabled = true
 	}
 
@@ -285,10 +964,12 @@ func (cfg *Metrics) validateAndLog() {
 			log.Infof("Prebid Cache will run without unsupported metrics \"%s\".", cfg.Type)
 		} else {
 			// The only metrics engine specified in the configuration file is a non-supported
-			// metrics engine. We should log error and exit program
-			log.Fatalf("Metrics \"%s\" are not supported, exiting program.", cfg.Type)
+			// metrics engine
+			errs.add(fmt.Errorf("Metrics \"%s\" are not supported.", cfg.Type))
 		}
 	}
+
+	return errs.toError()
 }
 
 type MetricsType string
@@ -308,16 +989,20 @@ type InfluxMetrics struct {
 	AlignTimestamps bool   `mapstructure:"align_timestamps"`
 }
 
-func (influxMetricsConfig *InfluxMetrics) validateAndLog() {
+func (influxMetricsConfig *InfluxMetrics) validateAndLog() error {
 	// validate
+	var errs ValidationErrors
 	if influxMetricsConfig.Host == "" {
-		log.Fatalf(`Despite being enabled, influx metrics came with no host info: config.metrics.influx.host = "".`)
+		errs.add(errors.New(`Despite being enabled, influx metrics came with no host info: config.metrics.influx.host = "".`))
 	}
 	if influxMetricsConfig.Database == "" {
-		log.Fatalf(`Despite being enabled, influx metrics came with no database info: config.metrics.influx.database = "".`)
+		errs.add(errors.New(`Despite being enabled, influx metrics came with no database info: config.metrics.influx.database = "".`))
 	}
 	if influxMetricsConfig.Measurement == "" {
-		log.Fatalf(`Despite being enabled, influx metrics came with no measurement info: config.metrics.influx.measurement = "".`)
+		errs.add(errors.New(`Despite being enabled, influx metrics came with no measurement info: config.metrics.influx.measurement = "".`))
+	}
+	if len(errs) > 0 {
+		return errs
 	}
 
 	// log
@@ -325,6 +1010,7 @@ func (influxMetricsConfig *InfluxMetrics) validateAndLog() {
 	log.Infof("config.metrics.influx.database: %s", influxMetricsConfig.Database)
 	log.Infof("config.metrics.influx.measurement: %s", influxMetricsConfig.Measurement)
 	log.Infof("config.metrics.influx.align_timestamps: %v", influxMetricsConfig.AlignTimestamps)
+	return nil
 }
 
 type PrometheusMetrics struct {
@@ -336,14 +1022,15 @@ type PrometheusMetrics struct {
 }
 
 // validateAndLog will error out when the value of port is 0
-func (promMetricsConfig *PrometheusMetrics) validateAndLog() {
+func (promMetricsConfig *PrometheusMetrics) validateAndLog() error {
 	if promMetricsConfig.Port == 0 {
-		log.Fatalf(`Despite being enabled, prometheus metrics came with an empty port number: config.metrics.prometheus.port = 0`)
+		return errors.New(`Despite being enabled, prometheus metrics came with an empty port number: config.metrics.prometheus.port = 0`)
 	}
 
 	log.Infof("config.metrics.prometheus.namespace: %s", promMetricsConfig.Namespace)
 	log.Infof("config.metrics.prometheus.subsystem: %s", promMetricsConfig.Subsystem)
 	log.Infof("config.metrics.prometheus.port: %d", promMetricsConfig.Port)
+	return nil
 }
 
 func (m *PrometheusMetrics) Timeout() time.Duration {
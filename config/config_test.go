@@ -1,6 +1,7 @@
 package config
 
 import (
+	"crypto/tls"
 	"os"
 	"path/filepath"
 	"strings"
@@ -68,6 +69,14 @@ func TestLogValidateAndLog(t *testing.T) {
 	assert.Nil(t, hook.LastEntry())
 }
 
+func TestLogValidateAndLogInvalidLevel(t *testing.T) {
+	configLogObject := Log{Level: "verbose"}
+
+	err := configLogObject.validateAndLog()
+
+	assertValidationErrors(t, []string{`invalid config.log.level: verbose. It must be "trace", "debug", "info", "warning", "error", "fatal" or "panic"`}, err)
+}
+
 func TestCheckMetricsEnabled(t *testing.T) {
 
 	// Structure to hold the expected log entry values
@@ -199,12 +208,6 @@ func TestCheckMetricsEnabled(t *testing.T) {
 			prometheusEnabled: false,
 			metricType:        "unknown",
 			expectedError:     true,
-			expectedLogInfo: []logComponents{
-				{
-					msg: "Metrics \"unknown\" are not supported, exiting program.",
-					lvl: logrus.FatalLevel,
-				},
-			},
 		},
 		{
 			description:       "[10] metricType = \"unknown\"; prometheus flags on.",
@@ -307,22 +310,14 @@ func TestCheckMetricsEnabled(t *testing.T) {
 	// logrus entries will be recorded to this `hook` object so we can compare and assert them
 	hook := testLogrus.NewGlobal()
 
-	//substitute logger exit function so execution doesn't get interrupted when log.Fatalf() call comes
-	defer func() { logrus.StandardLogger().ExitFunc = nil }()
-	var fatal bool
-	logrus.StandardLogger().ExitFunc = func(int) { fatal = true }
-
 	for i, tc := range testCases {
-		// Reset the fatal flag to false every test
-		fatal = false
-
 		// Set test flags in metrics object
 		cfg.Type = tc.metricType
 		cfg.Influx.Enabled = tc.influxEnabled
 		cfg.Prometheus.Enabled = tc.prometheusEnabled
 
 		//run test
-		cfg.validateAndLog()
+		err := cfg.validateAndLog()
 
 		// Assert logrus expected entries
 		if assert.Equal(t, len(tc.expectedLogInfo), len(hook.Entries), "Incorrect number of entries were logged to logrus in test %d: len(tc.expectedLogInfo) = %d len(hook.Entries) = %d", i+1, len(tc.expectedLogInfo), len(hook.Entries)) {
@@ -334,8 +329,12 @@ func TestCheckMetricsEnabled(t *testing.T) {
 			return
 		}
 
-		// Assert log.Fatalf() was called or not
-		assert.Equal(t, tc.expectedError, fatal, "Test case %d failed.", i+1)
+		// Assert an error was returned or not
+		if tc.expectedError {
+			assert.EqualError(t, err, "Metrics \"unknown\" are not supported.", "Test case %d failed.", i+1)
+		} else {
+			assert.NoError(t, err, "Test case %d failed.", i+1)
+		}
 
 		//Reset log after every test and assert successful reset
 		hook.Reset()
@@ -494,7 +493,7 @@ func TestInfluxValidateAndLog(t *testing.T) {
 		// In
 		influxConfig *InfluxMetrics
 		//out
-		expectError     bool
+		expectedErrors  []string
 		expectedLogInfo []logComponents
 	}
 	testCases := []aTest{
@@ -505,15 +504,10 @@ func TestInfluxValidateAndLog(t *testing.T) {
 				Database:    "",
 				Measurement: "",
 			},
-			expectError: true,
-			expectedLogInfo: []logComponents{
-				{lvl: logrus.FatalLevel, msg: `Despite being enabled, influx metrics came with no host info: config.metrics.influx.host = "".`},
-				{lvl: logrus.FatalLevel, msg: `Despite being enabled, influx metrics came with no database info: config.metrics.influx.database = "".`},
-				{lvl: logrus.FatalLevel, msg: `Despite being enabled, influx metrics came with no measurement info: config.metrics.influx.measurement = "".`},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.host: "},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.database: "},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.measurement: "},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.align_timestamps: false"},
+			expectedErrors: []string{
+				`Despite being enabled, influx metrics came with no host info: config.metrics.influx.host = "".`,
+				`Despite being enabled, influx metrics came with no database info: config.metrics.influx.database = "".`,
+				`Despite being enabled, influx metrics came with no measurement info: config.metrics.influx.measurement = "".`,
 			},
 		},
 		{
@@ -523,13 +517,8 @@ func TestInfluxValidateAndLog(t *testing.T) {
 				Database:    "database-value",
 				Measurement: "measurement-value",
 			},
-			expectError: true,
-			expectedLogInfo: []logComponents{
-				{lvl: logrus.FatalLevel, msg: `Despite being enabled, influx metrics came with no host info: config.metrics.influx.host = "".`},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.host: "},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.database: database-value"},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.measurement: measurement-value"},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.align_timestamps: false"},
+			expectedErrors: []string{
+				`Despite being enabled, influx metrics came with no host info: config.metrics.influx.host = "".`,
 			},
 		},
 		{
@@ -539,13 +528,8 @@ func TestInfluxValidateAndLog(t *testing.T) {
 				Database:    "",
 				Measurement: "measurement-value",
 			},
-			expectError: true,
-			expectedLogInfo: []logComponents{
-				{lvl: logrus.FatalLevel, msg: `Despite being enabled, influx metrics came with no database info: config.metrics.influx.database = "".`},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.host: http://fakeurl.com"},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.database: "},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.measurement: measurement-value"},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.align_timestamps: false"},
+			expectedErrors: []string{
+				`Despite being enabled, influx metrics came with no database info: config.metrics.influx.database = "".`,
 			},
 		},
 		{
@@ -555,13 +539,8 @@ func TestInfluxValidateAndLog(t *testing.T) {
 				Database:    "database-value",
 				Measurement: "",
 			},
-			expectError: true,
-			expectedLogInfo: []logComponents{
-				{lvl: logrus.FatalLevel, msg: `Despite being enabled, influx metrics came with no measurement info: config.metrics.influx.measurement = "".`},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.host: http://fakeurl.com"},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.database: database-value"},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.measurement: "},
-				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.align_timestamps: false"},
+			expectedErrors: []string{
+				`Despite being enabled, influx metrics came with no measurement info: config.metrics.influx.measurement = "".`,
 			},
 		},
 		{
@@ -572,7 +551,6 @@ func TestInfluxValidateAndLog(t *testing.T) {
 				Measurement:     "measurement-value",
 				AlignTimestamps: true,
 			},
-			expectError: false,
 			expectedLogInfo: []logComponents{
 				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.host: http://fakeurl.com"},
 				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.database: database-value"},
@@ -588,7 +566,6 @@ func TestInfluxValidateAndLog(t *testing.T) {
 				Measurement:     "measurement-value",
 				AlignTimestamps: true,
 			},
-			expectError: false,
 			expectedLogInfo: []logComponents{
 				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.host: http://fakeurl.com"},
 				{lvl: logrus.InfoLevel, msg: "config.metrics.influx.database: database-value"},
@@ -598,17 +575,9 @@ func TestInfluxValidateAndLog(t *testing.T) {
 		},
 	}
 
-	//substitute logger exit function so execution doesn't get interrupted
-	defer func() { logrus.StandardLogger().ExitFunc = nil }()
-	var fatal bool
-	logrus.StandardLogger().ExitFunc = func(int) { fatal = true }
-
 	for j, tc := range testCases {
-		// Reset the fatal flag to false every test
-		fatal = false
-
 		//run test
-		tc.influxConfig.validateAndLog()
+		err := tc.influxConfig.validateAndLog()
 
 		// Assert logrus expected entries
 		if assert.Equal(t, len(tc.expectedLogInfo), len(hook.Entries), "Incorrect number of entries were logged to logrus in test %d: len(tc.expectedLogInfo) = %d len(hook.Entries) = %d", j, len(tc.expectedLogInfo), len(hook.Entries)) {
@@ -620,8 +589,8 @@ func TestInfluxValidateAndLog(t *testing.T) {
 			return
 		}
 
-		// Assert log.Fatalf() was called or not
-		assert.Equal(t, tc.expectError, fatal)
+		// Assert the expected errors were returned
+		assertValidationErrors(t, tc.expectedErrors, err, "Test case %d failed", j)
 
 		//Reset log after every test and assert successful reset
 		hook.Reset()
@@ -640,7 +609,7 @@ func TestPrometheusValidateAndLog(t *testing.T) {
 		// In
 		prometheusConfig *PrometheusMetrics
 		//out
-		expectError     bool
+		expectedErrors  []string
 		expectedLogInfo []logComponents
 	}
 	testCases := []aTest{
@@ -651,24 +620,8 @@ func TestPrometheusValidateAndLog(t *testing.T) {
 				Namespace: "prebid",
 				Subsystem: "cache",
 			},
-			expectError: true,
-			expectedLogInfo: []logComponents{
-				{
-					msg: `Despite being enabled, prometheus metrics came with an empty port number: config.metrics.prometheus.port = 0`,
-					lvl: logrus.FatalLevel,
-				},
-				{
-					msg: "config.metrics.prometheus.namespace: prebid",
-					lvl: logrus.InfoLevel,
-				},
-				{
-					msg: "config.metrics.prometheus.subsystem: cache",
-					lvl: logrus.InfoLevel,
-				},
-				{
-					msg: "config.metrics.prometheus.port: 0",
-					lvl: logrus.InfoLevel,
-				},
+			expectedErrors: []string{
+				`Despite being enabled, prometheus metrics came with an empty port number: config.metrics.prometheus.port = 0`,
 			},
 		},
 		{
@@ -678,7 +631,6 @@ func TestPrometheusValidateAndLog(t *testing.T) {
 				Namespace: "",
 				Subsystem: "cache",
 			},
-			expectError: false,
 			expectedLogInfo: []logComponents{
 				{
 					msg: "config.metrics.prometheus.namespace: ",
@@ -701,7 +653,6 @@ func TestPrometheusValidateAndLog(t *testing.T) {
 				Namespace: "prebid",
 				Subsystem: "",
 			},
-			expectError: false,
 			expectedLogInfo: []logComponents{
 				{
 					msg: "config.metrics.prometheus.namespace: prebid",
@@ -724,7 +675,6 @@ func TestPrometheusValidateAndLog(t *testing.T) {
 				Namespace: "prebid",
 				Subsystem: "cache",
 			},
-			expectError: false,
 			expectedLogInfo: []logComponents{
 				{
 					msg: "config.metrics.prometheus.namespace: prebid",
@@ -747,7 +697,6 @@ func TestPrometheusValidateAndLog(t *testing.T) {
 				Namespace: "",
 				Subsystem: "",
 			},
-			expectError: false,
 			expectedLogInfo: []logComponents{
 				{
 					msg: "config.metrics.prometheus.namespace: ",
@@ -768,17 +717,9 @@ func TestPrometheusValidateAndLog(t *testing.T) {
 	// logrus entries will be recorded to this `hook` object so we can compare and assert them
 	hook := testLogrus.NewGlobal()
 
-	//substitute logger exit function so execution doesn't get interrupted
-	defer func() { logrus.StandardLogger().ExitFunc = nil }()
-	var fatal bool
-	logrus.StandardLogger().ExitFunc = func(int) { fatal = true }
-
 	for _, tc := range testCases {
-		// Reset the fatal flag to false every test
-		fatal = false
-
 		//run test
-		tc.prometheusConfig.validateAndLog()
+		err := tc.prometheusConfig.validateAndLog()
 
 		// Assert logrus expected entries
 		if assert.Equal(t, len(tc.expectedLogInfo), len(hook.Entries), "Incorrect number of entries were logged to logrus in test %s.", tc.description) {
@@ -790,8 +731,8 @@ func TestPrometheusValidateAndLog(t *testing.T) {
 			return
 		}
 
-		// Assert log.Fatalf() was called or not
-		assert.Equal(t, tc.expectError, fatal)
+		// Assert the expected errors were returned
+		assertValidationErrors(t, tc.expectedErrors, err, tc.description)
 
 		//Reset log after every test and assert successful reset
 		hook.Reset()
@@ -813,7 +754,7 @@ func TestRequestLimitsValidateAndLog(t *testing.T) {
 		description        string
 		inRequestLimitsCfg *RequestLimits
 		expectedLogInfo    []logComponents
-		expectFatal        bool
+		expectedErrors     []string
 	}{
 		{
 			description:        "Blank RequestLimits",
@@ -823,8 +764,9 @@ func TestRequestLimitsValidateAndLog(t *testing.T) {
 				{msg: `config.request_limits.max_ttl_seconds: 0`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_size_bytes: 0`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_num_values: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_header_size_bytes: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.validate_xml: off`, lvl: logrus.InfoLevel},
 			},
-			expectFatal: false,
 		},
 		{
 			description:        "allow_setting_keys flag set to true",
@@ -834,80 +776,110 @@ func TestRequestLimitsValidateAndLog(t *testing.T) {
 				{msg: `config.request_limits.max_ttl_seconds: 0`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_size_bytes: 0`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_num_values: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_header_size_bytes: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.validate_xml: off`, lvl: logrus.InfoLevel},
 			},
-			expectFatal: false,
 		},
 		{
-			description:        "Negative max_ttl_seconds, expect fatal level log and early exit",
+			description:        "Negative max_ttl_seconds, expect error",
 			inRequestLimitsCfg: &RequestLimits{MaxTTLSeconds: -1},
 			expectedLogInfo: []logComponents{
 				{msg: `config.request_limits.allow_setting_keys: false`, lvl: logrus.InfoLevel},
-				{msg: `invalid config.request_limits.max_ttl_seconds: -1. Value cannot be negative.`, lvl: logrus.FatalLevel},
+				{msg: `config.request_limits.max_size_bytes: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_num_values: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_header_size_bytes: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.validate_xml: off`, lvl: logrus.InfoLevel},
 			},
-			expectFatal: true,
+			expectedErrors: []string{`invalid config.request_limits.max_ttl_seconds: -1. Value cannot be negative.`},
 		},
 		{
-			description:        "Negative max_size_bytes, expect fatal level log and early exit",
+			description:        "Negative max_size_bytes, expect error",
 			inRequestLimitsCfg: &RequestLimits{MaxSize: -1},
 			expectedLogInfo: []logComponents{
 				{msg: `config.request_limits.allow_setting_keys: false`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_ttl_seconds: 0`, lvl: logrus.InfoLevel},
-				{msg: `invalid config.request_limits.max_size_bytes: -1. Value cannot be negative.`, lvl: logrus.FatalLevel},
+				{msg: `config.request_limits.max_num_values: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_header_size_bytes: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.validate_xml: off`, lvl: logrus.InfoLevel},
 			},
-			expectFatal: true,
+			expectedErrors: []string{`invalid config.request_limits.max_size_bytes: -1. Value cannot be negative.`},
 		},
 		{
-			description:        "Negative max_num_values, expect fatal level log and early exit",
+			description:        "Negative max_num_values, expect error",
 			inRequestLimitsCfg: &RequestLimits{MaxNumValues: -1},
 			expectedLogInfo: []logComponents{
 				{msg: `config.request_limits.allow_setting_keys: false`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_ttl_seconds: 0`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_size_bytes: 0`, lvl: logrus.InfoLevel},
-				{msg: `invalid config.request_limits.max_num_values: -1. Value cannot be negative.`, lvl: logrus.FatalLevel},
+				{msg: `config.request_limits.max_header_size_bytes: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.validate_xml: off`, lvl: logrus.InfoLevel},
 			},
-			expectFatal: true,
+			expectedErrors: []string{`invalid config.request_limits.max_num_values: -1. Value cannot be negative.`},
 		},
 		{
-			description:        "Negative max_header_size_bytes, expect fatal level log and early exit",
+			description:        "Negative max_header_size_bytes, expect error",
 			inRequestLimitsCfg: &RequestLimits{MaxHeaderSize: -1},
 			expectedLogInfo: []logComponents{
 				{msg: `config.request_limits.allow_setting_keys: false`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_ttl_seconds: 0`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_size_bytes: 0`, lvl: logrus.InfoLevel},
 				{msg: `config.request_limits.max_num_values: 0`, lvl: logrus.InfoLevel},
-				{msg: `invalid config.request_limits.max_header_size_bytes: -1. Value cannot be negative.`, lvl: logrus.FatalLevel},
+				{msg: `config.request_limits.validate_xml: off`, lvl: logrus.InfoLevel},
 			},
-			expectFatal: true,
+			expectedErrors: []string{`invalid config.request_limits.max_header_size_bytes: -1. Value cannot be negative.`},
+		},
+		{
+			description:        "Every value is negative, expect all errors",
+			inRequestLimitsCfg: &RequestLimits{MaxTTLSeconds: -1, MaxSize: -1, MaxNumValues: -1, MaxHeaderSize: -1},
+			expectedLogInfo: []logComponents{
+				{msg: `config.request_limits.allow_setting_keys: false`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.validate_xml: off`, lvl: logrus.InfoLevel},
+			},
+			expectedErrors: []string{
+				`invalid config.request_limits.max_ttl_seconds: -1. Value cannot be negative.`,
+				`invalid config.request_limits.max_size_bytes: -1. Value cannot be negative.`,
+				`invalid config.request_limits.max_num_values: -1. Value cannot be negative.`,
+				`invalid config.request_limits.max_header_size_bytes: -1. Value cannot be negative.`,
+			},
+		},
+		{
+			description:        "XML values validated and rejected",
+			inRequestLimitsCfg: &RequestLimits{ValidateXML: ValidateXMLReject},
+			expectedLogInfo: []logComponents{
+				{msg: `config.request_limits.allow_setting_keys: false`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_ttl_seconds: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_size_bytes: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_num_values: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_header_size_bytes: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.validate_xml: reject`, lvl: logrus.InfoLevel},
+			},
+		},
+		{
+			description:        "Unknown validate_xml mode, expect error",
+			inRequestLimitsCfg: &RequestLimits{ValidateXML: "strict"},
+			expectedLogInfo: []logComponents{
+				{msg: `config.request_limits.allow_setting_keys: false`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_ttl_seconds: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_size_bytes: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_num_values: 0`, lvl: logrus.InfoLevel},
+				{msg: `config.request_limits.max_header_size_bytes: 0`, lvl: logrus.InfoLevel},
+			},
+			expectedErrors: []string{`invalid config.request_limits.validate_xml: strict. It must be "off", "log" or "reject".`},
 		},
 	}
 
-	//substitute logger exit function so execution doesn't get interrupted
-	defer func() { logrus.StandardLogger().ExitFunc = nil }()
-	var fatal bool
-	logrus.StandardLogger().ExitFunc = func(int) { fatal = true }
-
 	for _, tc := range testCases {
-		// Reset the fatal flag to false every test
-		fatal = false
-
 		// Run test
-		tc.inRequestLimitsCfg.validateAndLog()
+		err := tc.inRequestLimitsCfg.validateAndLog()
 
 		// Assert logrus expected entries
-		logEntryCount := 0
-		for i := 0; i < len(tc.expectedLogInfo); i++ {
-			assert.Equal(t, tc.expectedLogInfo[i].msg, hook.Entries[i].Message, tc.description+":message")
-			assert.Equal(t, tc.expectedLogInfo[i].lvl, hook.Entries[i].Level, tc.description+":log level")
-
-			logEntryCount++
-			if tc.expectedLogInfo[i].lvl == logrus.FatalLevel {
-				break
+		if assert.Len(t, hook.Entries, len(tc.expectedLogInfo), tc.description) {
+			for i := 0; i < len(tc.expectedLogInfo); i++ {
+				assert.Equal(t, tc.expectedLogInfo[i].msg, hook.Entries[i].Message, tc.description+":message")
+				assert.Equal(t, tc.expectedLogInfo[i].lvl, hook.Entries[i].Level, tc.description+":log level")
 			}
 		}
-		if tc.expectedLogInfo[logEntryCount-1].lvl == logrus.FatalLevel && !fatal {
-			t.Errorf("Log level fatal was expected. %s", tc.description)
-		}
-		assert.Len(t, tc.expectedLogInfo, logEntryCount, tc.description)
+		assertValidationErrors(t, tc.expectedErrors, err, tc.description)
 
 		//Reset log after every test and assert successful reset
 		hook.Reset()
@@ -927,24 +899,21 @@ func TestRequestLogging(t *testing.T) {
 		name                string
 		inRequestLoggingCfg *RequestLogging
 		expectedLogInfo     []logComponents
+		expectedErrors      []string
 	}{
 		{
-			name: "invalid_negative", // must be greater or equal to zero. Expect fatal log
+			name: "invalid_negative", // must be greater or equal to zero. Expect error
 			inRequestLoggingCfg: &RequestLogging{
 				RefererSamplingRate: -0.1,
 			},
-			expectedLogInfo: []logComponents{
-				{msg: `invalid config.request_logging.referer_sampling_rate: value must be positive and not greater than 1.0. Got -0.1`, lvl: logrus.FatalLevel},
-			},
+			expectedErrors: []string{`invalid config.request_logging.referer_sampling_rate: value must be positive and not greater than 1.0. Got -0.1`},
 		},
 		{
-			name: "invalid_high", // must be less than or equal to 1. expect fatal log.
+			name: "invalid_high", // must be less than or equal to 1. Expect error
 			inRequestLoggingCfg: &RequestLogging{
 				RefererSamplingRate: 1.1,
 			},
-			expectedLogInfo: []logComponents{
-				{msg: `invalid config.request_logging.referer_sampling_rate: value must be positive and not greater than 1.0. Got 1.1`, lvl: logrus.FatalLevel},
-			},
+			expectedErrors: []string{`invalid config.request_logging.referer_sampling_rate: value must be positive and not greater than 1.0. Got 1.1`},
 		},
 		{
 			name: "valid_one", // sampling rate of 1.0 is between the acceptable threshold. Expect info log"
@@ -975,13 +944,10 @@ func TestRequestLogging(t *testing.T) {
 		},
 	}
 
-	//substitute logger exit function so execution doesn't get interrupted
-	defer func() { logrus.StandardLogger().ExitFunc = nil }()
-	logrus.StandardLogger().ExitFunc = func(int) {}
-
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			tc.inRequestLoggingCfg.validateAndLog()
+			err := tc.inRequestLoggingCfg.validateAndLog()
+			assertValidationErrors(t, tc.expectedErrors, err, tc.name)
 
 			// assertions
 			require.Len(t, hook.Entries, len(tc.expectedLogInfo), tc.name+":log_entries")
@@ -998,6 +964,192 @@ func TestRequestLogging(t *testing.T) {
 	}
 }
 
+func TestCORSValidateAndLog(t *testing.T) {
+	hook := testLogrus.NewGlobal()
+
+	testCases := []struct {
+		desc            string
+		inCORS          CORS
+		expectedLogMsgs []string
+	}{
+		{
+			desc:   "No allowed origins, any origin is allowed. Expect no log",
+			inCORS: CORS{AllowedOrigins: []string{}},
+		},
+		{
+			desc:            "Allowed origins",
+			inCORS:          CORS{AllowedOrigins: []string{"https://prebid.org"}},
+			expectedLogMsgs: []string{"config.cors.allowed_origins: [https://prebid.org]"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc.inCORS.validateAndLog()
+
+		if assert.Len(t, hook.Entries, len(tc.expectedLogMsgs), tc.desc) {
+			for i, msg := range tc.expectedLogMsgs {
+				assert.Equal(t, msg, hook.Entries[i].Message, tc.desc)
+			}
+		}
+		hook.Reset()
+	}
+}
+
+func TestVASTWrapperValidateAndLog(t *testing.T) {
+	hook := testLogrus.NewGlobal()
+
+	testCases := []struct {
+		desc            string
+		inVASTWrapper   VASTWrapper
+		expectedErrors  []string
+		expectedLogMsgs []string
+	}{
+		{
+			desc:          "Nothing configured. Expect no log",
+			inVASTWrapper: VASTWrapper{ImpressionURLs: []string{}, ErrorURLs: []string{}},
+		},
+		{
+			desc: "Valid URLs",
+			inVASTWrapper: VASTWrapper{
+				Enabled:        true,
+				BaseURL:        "https://cache.prebid.org/",
+				ImpressionURLs: []string{"https://tracking.prebid.org/impression"},
+				ErrorURLs:      []string{"http://tracking.prebid.org/error?code=[ERRORCODE]"},
+			},
+			expectedLogMsgs: []string{
+				"config.vast_wrapper.enabled: true",
+				"config.vast_wrapper.base_url: https://cache.prebid.org/",
+				"config.vast_wrapper.impression_urls: [https://tracking.prebid.org/impression]",
+				"config.vast_wrapper.error_urls: [http://tracking.prebid.org/error?code=[ERRORCODE]]",
+			},
+		},
+		{
+			desc:            "Enabled without a base URL",
+			inVASTWrapper:   VASTWrapper{Enabled: true},
+			expectedErrors:  []string{"invalid config.vast_wrapper.base_url: the base URL cannot be empty when VAST wrappers are enabled."},
+			expectedLogMsgs: []string{"config.vast_wrapper.enabled: true"},
+		},
+		{
+			desc:           "Relative base URL",
+			inVASTWrapper:  VASTWrapper{BaseURL: "cache.prebid.org"},
+			expectedErrors: []string{"invalid config.vast_wrapper.base_url: cache.prebid.org. It must be an absolute http or https URL without a query."},
+		},
+		{
+			desc:           "Base URL with a query",
+			inVASTWrapper:  VASTWrapper{BaseURL: "https://cache.prebid.org?a=b"},
+			expectedErrors: []string{"invalid config.vast_wrapper.base_url: https://cache.prebid.org?a=b. It must be an absolute http or https URL without a query."},
+		},
+		{
+			desc: "Tracking URLs that aren't http or https",
+			inVASTWrapper: VASTWrapper{
+				ImpressionURLs: []string{"https://tracking.prebid.org/impression", "ftp://tracking.prebid.org"},
+				ErrorURLs:      []string{"/error"},
+			},
+			expectedErrors: []string{
+				"invalid config.vast_wrapper.impression_urls[1]: ftp://tracking.prebid.org. It must be an absolute http or https URL.",
+				"invalid config.vast_wrapper.error_urls[0]: /error. It must be an absolute http or https URL.",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.inVASTWrapper.validateAndLog()
+
+		if len(tc.expectedErrors) == 0 {
+			assert.NoError(t, err, tc.desc)
+		} else if assert.Error(t, err, tc.desc) {
+			assert.Equal(t, strings.Join(tc.expectedErrors, "\n"), err.Error(), tc.desc)
+		}
+		if assert.Len(t, hook.Entries, len(tc.expectedLogMsgs), tc.desc) {
+			for i, msg := range tc.expectedLogMsgs {
+				assert.Equal(t, msg, hook.Entries[i].Message, tc.desc)
+			}
+		}
+		hook.Reset()
+	}
+}
+
+func TestHealthCheckValidateAndLog(t *testing.T) {
+	hook := testLogrus.NewGlobal()
+
+	type logComponents struct {
+		msg string
+		lvl logrus.Level
+	}
+
+	testCases := []struct {
+		name            string
+		inHealthCheck   *HealthCheck
+		expectedLogInfo []logComponents
+		expectedErrors  []string
+	}{
+		{
+			name:          "disabled",
+			inHealthCheck: &HealthCheck{},
+			expectedLogInfo: []logComponents{
+				{msg: `config.health_check.enabled: false`, lvl: logrus.InfoLevel},
+				{msg: `config.health_check.drain_delay_ms: 0`, lvl: logrus.InfoLevel},
+			},
+		},
+		{
+			name:          "enabled",
+			inHealthCheck: &HealthCheck{Enabled: true, IntervalMillis: 5000, TimeoutMillis: 1000, DrainDelayMillis: 10000},
+			expectedLogInfo: []logComponents{
+				{msg: `config.health_check.enabled: true`, lvl: logrus.InfoLevel},
+				{msg: `config.health_check.interval_ms: 5000`, lvl: logrus.InfoLevel},
+				{msg: `config.health_check.timeout_ms: 1000`, lvl: logrus.InfoLevel},
+				{msg: `config.health_check.drain_delay_ms: 10000`, lvl: logrus.InfoLevel},
+			},
+		},
+		{
+			name:          "enabled_zero_interval",
+			inHealthCheck: &HealthCheck{Enabled: true, IntervalMillis: 0, TimeoutMillis: 1000},
+			expectedLogInfo: []logComponents{
+				{msg: `config.health_check.enabled: true`, lvl: logrus.InfoLevel},
+				{msg: `config.health_check.timeout_ms: 1000`, lvl: logrus.InfoLevel},
+				{msg: `config.health_check.drain_delay_ms: 0`, lvl: logrus.InfoLevel},
+			},
+			expectedErrors: []string{`invalid config.health_check.interval_ms: 0. Value must be greater than zero.`},
+		},
+		{
+			name:          "enabled_negative_timeout",
+			inHealthCheck: &HealthCheck{Enabled: true, IntervalMillis: 5000, TimeoutMillis: -1},
+			expectedLogInfo: []logComponents{
+				{msg: `config.health_check.enabled: true`, lvl: logrus.InfoLevel},
+				{msg: `config.health_check.interval_ms: 5000`, lvl: logrus.InfoLevel},
+				{msg: `config.health_check.drain_delay_ms: 0`, lvl: logrus.InfoLevel},
+			},
+			expectedErrors: []string{`invalid config.health_check.timeout_ms: -1. Value must be greater than zero.`},
+		},
+		{
+			name:          "negative_drain_delay",
+			inHealthCheck: &HealthCheck{DrainDelayMillis: -1},
+			expectedLogInfo: []logComponents{
+				{msg: `config.health_check.enabled: false`, lvl: logrus.InfoLevel},
+			},
+			expectedErrors: []string{`invalid config.health_check.drain_delay_ms: -1. Value cannot be negative.`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.inHealthCheck.validateAndLog()
+			assertValidationErrors(t, tc.expectedErrors, err, tc.name)
+
+			// assertions
+			require.Len(t, hook.Entries, len(tc.expectedLogInfo), tc.name+":log_entries")
+			for i := 0; i < len(tc.expectedLogInfo); i++ {
+				assert.Equal(t, tc.expectedLogInfo[i].msg, hook.Entries[i].Message, tc.name+":message")
+				assert.Equal(t, tc.expectedLogInfo[i].lvl, hook.Entries[i].Level, tc.name+":log_level")
+			}
+
+			//Reset log after every test and assert successful reset
+			hook.Reset()
+			assert.Nil(t, hook.LastEntry())
+		})
+	}
+}
+
 func TestCompressionValidateAndLog(t *testing.T) {
 
 	// logrus entries will be recorded to this `hook` object so we can compare and assert them
@@ -1013,14 +1165,13 @@ func TestCompressionValidateAndLog(t *testing.T) {
 		inCompressionCfg *Compression
 		inBackendType    BackendType
 		expectedLogInfo  []logComponents
+		expectedErrors   []string
 	}{
 		{
-			description:      "Blank compression type, expect fatal level log entry",
+			description:      "Blank compression type, expect error",
 			inCompressionCfg: &Compression{Type: CompressionType("")},
 			inBackendType:    BackendMemory,
-			expectedLogInfo: []logComponents{
-				{msg: `invalid config.compression.type: . It must be "none" or "snappy"`, lvl: logrus.FatalLevel},
-			},
+			expectedErrors:   []string{`invalid config.compression.type: . It must be "none" or "snappy"`},
 		},
 		{
 			description:      "Valid compression type 'none', expect info level log entry",
@@ -1039,22 +1190,17 @@ func TestCompressionValidateAndLog(t *testing.T) {
 			},
 		},
 		{
-			description:      "Unsupported compression, expect fatal level log entry",
+			description:      "Unsupported compression, expect error",
 			inCompressionCfg: &Compression{Type: CompressionType("UnknownCompressionType")},
 			inBackendType:    BackendMemory,
-			expectedLogInfo: []logComponents{
-				{msg: `invalid config.compression.type: UnknownCompressionType. It must be "none" or "snappy"`, lvl: logrus.FatalLevel},
-			},
+			expectedErrors:   []string{`invalid config.compression.type: UnknownCompressionType. It must be "none" or "snappy"`},
 		},
 	}
 
-	//substitute logger exit function so execution doesn't get interrupted
-	defer func() { logrus.StandardLogger().ExitFunc = nil }()
-	logrus.StandardLogger().ExitFunc = func(int) {}
-
 	for _, tc := range testCases {
 		// Run test
-		tc.inCompressionCfg.validateAndLog()
+		err := tc.inCompressionCfg.validateAndLog()
+		assertValidationErrors(t, tc.expectedErrors, err, tc.description)
 
 		// Assert logrus expected entries
 		if assert.Len(t, hook.Entries, len(tc.expectedLogInfo), tc.description) {
@@ -1166,6 +1312,8 @@ func TestConfigurationValidateAndLog(t *testing.T) {
 	expectedLogInfo := []logComponents{
 		{msg: "config.port: 2424", lvl: logrus.InfoLevel},
 		{msg: "config.admin_port: 2525", lvl: logrus.InfoLevel},
+		{msg: "config.tls.enabled: false", lvl: logrus.InfoLevel},
+		{msg: "config.admin_tls.enabled: false", lvl: logrus.InfoLevel},
 		{msg: "config.log.level: info", lvl: logrus.InfoLevel},
 		{msg: "config.rate_limiter.enabled: true", lvl: logrus.InfoLevel},
 		{msg: "config.rate_limiter.num_requests: 100", lvl: logrus.InfoLevel},
@@ -1174,7 +1322,10 @@ func TestConfigurationValidateAndLog(t *testing.T) {
 		{msg: "config.request_limits.max_size_bytes: 10240", lvl: logrus.InfoLevel},
 		{msg: "config.request_limits.max_num_values: 10", lvl: logrus.InfoLevel},
 		{msg: "config.request_limits.max_header_size_bytes: 1048576", lvl: logrus.InfoLevel},
+		{msg: "config.request_limits.validate_xml: off", lvl: logrus.InfoLevel},
 		{msg: "config.request_logging.referer_sampling_rate: 0", lvl: logrus.InfoLevel},
+		{msg: "config.health_check.enabled: false", lvl: logrus.InfoLevel},
+		{msg: "config.health_check.drain_delay_ms: 0", lvl: logrus.InfoLevel},
 		{msg: "config.backend.type: memory", lvl: logrus.InfoLevel},
 		{msg: "config.compression.type: snappy", lvl: logrus.InfoLevel},
 		{msg: "Prebid Cache will run without metrics", lvl: logrus.InfoLevel},
@@ -1196,6 +1347,204 @@ func TestConfigurationValidateAndLog(t *testing.T) {
 	assert.Nil(t, hook.LastEntry())
 }
 
+func TestTLSValidateAndLog(t *testing.T) {
+	// logrus entries will be recorded to this `hook` object so we can compare and assert them
+	hook := testLogrus.NewGlobal()
+
+	type logComponents struct {
+		msg string
+		lvl logrus.Level
+	}
+
+	testCases := []struct {
+		description     string
+		inTLSConfig     *TLS
+		expectedLogInfo []logComponents
+		expectedErrors  []string
+	}{
+		{
+			description: "TLS disabled, only log the enabled flag",
+			inTLSConfig: &TLS{Enabled: false, CertFile: "cert.pem"},
+			expectedLogInfo: []logComponents{
+				{msg: "config.tls.enabled: false", lvl: logrus.InfoLevel},
+			},
+		},
+		{
+			description: "TLS enabled without a key file, expect error",
+			inTLSConfig: &TLS{Enabled: true, CertFile: "cert.pem"},
+			expectedLogInfo: []logComponents{
+				{msg: "config.tls.enabled: true", lvl: logrus.InfoLevel},
+			},
+			expectedErrors: []string{"invalid config.tls: cert_file and key_file are required when TLS is enabled."},
+		},
+		{
+			description: "Unknown min_version and cipher suite, expect both errors",
+			inTLSConfig: &TLS{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem", MinVersion: "2.0", CipherSuites: []string{"TLS_UNKNOWN"}},
+			expectedLogInfo: []logComponents{
+				{msg: "config.tls.enabled: true", lvl: logrus.InfoLevel},
+			},
+			expectedErrors: []string{
+				`invalid config.tls.min_version: 2.0. It must be "1.0", "1.1", "1.2" or "1.3"`,
+				"invalid config.tls.cipher_suites: TLS_UNKNOWN is not a supported cipher suite",
+			},
+		},
+		{
+			description: "Valid mutual TLS configuration",
+			inTLSConfig: &TLS{
+				Enabled:               true,
+				CertFile:              "cert.pem",
+				KeyFile:               "key.pem",
+				MinVersion:            "1.3",
+				CipherSuites:          []string{"TLS_AES_128_GCM_SHA256"},
+				ClientCAFile:          "ca.pem",
+				ReloadIntervalSeconds: 60,
+			},
+			expectedLogInfo: []logComponents{
+				{msg: "config.tls.enabled: true", lvl: logrus.InfoLevel},
+				{msg: "config.tls.cert_file: cert.pem", lvl: logrus.InfoLevel},
+				{msg: "config.tls.key_file: key.pem", lvl: logrus.InfoLevel},
+				{msg: "config.tls.min_version: 1.3", lvl: logrus.InfoLevel},
+				{msg: "config.tls.cipher_suites: [TLS_AES_128_GCM_SHA256]", lvl: logrus.InfoLevel},
+				{msg: "config.tls.client_ca_file: ca.pem. Clients must present a certificate signed by this CA", lvl: logrus.InfoLevel},
+				{msg: "config.tls.reload_interval_seconds: 60", lvl: logrus.InfoLevel},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Run test
+		err := tc.inTLSConfig.validateAndLog("tls")
+		assertValidationErrors(t, tc.expectedErrors, err, tc.description)
+
+		// Assert logrus expected entries
+		if assert.Len(t, hook.Entries, len(tc.expectedLogInfo), tc.description) {
+			for i := 0; i < len(tc.expectedLogInfo); i++ {
+				assert.Equal(t, tc.expectedLogInfo[i].msg, hook.Entries[i].Message, tc.description+":message")
+				assert.Equal(t, tc.expectedLogInfo[i].lvl, hook.Entries[i].Level, tc.description+":log level")
+			}
+		}
+
+		//Reset log after every test and assert successful reset
+		hook.Reset()
+		assert.Nil(t, hook.LastEntry())
+	}
+}
+
+func TestTLSVersionAndCipherSuites(t *testing.T) {
+	testCases := []struct {
+		description        string
+		inTLSConfig        TLS
+		expectedMinVersion uint16
+		expectedSuites     []uint16
+		expectedInterval   time.Duration
+	}{
+		{
+			description:        "Blank values default to TLS 1.2 and the crypto/tls cipher suites",
+			inTLSConfig:        TLS{},
+			expectedMinVersion: tls.VersionTLS12,
+		},
+		{
+			description: "Explicit values",
+			inTLSConfig: TLS{
+				MinVersion:            "1.1",
+				CipherSuites:          []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_RSA_WITH_AES_128_CBC_SHA"},
+				ReloadIntervalSeconds: 5,
+			},
+			expectedMinVersion: tls.VersionTLS11,
+			expectedSuites:     []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, tls.TLS_RSA_WITH_AES_128_CBC_SHA},
+			expectedInterval:   5 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expectedMinVersion, tc.inTLSConfig.MinTLSVersion(), tc.description)
+		assert.Equal(t, tc.expectedSuites, tc.inTLSConfig.CipherSuiteIDs(), tc.description)
+		assert.Equal(t, tc.expectedInterval, tc.inTLSConfig.ReloadInterval(), tc.description)
+	}
+}
+
+func TestListenerValidateAndLog(t *testing.T) {
+	// logrus entries will be recorded to this `hook` object so we can compare and assert them
+	hook := testLogrus.NewGlobal()
+
+	type logComponents struct {
+		msg string
+		lvl logrus.Level
+	}
+
+	testCases := []struct {
+		description      string
+		inListenerConfig *Listener
+		expectedLogInfo  []logComponents
+		expectedErrors   []string
+	}{
+		{
+			description:      "TCP listener without h2c, nothing gets logged",
+			inListenerConfig: &Listener{UnixSocketPermissions: "0660"},
+			expectedLogInfo:  []logComponents{},
+		},
+		{
+			description:      "Unix socket with h2c",
+			inListenerConfig: &Listener{UnixSocketPath: "/tmp/main.sock", UnixSocketPermissions: "0600", H2C: true},
+			expectedLogInfo: []logComponents{
+				{msg: "config.listener.unix_socket_path: /tmp/main.sock", lvl: logrus.InfoLevel},
+				{msg: "config.listener.unix_socket_permissions: 0600", lvl: logrus.InfoLevel},
+				{msg: "config.listener.h2c: true", lvl: logrus.InfoLevel},
+			},
+		},
+		{
+			description:      "Unix socket permissions are not an octal file mode, expect error",
+			inListenerConfig: &Listener{UnixSocketPath: "/tmp/main.sock", UnixSocketPermissions: "01777"},
+			expectedLogInfo:  []logComponents{},
+			expectedErrors:   []string{"invalid config.listener.unix_socket_permissions: 01777. It must be an octal file mode no greater than 0777"},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Run test
+		err := tc.inListenerConfig.validateAndLog("listener")
+		assertValidationErrors(t, tc.expectedErrors, err, tc.description)
+
+		// Assert logrus expected entries
+		if assert.Len(t, hook.Entries, len(tc.expectedLogInfo), tc.description) {
+			for i := 0; i < len(tc.expectedLogInfo); i++ {
+				assert.Equal(t, tc.expectedLogInfo[i].msg, hook.Entries[i].Message, tc.description+":message")
+				assert.Equal(t, tc.expectedLogInfo[i].lvl, hook.Entries[i].Level, tc.description+":log level")
+			}
+		}
+
+		//Reset log after every test and assert successful reset
+		hook.Reset()
+		assert.Nil(t, hook.LastEntry())
+	}
+}
+
+func TestSocketFileMode(t *testing.T) {
+	testCases := []struct {
+		description   string
+		inPermissions string
+		expectedMode  os.FileMode
+		expectedError bool
+	}{
+		{description: "Octal with leading zero", inPermissions: "0660", expectedMode: 0660},
+		{description: "Octal without leading zero", inPermissions: "600", expectedMode: 0600},
+		{description: "Not an octal number", inPermissions: "0689", expectedError: true},
+		{description: "Greater than 0777", inPermissions: "1777", expectedError: true},
+		{description: "Empty", inPermissions: "", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		cfg := Listener{UnixSocketPermissions: tc.inPermissions}
+		mode, err := cfg.SocketFileMode()
+		if tc.expectedError {
+			assert.Error(t, err, tc.description)
+		} else {
+			assert.NoError(t, err, tc.description)
+			assert.Equal(t, tc.expectedMode, mode, tc.description)
+		}
+	}
+}
+
 func TestPrometheusTimeoutDuration(t *testing.T) {
 	prometheusConfig := &PrometheusMetrics{
 		TimeoutMillisRaw: 5,
@@ -1280,8 +1629,22 @@ func setEnvVar(t *testing.T, key string, val string) func() {
 
 func getExpectedDefaultConfig() Configuration {
 	return Configuration{
-		Port:          2424,
-		AdminPort:     2525,
+		Port:      2424,
+		AdminPort: 2525,
+		TLS: TLS{
+			CipherSuites:          []string{},
+			ReloadIntervalSeconds: utils.TLS_RELOAD_INTERVAL_SECONDS,
+		},
+		AdminTLS: TLS{
+			CipherSuites:          []string{},
+			ReloadIntervalSeconds: utils.TLS_RELOAD_INTERVAL_SECONDS,
+		},
+		Listener: Listener{
+			UnixSocketPermissions: utils.UNIX_SOCKET_PERMISSIONS,
+		},
+		AdminListener: Listener{
+			UnixSocketPermissions: utils.UNIX_SOCKET_PERMISSIONS,
+		},
 		IndexResponse: "This application stores short-term data for use in Prebid.",
 		Log: Log{
 			Level: Info,
@@ -1294,20 +1657,77 @@ func getExpectedDefaultConfig() Configuration {
 			Aerospike: Aerospike{
 				Hosts:          []string{},
 				MaxReadRetries: 2,
+				SetName:        "uuid",
+				BinName:        "value",
+				AuthMode:       AerospikeAuthInternal,
 			},
 			Cassandra: Cassandra{
-				DefaultTTL: utils.CASSANDRA_DEFAULT_TTL_SECONDS,
+				DefaultTTL:        utils.CASSANDRA_DEFAULT_TTL_SECONDS,
+				ReadConsistency:   "one",
+				WriteConsistency:  "local_one",
+				ReplicationFactor: 1,
 			},
 			Redis: Redis{
+				Mode:              RedisStandalone,
+				Addresses:         []string{},
 				ExpirationMinutes: utils.REDIS_DEFAULT_EXPIRATION_MINUTES,
+				TLS: RedisTLS{
+					ReloadIntervalSeconds: utils.TLS_RELOAD_INTERVAL_SECONDS,
+				},
 			},
 			Ignite: Ignite{
-				Headers: map[string]string{},
+				Protocol:      IgniteREST,
+				Headers:       map[string]string{},
+				TimeoutMillis: 1000,
+				MaxIdleConns:  10,
+			},
+			Bolt: Bolt{
+				CompactionIntervalSeconds: utils.BOLT_COMPACTION_INTERVAL_SECONDS,
+			},
+			Postgres: Postgres{
+				Port:                   5432,
+				SSLMode:                "require",
+				Table:                  "cache",
+				MaxOpenConns:           10,
+				MaxIdleConns:           5,
+				ConnMaxLifetimeSeconds: 300,
+				ReaperIntervalSeconds:  60,
+				ReaperBatchSize:        1000,
+			},
+			S3: S3{
+				Region:                  "us-east-1",
+				TimeoutMillis:           2000,
+				MaxIdleConns:            100,
+				LifecycleExpirationDays: 1,
+			},
+			DynamoDB: DynamoDB{
+				Region:        "us-east-1",
+				Table:         "prebid-cache",
+				TimeoutMillis: 1000,
+				MaxIdleConns:  100,
+			},
+			NATS: NATS{
+				Servers:       []string{},
+				Bucket:        "prebid-cache",
+				Replicas:      1,
+				MaxAgeSeconds: utils.REQUEST_MAX_TTL_SECONDS,
+				TimeoutMillis: 1000,
+			},
+			Migrate: Migrate{
+				CopyOnReadTTLSeconds: utils.REQUEST_MAX_TTL_SECONDS,
+			},
+			Shadow: Shadow{
+				SamplingRate:  1,
+				QueueSize:     utils.SHADOW_QUEUE_SIZE,
+				Workers:       utils.SHADOW_WORKERS,
+				TimeoutMillis: utils.SHADOW_TIMEOUT_MS,
 			},
 		},
 		Compression: Compression{
 			Type: CompressionType("snappy"),
 		},
+		ContentTypes: []ContentType{},
+		JSONSchemas:  []JSONSchema{},
 		RateLimiting: RateLimiting{
 			Enabled:              true,
 			MaxRequestsPerSecond: 100,
@@ -1315,11 +1735,23 @@ func getExpectedDefaultConfig() Configuration {
 		RequestLogging: RequestLogging{
 			RefererSamplingRate: 0.00,
 		},
+		CORS: CORS{
+			AllowedOrigins: []string{},
+		},
+		VASTWrapper: VASTWrapper{
+			ImpressionURLs: []string{},
+			ErrorURLs:      []string{},
+		},
+		HealthCheck: HealthCheck{
+			IntervalMillis: utils.HEALTH_CHECK_INTERVAL_MS,
+			TimeoutMillis:  utils.HEALTH_CHECK_TIMEOUT_MS,
+		},
 		RequestLimits: RequestLimits{
 			MaxSize:       10240,
 			MaxNumValues:  10,
 			MaxTTLSeconds: 3600,
 			MaxHeaderSize: 1048576,
+			ValidateXML:   "off",
 		},
 		Routes: Routes{
 			AllowPublicWrite: true,
@@ -1330,8 +1762,33 @@ func getExpectedDefaultConfig() Configuration {
 // Returns a Configuration object that matches the values found in the `sample_full_config.yaml`
 func getExpectedFullConfigForTestFile() Configuration {
 	return Configuration{
-		Port:          9000,
-		AdminPort:     2525,
+		Port:      9000,
+		AdminPort: 2525,
+		TLS: TLS{
+			Enabled:               true,
+			CertFile:              "/etc/prebid-cache/tls/server.pem",
+			KeyFile:               "/etc/prebid-cache/tls/server-key.pem",
+			MinVersion:            "1.2",
+			CipherSuites:          []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			ReloadIntervalSeconds: 30,
+		},
+		AdminTLS: TLS{
+			Enabled:               true,
+			CertFile:              "/etc/prebid-cache/tls/admin.pem",
+			KeyFile:               "/etc/prebid-cache/tls/admin-key.pem",
+			CipherSuites:          []string{},
+			ClientCAFile:          "/etc/prebid-cache/tls/ca.pem",
+			ReloadIntervalSeconds: utils.TLS_RELOAD_INTERVAL_SECONDS,
+		},
+		Listener: Listener{
+			UnixSocketPath:        "/var/run/prebid-cache/main.sock",
+			UnixSocketPermissions: "0666",
+			H2C:                   true,
+		},
+		AdminListener: Listener{
+			UnixSocketPermissions: utils.UNIX_SOCKET_PERMISSIONS,
+			H2C:                   true,
+		},
 		IndexResponse: "Any index response",
 		Log: Log{
 			Level: Info,
@@ -1346,55 +1803,200 @@ func getExpectedFullConfigForTestFile() Configuration {
 			MaxTTLSeconds:    5000,
 			AllowSettingKeys: true,
 			MaxHeaderSize:    16384, //16KiB
+			ValidateXML:      "reject",
+		},
+		CORS: CORS{
+			AllowedOrigins: []string{"https://prebid.org", "https://www.prebid.org"},
+		},
+		VASTWrapper: VASTWrapper{
+			Enabled:        true,
+			BaseURL:        "https://cache.prebid.org",
+			ImpressionURLs: []string{"https://tracking.prebid.org/impression"},
+			ErrorURLs:      []string{"https://tracking.prebid.org/error?code=[ERRORCODE]"},
+		},
+		HealthCheck: HealthCheck{
+			Enabled:          true,
+			IntervalMillis:   2000,
+			TimeoutMillis:    500,
+			DrainDelayMillis: 10000,
 		},
 		Backend: Backend{
 			Type: BackendMemory,
 			Aerospike: Aerospike{
-				DefaultTTLSecs:      3600,
-				Host:                "aerospike.prebid.com",
-				Hosts:               []string{"aerospike2.prebid.com", "aerospike3.prebid.com"},
-				Port:                3000,
-				Namespace:           "whatever",
-				User:                "foo",
-				Password:            "bar",
-				MaxReadRetries:      2,
-				ConnIdleTimeoutSecs: 2,
+				DefaultTTLSecs:           3600,
+				Host:                     "aerospike.prebid.com",
+				Hosts:                    []string{"aerospike2.prebid.com", "aerospike3.prebid.com"},
+				Port:                     3000,
+				Namespace:                "whatever",
+				User:                     "foo",
+				Password:                 "bar",
+				MaxReadRetries:           2,
+				ConnIdleTimeoutSecs:      2,
+				SetName:                  "cache",
+				BinName:                  "payload",
+				AuthMode:                 AerospikeAuthExternal,
+				ReadSocketTimeoutMillis:  50,
+				ReadTotalTimeoutMillis:   100,
+				WriteSocketTimeoutMillis: 75,
+				WriteTotalTimeoutMillis:  150,
+				RackAware:                true,
+				RackID:                   2,
 			},
 			Cassandra: Cassandra{
-				Hosts:      "127.0.0.1",
-				Keyspace:   "prebid",
-				DefaultTTL: 60,
+				Hosts:                "127.0.0.1",
+				Keyspace:             "prebid",
+				DefaultTTL:           60,
+				Username:             "cassandra-user",
+				Password:             "cassandra-password",
+				LocalDC:              "dc1",
+				ReadConsistency:      "local_quorum",
+				WriteConsistency:     "local_quorum",
+				ProtocolVersion:      4,
+				TimeoutMillis:        200,
+				ConnectTimeoutMillis: 1000,
+				CreateSchemaOnStart:  true,
+				ReplicationFactor:    3,
 			},
 			Memcache: Memcache{
-				Hosts: []string{"10.0.0.1:11211", "127.0.0.1"},
+				Hosts:         []string{"10.0.0.1:11211", "127.0.0.1"},
+				TimeoutMillis: 50,
+				MaxIdleConns:  16,
 			},
 			Redis: Redis{
+				Mode:              RedisStandalone,
+				Addresses:         []string{},
 				Host:              "127.0.0.1",
 				Port:              6379,
+				Username:          "prebid-cache",
 				Password:          "redis-password",
 				Db:                1,
+				PoolSize:          10,
 				ExpirationMinutes: 1,
 				TLS: RedisTLS{
-					Enabled:            false,
-					InsecureSkipVerify: false,
+					Enabled:               false,
+					InsecureSkipVerify:    false,
+					ReloadIntervalSeconds: 30,
 				},
 			},
 			Ignite: Ignite{
-				Scheme: "http",
-				Host:   "127.0.0.1",
-				Port:   8080,
+				Protocol: IgniteREST,
+				Scheme:   "http",
+				Host:     "127.0.0.1",
+				Port:     8080,
 				Headers: map[string]string{
 					"Content-Length": "0",
 				},
+				Username:      "prebid-cache",
+				Password:      "ignite-password",
+				TimeoutMillis: 500,
+				MaxIdleConns:  20,
 				Cache: IgniteCache{
 					Name:          "whatever",
 					CreateOnStart: false,
 				},
 			},
+			Bolt: Bolt{
+				DataDir:                   "/var/lib/prebid-cache",
+				CompactionIntervalSeconds: 30,
+				MaxSizeMB:                 512,
+			},
+			Postgres: Postgres{
+				Host:                   "127.0.0.1",
+				Port:                   5433,
+				Database:               "prebid",
+				User:                   "prebid-cache",
+				Password:               "postgres-password",
+				SSLMode:                "verify-full",
+				Table:                  "prebid_cache",
+				MaxOpenConns:           20,
+				MaxIdleConns:           10,
+				ConnMaxLifetimeSeconds: 600,
+				CreateSchemaOnStart:    true,
+				ReaperIntervalSeconds:  30,
+				ReaperBatchSize:        500,
+			},
+			S3: S3{
+				Endpoint:                "http://minio:9000",
+				Region:                  "eu-west-1",
+				Bucket:                  "prebid-cache",
+				Prefix:                  "creatives/",
+				ForcePathStyle:          true,
+				AccessKeyID:             "minio-access-key",
+				SecretAccessKey:         "minio-secret-key",
+				TimeoutMillis:           5000,
+				MaxIdleConns:            50,
+				ConfigureLifecycle:      true,
+				LifecycleExpirationDays: 2,
+			},
+			DynamoDB: DynamoDB{
+				Endpoint:           "http://dynamodb-local:8000",
+				Region:             "eu-west-1",
+				Table:              "prebid_cache",
+				AccessKeyID:        "dynamodb-access-key",
+				SecretAccessKey:    "dynamodb-secret-key",
+				SessionToken:       "dynamodb-session-token",
+				TimeoutMillis:      500,
+				MaxIdleConns:       50,
+				ConsistentReads:    true,
+				CreateTableOnStart: true,
+			},
+			NATS: NATS{
+				Servers:       []string{"nats://nats-1:4222", "nats://nats-2:4222"},
+				Bucket:        "prebid_cache",
+				Replicas:      3,
+				MaxAgeSeconds: 7200,
+				Username:      "nats-user",
+				Password:      "nats-password",
+				TLS: NATSTLS{
+					CAFile:   "/etc/nats/ca.pem",
+					CertFile: "/etc/nats/client.pem",
+					KeyFile:  "/etc/nats/client-key.pem",
+				},
+				TimeoutMillis:       500,
+				CreateBucketOnStart: true,
+			},
+			Routed: Routed{
+				Pointers: BackendRedis,
+				Default:  BackendRedis,
+				Routes: []Route{
+					{Backend: BackendS3, Type: "xml", MinSizeBytes: 10240},
+					{Backend: BackendPostgres, MaxSizeBytes: 10240},
+				},
+			},
+			Migrate: Migrate{
+				From:                 BackendMemcache,
+				To:                   BackendAerospike,
+				MirrorWritesSeconds:  3600,
+				CopyOnRead:           true,
+				CopyOnReadTTLSeconds: 300,
+			},
+			Shadow: Shadow{
+				Enabled:       true,
+				Type:          BackendRedis,
+				SamplingRate:  0.05,
+				QueueSize:     500,
+				Workers:       2,
+				TimeoutMillis: 50,
+			},
 		},
 		Compression: Compression{
 			Type: CompressionType("snappy"),
 		},
+		ContentTypes: []ContentType{
+			{
+				Name:        "html",
+				ContentType: "text/html; charset=utf-8",
+				Validator:   "utf8",
+			},
+			{
+				Name:        "image",
+				ContentType: "image/png",
+				Encoding:    "base64",
+			},
+		},
+		JSONSchemas: []JSONSchema{
+			{Name: "openrtb_bid", File: "configtest/openrtb_bid_schema.json", Types: []string{"json"}},
+		},
 		Metrics: Metrics{
 			Type: MetricsType("none"),
 			Influx: InfluxMetrics{
@@ -1417,3 +2019,317 @@ func getExpectedFullConfigForTestFile() Configuration {
 		},
 	}
 }
+
+func TestConfigurationValidate(t *testing.T) {
+	hook := testLogrus.NewGlobal()
+
+	cfg := getExpectedDefaultConfig()
+	cfg.Log.Level = "verbose"
+	cfg.RequestLimits.MaxNumValues = -1
+	cfg.Backend.Type = "unknown"
+	cfg.Compression.Type = "unknown"
+
+	err := cfg.Validate()
+
+	// Every problem gets reported, in the order the sections are validated
+	assertValidationErrors(t, []string{
+		`invalid config.log.level: verbose. It must be "trace", "debug", "info", "warning", "error", "fatal" or "panic"`,
+		"invalid config.request_limits.max_num_values: -1. Value cannot be negative.",
+		`invalid config.backend.type: unknown. It must be "aerospike", "cassandra", "memcache", "redis",  "ignite", "bolt", "postgres", "s3", "dynamodb", "nats", "memory", "migrate" or "routed".`,
+		`invalid config.compression.type: unknown. It must be "none" or "snappy"`,
+	}, err)
+
+	// Validate never exits the process
+	for _, entry := range hook.AllEntries() {
+		assert.NotEqual(t, logrus.FatalLevel, entry.Level, entry.Message)
+	}
+
+	cfg = getExpectedDefaultConfig()
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidateRoutingByType(t *testing.T) {
+	cfg := getExpectedDefaultConfig()
+	cfg.Backend.Type = BackendRouted
+	cfg.Backend.Routed = Routed{
+		Pointers: BackendMemory,
+		Routes:   []Route{{Backend: BackendMemcache, MinSizeBytes: 1024}, {Backend: BackendMemcache, Type: "xml"}},
+	}
+
+	cfg.Compression.Type = CompressionSnappy
+	assert.EqualError(t, cfg.validateRoutingByType(), "invalid config.backend.routed.routes[1].type: xml. Values can't be routed by type when config.compression.type is snappy.", "Compressed values")
+
+	cfg.Compression.Type = CompressionNone
+	assert.NoError(t, cfg.validateRoutingByType(), "Uncompressed values")
+
+	cfg.Backend.Routed.Routes[1].Type = "html"
+	assert.EqualError(t, cfg.validateRoutingByType(), `invalid config.backend.routed.routes[1].type: html. It must be one of the content types ["json", "xml"] or empty to match any type.`, "Unknown content type")
+
+	cfg.ContentTypes = []ContentType{{Name: "html", ContentType: "text/html"}}
+	assert.NoError(t, cfg.validateRoutingByType(), "Configured content type")
+
+	cfg.Backend.Routed.Routes = cfg.Backend.Routed.Routes[:1]
+	cfg.Compression.Type = CompressionSnappy
+	assert.NoError(t, cfg.validateRoutingByType(), "Compressed values routed by size only")
+}
+
+func TestValidateMigrateCopyTTL(t *testing.T) {
+	cfg := getExpectedDefaultConfig()
+	cfg.Backend.Type = BackendMigrate
+	cfg.Backend.Migrate = Migrate{From: BackendMemory, To: BackendMemcache, CopyOnRead: true, CopyOnReadTTLSeconds: 3600}
+	cfg.RequestLimits.MaxTTLSeconds = 3600
+	assert.NoError(t, cfg.validateMigrateCopyTTL(), "Copy TTL equal to the max TTL")
+
+	cfg.Backend.Migrate.CopyOnReadTTLSeconds = 3601
+	assert.EqualError(t, cfg.validateMigrateCopyTTL(), "invalid config.backend.migrate.copy_on_read_ttl_seconds: 3601. Value cannot exceed config.request_limits.max_ttl_seconds: 3600.", "Copy TTL above the max TTL")
+
+	cfg.RequestLimits.MaxTTLSeconds = 0
+	assert.NoError(t, cfg.validateMigrateCopyTTL(), "No max TTL")
+
+	cfg.RequestLimits.MaxTTLSeconds = 3600
+	cfg.Backend.Migrate.CopyOnRead = false
+	assert.NoError(t, cfg.validateMigrateCopyTTL(), "Copy on read disabled")
+}
+
+func TestValidateAndLogJSONSchemas(t *testing.T) {
+	schemaFile := filepath.Join("configtest", "openrtb_bid_schema.json")
+	invalidSchemaFile := filepath.Join(t.TempDir(), "invalid_schema.json")
+	require.NoError(t, os.WriteFile(invalidSchemaFile, []byte(`{"type": 5}`), 0600))
+
+	testCases := []struct {
+		desc          string
+		inCfg         func(cfg *Configuration)
+		expectedError string
+		// expectedErrorPrefix is checked instead of expectedError when the end of the error comes
+		// from the schema library
+		expectedErrorPrefix string
+	}{
+		{
+			desc: "Schema of the json type",
+		},
+		{
+			desc: "Schema of a content type with the json validator",
+			inCfg: func(cfg *Configuration) {
+				cfg.ContentTypes = []ContentType{{Name: "bid", ContentType: "application/json", Validator: "json"}}
+				cfg.JSONSchemas[0].Types = []string{"json", "bid"}
+			},
+		},
+		{
+			desc:          "Empty name",
+			inCfg:         func(cfg *Configuration) { cfg.JSONSchemas[0].Name = "" },
+			expectedError: "invalid config.json_schemas[0].name: . It cannot be empty.",
+		},
+		{
+			desc:          "No types",
+			inCfg:         func(cfg *Configuration) { cfg.JSONSchemas[0].Types = nil },
+			expectedError: "invalid config.json_schemas[0].types: []. It must list at least one content type.",
+		},
+		{
+			desc:          "Type other than json",
+			inCfg:         func(cfg *Configuration) { cfg.JSONSchemas[0].Types = []string{"xml"} },
+			expectedError: `invalid config.json_schemas[0].types: xml. It must be "json" or a content type with the "json" validator and raw encoding.`,
+		},
+		{
+			desc: "Content type without the json validator",
+			inCfg: func(cfg *Configuration) {
+				cfg.ContentTypes = []ContentType{{Name: "html", ContentType: "text/html"}}
+				cfg.JSONSchemas[0].Types = []string{"html"}
+			},
+			expectedError: `invalid config.json_schemas[0].types: html. It must be "json" or a content type with the "json" validator and raw encoding.`,
+		},
+		{
+			desc:                "Schema that doesn't compile",
+			inCfg:               func(cfg *Configuration) { cfg.JSONSchemas[0].File = invalidSchemaFile },
+			expectedErrorPrefix: "invalid config.json_schemas[0].file: " + invalidSchemaFile + ". ",
+		},
+		{
+			desc: "Errors of every entry",
+			inCfg: func(cfg *Configuration) {
+				cfg.JSONSchemas[0].Name = ""
+				cfg.JSONSchemas[0].Types = []string{"xml"}
+				cfg.JSONSchemas = append(cfg.JSONSchemas, JSONSchema{Name: "other_bid", File: schemaFile})
+			},
+			expectedError: "invalid config.json_schemas[0].name: . It cannot be empty.\n" +
+				`invalid config.json_schemas[0].types: xml. It must be "json" or a content type with the "json" validator and raw encoding.` + "\n" +
+				"invalid config.json_schemas[1].types: []. It must list at least one content type.",
+		},
+		{
+			desc: "Type with two schemas",
+			inCfg: func(cfg *Configuration) {
+				cfg.JSONSchemas = append(cfg.JSONSchemas, JSONSchema{Name: "other_bid", File: schemaFile, Types: []string{"json"}})
+			},
+			expectedError: "invalid config.json_schemas: content type json already matches the openrtb_bid JSON schema.",
+		},
+	}
+
+	for _, tc := range testCases {
+		cfg := &Configuration{
+			JSONSchemas: []JSONSchema{{Name: "openrtb_bid", File: schemaFile, Types: []string{"json"}}},
+		}
+		if tc.inCfg != nil {
+			tc.inCfg(cfg)
+		}
+
+		err := cfg.validateAndLogJSONSchemas()
+
+		switch {
+		case tc.expectedErrorPrefix != "":
+			if assert.Error(t, err, tc.desc) {
+				assert.True(t, strings.HasPrefix(err.Error(), tc.expectedErrorPrefix), tc.desc)
+			}
+		case tc.expectedError != "":
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		default:
+			assert.NoError(t, err, tc.desc)
+		}
+	}
+}
+
+func TestValidateAndLogContentTypes(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		inCfg         []ContentType
+		expectedError string
+	}{
+		{
+			desc: "Valid content types",
+			inCfg: []ContentType{
+				{Name: "html", ContentType: "text/html; charset=utf-8", Validator: "utf8"},
+				{Name: "protobuf", ContentType: "application/x-protobuf", Encoding: "base64"},
+			},
+		},
+		{
+			desc:          "Name with uppercase letters",
+			inCfg:         []ContentType{{Name: "HTML", ContentType: "text/html"}},
+			expectedError: "invalid config.content_types[0].name: HTML. It must be lowercase letters, digits or underscores, starting with a letter.",
+		},
+		{
+			desc:          "Invalid media type",
+			inCfg:         []ContentType{{Name: "html", ContentType: "text/html;;"}},
+			expectedError: "invalid config.content_types[0].content_type: text/html;;. It must be a media type such as text/html.",
+		},
+		{
+			desc:          "Unknown encoding",
+			inCfg:         []ContentType{{Name: "image", ContentType: "image/png", Encoding: "hex"}},
+			expectedError: `invalid config.content_types[0].encoding: hex. It must be "raw" or "base64".`,
+		},
+		{
+			desc:          "Unknown validator",
+			inCfg:         []ContentType{{Name: "html", ContentType: "text/html", Validator: "html"}},
+			expectedError: `invalid config.content_types[0].validator: html. It must be "json", "xml", "utf8" or empty.`,
+		},
+		{
+			desc:          "Name starting with the name of a built-in type",
+			inCfg:         []ContentType{{Name: "jsonp", ContentType: "application/javascript"}},
+			expectedError: "invalid config.content_types: content type jsonp conflicts with content type json, as one of their names starts with the other.",
+		},
+		{
+			desc: "Name that's the start of the name of another type",
+			inCfg: []ContentType{
+				{Name: "text_template", ContentType: "text/plain"},
+				{Name: "text", ContentType: "text/plain"},
+			},
+			expectedError: "invalid config.content_types: content type text conflicts with content type text_template, as one of their names starts with the other.",
+		},
+	}
+
+	for _, tc := range testCases {
+		err := validateAndLogContentTypes(tc.inCfg)
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	defer setEnvVar(t, "PBC_METRICS_INFLUX_HOST", "env-var-defined-metrics-host")()
+
+	cfg, err := LoadConfigFile(filepath.Join("configtest", "sample_full_config.yaml"))
+	if assert.NoError(t, err) {
+		expectedConfig := getExpectedFullConfigForTestFile()
+		expectedConfig.Metrics.Influx.Host = "env-var-defined-metrics-host"
+		assert.Equal(t, expectedConfig, cfg)
+	}
+
+	_, err = LoadConfigFile(filepath.Join("configtest", "config_invalid.yaml"))
+	assert.Error(t, err, "Malformed file")
+
+	_, err = LoadConfigFile(filepath.Join("configtest", "does_not_exist.yaml"))
+	assert.Error(t, err, "Missing file")
+}
+
+func TestMarshalRedacted(t *testing.T) {
+	cfg := getExpectedFullConfigForTestFile()
+	cfg.Backend.Redis.Password = ""
+
+	out, err := cfg.MarshalRedacted()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NotContains(t, string(out), "metrics-password")
+	assert.Contains(t, string(out), "password: '[REDACTED]'")
+	assert.Contains(t, string(out), `password: ""`, "Empty secrets are printed as they are")
+	assert.Contains(t, string(out), "port: 9000")
+
+	// Marshaling must not modify the configuration
+	assert.Equal(t, "metrics-password", cfg.Metrics.Influx.Password)
+}
+
+func TestMarshalRedactedBackendSecrets(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		backend Backend
+		secrets []string
+		printed string
+	}{
+		{
+			desc:    "S3 credentials",
+			backend: Backend{Type: BackendS3, S3: S3{AccessKeyID: "access-key", SecretAccessKey: "s3-secret-key", SessionToken: "s3-session-token"}},
+			secrets: []string{"s3-secret-key", "s3-session-token"},
+			printed: "access_key_id: access-key",
+		},
+		{
+			desc:    "DynamoDB credentials",
+			backend: Backend{Type: BackendDynamoDB, DynamoDB: DynamoDB{AccessKeyID: "access-key", SecretAccessKey: "dynamodb-secret-key", SessionToken: "dynamodb-session-token"}},
+			secrets: []string{"dynamodb-secret-key", "dynamodb-session-token"},
+			printed: "access_key_id: access-key",
+		},
+		{
+			desc:    "NATS token",
+			backend: Backend{Type: BackendNATS, NATS: NATS{Token: "nats-token", CredsFile: "/etc/nats/user.creds"}},
+			secrets: []string{"nats-token"},
+			printed: "creds_file: /etc/nats/user.creds",
+		},
+	}
+
+	for _, tc := range testCases {
+		cfg := Configuration{Backend: tc.backend}
+		out, err := cfg.MarshalRedacted()
+		if !assert.NoError(t, err, tc.desc) {
+			continue
+		}
+		for _, secret := range tc.secrets {
+			assert.NotContains(t, string(out), secret, tc.desc)
+		}
+		assert.Contains(t, string(out), tc.printed, "Settings that aren't secret are printed: "+tc.desc)
+	}
+}
+
+// assertValidationErrors asserts err holds exactly the expected error messages, in order
+func assertValidationErrors(t *testing.T, expected []string, err error, msgAndArgs ...interface{}) {
+	t.Helper()
+
+	var actual []string
+	if validationErrs, ok := err.(ValidationErrors); ok {
+		for _, e := range validationErrs {
+			actual = append(actual, e.Error())
+		}
+	} else if err != nil {
+		actual = append(actual, err.Error())
+	}
+	assert.Equal(t, expected, actual, msgAndArgs...)
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// redactedValue replaces the values of secret settings in printed configurations
+const redactedValue = "[REDACTED]"
+
+// secretKeys are the names of the settings whose values must never get printed, wherever they're nested
+var secretKeys = map[string]bool{
+	"password":          true,
+	"secret_access_key": true,
+	"session_token":     true,
+	"token":             true,
+}
+
+// MarshalRedacted returns the YAML representation of cfg, keys in declaration order, with the values
+// of secret settings redacted.
+func (cfg *Configuration) MarshalRedacted() ([]byte, error) {
+	return yaml.Marshal(redact("", reflect.ValueOf(*cfg)))
+}
+
+func redact(key string, value reflect.Value) interface{} {
+	if value.Kind() != reflect.Struct {
+		if secretKeys[key] && !value.IsZero() {
+			return redactedValue
+		}
+		return value.Interface()
+	}
+
+	fields := make(yaml.MapSlice, 0, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("mapstructure"), ",")[0]
+		fields = append(fields, yaml.MapItem{Key: name, Value: redact(name, value.Field(i))})
+	}
+	return fields
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prebid/prebid-cache/utils"
+)
+
+// runtimeSettingKeys lists the configuration keys that can change on a running Prebid Cache. Changes
+// to any other key only take effect after a restart.
+var runtimeSettingKeys = []string{
+	"log",
+	"rate_limiter",
+	"request_limits.allow_setting_keys",
+	"request_limits.max_num_values",
+	"request_limits.validate_xml",
+	"request_logging",
+	"cors",
+	"vast_wrapper",
+}
+
+// RuntimeSettings holds the configuration values the request handlers read on every request. The
+// max_size_bytes, max_ttl_seconds and max_header_size_bytes request limits are baked into the backend
+// and the servers on start, so RequestLimits keeps their startup values until a restart.
+type RuntimeSettings struct {
+	RateLimiting   RateLimiting
+	RequestLimits  RequestLimits
+	RequestLogging RequestLogging
+	CORS           CORS
+	VASTWrapper    VASTWrapper
+}
+
+// Settings holds the RuntimeSettings in effect. Updates are applied atomically so a request never
+// observes a mix of old and new values.
+type Settings struct {
+	current atomic.Value
+	cfg     atomic.Value
+
+	contentTypes *utils.ContentTypes
+	jsonSchemas  *utils.JSONSchemas
+	// startupLimits are the request limits Prebid Cache started with
+	startupLimits RequestLimits
+}
+
+// NewSettings returns a Settings holding the runtime values of cfg
+func NewSettings(cfg Configuration) *Settings {
+	// cfg has already been validated, so its content types don't conflict and its JSON schemas compile
+	contentTypes, _ := NewContentTypes(cfg.ContentTypes)
+	jsonSchemas, err := NewJSONSchemas(cfg.JSONSchemas)
+	if err != nil {
+		jsonSchemas = utils.NewJSONSchemas()
+	}
+	s := &Settings{contentTypes: contentTypes, jsonSchemas: jsonSchemas, startupLimits: cfg.RequestLimits}
+	s.store(cfg)
+	return s
+}
+
+// ContentTypes returns the content types values can be put as. They only change on a restart.
+func (s *Settings) ContentTypes() *utils.ContentTypes {
+	return s.contentTypes
+}
+
+// JSONSchemas returns the JSON schemas values must match, by content type. They only change on a
+// restart.
+func (s *Settings) JSONSchemas() *utils.JSONSchemas {
+	return s.jsonSchemas
+}
+
+// Load returns the RuntimeSettings in effect. The returned value must not be modified.
+func (s *Settings) Load() *RuntimeSettings {
+	return s.current.Load().(*RuntimeSettings)
+}
+
+// Update swaps in the runtime values of cfg and returns the keys of the settings that changed but
+// require a restart to take effect.
+func (s *Settings) Update(cfg Configuration) []string {
+	previous := s.cfg.Load().(Configuration)
+	s.store(cfg)
+	return restartRequiredChanges(previous, cfg)
+}
+
+func (s *Settings) store(cfg Configuration) {
+	s.cfg.Store(cfg)
+
+	// Changes to the restart-only request limits get reported by Update, but aren't served
+	requestLimits := cfg.RequestLimits
+	requestLimits.MaxSize = s.startupLimits.MaxSize
+	requestLimits.MaxTTLSeconds = s.startupLimits.MaxTTLSeconds
+	requestLimits.MaxHeaderSize = s.startupLimits.MaxHeaderSize
+
+	s.current.Store(&RuntimeSettings{
+		RateLimiting:   cfg.RateLimiting,
+		RequestLimits:  requestLimits,
+		RequestLogging: cfg.RequestLogging,
+		CORS:           cfg.CORS,
+		VASTWrapper:    cfg.VASTWrapper,
+	})
+}
+
+// restartRequiredChanges returns the keys of the values that differ between previous and updated,
+// other than the ones listed in runtimeSettingKeys
+func restartRequiredChanges(previous, updated Configuration) []string {
+	return changedKeys("", reflect.ValueOf(previous), reflect.ValueOf(updated))
+}
+
+func changedKeys(prefix string, previous, updated reflect.Value) []string {
+	for _, key := range runtimeSettingKeys {
+		if prefix == key {
+			return nil
+		}
+	}
+
+	if previous.Kind() != reflect.Struct {
+		if reflect.DeepEqual(previous.Interface(), updated.Interface()) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	var keys []string
+	for i := 0; i < previous.NumField(); i++ {
+		name := strings.Split(previous.Type().Field(i).Tag.Get("mapstructure"), ",")[0]
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		keys = append(keys, changedKeys(name, previous.Field(i), updated.Field(i))...)
+	}
+	return keys
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsUpdate(t *testing.T) {
+	initial := getExpectedDefaultConfig()
+	settings := NewSettings(initial)
+
+	loaded := settings.Load()
+	assert.Equal(t, initial.RateLimiting, loaded.RateLimiting)
+	assert.Equal(t, initial.RequestLimits, loaded.RequestLimits)
+	assert.Equal(t, initial.RequestLogging, loaded.RequestLogging)
+	assert.Equal(t, initial.CORS, loaded.CORS)
+	assert.Equal(t, initial.VASTWrapper, loaded.VASTWrapper)
+
+	testCases := []struct {
+		desc                    string
+		update                  func(cfg *Configuration)
+		expectedRestartRequired []string
+	}{
+		{
+			desc:   "No changes",
+			update: func(cfg *Configuration) {},
+		},
+		{
+			desc: "Runtime settings only",
+			update: func(cfg *Configuration) {
+				cfg.Log.Level = Debug
+				cfg.RateLimiting = RateLimiting{Enabled: false, MaxRequestsPerSecond: 50}
+				cfg.RequestLimits.MaxNumValues = 20
+				cfg.RequestLimits.AllowSettingKeys = true
+				cfg.RequestLogging.RefererSamplingRate = 0.5
+				cfg.CORS.AllowedOrigins = []string{"https://prebid.org"}
+				cfg.VASTWrapper.BaseURL = "https://cache.prebid.org"
+			},
+		},
+		{
+			desc: "Settings that require a restart",
+			update: func(cfg *Configuration) {
+				cfg.Port = 8000
+				cfg.RequestLimits.MaxSize = 1
+				cfg.RequestLimits.MaxNumValues = 20
+				cfg.Backend.Type = BackendRedis
+				cfg.Backend.Ignite.Headers = map[string]string{"Content-Length": "0"}
+			},
+			expectedRestartRequired: []string{"port", "request_limits.max_size_bytes", "backend.type", "backend.ignite.headers"},
+		},
+	}
+
+	for _, tc := range testCases {
+		settings := NewSettings(initial)
+
+		updated := getExpectedDefaultConfig()
+		tc.update(&updated)
+		restartRequired := settings.Update(updated)
+
+		assert.Equal(t, tc.expectedRestartRequired, restartRequired, tc.desc)
+		assert.Equal(t, updated.RateLimiting, settings.Load().RateLimiting, tc.desc)
+		expectedLimits := updated.RequestLimits
+		expectedLimits.MaxSize = initial.RequestLimits.MaxSize
+		assert.Equal(t, expectedLimits, settings.Load().RequestLimits, tc.desc)
+		assert.Equal(t, updated.RequestLogging, settings.Load().RequestLogging, tc.desc)
+		assert.Equal(t, updated.CORS, settings.Load().CORS, tc.desc)
+		assert.Equal(t, updated.VASTWrapper, settings.Load().VASTWrapper, tc.desc)
+	}
+}
+
+func TestSettingsUpdateKeepsRestartOnlyRequestLimits(t *testing.T) {
+	initial := getExpectedDefaultConfig()
+	settings := NewSettings(initial)
+
+	updated := getExpectedDefaultConfig()
+	updated.RequestLimits.MaxSize = 1
+	updated.RequestLimits.MaxTTLSeconds = 2
+	updated.RequestLimits.MaxHeaderSize = 3
+	updated.RequestLimits.MaxNumValues = 4
+	restartRequired := settings.Update(updated)
+
+	assert.Equal(t, []string{"request_limits.max_size_bytes", "request_limits.max_ttl_seconds", "request_limits.max_header_size_bytes"}, restartRequired)
+	assert.Equal(t, initial.RequestLimits.MaxSize, settings.Load().RequestLimits.MaxSize)
+	assert.Equal(t, initial.RequestLimits.MaxTTLSeconds, settings.Load().RequestLimits.MaxTTLSeconds)
+	assert.Equal(t, initial.RequestLimits.MaxHeaderSize, settings.Load().RequestLimits.MaxHeaderSize)
+	assert.Equal(t, 4, settings.Load().RequestLimits.MaxNumValues)
+}
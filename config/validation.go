@@ -0,0 +1,34 @@
+package config
+
+import "strings"
+
+// ValidationErrors holds every problem found while validating a configuration
+type ValidationErrors []error
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// add appends err to the collected errors. Nil errors are ignored and nested ValidationErrors get flattened.
+func (errs *ValidationErrors) add(err error) {
+	if err == nil {
+		return
+	}
+	if nested, ok := err.(ValidationErrors); ok {
+		*errs = append(*errs, nested...)
+		return
+	}
+	*errs = append(*errs, err)
+}
+
+// toError returns nil if no errors were collected, so the result can be compared against nil
+func (errs ValidationErrors) toError() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -2,13 +2,17 @@ package endpoints
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/prebid/prebid-cache/backends"
+	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/metrics"
 	"github.com/prebid/prebid-cache/utils"
 	log "github.com/sirupsen/logrus"
@@ -16,28 +20,21 @@ import (
 
 // GetHandler serves "GET /cache" requests.
 type GetHandler struct {
-	backend backends.Backend
-	metrics *metrics.Metrics
-	cfg     getHandlerConfig
+	backend  backends.Backend
+	metrics  *metrics.Metrics
+	settings *config.Settings
 }
 
-type getHandlerConfig struct {
-	allowCustomKeys bool
-	refererLogRate  float64
-}
-
-// NewGetHandler returns the handle function for the "/cache" endpoint when it receives a GET request
-func NewGetHandler(storage backends.Backend, metrics *metrics.Metrics, allowCustomKeys bool, refererSamplingRate float64) func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+// NewGetHandler returns the handle function for the "/cache" endpoint when it receives a GET request.
+// The request limits and logging settings are read from settings on every request.
+func NewGetHandler(storage backends.Backend, metrics *metrics.Metrics, settings *config.Settings) func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	getHandler := &GetHandler{
 		// Assign storage client to get endpoint
 		backend: storage,
 		// pass metrics engine
 		metrics: metrics,
 		// Pass configuration values
-		cfg: getHandlerConfig{
-			allowCustomKeys: allowCustomKeys,
-			refererLogRate:  refererSamplingRate,
-		},
+		settings: settings,
 	}
 
 	// Return handle function
@@ -47,15 +44,17 @@ func NewGetHandler(storage backends.Backend, metrics *metrics.Metrics, allowCust
 func (e *GetHandler) handle(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	e.metrics.RecordGetTotal()
 
-	// If incoming request comes with a referer header, there's a e.cfg.refererLogRate percent chance
+	settings := e.settings.Load()
+
+	// If incoming request comes with a referer header, there's a referer_sampling_rate percent chance
 	// getting it logged
-	if referer := r.Referer(); referer != "" && utils.RandomPick(e.cfg.refererLogRate) {
+	if referer := r.Referer(); referer != "" && utils.RandomPick(settings.RequestLogging.RefererSamplingRate) {
 		log.Info("GET request Referer header: " + referer)
 	}
 
 	start := time.Now()
 
-	uuid, parseErr := parseUUID(r, e.cfg.allowCustomKeys)
+	uuid, parseErr := parseUUID(r, settings.RequestLimits.AllowSettingKeys)
 	if parseErr != nil {
 		// parseUUID either returns http.StatusBadRequest or http.StatusNotFound. Both should be
 		// accounted using RecordGetBadRequest()
@@ -63,6 +62,12 @@ func (e *GetHandler) handle(w http.ResponseWriter, r *http.Request, ps httproute
 		return
 	}
 
+	wrap, err := parseWrapper(r, settings.VASTWrapper)
+	if err != nil {
+		e.handleException(w, uuid, err)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
 
@@ -72,7 +77,12 @@ func (e *GetHandler) handle(w http.ResponseWriter, r *http.Request, ps httproute
 		return
 	}
 
-	if err := writeGetResponse(w, storedData); err != nil {
+	if wrap {
+		err = writeVASTWrapperResponse(w, uuid, storedData, e.settings.ContentTypes(), settings.VASTWrapper)
+	} else {
+		err = writeGetResponse(w, storedData, e.settings.ContentTypes())
+	}
+	if err != nil {
 		e.handleException(w, uuid, err)
 		return
 	}
@@ -97,18 +107,71 @@ func parseUUID(r *http.Request, allowCustomKeys bool) (string, error) {
 	return uuid, nil
 }
 
+// parseWrapper tells whether the "wrapper" query parameter asks for the stored VAST document to be
+// wrapped in a Wrapper VAST document, which requires VAST wrappers to be enabled
+func parseWrapper(r *http.Request, cfg config.VASTWrapper) (bool, error) {
+	wrapper := r.URL.Query().Get("wrapper")
+	if wrapper == "" {
+		return false, nil
+	}
+	wrap, err := strconv.ParseBool(wrapper)
+	if err != nil {
+		return false, utils.NewPBCError(utils.GET_BAD_REQUEST, fmt.Sprintf("invalid wrapper value %s", wrapper))
+	}
+	if wrap && !cfg.Enabled {
+		return false, utils.NewPBCError(utils.GET_BAD_REQUEST, "VAST wrappers are disabled")
+	}
+	return wrap, nil
+}
+
+// writeVASTWrapperResponse sends back a Wrapper VAST document whose VASTAdTagURI points at the
+// "GET /cache" URL of the stored VAST document, so players that can't take inline VAST can play it.
+// Only xml values can be wrapped.
+func writeVASTWrapperResponse(w http.ResponseWriter, uuid, storedData string, contentTypes *utils.ContentTypes, cfg config.VASTWrapper) error {
+	contentType, ok := contentTypes.Match(storedData)
+	if !ok {
+		return utils.NewPBCError(utils.UNKNOWN_STORED_DATA_TYPE)
+	}
+	if contentType.Name != utils.XML_PREFIX {
+		return utils.NewPBCError(utils.GET_BAD_REQUEST, fmt.Sprintf("%s values can't be wrapped in VAST", contentType.Name))
+	}
+
+	adTagURI := strings.TrimSuffix(cfg.BaseURL, "/") + "/cache?uuid=" + url.QueryEscape(uuid)
+
+	wrapper, err := utils.NewVASTWrapper(storedData[len(contentType.Name):], uuid, adTagURI, cfg.ImpressionURLs, cfg.ErrorURLs)
+	if err != nil {
+		if pbcErr, isPBCErr := err.(utils.PBCError); isPBCErr {
+			// Stored values that aren't VAST can't be wrapped, but they aren't corrupted either
+			return utils.NewPBCError(utils.GET_BAD_REQUEST, pbcErr.Error())
+		}
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType.MediaType)
+	w.Write([]byte(wrapper))
+	return nil
+}
+
 // writeGetResponse writes the "Content-Type" header and sends back the stored data as a response if
-// the sotred data is prefixed by either the "xml" or "json"
-func writeGetResponse(w http.ResponseWriter, storedData string) error {
-	if strings.HasPrefix(storedData, utils.XML_PREFIX) {
-		w.Header().Set("Content-Type", "application/xml")
-		w.Write([]byte(storedData)[len(utils.XML_PREFIX):])
-	} else if strings.HasPrefix(storedData, utils.JSON_PREFIX) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(storedData)[len(utils.JSON_PREFIX):])
-	} else {
+// the stored data is prefixed by the name of one of the content types. Base64-encoded values are
+// sent back decoded.
+func writeGetResponse(w http.ResponseWriter, storedData string, contentTypes *utils.ContentTypes) error {
+	contentType, ok := contentTypes.Match(storedData)
+	if !ok {
 		return utils.NewPBCError(utils.UNKNOWN_STORED_DATA_TYPE)
 	}
+
+	value := []byte(storedData)[len(contentType.Name):]
+	if contentType.Encoding == utils.ENCODING_BASE64 {
+		decoded, err := base64.StdEncoding.DecodeString(string(value))
+		if err != nil {
+			return utils.NewPBCError(utils.UNKNOWN_STORED_DATA_TYPE)
+		}
+		value = decoded
+	}
+
+	w.Header().Set("Content-Type", contentType.MediaType)
+	w.Write(value)
 	return nil
 }
 
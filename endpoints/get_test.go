@@ -2,6 +2,7 @@ package endpoints
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,8 +14,10 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/prebid/prebid-cache/backends"
+	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/metrics"
 	"github.com/prebid/prebid-cache/metrics/metricstest"
+	"github.com/prebid/prebid-cache/utils"
 )
 
 func TestGetJsonTests(t *testing.T) {
@@ -33,7 +36,7 @@ func TestGetJsonTests(t *testing.T) {
 		}
 
 		router := httprouter.New()
-		router.GET("/cache", NewGetHandler(backend, m, tc.HostConfig.AllowSettingKeys, tc.HostConfig.RefererLogRate))
+		router.GET("/cache", NewGetHandler(backend, m, newTestSettings(10, tc.HostConfig.AllowSettingKeys, tc.HostConfig.RefererLogRate)))
 		request, err := http.NewRequest("GET", "/cache?"+tc.Request.Query, nil)
 		if !assert.NoError(t, err, "Failed to create a GET request: %v", err) {
 			hook.Reset()
@@ -91,7 +94,7 @@ func TestGetInvalidUUIDs(t *testing.T) {
 		},
 	}
 
-	router.GET("/cache", NewGetHandler(backend, m, false, 0.0))
+	router.GET("/cache", NewGetHandler(backend, m, newTestSettings(10, false, 0.0)))
 
 	getResults := doMockGet(t, router, "fdd9405b-ef2b-46da-a55a-2f526d338e16")
 	if getResults.Code != http.StatusNotFound {
@@ -314,7 +317,7 @@ func TestGetHandler(t *testing.T) {
 				&mockMetrics,
 			},
 		}
-		router.GET("/cache", NewGetHandler(backend, m, test.in.cfg.allowKeys, test.in.cfg.refererSamplingRate))
+		router.GET("/cache", NewGetHandler(backend, m, newTestSettings(10, test.in.cfg.allowKeys, test.in.cfg.refererSamplingRate)))
 
 		// Run test
 		getResults := httptest.NewRecorder()
@@ -355,3 +358,149 @@ func TestGetHandler(t *testing.T) {
 		hook.Reset()
 	}
 }
+
+func TestWriteGetResponseContentTypes(t *testing.T) {
+	contentTypes, err := config.NewContentTypes([]config.ContentType{
+		{Name: "html", ContentType: "text/html; charset=utf-8"},
+		{Name: "image", ContentType: "image/png", Encoding: "base64"},
+	})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc                string
+		storedData          string
+		expectedContentType string
+		expectedBody        string
+		expectedError       error
+	}{
+		{
+			desc:                "json value",
+			storedData:          `json{"field":"value"}`,
+			expectedContentType: "application/json",
+			expectedBody:        `{"field":"value"}`,
+		},
+		{
+			desc:                "xml value",
+			storedData:          "xml<tag>xml data here</tag>",
+			expectedContentType: "application/xml",
+			expectedBody:        "<tag>xml data here</tag>",
+		},
+		{
+			desc:                "html value",
+			storedData:          "html<p>Ad</p>",
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "<p>Ad</p>",
+		},
+		{
+			desc:                "base64-encoded image value, served decoded",
+			storedData:          "imageaW1hZ2UgYnl0ZXM=",
+			expectedContentType: "image/png",
+			expectedBody:        "image bytes",
+		},
+		{
+			desc:          "image value that's not base64-encoded",
+			storedData:    "imagenot base64",
+			expectedError: utils.NewPBCError(utils.UNKNOWN_STORED_DATA_TYPE),
+		},
+		{
+			desc:          "value of an unknown type",
+			storedData:    "css p {}",
+			expectedError: utils.NewPBCError(utils.UNKNOWN_STORED_DATA_TYPE),
+		},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+
+		err := writeGetResponse(w, tc.storedData, contentTypes)
+
+		assert.Equal(t, tc.expectedError, err, tc.desc)
+		assert.Equal(t, tc.expectedContentType, w.Header().Get("Content-Type"), tc.desc)
+		assert.Equal(t, tc.expectedBody, w.Body.String(), tc.desc)
+	}
+}
+
+func TestGetVASTWrapper(t *testing.T) {
+	const vastUUID = "36-char-uuid-of-a-stored-vast-000000"
+	const jsonUUID = "36-char-uuid-of-a-stored-json-000000"
+	const missingUUID = "36-char-uuid-of-a-missing-value-0000"
+	const vast = `<VAST version="4.0"><Ad><InLine/></Ad></VAST>`
+
+	testCases := []struct {
+		desc          string
+		cfg           config.VASTWrapper
+		query         string
+		expectedCode  int
+		expectedBody  string
+		expectedTypes string
+	}{
+		{
+			desc:          "Wrapper pointing at the configured base URL, with tracking URLs",
+			cfg:           config.VASTWrapper{Enabled: true, BaseURL: "https://cache.prebid.org/", ImpressionURLs: []string{"https://tracking.prebid.org/imp"}, ErrorURLs: []string{"https://tracking.prebid.org/err"}},
+			query:         "uuid=" + vastUUID + "&wrapper=true",
+			expectedCode:  http.StatusOK,
+			expectedBody:  `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<VAST version="4.0"><Ad id="` + vastUUID + `"><Wrapper><AdSystem>prebid-cache</AdSystem><VASTAdTagURI><![CDATA[https://cache.prebid.org/cache?uuid=` + vastUUID + `]]></VASTAdTagURI><Error><![CDATA[https://tracking.prebid.org/err]]></Error><Impression><![CDATA[https://tracking.prebid.org/imp]]></Impression><Creatives></Creatives></Wrapper></Ad></VAST>`,
+			expectedTypes: "application/xml",
+		},
+		{
+			desc:         "Wrappers are disabled",
+			query:        "uuid=" + vastUUID + "&wrapper=1",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "GET /cache uuid=" + vastUUID + ": VAST wrappers are disabled\n",
+		},
+		{
+			desc:         "The wrapper value is checked before the value is read",
+			query:        "uuid=" + missingUUID + "&wrapper=maybe",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "GET /cache uuid=" + missingUUID + ": invalid wrapper value maybe\n",
+		},
+		{
+			desc:          "No wrapper asked for, the stored document is served",
+			query:         "uuid=" + vastUUID + "&wrapper=false",
+			expectedCode:  http.StatusOK,
+			expectedBody:  vast,
+			expectedTypes: "application/xml",
+		},
+		{
+			desc:         "Invalid wrapper value",
+			query:        "uuid=" + vastUUID + "&wrapper=maybe",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "GET /cache uuid=" + vastUUID + ": invalid wrapper value maybe\n",
+		},
+		{
+			desc:         "JSON values can't be wrapped",
+			cfg:          config.VASTWrapper{Enabled: true, BaseURL: "https://cache.prebid.org"},
+			query:        "uuid=" + jsonUUID + "&wrapper=true",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "GET /cache uuid=" + jsonUUID + ": json values can't be wrapped in VAST\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		backend := backends.NewMemoryBackend()
+		backend.Put(context.Background(), vastUUID, utils.XML_PREFIX+vast, 0)
+		backend.Put(context.Background(), jsonUUID, utils.JSON_PREFIX+`{"field":"value"}`, 0)
+
+		mockMetrics := metricstest.CreateMockMetrics()
+		m := &metrics.Metrics{
+			MetricEngines: []metrics.CacheMetrics{
+				&mockMetrics,
+			},
+		}
+		settings := config.NewSettings(config.Configuration{VASTWrapper: tc.cfg})
+
+		router := httprouter.New()
+		router.GET("/cache", NewGetHandler(backend, m, settings))
+
+		request, err := http.NewRequest("GET", "http://prebid-cache.example.com/cache?"+tc.query, nil)
+		assert.NoError(t, err, tc.desc)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, request)
+
+		assert.Equal(t, tc.expectedCode, rr.Code, tc.desc)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), tc.desc)
+		if tc.expectedTypes != "" {
+			assert.Equal(t, tc.expectedTypes, rr.Header().Get("Content-Type"), tc.desc)
+		}
+	}
+}
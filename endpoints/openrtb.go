@@ -0,0 +1,263 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prebid/prebid-cache/backends"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/metrics"
+	"github.com/prebid/prebid-cache/utils"
+)
+
+// NewOpenRTBHandler returns the handle function for the "/cache/openrtb" endpoint when it receives a POST
+// request. The bids of the OpenRTB 2.x BidResponse it gets are stored the same way "POST /cache" stores puts.
+func NewOpenRTBHandler(storage backends.Backend, metrics *metrics.Metrics, settings *config.Settings) func(http.ResponseWriter, *http.Request, httprouter.Params) {
+	return newPutHandler(storage, metrics, settings).handleOpenRTB
+}
+
+// handleOpenRTB is the handler function that gets assigned to the POST method of the `/cache/openrtb` endpoint
+func (e *PutHandler) handleOpenRTB(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	e.serve(w, r, e.processOpenRTBRequest)
+}
+
+// openRTBBid holds the fields of an OpenRTB 2.x Bid Prebid Cache needs to store it
+type openRTBBid struct {
+	ID  string `json:"id"`
+	AdM string `json:"adm"`
+	Exp int    `json:"exp"`
+}
+
+// OpenRTBResponse will be marshaled to be written into the http response of "POST /cache/openrtb"
+type OpenRTBResponse struct {
+	Bids   map[string]openRTBResponseObject `json:"bids"`
+	Errors []openRTBBidError                `json:"errors,omitempty"`
+}
+
+// openRTBResponseObject holds the UUIDs a bid and its VAST markup, if any, were stored under
+type openRTBResponseObject struct {
+	UUID     string `json:"uuid"`
+	VASTUUID string `json:"vastuuid,omitempty"`
+}
+
+// openRTBBidError reports a bid that was skipped, by its position in the BidResponse
+type openRTBBidError struct {
+	SeatBid int    `json:"seatbid"`
+	Bid     int    `json:"bid"`
+	ID      string `json:"id,omitempty"`
+	Error   string `json:"error"`
+}
+
+// openRTBPuts maps a bid to the indexes of the puts storing it and its VAST markup in a putRequest.
+// vastIndex is -1 for bids without VAST markup.
+type openRTBPuts struct {
+	bidID     string
+	bidIndex  int
+	vastIndex int
+}
+
+// processOpenRTBRequest turns every bid of the incoming BidResponse into a JSON put of the bid and, if
+// its adm is a VAST document, an XML put of the adm, in which case the bid gets stored without it.
+// Both expire after the bid's exp seconds. The puts are stored like the ones of a "POST /cache"
+// request and the UUIDs they got are returned by bid ID, along with the bids that were skipped.
+func (e *PutHandler) processOpenRTBRequest(r *http.Request) ([]byte, error) {
+	putRequest, bids, bidErrors, err := e.parseOpenRTBRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer e.memory.requestPool.Put(putRequest)
+
+	// Allocate a PutResponse object in thread-safe memory
+	putResponse := e.memory.putResponsePool.Get().(*PutResponse)
+	putResponse.Responses = make([]putResponseObject, len(putRequest.Puts))
+	defer e.memory.putResponsePool.Put(putResponse)
+
+	// Send elements to storage service or database
+	if pcErr := e.putElements(putRequest, putResponse); pcErr != nil {
+		return nil, pcErr
+	}
+
+	response := OpenRTBResponse{Bids: make(map[string]openRTBResponseObject, len(bids)), Errors: bidErrors}
+	for _, bid := range bids {
+		resp := openRTBResponseObject{UUID: putResponse.Responses[bid.bidIndex].UUID}
+		if bid.vastIndex >= 0 {
+			resp.VASTUUID = putResponse.Responses[bid.vastIndex].UUID
+		}
+		response.Bids[bid.bidID] = resp
+	}
+
+	// Marshal Prebid Cache's response
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		return nil, utils.NewPBCError(utils.MARSHAL_RESPONSE)
+	}
+
+	return bytes, nil
+}
+
+// parseOpenRTBRequest decodes the incoming BidResponse as it's read and builds the puts storing its
+// bids into a thread-safe memory pool. Bids that aren't valid OpenRTB bids, have no ID or the ID of
+// a previous bid are skipped and reported. No more puts than the maximum allowed in Prebid Cache's
+// configuration can be built.
+func (e *PutHandler) parseOpenRTBRequest(r *http.Request) (*putRequest, []openRTBPuts, []openRTBBidError, error) {
+	if r == nil {
+		return nil, nil, nil, utils.NewPBCError(utils.PUT_BAD_REQUEST)
+	}
+	defer r.Body.Close()
+
+	// Allocate a PutRequest object in thread-safe memory
+	put := e.memory.requestPool.Get().(*putRequest)
+	put.Puts = put.Puts[:0]
+
+	var bids []openRTBPuts
+	var bidErrors []openRTBBidError
+	seen := make(map[string]bool)
+	maxNumValues := e.settings.Load().RequestLimits.MaxNumValues
+
+	err := decodeOpenRTBBids(r.Body, func(seatBidIndex, bidIndex int, rawBid json.RawMessage) error {
+		var bid openRTBBid
+		if err := json.Unmarshal(rawBid, &bid); err != nil {
+			bidErrors = append(bidErrors, openRTBBidError{SeatBid: seatBidIndex, Bid: bidIndex, Error: fmt.Sprintf("not a valid OpenRTB bid: %v", err)})
+			return nil
+		}
+		if bid.ID == "" {
+			bidErrors = append(bidErrors, openRTBBidError{SeatBid: seatBidIndex, Bid: bidIndex, Error: "missing its id"})
+			return nil
+		}
+		if seen[bid.ID] {
+			bidErrors = append(bidErrors, openRTBBidError{SeatBid: seatBidIndex, Bid: bidIndex, ID: bid.ID, Error: "duplicate id"})
+			return nil
+		}
+		seen[bid.ID] = true
+
+		puts := openRTBPuts{bidID: bid.ID, vastIndex: -1}
+		var vastPut *putObject
+		if _, err := utils.ValidateVAST(bid.AdM); err == nil {
+			// XML values get put as JSON strings. Marshalling a string can't fail.
+			adm, _ := json.Marshal(bid.AdM)
+			vastPut = &putObject{Type: utils.XML_PREFIX, TTLSeconds: bid.Exp, Value: adm}
+			if rawBid, err = withoutAdM(rawBid); err != nil {
+				return utils.NewPBCError(utils.PUT_BAD_REQUEST, fmt.Sprintf("Invalid request body: %v", err))
+			}
+		}
+
+		if len(put.Puts) == maxNumValues || vastPut != nil && len(put.Puts)+1 == maxNumValues {
+			return utils.NewPBCError(utils.PUT_MAX_NUM_VALUES, fmt.Sprintf("More keys than allowed: %d", maxNumValues))
+		}
+		puts.bidIndex = len(put.Puts)
+		put.Puts = append(put.Puts, putObject{Type: utils.JSON_PREFIX, TTLSeconds: bid.Exp, Value: rawBid})
+		if vastPut != nil {
+			puts.vastIndex = len(put.Puts)
+			put.Puts = append(put.Puts, *vastPut)
+		}
+		bids = append(bids, puts)
+		return nil
+	})
+	if err != nil {
+		// place memory back in sync pool
+		e.memory.requestPool.Put(put)
+		return nil, nil, nil, err
+	}
+
+	return put, bids, bidErrors, nil
+}
+
+// decodeOpenRTBBids decodes the bids of the BidResponse in body one by one, and calls visit with each
+// of them along with its position in seatbid[].bid[]. Decoding stops at the first error visit returns.
+// Fields other than "seatbid" and "bid" are skipped.
+func decodeOpenRTBBids(body io.Reader, visit func(seatBidIndex, bidIndex int, rawBid json.RawMessage) error) error {
+	decoder := json.NewDecoder(body)
+
+	err := decodeObject(decoder, "seatbid", func() error {
+		return decodeArray(decoder, func(seatBidIndex int) error {
+			return decodeObject(decoder, "bid", func() error {
+				return decodeArray(decoder, func(bidIndex int) error {
+					var rawBid json.RawMessage
+					if err := decoder.Decode(&rawBid); err != nil {
+						return bodyError(err)
+					}
+					return visit(seatBidIndex, bidIndex, rawBid)
+				})
+			})
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		if err != nil {
+			return bodyError(err)
+		}
+		return utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: unexpected data after the request")
+	}
+	return nil
+}
+
+// decodeObject decodes the next JSON object of decoder, calling decodeField to decode the value of its
+// field named field and skipping the other ones. A null object is decoded as an empty one.
+func decodeObject(decoder *json.Decoder, field string, decodeField func() error) error {
+	if isNull, err := nextIsNull(decoder, '{'); isNull || err != nil {
+		return err
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return bodyError(err)
+		}
+		if name, _ := key.(string); strings.EqualFold(name, field) {
+			if err := decodeField(); err != nil {
+				return err
+			}
+			continue
+		}
+		var skipped json.RawMessage
+		if err := decoder.Decode(&skipped); err != nil {
+			return bodyError(err)
+		}
+	}
+	return expectDelim(decoder, '}')
+}
+
+// decodeArray decodes the next JSON array of decoder, calling decodeElement for each of its elements.
+// A null array is decoded as an empty one.
+func decodeArray(decoder *json.Decoder, decodeElement func(index int) error) error {
+	if isNull, err := nextIsNull(decoder, '['); isNull || err != nil {
+		return err
+	}
+	for index := 0; decoder.More(); index++ {
+		if err := decodeElement(index); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
+}
+
+// nextIsNull reads the next token of decoder, which must be either null or delim
+func nextIsNull(decoder *json.Decoder, delim json.Delim) (bool, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return false, bodyError(err)
+	}
+	if token == nil {
+		return true, nil
+	}
+	if token != delim {
+		return false, utils.NewPBCError(utils.PUT_BAD_REQUEST, fmt.Sprintf("Invalid request body: expected %v. Found %v", delim, token))
+	}
+	return false, nil
+}
+
+// withoutAdM returns the bid in rawBid without its adm field
+func withoutAdM(rawBid json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rawBid, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "adm")
+	return json.Marshal(fields)
+}
@@ -0,0 +1,197 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prebid/prebid-cache/backends"
+	"github.com/prebid/prebid-cache/metrics"
+	"github.com/prebid/prebid-cache/metrics/metricstest"
+	"github.com/prebid/prebid-cache/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenRTBPut(t *testing.T) {
+	videoBid := `{"id":"bid-1","impid":"imp-1","price":1.25,"adm":"<VAST version=\"4.0\"><Ad><InLine></InLine></Ad></VAST>","exp":300}`
+	bannerBid := `{"id":"bid-2","impid":"imp-2","price":0.5,"adm":"<div>banner</div>","exp":60}`
+
+	backend := &mockBackend{}
+	backend.On("Put", mock.Anything, mock.Anything, utils.JSON_PREFIX+`{"exp":300,"id":"bid-1","impid":"imp-1","price":1.25}`, 300).Return(nil)
+	backend.On("Put", mock.Anything, mock.Anything, utils.XML_PREFIX+`<VAST version="4.0"><Ad><InLine></InLine></Ad></VAST>`, 300).Return(nil)
+	backend.On("Put", mock.Anything, mock.Anything, utils.JSON_PREFIX+bannerBid, 60).Return(nil)
+
+	router := newOpenRTBRouter(backend, 10)
+	rr := doOpenRTBPut(t, router, `{"id":"resp-1","seatbid":[{"seat":"a","bid":[`+videoBid+`]},{"seat":"b","bid":[`+bannerBid+`]}],"cur":"USD"}`)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	backend.AssertExpectations(t)
+
+	var response OpenRTBResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Bids, 2)
+	assert.NotEmpty(t, response.Bids["bid-1"].UUID)
+	assert.NotEmpty(t, response.Bids["bid-1"].VASTUUID)
+	assert.NotEqual(t, response.Bids["bid-1"].UUID, response.Bids["bid-1"].VASTUUID)
+	assert.NotEmpty(t, response.Bids["bid-2"].UUID)
+	assert.Empty(t, response.Bids["bid-2"].VASTUUID, "Banner markup shouldn't get stored on its own")
+	assert.Empty(t, response.Errors)
+}
+
+func TestOpenRTBPutStoresRetrievableValues(t *testing.T) {
+	backend := backends.NewMemoryBackend()
+	router := newOpenRTBRouter(backend, 10)
+
+	bid := `{"id":"bid-1","adm":"  <?xml version=\"1.0\"?><VAST version=\"3.0\"><Ad><Wrapper></Wrapper></Ad></VAST>"}`
+	rr := doOpenRTBPut(t, router, `{"seatbid":[{"bid":[`+bid+`]}]}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response OpenRTBResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	storedBid, err := backend.Get(context.Background(), response.Bids["bid-1"].UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.JSON_PREFIX+`{"id":"bid-1"}`, storedBid, "VAST markup isn't stored twice")
+
+	storedVAST, err := backend.Get(context.Background(), response.Bids["bid-1"].VASTUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.XML_PREFIX+`  <?xml version="1.0"?><VAST version="3.0"><Ad><Wrapper></Wrapper></Ad></VAST>`, storedVAST)
+}
+
+func TestOpenRTBPutErrors(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		maxNumValues int
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			desc:         "Malformed BidResponse",
+			maxNumValues: 10,
+			body:         `{"seatbid":[`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid request body: unexpected end of JSON input\n",
+		},
+		{
+			desc:         "Invalid JSON isn't echoed back",
+			maxNumValues: 10,
+			body:         `{"seatbid":[{"bid":[{"id":"bid-1","adm":"secret markup"]}]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid request body: invalid character ']' after object key:value pair\n",
+		},
+		{
+			desc:         "Seatbid is not an array",
+			maxNumValues: 10,
+			body:         `{"seatbid":{"bid":[]}}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid request body: expected [. Found {\n",
+		},
+		{
+			desc:         "Negative exp",
+			maxNumValues: 10,
+			body:         `{"seatbid":[{"bid":[{"id":"bid-1","exp":-1}]}]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "ttlseconds must not be negative -1.\n",
+		},
+		{
+			desc:         "Bid and VAST markup count as two values",
+			maxNumValues: 1,
+			body:         `{"seatbid":[{"bid":[{"id":"bid-1","adm":"<VAST version=\"4.0\"><Ad><InLine></InLine></Ad></VAST>"}]}]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "More keys than allowed: 1\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		router := newOpenRTBRouter(backends.NewMemoryBackend(), tc.maxNumValues)
+		rr := doOpenRTBPut(t, router, tc.body)
+
+		assert.Equal(t, tc.expectedCode, rr.Code, tc.desc)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), tc.desc)
+	}
+}
+
+func TestOpenRTBPutNoBids(t *testing.T) {
+	router := newOpenRTBRouter(backends.NewMemoryBackend(), 10)
+	rr := doOpenRTBPut(t, router, `{"id":"resp-1","nbr":2}`)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"bids":{}}`, rr.Body.String())
+}
+
+func TestOpenRTBPutSkippedBids(t *testing.T) {
+	router := newOpenRTBRouter(backends.NewMemoryBackend(), 10)
+	rr := doOpenRTBPut(t, router, `{"seatbid":[{"bid":["bid-0",{"price":1},{"id":"bid-1"}]},{"bid":[{"id":"bid-1"},{"id":"bid-2"}]}]}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response OpenRTBResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Len(t, response.Bids, 2, "Valid bids get stored")
+	assert.NotEmpty(t, response.Bids["bid-1"].UUID)
+	assert.NotEmpty(t, response.Bids["bid-2"].UUID)
+	assert.Equal(t, []openRTBBidError{
+		{SeatBid: 0, Bid: 0, Error: "not a valid OpenRTB bid: json: cannot unmarshal string into Go value of type endpoints.openRTBBid"},
+		{SeatBid: 0, Bid: 1, Error: "missing its id"},
+		{SeatBid: 1, Bid: 0, ID: "bid-1", Error: "duplicate id"},
+	}, response.Errors, "Invalid bids are skipped and reported")
+}
+
+func TestOpenRTBPutVASTDetection(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		adm          string
+		expectedVAST bool
+	}{
+		{desc: "VAST document", adm: `<VAST version=\"4.0\"><Ad><InLine></InLine></Ad></VAST>`, expectedVAST: true},
+		{desc: "VAST document with an XML declaration and whitespace", adm: `\n<?xml version=\"1.0\"?>\n<VAST version=\"2.0\"><Ad><Wrapper/></Ad></VAST>`, expectedVAST: true},
+		{desc: "Banner HTML", adm: `<div>banner</div>`},
+		{desc: "HTML mentioning VAST", adm: `<div><VAST/></div>`},
+		{desc: "Native JSON mentioning VAST", adm: `{\"native\":{\"assets\":[{\"video\":{\"vasttag\":\"<VAST/>\"}}]}}`},
+		{desc: "No markup", adm: ""},
+	}
+
+	for _, tc := range testCases {
+		router := newOpenRTBRouter(backends.NewMemoryBackend(), 10)
+		rr := doOpenRTBPut(t, router, `{"seatbid":[{"bid":[{"id":"bid-1","adm":"`+tc.adm+`"}]}]}`)
+		if !assert.Equal(t, http.StatusOK, rr.Code, tc.desc) {
+			continue
+		}
+
+		var response OpenRTBResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response), tc.desc)
+		assert.Equal(t, tc.expectedVAST, response.Bids["bid-1"].VASTUUID != "", tc.desc)
+	}
+}
+
+func newOpenRTBRouter(backend backends.Backend, maxNumValues int) *httprouter.Router {
+	mockMetrics := metricstest.CreateMockMetrics()
+	m := &metrics.Metrics{
+		MetricEngines: []metrics.CacheMetrics{
+			&mockMetrics,
+		},
+	}
+
+	router := httprouter.New()
+	router.POST("/cache/openrtb", NewOpenRTBHandler(backend, m, newTestSettings(maxNumValues, false, 0)))
+	return router
+}
+
+func doOpenRTBPut(t *testing.T, router *httprouter.Router, content string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+
+	request, err := http.NewRequest("POST", "/cache/openrtb", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to create a POST request: %v", err)
+	}
+	router.ServeHTTP(rr, request)
+
+	return rr
+}
@@ -2,16 +2,20 @@ package endpoints
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/prebid/prebid-cache/backends"
 	backendDecorators "github.com/prebid/prebid-cache/backends/decorators"
+	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/metrics"
 	"github.com/prebid/prebid-cache/utils"
 	"github.com/sirupsen/logrus"
@@ -19,16 +23,10 @@ import (
 
 // PutHandler serves "POST /cache" requests.
 type PutHandler struct {
-	backend backends.Backend
-	cfg     putHandlerConfig
-	memory  syncPools
-	metrics *metrics.Metrics
-}
-
-type putHandlerConfig struct {
-	maxNumValues   int
-	allowKeys      bool
-	refererLogRate float64
+	backend  backends.Backend
+	settings *config.Settings
+	memory   syncPools
+	metrics  *metrics.Metrics
 }
 
 type syncPools struct {
@@ -36,8 +34,13 @@ type syncPools struct {
 	putResponsePool sync.Pool
 }
 
-// NewPutHandler returns the handle function for the "/cache" endpoint when it receives a POST request
-func NewPutHandler(storage backends.Backend, metrics *metrics.Metrics, maxNumValues int, allowKeys bool, refererLogRate float64) func(http.ResponseWriter, *http.Request, httprouter.Params) {
+// NewPutHandler returns the handle function for the "/cache" endpoint when it receives a POST request.
+// The request limits and logging settings are read from settings on every request.
+func NewPutHandler(storage backends.Backend, metrics *metrics.Metrics, settings *config.Settings) func(http.ResponseWriter, *http.Request, httprouter.Params) {
+	return newPutHandler(storage, metrics, settings).handle
+}
+
+func newPutHandler(storage backends.Backend, metrics *metrics.Metrics, settings *config.Settings) *PutHandler {
 	putHandler := &PutHandler{}
 
 	// Assign storage client to put endpoint
@@ -47,11 +50,7 @@ func NewPutHandler(storage backends.Backend, metrics *metrics.Metrics, maxNumVal
 	putHandler.metrics = metrics
 
 	// Pass configuration values
-	putHandler.cfg = putHandlerConfig{
-		maxNumValues:   maxNumValues,
-		allowKeys:      allowKeys,
-		refererLogRate: refererLogRate,
-	}
+	putHandler.settings = settings
 
 	// Instantiate thread-safe memory pools
 	putHandler.memory = syncPools{
@@ -67,51 +66,144 @@ func NewPutHandler(storage backends.Backend, metrics *metrics.Metrics, maxNumVal
 		},
 	}
 
-	return putHandler.handle
+	return putHandler
+}
+
+// putOverheadBytes is the room a put gets in a request body for its fields other than its value
+const putOverheadBytes = 1024
+
+// maxJSONEscapingFactor is how much larger JSON escaping can make a value at worst: encoders such as
+// Go's escape <, > and & as 6-byte \u003c sequences by default
+const maxJSONEscapingFactor = 6
+
+// maxBodySize is the largest request body Prebid Cache reads when values have a max size: max_num_values puts whose values take up
+// to max_size_bytes even after JSON escaping made each of their bytes 6 bytes long, and the room the rest of the request needs
+func maxBodySize(limits config.RequestLimits) int64 {
+	return int64(limits.MaxNumValues)*(maxJSONEscapingFactor*int64(limits.MaxSize)+putOverheadBytes) + putOverheadBytes
 }
 
-// parseRequest unmarshals the incoming put request into a thread-safe memory pool. If
-// the incoming request could not be unmarshalled or if the request comes with more
-// elements to put than the maximum allowed in Prebid Cache's configuration, the
-// corresponding error is returned
+// parseRequest decodes the incoming put request into a thread-safe memory pool as it's read. If
+// the incoming request could not be decoded or if the request comes with more elements to put
+// than the maximum allowed in Prebid Cache's configuration, the corresponding error is returned
+// without reading the rest of the request
 func (e *PutHandler) parseRequest(r *http.Request) (*putRequest, error) {
 	if r == nil {
 		return nil, utils.NewPBCError(utils.PUT_BAD_REQUEST)
 	}
-
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		return nil, utils.NewPBCError(utils.PUT_BAD_REQUEST)
-	}
 	defer r.Body.Close()
 
 	// Allocate a PutRequest object in thread-safe memory
 	put := e.memory.requestPool.Get().(*putRequest)
-	put.Puts = make([]putObject, 0)
+	put.Puts = put.Puts[:0]
 
-	if err := json.Unmarshal(body, put); err != nil {
+	if err := decodePutRequest(r.Body, put, e.settings.Load().RequestLimits.MaxNumValues); err != nil {
 		// place memory back in sync pool
 		e.memory.requestPool.Put(put)
-		return nil, utils.NewPBCError(utils.PUT_BAD_REQUEST, string(body))
+		return nil, err
 	}
 
-	if len(put.Puts) > e.cfg.maxNumValues {
-		// place memory back in sync pool
-		e.memory.requestPool.Put(put)
-		return nil, utils.NewPBCError(utils.PUT_MAX_NUM_VALUES, fmt.Sprintf("More keys than allowed: %d", e.cfg.maxNumValues))
+	return put, nil
+}
+
+// decodePutRequest decodes the puts in body one by one into put, and stops at the first one over
+// maxNumValues. Fields other than "puts" are skipped.
+func decodePutRequest(body io.Reader, put *putRequest, maxNumValues int) error {
+	decoder := json.NewDecoder(body)
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
 	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return bodyError(err)
+		}
+		if name, _ := key.(string); !strings.EqualFold(name, "puts") {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return bodyError(err)
+			}
+			continue
+		}
 
-	return put, nil
+		// As with any JSON field, the last "puts" wins
+		put.Puts = put.Puts[:0]
+		token, err := decoder.Token()
+		if err != nil {
+			return bodyError(err)
+		}
+		if token == nil {
+			continue
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return utils.NewPBCError(utils.PUT_BAD_REQUEST, fmt.Sprintf("Invalid request body: puts must be an array. Found %v", token))
+		}
+		for decoder.More() {
+			if len(put.Puts) == maxNumValues {
+				return utils.NewPBCError(utils.PUT_MAX_NUM_VALUES, fmt.Sprintf("More keys than allowed: %d", maxNumValues))
+			}
+			var po putObject
+			if err := decoder.Decode(&po); err != nil {
+				return bodyError(err)
+			}
+			put.Puts = append(put.Puts, po)
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return err
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		if err != nil {
+			return bodyError(err)
+		}
+		return utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: unexpected data after the request")
+	}
+	return nil
+}
+
+// expectDelim reads the next token of decoder, which must be delim
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return bodyError(err)
+	}
+	if token != delim {
+		return utils.NewPBCError(utils.PUT_BAD_REQUEST, fmt.Sprintf("Invalid request body: expected %v. Found %v", delim, token))
+	}
+	return nil
+}
+
+// bodyError returns the error for a request body that couldn't be read or decoded. Bodies over
+// the size maxBodySize allows get a PUT_REQUEST_TOO_LARGE error.
+func bodyError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return utils.NewPBCError(utils.PUT_REQUEST_TOO_LARGE, fmt.Sprintf("Request body is larger than the %d bytes allowed", maxBytesErr.Limit))
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: unexpected end of JSON input")
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return utils.NewPBCError(utils.PUT_BAD_REQUEST, fmt.Sprintf("Invalid request body: %v", err))
+	default:
+		return utils.NewPBCError(utils.PUT_BAD_REQUEST)
+	}
 }
 
 // parsePutObject returns an error if the putObject comes with an invalid field
 // and formats the string according to its type:
 //   - XML content gets unmarshaled in order to un-escape it and then gets
 //     prepended by its type
-//   - JSON content gets prepended by its type
+//   - JSON content, which decoding the request already validated, gets prepended by its type
 //
 // No other formats are supported.
-func parsePutObject(p putObject) (string, error) {
+func parsePutObject(p putObject, contentTypes *utils.ContentTypes) (string, error) {
 	var toCache string
 
 	// Make sure there's data to store
@@ -124,7 +216,7 @@ func parsePutObject(p putObject) (string, error) {
 		return "", utils.NewPBCError(utils.NEGATIVE_TTL, fmt.Sprintf("ttlseconds must not be negative %d.", p.TTLSeconds))
 	}
 
-	// Limit the type of data to XML or JSON
+	// Limit the type of data to XML, JSON or the configured content types
 	if p.Type == utils.XML_PREFIX {
 		// Be careful about the cross-script escaping issues here. JSON requires quotation marks to be escaped,
 		// for example... so we'll need to un-escape it before we consider it to be XML content.
@@ -136,13 +228,45 @@ func parsePutObject(p putObject) (string, error) {
 		toCache = p.Type + interpreted
 	} else if p.Type == utils.JSON_PREFIX {
 		toCache = p.Type + string(p.Value)
+	} else if contentType, ok := contentTypes.Get(p.Type); ok {
+		value, err := parseContentTypeValue(p.Value, contentType)
+		if err != nil {
+			return "", err
+		}
+
+		toCache = p.Type + value
 	} else {
-		return "", utils.NewPBCError(utils.UNSUPPORTED_DATA_TO_STORE, fmt.Sprintf("Type must be one of [\"json\", \"xml\"]. Found '%s'", p.Type))
+		return "", utils.NewPBCError(utils.UNSUPPORTED_DATA_TO_STORE, fmt.Sprintf("Type must be one of [\"%s\"]. Found '%s'", strings.Join(contentTypes.Names(), `", "`), p.Type))
 	}
 
 	return toCache, nil
 }
 
+// parseContentTypeValue returns the string value of a configured content type, which gets stored as
+// it's put. Base64-encoded values must decode, and are validated decoded.
+func parseContentTypeValue(rawValue json.RawMessage, contentType utils.ContentType) (string, error) {
+	var value string
+	if err := json.Unmarshal(rawValue, &value); err != nil {
+		return "", utils.NewPBCError(utils.PUT_BAD_REQUEST, fmt.Sprintf("%s values must have a String value. Found %s", contentType.Name, rawValue))
+	}
+
+	decoded := []byte(value)
+	if contentType.Encoding == utils.ENCODING_BASE64 {
+		var err error
+		if decoded, err = base64.StdEncoding.DecodeString(value); err != nil {
+			return "", utils.NewPBCError(utils.PUT_BAD_REQUEST, fmt.Sprintf("Invalid %s value: value is not base64-encoded", contentType.Name))
+		}
+	}
+
+	if contentType.Validate != nil {
+		if err := contentType.Validate(decoded); err != nil {
+			return "", utils.NewPBCError(utils.PUT_BAD_REQUEST, fmt.Sprintf("Invalid %s value: %v", contentType.Name, err))
+		}
+	}
+
+	return value, nil
+}
+
 // unescapeXML unmarshalls the rawXML into a string in order to unescape characters
 func unescapeXML(rawXML json.RawMessage) (string, error) {
 	if rawXML[0] != byte('"') || rawXML[len(rawXML)-1] != byte('"') {
@@ -157,13 +281,53 @@ func unescapeXML(rawXML json.RawMessage) (string, error) {
 	return interpreted, nil
 }
 
+// validateXML checks the XML value of the put at index is a well-formed VAST document when
+// request_limits.validate_xml is on. Invalid values are counted by reason and logged, and only get
+// rejected in "reject" mode.
+func (e *PutHandler) validateXML(value string, index int) error {
+	mode := e.settings.Load().RequestLimits.ValidateXML
+	if mode == "" || mode == config.ValidateXMLOff {
+		return nil
+	}
+
+	reason, err := utils.ValidateVAST(value)
+	if err == nil {
+		return nil
+	}
+	e.metrics.RecordPutInvalidXML(reason)
+
+	if mode == config.ValidateXMLReject {
+		return utils.NewPBCError(err.(utils.PBCError).Type, fmt.Sprintf("POST /cache element %d: %v", index, err))
+	}
+	logrus.Warnf("POST /cache element %d: %v", index, err)
+	return nil
+}
+
+// validateJSONSchema checks the value of the put at index matches the JSON schema configured for its
+// content type, if any, and records how long that took
+func (e *PutHandler) validateJSONSchema(contentType, value string, index int) error {
+	schema, ok := e.settings.JSONSchemas().Get(contentType)
+	if !ok {
+		return nil
+	}
+
+	start := time.Now()
+	err := schema.Validate([]byte(value))
+	e.metrics.RecordPutJSONValidationDuration(time.Since(start))
+
+	if err != nil {
+		return utils.NewPBCError(utils.INVALID_JSON, fmt.Sprintf("POST /cache element %d: Invalid %s value: %v", index, contentType, err))
+	}
+	return nil
+}
+
 func classifyBackendError(err error, index int) error {
 	if _, ok := err.(*backendDecorators.BadPayloadSize); ok {
 		return utils.NewPBCError(utils.BAD_PAYLOAD_SIZE, fmt.Sprintf("POST /cache element %d exceeded max size: %v", index, err.Error()))
 	}
 
-	switch err {
-	case context.DeadlineExceeded:
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
 		return utils.NewPBCError(utils.PUT_DEADLINE_EXCEEDED)
 	default:
 		return utils.NewPBCError(utils.PUT_INTERNAL_SERVER, err.Error())
@@ -184,30 +348,45 @@ func logBackendError(err error) {
 
 // handle is the handler function that gets assigned to the POST method of the `/cache` endpoint
 func (e *PutHandler) handle(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	e.serve(w, r, e.processPutRequest)
+}
+
+// serve records the put metrics of a request and writes the response process builds for it, or
+// the error it failed with
+func (e *PutHandler) serve(w http.ResponseWriter, r *http.Request, process func(*http.Request) ([]byte, error)) {
 	e.metrics.RecordPutTotal()
 
-	// If incoming request comes with a referer header, there's a e.cfg.refererLogRate percent chance
+	// If incoming request comes with a referer header, there's a referer_sampling_rate percent chance
 	// getting it logged
-	if referer := r.Referer(); referer != "" && utils.RandomPick(e.cfg.refererLogRate) {
+	if referer := r.Referer(); referer != "" && utils.RandomPick(e.settings.Load().RequestLogging.RefererSamplingRate) {
 		logrus.Info("POST request Referer header: " + referer)
 	}
 
 	start := time.Now()
 
-	bytes, err := e.processPutRequest(r)
+	// Cap the body so a single huge request can't make Prebid Cache allocate arbitrary memory, unless
+	// values have no max size
+	if limits := e.settings.Load().RequestLimits; limits.MaxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize(limits))
+	}
+
+	bytes, err := process(r)
 	if err != nil {
 		// At least one of the elements in the incoming request could not be stored
 		// write the http error and log corresponding metrics
 		var statusCode int
 		if pbcErr, isPBCErr := err.(utils.PBCError); isPBCErr {
 			statusCode = pbcErr.StatusCode
+			if pbcErr.Type == utils.PUT_REQUEST_TOO_LARGE {
+				e.metrics.RecordPutRequestTooLarge()
+			}
 			if statusCode >= 400 && statusCode < 500 {
 				e.metrics.RecordPutBadRequest()
 			} else {
 				e.metrics.RecordPutError()
 			}
 		} else {
-			// All errors returned by e.processPutRequest(r) should be utils.PBCErrors
+			// All errors returned by process(r) should be utils.PBCErrors
 			// if not, consider it an interval server error with a http.StatusInternalServerError
 			// status code and accounted under RecordPutError()
 			statusCode = http.StatusInternalServerError
@@ -287,14 +466,26 @@ func (e *PutHandler) putElements(put *putRequest, resps *PutResponse) error {
 func (e *PutHandler) put(po *putObject, resp *putResponseObject, index int, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	toCache, err := parsePutObject(*po)
+	toCache, err := parsePutObject(*po, e.settings.ContentTypes())
 	if err != nil {
 		resp.err = err
 		return
 	}
 
+	if po.Type == utils.XML_PREFIX {
+		if err := e.validateXML(toCache[len(utils.XML_PREFIX):], index); err != nil {
+			resp.err = err
+			return
+		}
+	}
+
+	if err := e.validateJSONSchema(po.Type, toCache[len(po.Type):], index); err != nil {
+		resp.err = err
+		return
+	}
+
 	// Only allow setting a provided key if configured (and ensure a key is provided).
-	if e.cfg.allowKeys && len(po.Key) > 0 {
+	if e.settings.Load().RequestLimits.AllowSettingKeys && len(po.Key) > 0 {
 		// put object comes with custom key, which we are allowed to use
 		resp.UUID = po.Key
 		e.metrics.RecordPutKeyProvided()
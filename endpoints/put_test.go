@@ -50,7 +50,7 @@ func TestPutJsonTests(t *testing.T) {
 		}
 
 		router := httprouter.New()
-		router.POST("/cache", NewPutHandler(backend, m, tc.HostConfig.MaxNumValues, tc.HostConfig.AllowSettingKeys, tc.HostConfig.RefererLogRate))
+		router.POST("/cache", NewPutHandler(backend, m, newTestSettings(tc.HostConfig.MaxNumValues, tc.HostConfig.AllowSettingKeys, tc.HostConfig.RefererLogRate)))
 		request, err := http.NewRequest("POST", "/cache", strings.NewReader(string(tc.Request.Body)))
 		if !assert.NoError(t, err, "Failed to create a POST request. Test file: %s Error: %v", testFile, err) {
 			hook.Reset()
@@ -139,6 +139,19 @@ type hostConfig struct {
 	RefererLogRate   float64     `json:"referer_sampling_rate"`
 }
 
+// newTestSettings returns the runtime settings the PUT and GET handlers read on every request
+func newTestSettings(maxNumValues int, allowSettingKeys bool, refererSamplingRate float64) *config.Settings {
+	return config.NewSettings(config.Configuration{
+		RequestLimits: config.RequestLimits{
+			MaxNumValues:     maxNumValues,
+			AllowSettingKeys: allowSettingKeys,
+		},
+		RequestLogging: config.RequestLogging{
+			RefererSamplingRate: refererSamplingRate,
+		},
+	})
+}
+
 type fakeBackend struct {
 	ErrorMsg       string       `json:"throw_error_message"`
 	ReturnBool     bool         `json:"throw_bool"`
@@ -396,19 +409,19 @@ func TestStatusEndpointReadiness(t *testing.T) {
 	testCases := []testCase{
 		{
 			description:      "Empty response",
-			handler:          NewStatusEndpoint(""),
+			handler:          NewStatusEndpoint("", nil),
 			expectedRespCode: http.StatusNoContent,
 			expectedRespBody: bytes.NewBuffer(nil),
 		},
 		{
 			description:      "string response",
-			handler:          NewStatusEndpoint("ready"),
+			handler:          NewStatusEndpoint("ready", nil),
 			expectedRespCode: http.StatusOK,
 			expectedRespBody: bytes.NewBuffer([]byte("ready")),
 		},
 		{
 			description:      "JSON string response",
-			handler:          NewStatusEndpoint(`{"status": "ok"}`),
+			handler:          NewStatusEndpoint(`{"status": "ok"}`, nil),
 			expectedRespCode: http.StatusOK,
 			expectedRespBody: bytes.NewBuffer([]byte(`{"status": "ok"}`)),
 		},
@@ -566,8 +579,8 @@ func TestSuccessfulPut(t *testing.T) {
 				},
 			}
 
-			router.POST("/cache", NewPutHandler(backend, m, 10, true, 0.0))
-			router.GET("/cache", NewGetHandler(backend, m, true, 0.0))
+			router.POST("/cache", NewPutHandler(backend, m, newTestSettings(10, true, 0.0)))
+			router.GET("/cache", NewGetHandler(backend, m, newTestSettings(10, true, 0.0)))
 
 			// Feed the tests input put request to the endpoint's handle
 			putResponse := doPut(t, router, tc.inPutBody)
@@ -614,13 +627,13 @@ func TestMalformedOrInvalidValue(t *testing.T) {
 		{
 			desc:             "Badly escaped character in value field",
 			inPutBody:        `{"puts":[{"type":"json","value":"badly-esca"ped"}]}`,
-			expectedError:    utils.NewPBCError(utils.PUT_BAD_REQUEST, `{"puts":[{"type":"json","value":"badly-esca"ped"}]}`),
+			expectedError:    utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: invalid character 'p' after object key:value pair"),
 			expectedPutCalls: 0,
 		},
 		{
 			desc:             "Malformed JSON in value field",
 			inPutBody:        `{"puts":[{"type":"json","value":malformed}]}`,
-			expectedError:    utils.NewPBCError(utils.PUT_BAD_REQUEST, `{"puts":[{"type":"json","value":malformed}]}`),
+			expectedError:    utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: invalid character 'm' looking for beginning of value"),
 			expectedPutCalls: 0,
 		},
 		{
@@ -656,7 +669,7 @@ func TestMalformedOrInvalidValue(t *testing.T) {
 			},
 		}
 
-		router.POST("/cache", NewPutHandler(backend, m, 10, true, 0.0))
+		router.POST("/cache", NewPutHandler(backend, m, newTestSettings(10, true, 0.0)))
 
 		// Run test
 		putResponse := doPut(t, router, tc.inPutBody)
@@ -692,7 +705,7 @@ func TestNonSupportedType(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	router.POST("/cache", NewPutHandler(backend, m, 10, true, 0.0))
+	router.POST("/cache", NewPutHandler(backend, m, newTestSettings(10, true, 0.0)))
 
 	putResponse := doPut(t, router, requestBody)
 
@@ -728,7 +741,7 @@ func TestPutNegativeTTL(t *testing.T) {
 		},
 	}
 
-	testRouter.POST("/cache", NewPutHandler(testBackend, m, 10, true, 0.0))
+	testRouter.POST("/cache", NewPutHandler(testBackend, m, newTestSettings(10, true, 0.0)))
 
 	recorder := httptest.NewRecorder()
 
@@ -830,7 +843,7 @@ func TestCustomKey(t *testing.T) {
 			}
 
 			router := httprouter.New()
-			putEndpointHandler := NewPutHandler(mockBackendWithValues, m, 10, tgroup.allowSettingKeys, 0.0)
+			putEndpointHandler := NewPutHandler(mockBackendWithValues, m, newTestSettings(10, tgroup.allowSettingKeys, 0.0))
 			router.POST("/cache", putEndpointHandler)
 
 			recorder := httptest.NewRecorder()
@@ -867,7 +880,7 @@ func TestRequestReadError(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	putEndpointHandler := NewPutHandler(mockBackendWithValues, m, 10, false, 0.0)
+	putEndpointHandler := NewPutHandler(mockBackendWithValues, m, newTestSettings(10, false, 0.0))
 
 	router := httprouter.New()
 	router.POST("/cache", putEndpointHandler)
@@ -912,7 +925,7 @@ func TestTooManyPutElements(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	router.POST("/cache", NewPutHandler(backend, m, len(putElements)-1, true, 0.0))
+	router.POST("/cache", NewPutHandler(backend, m, newTestSettings(len(putElements)-1, true, 0.0)))
 
 	putResponse := doPut(t, router, reqBody)
 
@@ -926,6 +939,34 @@ func TestTooManyPutElements(t *testing.T) {
 	metricstest.AssertMetrics(t, expectedMetrics, mockMetrics)
 }
 
+// TestTooManyPutElementsAfterSettingsUpdate asserts the PUT handler enforces the max_num_values
+// in effect at the time of the request rather than the one it was created with
+func TestTooManyPutElementsAfterSettingsUpdate(t *testing.T) {
+	reqBody := `{"puts":[{"type":"json","value":true}, {"type":"xml","value":"plain text"}]}`
+
+	backend := &mockBackend{}
+	backend.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockMetrics := metricstest.CreateMockMetrics()
+	m := &metrics.Metrics{
+		MetricEngines: []metrics.CacheMetrics{
+			&mockMetrics,
+		},
+	}
+	settings := newTestSettings(2, false, 0.0)
+	router := httprouter.New()
+	router.POST("/cache", NewPutHandler(backend, m, settings))
+
+	putResponse := doPut(t, router, reqBody)
+	assert.Equal(t, http.StatusOK, putResponse.Code, "Two elements should have been allowed")
+
+	settings.Update(config.Configuration{RequestLimits: config.RequestLimits{MaxNumValues: 1}})
+
+	putResponse = doPut(t, router, reqBody)
+	assert.Equal(t, http.StatusBadRequest, putResponse.Code, "Two elements should have been rejected after the update")
+	assert.Equal(t, "More keys than allowed: 1\n", putResponse.Body.String())
+}
+
 // TestMultiPutRequest asserts results for requests with more than one element in the "puts" array
 func TestMultiPutRequest(t *testing.T) {
 	type aTest struct {
@@ -967,8 +1008,8 @@ func TestMultiPutRequest(t *testing.T) {
 		},
 	}
 
-	router.POST("/cache", NewPutHandler(backend, m, 10, true, 0.0))
-	router.GET("/cache", NewGetHandler(backend, m, true, 0.0))
+	router.POST("/cache", NewPutHandler(backend, m, newTestSettings(10, true, 0.0)))
+	router.GET("/cache", NewGetHandler(backend, m, newTestSettings(10, true, 0.0)))
 
 	rr := httptest.NewRecorder()
 
@@ -1018,7 +1059,7 @@ func TestBadPayloadSizePutError(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	router.POST("/cache", NewPutHandler(backend, m, 10, true, 0.0))
+	router.POST("/cache", NewPutHandler(backend, m, newTestSettings(10, true, 0.0)))
 
 	putResponse := doPut(t, router, reqBody)
 
@@ -1038,7 +1079,7 @@ func TestInternalPutClientError(t *testing.T) {
 	expectedMetrics := []string{
 		"RecordPutTotal",
 		"RecordPutError",
-		"RecordPutBackendXml",
+		"RecordPutBackendType",
 		"RecordPutBackendError",
 		"RecordPutBackendSize",
 		"RecordPutBackendTTLSeconds",
@@ -1053,9 +1094,9 @@ func TestInternalPutClientError(t *testing.T) {
 		},
 	}
 	// Use mock client that will return an error
-	backendWithMetrics := decorators.LogMetrics(newErrorReturningBackend(), m)
+	backendWithMetrics := decorators.LogMetrics(newErrorReturningBackend(), m, newTestSettings(10, true, 0.0).ContentTypes())
 
-	router.POST("/cache", NewPutHandler(backendWithMetrics, m, 10, true, 0.0))
+	router.POST("/cache", NewPutHandler(backendWithMetrics, m, newTestSettings(10, true, 0.0)))
 
 	// Run test
 	putResponse := doPut(t, router, reqBody)
@@ -1066,6 +1107,125 @@ func TestInternalPutClientError(t *testing.T) {
 	metricstest.AssertMetrics(t, expectedMetrics, mockMetrics)
 }
 
+func TestPutValidateXML(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		mode            string
+		value           string
+		expectedCode    int
+		expectedBody    string
+		expectedMetrics []string
+	}{
+		{
+			desc:         "Validation off stores malformed XML",
+			mode:         config.ValidateXMLOff,
+			value:        "<VAST version='4.0'><Ad>",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:            "Log mode stores malformed XML, counting it",
+			mode:            config.ValidateXMLLog,
+			value:           "<VAST version='4.0'><Ad>",
+			expectedCode:    http.StatusOK,
+			expectedMetrics: []string{"RecordPutInvalidXML"},
+		},
+		{
+			desc:            "Reject mode rejects malformed XML",
+			mode:            config.ValidateXMLReject,
+			value:           "<VAST version='4.0'><Ad>",
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    "POST /cache element 0: Invalid XML value: XML syntax error on line 1: unexpected EOF\n",
+			expectedMetrics: []string{"RecordPutInvalidXML"},
+		},
+		{
+			desc:            "Reject mode rejects VAST without a version",
+			mode:            config.ValidateXMLReject,
+			value:           "<VAST><Ad><InLine></InLine></Ad></VAST>",
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    "POST /cache element 0: Invalid VAST value: the <VAST> element has no version attribute\n",
+			expectedMetrics: []string{"RecordPutInvalidXML"},
+		},
+		{
+			desc:         "Reject mode stores valid VAST",
+			mode:         config.ValidateXMLReject,
+			value:        "<VAST version='4.0'><Ad><Wrapper></Wrapper></Ad></VAST>",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		router := httprouter.New()
+		mockMetrics := metricstest.CreateMockMetrics()
+		m := &metrics.Metrics{
+			MetricEngines: []metrics.CacheMetrics{
+				&mockMetrics,
+			},
+		}
+		settings := config.NewSettings(config.Configuration{
+			RequestLimits: config.RequestLimits{MaxNumValues: 10, ValidateXML: tc.mode},
+		})
+		router.POST("/cache", NewPutHandler(backends.NewMemoryBackend(), m, settings))
+
+		putResponse := doPut(t, router, `{"puts":[{"type":"xml","value":"`+tc.value+`"}]}`)
+
+		assert.Equal(t, tc.expectedCode, putResponse.Code, tc.desc)
+		if tc.expectedBody != "" {
+			assert.Equal(t, tc.expectedBody, putResponse.Body.String(), tc.desc)
+		}
+		if len(tc.expectedMetrics) > 0 {
+			mockMetrics.AssertCalled(t, "RecordPutInvalidXML")
+		} else {
+			mockMetrics.AssertNotCalled(t, "RecordPutInvalidXML")
+		}
+	}
+}
+
+func TestPutJSONSchema(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		value        string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			desc:         "Bid matching the schema",
+			value:        `{"id":"bid-1","impid":"imp-1","price":1.25}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "Bid not matching the schema",
+			value:        `{"id":"bid-1","impid":"imp-1","price":"1.25"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "POST /cache element 0: Invalid json value: value doesn't match the openrtb_bid JSON schema: /price: expected number, but got string\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		router := httprouter.New()
+		mockMetrics := metricstest.CreateMockMetrics()
+		m := &metrics.Metrics{
+			MetricEngines: []metrics.CacheMetrics{
+				&mockMetrics,
+			},
+		}
+		settings := config.NewSettings(config.Configuration{
+			RequestLimits: config.RequestLimits{MaxNumValues: 10},
+			JSONSchemas: []config.JSONSchema{
+				{Name: "openrtb_bid", File: "../config/configtest/openrtb_bid_schema.json", Types: []string{"json"}},
+			},
+		})
+		router.POST("/cache", NewPutHandler(backends.NewMemoryBackend(), m, settings))
+
+		putResponse := doPut(t, router, `{"puts":[{"type":"json","value":`+tc.value+`}]}`)
+
+		assert.Equal(t, tc.expectedCode, putResponse.Code, tc.desc)
+		if tc.expectedBody != "" {
+			assert.Equal(t, tc.expectedBody, putResponse.Body.String(), tc.desc)
+		}
+		mockMetrics.AssertCalled(t, "RecordPutJSONValidationDuration")
+	}
+}
+
 func TestEmptyPutRequests(t *testing.T) {
 	type testOutput struct {
 		jsonResponse            string
@@ -1129,7 +1289,7 @@ func TestEmptyPutRequests(t *testing.T) {
 			},
 		}
 		router := httprouter.New()
-		router.POST("/cache", NewPutHandler(backend, m, 10, true, 0.0))
+		router.POST("/cache", NewPutHandler(backend, m, newTestSettings(10, true, 0.0)))
 		rr := httptest.NewRecorder()
 
 		// Create request everytime
@@ -1172,7 +1332,7 @@ func TestPutClientDeadlineExceeded(t *testing.T) {
 			&mockMetrics,
 		},
 	}
-	router.POST("/cache", NewPutHandler(backend, m, 10, true, 0.0))
+	router.POST("/cache", NewPutHandler(backend, m, newTestSettings(10, true, 0.0)))
 
 	putResponse := doPut(t, router, reqBody)
 
@@ -1210,7 +1370,7 @@ func TestParseRequest(t *testing.T) {
 				r, _ := http.NewRequest("POST", "http://fakeurl.com", bytes.NewBuffer([]byte(`malformed`)))
 				return r
 			},
-			testOut{nil, utils.NewPBCError(utils.PUT_BAD_REQUEST, "malformed")},
+			testOut{nil, utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: invalid character 'm' looking for beginning of value")},
 		},
 		{
 			"valid request body. Expect no error",
@@ -1246,7 +1406,7 @@ func TestParseRequest(t *testing.T) {
 					New: func() interface{} { return &putRequest{} },
 				},
 			},
-			cfg: putHandlerConfig{maxNumValues: 1},
+			settings: newTestSettings(1, false, 0.0),
 		}
 		// run
 		put, err := putHandler.parseRequest(tc.getInputRequest())
@@ -1256,6 +1416,154 @@ func TestParseRequest(t *testing.T) {
 	}
 }
 
+func TestDecodePutRequest(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		inBody       string
+		expectedPuts []putObject
+		expectedErr  error
+	}{
+		{
+			desc:         "Fields other than puts are skipped",
+			inBody:       `{"id":{"nested":[1,2]},"puts":[{"type":"json","ttlseconds":60,"value":[1,2]}],"other":"field"}`,
+			expectedPuts: []putObject{{Type: "json", TTLSeconds: 60, Value: json.RawMessage(`[1,2]`)}},
+		},
+		{
+			desc:   "Null puts",
+			inBody: `{"puts":null}`,
+		},
+		{
+			desc:         "Last puts wins",
+			inBody:       `{"puts":[{"type":"json","value":1}],"PUTS":[{"type":"json","value":2}]}`,
+			expectedPuts: []putObject{{Type: "json", Value: json.RawMessage(`2`)}},
+		},
+		{
+			desc:        "Decoding stops at the first put over the max, without reading the rest of the body",
+			inBody:      `{"puts":[{"type":"json","value":1},{"type":"json","value":2},{"type":"json","value":3}, this is never read`,
+			expectedErr: utils.NewPBCError(utils.PUT_MAX_NUM_VALUES, "More keys than allowed: 2"),
+		},
+		{
+			desc:        "Request isn't an object",
+			inBody:      `[{"type":"json","value":1}]`,
+			expectedErr: utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: expected {. Found ["),
+		},
+		{
+			desc:        "Puts isn't an array",
+			inBody:      `{"puts":{"type":"json","value":1}}`,
+			expectedErr: utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: puts must be an array. Found {"),
+		},
+		{
+			desc:        "Put isn't an object",
+			inBody:      `{"puts":["json"]}`,
+			expectedErr: utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: json: cannot unmarshal string into Go value of type endpoints.putObject"),
+		},
+		{
+			desc:        "Truncated body",
+			inBody:      `{"puts":[{"type":"json","value":1}`,
+			expectedErr: utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: unexpected end of JSON input"),
+		},
+		{
+			desc:        "Data after the request",
+			inBody:      `{"puts":[]} {"puts":[]}`,
+			expectedErr: utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid request body: unexpected data after the request"),
+		},
+	}
+
+	for _, tc := range testCases {
+		put := &putRequest{}
+
+		err := decodePutRequest(strings.NewReader(tc.inBody), put, 2)
+
+		assert.Equal(t, tc.expectedErr, err, tc.desc)
+		if tc.expectedErr == nil {
+			assert.Equal(t, tc.expectedPuts, put.Puts, tc.desc)
+		}
+	}
+}
+
+func TestPutRequestTooLarge(t *testing.T) {
+	backend := &mockBackend{}
+	mockMetrics := metricstest.CreateMockMetrics()
+	m := &metrics.Metrics{
+		MetricEngines: []metrics.CacheMetrics{
+			&mockMetrics,
+		},
+	}
+	settings := config.NewSettings(config.Configuration{
+		RequestLimits: config.RequestLimits{MaxSize: 10, MaxNumValues: 2},
+	})
+	router := httprouter.New()
+	router.POST("/cache", NewPutHandler(backend, m, settings))
+
+	// The body can't be larger than 2 * (6 * 10 + 1024) + 1024 = 3192 bytes
+	putResponse := doPut(t, router, `{"puts":[{"type":"xml","value":"`+strings.Repeat("a", 3192)+`"}]}`)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, putResponse.Code)
+	assert.Equal(t, "Request body is larger than the 3192 bytes allowed\n", putResponse.Body.String())
+	backend.AssertNotCalled(t, "Put")
+
+	expectedMetrics := []string{"RecordPutTotal", "RecordPutRequestTooLarge", "RecordPutBadRequest"}
+	metricstest.AssertMetrics(t, expectedMetrics, mockMetrics)
+}
+
+func TestPutWithoutMaxSize(t *testing.T) {
+	backend := backends.NewMemoryBackend()
+	mockMetrics := metricstest.CreateMockMetrics()
+	m := &metrics.Metrics{
+		MetricEngines: []metrics.CacheMetrics{
+			&mockMetrics,
+		},
+	}
+	settings := config.NewSettings(config.Configuration{
+		RequestLimits: config.RequestLimits{MaxSize: 0, MaxNumValues: 1},
+	})
+	router := httprouter.New()
+	router.POST("/cache", NewPutHandler(backend, m, settings))
+
+	// With a max size, the body couldn't be larger than 1 * (6 * 0 + 1024) + 1024 = 2048 bytes
+	value := strings.Repeat("a", 100*1024)
+	putResponse := doPut(t, router, `{"puts":[{"type":"xml","value":"`+value+`"}]}`)
+
+	assert.Equal(t, http.StatusOK, putResponse.Code, "Values of any size get stored when max_size_bytes is 0")
+}
+
+func TestPutHTMLEscapedMarkup(t *testing.T) {
+	backend := backends.NewMemoryBackend()
+	mockMetrics := metricstest.CreateMockMetrics()
+	m := &metrics.Metrics{
+		MetricEngines: []metrics.CacheMetrics{
+			&mockMetrics,
+		},
+	}
+	settings := config.NewSettings(config.Configuration{
+		RequestLimits: config.RequestLimits{MaxSize: 1000, MaxNumValues: 2},
+	})
+	router := httprouter.New()
+	router.POST("/cache", NewPutHandler(backend, m, settings))
+
+	// A value of max_size_bytes that is mostly angle brackets, which Go clients escape as \u003c and
+	// \u003e by default, so the body is about 6 times larger than the values it holds
+	value := "<VAST>" + strings.Repeat("<>", 493) + "<</VAST>"
+	require.Len(t, value, 1000)
+	body, err := json.Marshal(map[string]interface{}{
+		"puts": []map[string]interface{}{
+			{"type": "xml", "value": value},
+			{"type": "xml", "value": value},
+		},
+	})
+	require.NoError(t, err)
+	require.Contains(t, string(body), `\u003cVAST\u003e`, "Go clients escape markup")
+
+	putResponse := doPut(t, router, string(body))
+
+	assert.Equal(t, http.StatusOK, putResponse.Code, "Values within max_size_bytes fit in the body however they're escaped")
+}
+
+func TestMaxBodySize(t *testing.T) {
+	assert.Equal(t, int64(1024), maxBodySize(config.RequestLimits{}), "Room for an empty request")
+	assert.Equal(t, int64(10*(6*10240+1024)+1024), maxBodySize(config.RequestLimits{MaxSize: 10240, MaxNumValues: 10}))
+}
+
 // TestParsePutObject asserts *PutHandler's parsePutObject(p PutObject) method
 func TestParsePutObject(t *testing.T) {
 	type testOut struct {
@@ -1327,17 +1635,29 @@ func TestParsePutObject(t *testing.T) {
 			putObject{
 				Type:       "json",
 				TTLSeconds: 60,
-				Value:      json.RawMessage(`{"native":"{\"context\":1,\"plcmttype\":1,\"assets\":[{\"img\":{\"wmin\":30}}]}}`),
+				Value:      json.RawMessage(`{"native":"{\"context\":1,\"plcmttype\":1,\"assets\":[{\"img\":{\"wmin\":30}}]}"}`),
 			},
 			testOut{
-				`json{"native":"{\"context\":1,\"plcmttype\":1,\"assets\":[{\"img\":{\"wmin\":30}}]}}`,
+				`json{"native":"{\"context\":1,\"plcmttype\":1,\"assets\":[{\"img\":{\"wmin\":30}}]}"}`,
 				nil,
 			},
 		},
+		{
+			"JSON input is stored as decoded from the request, which already validated it",
+			putObject{
+				Type:       "json",
+				TTLSeconds: 60,
+				Value:      json.RawMessage(`{"native":"unterminated}`),
+			},
+			testOut{
+				value: `json{"native":"unterminated}`,
+				err:   nil,
+			},
+		},
 	}
 	for _, tc := range testCases {
 		// run
-		actualPutString, actualError := parsePutObject(tc.in)
+		actualPutString, actualError := parsePutObject(tc.in, newTestSettings(1, false, 0.0).ContentTypes())
 
 		// assertions
 		assert.Equal(t, tc.expected.value, actualPutString, tc.desc)
@@ -1345,6 +1665,59 @@ func TestParsePutObject(t *testing.T) {
 	}
 }
 
+func TestParsePutObjectConfiguredContentTypes(t *testing.T) {
+	contentTypes, err := config.NewContentTypes([]config.ContentType{
+		{Name: "html", ContentType: "text/html; charset=utf-8", Validator: "utf8"},
+		{Name: "image", ContentType: "image/png", Encoding: "base64"},
+		{Name: "vast", ContentType: "application/xml", Validator: "xml"},
+	})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc          string
+		in            putObject
+		expectedValue string
+		expectedError error
+	}{
+		{
+			desc:          "html value",
+			in:            putObject{Type: "html", Value: json.RawMessage(`"<p>Ad</p>"`)},
+			expectedValue: "html<p>Ad</p>",
+		},
+		{
+			desc:          "html value that's not a string",
+			in:            putObject{Type: "html", Value: json.RawMessage(`{"p":"Ad"}`)},
+			expectedError: utils.NewPBCError(utils.PUT_BAD_REQUEST, `html values must have a String value. Found {"p":"Ad"}`),
+		},
+		{
+			desc:          "vast value that's not well-formed XML",
+			in:            putObject{Type: "vast", Value: json.RawMessage(`"<VAST>"`)},
+			expectedError: utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid vast value: value is not well-formed XML: XML syntax error on line 1: unexpected EOF"),
+		},
+		{
+			desc:          "base64-encoded image value, stored encoded",
+			in:            putObject{Type: "image", Value: json.RawMessage(`"iVBORw0KGgo="`)},
+			expectedValue: "imageiVBORw0KGgo=",
+		},
+		{
+			desc:          "image value that's not base64-encoded",
+			in:            putObject{Type: "image", Value: json.RawMessage(`"not base64"`)},
+			expectedError: utils.NewPBCError(utils.PUT_BAD_REQUEST, "Invalid image value: value is not base64-encoded"),
+		},
+		{
+			desc:          "unknown type lists the configured types",
+			in:            putObject{Type: "css", Value: json.RawMessage(`"p {}"`)},
+			expectedError: utils.NewPBCError(utils.UNSUPPORTED_DATA_TO_STORE, `Type must be one of ["json", "xml", "html", "image", "vast"]. Found 'css'`),
+		},
+	}
+	for _, tc := range testCases {
+		actualValue, actualError := parsePutObject(tc.in, contentTypes)
+
+		assert.Equal(t, tc.expectedValue, actualValue, tc.desc)
+		assert.Equal(t, tc.expectedError, actualError, tc.desc)
+	}
+}
+
 // TestLogBackendError asserts this package's logBackendError(err error, index int) function
 func TestClassifyBackendError(t *testing.T) {
 	type testOutput struct {
@@ -1373,6 +1746,14 @@ func TestClassifyBackendError(t *testing.T) {
 				utils.HTTPDependencyTimeout,
 			},
 		},
+		{
+			"Wrapped DeadlineExceeded error",
+			fmt.Errorf("memcache: %w", context.DeadlineExceeded),
+			testOutput{
+				utils.NewPBCError(utils.PUT_DEADLINE_EXCEEDED),
+				utils.HTTPDependencyTimeout,
+			},
+		},
 		{
 			"Backend client error",
 			errors.New("Server memory error"),
@@ -1425,6 +1806,48 @@ func BenchmarkPutHandlerLen8(b *testing.B) {
 	benchmarkPutHandler(b, input)
 }
 
+// BenchmarkPutHandlerOversizedBody puts an 8MiB value, which used to be read whole before getting
+// rejected by the backend's size limit
+func BenchmarkPutHandlerOversizedBody(b *testing.B) {
+	body := []byte(`{"puts":[{"type":"xml","value":"` + strings.Repeat("a", 8*1024*1024) + `"}]}`)
+	benchmarkPutHandlerBody(b, body)
+}
+
+// BenchmarkPutHandlerTooManyPuts puts a thousand values, which used to be decoded whole before
+// getting rejected for being more than max_num_values
+func BenchmarkPutHandlerTooManyPuts(b *testing.B) {
+	puts := make([]string, 1000)
+	for i := range puts {
+		puts[i] = `{"type":"json","ttlseconds":60,"value":{"field":"value"}}`
+	}
+	body := []byte(`{"puts":[` + strings.Join(puts, ",") + `]}`)
+	benchmarkPutHandlerBody(b, body)
+}
+
+func benchmarkPutHandlerBody(b *testing.B, body []byte) {
+	mockMetrics := metricstest.CreateMockMetrics()
+	m := &metrics.Metrics{
+		MetricEngines: []metrics.CacheMetrics{
+			&mockMetrics,
+		},
+	}
+	settings := config.NewSettings(config.Configuration{
+		RequestLimits: config.RequestLimits{
+			MaxSize:      utils.REQUEST_MAX_SIZE_BYTES,
+			MaxNumValues: utils.REQUEST_MAX_NUM_VALUES,
+		},
+	})
+	router := httprouter.New()
+	router.POST("/cache", NewPutHandler(backends.NewMemoryBackend(), m, settings))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		request := httptest.NewRequest("POST", "/cache", bytes.NewReader(body))
+		router.ServeHTTP(httptest.NewRecorder(), request)
+	}
+}
+
 func doMockGet(t *testing.T, router *httprouter.Router, id string) *httptest.ResponseRecorder {
 	requestRecorder := httptest.NewRecorder()
 
@@ -1468,8 +1891,8 @@ func benchmarkPutHandler(b *testing.B, testCase string) {
 		},
 	}
 
-	router.POST("/cache", NewPutHandler(backend, m, 10, true, 0.0))
-	router.GET("/cache", NewGetHandler(backend, m, true, 0.0))
+	router.POST("/cache", NewPutHandler(backend, m, newTestSettings(10, true, 0.0)))
+	router.GET("/cache", NewGetHandler(backend, m, newTestSettings(10, true, 0.0)))
 
 	rr := httptest.NewRecorder()
 
@@ -2,6 +2,8 @@ package routing
 
 import (
 	"net/http"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/didip/tollbooth/v6"
@@ -15,41 +17,80 @@ import (
 	"github.com/rs/cors"
 )
 
-func NewAdminHandler(cfg config.Configuration, dataStore backends.Backend, appMetrics *metrics.Metrics) http.Handler {
+func NewAdminHandler(cfg config.Configuration, settings *config.Settings, dataStore backends.Backend, appMetrics *metrics.Metrics, readiness *endpoints.Readiness) http.Handler {
 	router := httprouter.New()
-	addReadRoutes(cfg, dataStore, appMetrics, router)
-	addWriteRoutes(cfg, dataStore, appMetrics, router)
+	addReadRoutes(cfg, settings, dataStore, appMetrics, readiness, router)
+	addWriteRoutes(settings, dataStore, appMetrics, router)
 	return router
 }
 
-func NewPublicHandler(cfg config.Configuration, dataStore backends.Backend, appMetrics *metrics.Metrics) http.Handler {
+func NewPublicHandler(cfg config.Configuration, settings *config.Settings, dataStore backends.Backend, appMetrics *metrics.Metrics, readiness *endpoints.Readiness) http.Handler {
 	router := httprouter.New()
-	addReadRoutes(cfg, dataStore, appMetrics, router)
+	addReadRoutes(cfg, settings, dataStore, appMetrics, readiness, router)
 	if cfg.Routes.AllowPublicWrite {
-		addWriteRoutes(cfg, dataStore, appMetrics, router)
+		addWriteRoutes(settings, dataStore, appMetrics, router)
 	}
 
-	handler := handleCors(router)
-	handler = handleRateLimiting(handler, cfg.RateLimiting)
-	return handler
+	return &middlewareHandler{next: router, settings: settings}
 }
 
-func addReadRoutes(cfg config.Configuration, dataStore backends.Backend, appMetrics *metrics.Metrics, router *httprouter.Router) {
-	router.GET("/", endpoints.NewIndexHandler(cfg.IndexResponse))          // Default route handler
-	router.GET("/status", endpoints.NewStatusEndpoint(cfg.StatusResponse)) // Determines whether the server is ready for more traffic.
-	router.GET("/cache", endpoints.NewGetHandler(dataStore, appMetrics, cfg.RequestLimits.AllowSettingKeys, cfg.RequestLogging.RefererSamplingRate))
+func addReadRoutes(cfg config.Configuration, settings *config.Settings, dataStore backends.Backend, appMetrics *metrics.Metrics, readiness *endpoints.Readiness, router *httprouter.Router) {
+	router.GET("/", endpoints.NewIndexHandler(cfg.IndexResponse))                     // Default route handler
+	router.GET("/status", endpoints.NewStatusEndpoint(cfg.StatusResponse, readiness)) // Determines whether the server is ready for more traffic.
+	router.GET("/cache", endpoints.NewGetHandler(dataStore, appMetrics, settings))
 	router.GET("/version", endpoints.NewVersionEndpoint(version.Ver, version.Rev))
 }
 
-func addWriteRoutes(cfg config.Configuration, dataStore backends.Backend, appMetrics *metrics.Metrics, router *httprouter.Router) {
-	router.POST("/cache", endpoints.NewPutHandler(dataStore, appMetrics, cfg.RequestLimits.MaxNumValues, cfg.RequestLimits.AllowSettingKeys, cfg.RequestLogging.RefererSamplingRate))
+func addWriteRoutes(settings *config.Settings, dataStore backends.Backend, appMetrics *metrics.Metrics, router *httprouter.Router) {
+	router.POST("/cache", endpoints.NewPutHandler(dataStore, appMetrics, settings))
+	router.POST("/cache/openrtb", endpoints.NewOpenRTBHandler(dataStore, appMetrics, settings))
 }
 
-func handleCors(handler http.Handler) http.Handler {
-	coresCfg := cors.New(cors.Options{AllowCredentials: true, AllowOriginFunc: func(origin string) bool {
-		return true
-	}})
-	return coresCfg.Handler(handler)
+// middlewareHandler wraps next with the CORS and rate limiting middleware. The middleware gets rebuilt
+// whenever its settings change, so configuration reloads take effect without a restart.
+type middlewareHandler struct {
+	next     http.Handler
+	settings *config.Settings
+
+	mutex   sync.RWMutex
+	applied *config.RuntimeSettings
+	handler http.Handler
+}
+
+func (m *middlewareHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.current().ServeHTTP(w, r)
+}
+
+func (m *middlewareHandler) current() http.Handler {
+	settings := m.settings.Load()
+
+	m.mutex.RLock()
+	applied, handler := m.applied, m.handler
+	m.mutex.RUnlock()
+	if applied == settings {
+		return handler
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.applied == nil || m.applied.RateLimiting != settings.RateLimiting || !reflect.DeepEqual(m.applied.CORS, settings.CORS) {
+		// Rebuilding the rate limiter resets the request counts, so only do it when needed
+		m.handler = handleRateLimiting(handleCors(m.next, settings.CORS), settings.RateLimiting)
+	}
+	m.applied = settings
+	return m.handler
+}
+
+func handleCors(handler http.Handler, cfg config.CORS) http.Handler {
+	options := cors.Options{AllowCredentials: true}
+	if len(cfg.AllowedOrigins) > 0 {
+		options.AllowedOrigins = cfg.AllowedOrigins
+	} else {
+		options.AllowOriginFunc = func(origin string) bool {
+			return true
+		}
+	}
+	return cors.New(options).Handler(handler)
 }
 
 func handleRateLimiting(next http.Handler, cfg config.RateLimiting) http.Handler {
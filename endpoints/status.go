@@ -1,22 +1,188 @@
 package endpoints
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/prebid/prebid-cache/backends"
+	"github.com/prebid/prebid-cache/config"
+	log "github.com/sirupsen/logrus"
 )
 
+// Values of the "status" field in the "GET /status" JSON response
+const (
+	StatusReady       = "ready"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+)
+
+// Readiness determines whether Prebid Cache should receive traffic. If health checks are enabled, the
+// backend health is checked every interval and the latest result is cached so "GET /status" never
+// waits on the backend. Once Drain() gets called, Prebid Cache reports itself as not ready for good.
+type Readiness struct {
+	backend backends.Backend
+	cfg     config.HealthCheck
+
+	draining  chan struct{}
+	drainOnce sync.Once
+
+	mutex         sync.RWMutex
+	backendStatus BackendStatus
+}
+
+// BackendStatus holds the result of the latest backend health check
+type BackendStatus struct {
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// StatusResponse gets marshaled into the "GET /status" JSON response body
+type StatusResponse struct {
+	Status  string         `json:"status"`
+	Backend *BackendStatus `json:"backend,omitempty"`
+}
+
+// NewReadiness returns a Readiness that checks the health of backend. If health checks are
+// enabled, the first check runs before NewReadiness returns.
+func NewReadiness(backend backends.Backend, cfg config.HealthCheck) *Readiness {
+	r := &Readiness{
+		backend:  backend,
+		cfg:      cfg,
+		draining: make(chan struct{}),
+	}
+	if cfg.Enabled {
+		r.check()
+	}
+	return r
+}
+
+// Run refreshes the cached backend health every interval until Drain() gets called
+func (r *Readiness) Run() {
+	if !r.cfg.Enabled {
+		return
+	}
+
+	ticker := time.NewTicker(r.cfg.Interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.draining:
+			return
+		case <-ticker.C:
+			r.check()
+		}
+	}
+}
+
+// Drain makes Prebid Cache report itself as not ready. It's meant to be called as soon as the process
+// is asked to shut down.
+func (r *Readiness) Drain() {
+	r.drainOnce.Do(func() {
+		log.Info("Draining: GET /status will respond 503 from now on")
+		close(r.draining)
+	})
+}
+
+// Draining tells whether Drain() was called
+func (r *Readiness) Draining() bool {
+	select {
+	case <-r.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// Status returns the readiness status along with the cached backend health, if health checks are enabled
+func (r *Readiness) Status() StatusResponse {
+	resp := StatusResponse{Status: StatusReady}
+
+	if r.cfg.Enabled {
+		r.mutex.RLock()
+		backendStatus := r.backendStatus
+		r.mutex.RUnlock()
+
+		resp.Backend = &backendStatus
+		if !backendStatus.Healthy {
+			resp.Status = StatusUnavailable
+		}
+	}
+
+	if r.Draining() {
+		resp.Status = StatusDraining
+	}
+	return resp
+}
+
+func (r *Readiness) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout())
+	defer cancel()
+
+	status := BackendStatus{Healthy: true, CheckedAt: time.Now()}
+	if err := backends.CheckHealth(ctx, r.backend); err != nil {
+		status.Healthy = false
+		status.Error = err.Error()
+	}
+
+	r.mutex.Lock()
+	previous := r.backendStatus
+	r.backendStatus = status
+	r.mutex.Unlock()
+
+	if status.Healthy != previous.Healthy || previous.CheckedAt.IsZero() {
+		if status.Healthy {
+			log.Info("Backend health check succeeded")
+		} else {
+			log.Errorf("Backend health check failed: %s", status.Error)
+		}
+	}
+}
+
 // NewStatusEndpoint returns a handler which writes the given response when the app is ready to serve requests.
-func NewStatusEndpoint(response string) httprouter.Handle {
-	// Today, the app always considers itself ready to serve requests.
-	if response == "" {
-		return func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+// If readiness is nil, the app always considers itself ready. Otherwise, it responds 503 while draining or,
+// if health checks are enabled, while the backend is unhealthy. With health checks enabled, the response
+// body is a JSON StatusResponse.
+func NewStatusEndpoint(response string, readiness *Readiness) httprouter.Handle {
+	responseBytes := []byte(response)
+	writeReady := func(w http.ResponseWriter) {
+		if len(responseBytes) == 0 {
 			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write(responseBytes)
+	}
+
+	if readiness == nil {
+		return func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+			writeReady(w)
 		}
 	}
 
-	responseBytes := []byte(response)
 	return func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-		w.Write(responseBytes)
+		status := readiness.Status()
+		if !readiness.cfg.Enabled && status.Status == StatusReady {
+			writeReady(w)
+			return
+		}
+
+		statusCode := http.StatusOK
+		if status.Status != StatusReady {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		body, err := json.Marshal(status)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		w.Write(body)
 	}
 }
@@ -0,0 +1,178 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prebid/prebid-cache/backends"
+	"github.com/prebid/prebid-cache/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// healthCheckBackend is a memory backend whose health check result can be changed on the fly
+type healthCheckBackend struct {
+	*backends.MemoryBackend
+	mutex sync.Mutex
+	err   error
+	calls int
+}
+
+func (b *healthCheckBackend) HealthCheck(ctx context.Context) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.calls++
+	return b.err
+}
+
+func (b *healthCheckBackend) setError(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.err = err
+}
+
+func (b *healthCheckBackend) numCalls() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.calls
+}
+
+func TestStatusEndpointWithReadiness(t *testing.T) {
+	enabled := config.HealthCheck{Enabled: true, IntervalMillis: 1000, TimeoutMillis: 100}
+
+	testCases := []struct {
+		description        string
+		inHealthCheck      config.HealthCheck
+		inStatusResponse   string
+		inBackendErr       error
+		inDraining         bool
+		expectedRespCode   int
+		expectedRespBody   string
+		expectedStatusBody *StatusResponse
+	}{
+		{
+			description:      "Health checks disabled, not draining. Keep responding the configured status response",
+			inStatusResponse: "ready",
+			expectedRespCode: http.StatusOK,
+			expectedRespBody: "ready",
+		},
+		{
+			description:      "Health checks disabled, not draining and no status response. Expect 204",
+			expectedRespCode: http.StatusNoContent,
+		},
+		{
+			description:        "Health checks disabled, draining. Expect 503",
+			inStatusResponse:   "ready",
+			inDraining:         true,
+			expectedRespCode:   http.StatusServiceUnavailable,
+			expectedStatusBody: &StatusResponse{Status: StatusDraining},
+		},
+		{
+			description:        "Healthy backend",
+			inHealthCheck:      enabled,
+			expectedRespCode:   http.StatusOK,
+			expectedStatusBody: &StatusResponse{Status: StatusReady, Backend: &BackendStatus{Healthy: true}},
+		},
+		{
+			description:        "Unhealthy backend",
+			inHealthCheck:      enabled,
+			inBackendErr:       errors.New("connection refused"),
+			expectedRespCode:   http.StatusServiceUnavailable,
+			expectedStatusBody: &StatusResponse{Status: StatusUnavailable, Backend: &BackendStatus{Healthy: false, Error: "connection refused"}},
+		},
+		{
+			description:        "Healthy backend while draining",
+			inHealthCheck:      enabled,
+			inDraining:         true,
+			expectedRespCode:   http.StatusServiceUnavailable,
+			expectedStatusBody: &StatusResponse{Status: StatusDraining, Backend: &BackendStatus{Healthy: true}},
+		},
+	}
+
+	for _, tc := range testCases {
+		backend := &healthCheckBackend{MemoryBackend: backends.NewMemoryBackend(), err: tc.inBackendErr}
+		readiness := NewReadiness(backend, tc.inHealthCheck)
+		if tc.inDraining {
+			readiness.Drain()
+		}
+
+		router := httprouter.New()
+		router.GET("/status", NewStatusEndpoint(tc.inStatusResponse, readiness))
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/status", nil)
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, tc.expectedRespCode, recorder.Code, tc.description)
+		if tc.expectedStatusBody == nil {
+			assert.Equal(t, tc.expectedRespBody, recorder.Body.String(), tc.description)
+			continue
+		}
+
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"), tc.description)
+		actual := &StatusResponse{}
+		if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), actual), tc.description) {
+			if actual.Backend != nil {
+				assert.False(t, actual.Backend.CheckedAt.IsZero(), tc.description)
+				actual.Backend.CheckedAt = time.Time{}
+			}
+			assert.Equal(t, tc.expectedStatusBody, actual, tc.description)
+		}
+	}
+}
+
+func TestReadinessRun(t *testing.T) {
+	backend := &healthCheckBackend{MemoryBackend: backends.NewMemoryBackend()}
+	readiness := NewReadiness(backend, config.HealthCheck{Enabled: true, IntervalMillis: 5, TimeoutMillis: 100})
+	assert.Equal(t, StatusReady, readiness.Status().Status)
+
+	stopped := make(chan struct{})
+	go func() {
+		readiness.Run()
+		close(stopped)
+	}()
+
+	// Backend goes down, the cached status gets refreshed
+	backend.setError(errors.New("connection refused"))
+	assert.Eventually(t, func() bool {
+		return readiness.Status().Status == StatusUnavailable
+	}, time.Second, 5*time.Millisecond)
+
+	// Backend recovers
+	backend.setError(nil)
+	assert.Eventually(t, func() bool {
+		return readiness.Status().Status == StatusReady
+	}, time.Second, 5*time.Millisecond)
+
+	// Draining stops the health checks
+	readiness.Drain()
+	readiness.Drain() // Calling it twice is safe
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after Drain()")
+	}
+	calls := backend.numCalls()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, calls, backend.numCalls())
+	assert.Equal(t, StatusDraining, readiness.Status().Status)
+}
+
+func TestReadinessDisabledHealthChecks(t *testing.T) {
+	backend := &healthCheckBackend{MemoryBackend: backends.NewMemoryBackend(), err: errors.New("connection refused")}
+	readiness := NewReadiness(backend, config.HealthCheck{Enabled: false})
+
+	// Run() returns right away and the backend never gets checked
+	readiness.Run()
+	assert.Equal(t, 0, backend.numCalls())
+	assert.Equal(t, StatusResponse{Status: StatusReady}, readiness.Status())
+	assert.False(t, readiness.Draining())
+
+	readiness.Drain()
+	assert.True(t, readiness.Draining())
+}
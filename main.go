@@ -7,6 +7,7 @@ import (
 
 	backendConfig "github.com/prebid/prebid-cache/backends/config"
 	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/endpoints"
 	"github.com/prebid/prebid-cache/endpoints/routing"
 	"github.com/prebid/prebid-cache/metrics"
 	"github.com/prebid/prebid-cache/server"
@@ -15,6 +16,10 @@ import (
 const configFileName = "config"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:], os.Stdout))
+	}
+
 	log.SetOutput(os.Stdout)
 	cfg := config.NewConfig(configFileName)
 	setLogLevel(cfg.Log.Level)
@@ -22,10 +27,13 @@ func main() {
 
 	appMetrics := metrics.CreateMetrics(cfg)
 	backend := backendConfig.NewBackend(cfg, appMetrics)
-	publicHandler := routing.NewPublicHandler(cfg, backend, appMetrics)
-	adminHandler := routing.NewAdminHandler(cfg, backend, appMetrics)
+	settings := config.NewSettings(cfg)
+	readiness := endpoints.NewReadiness(backend, cfg.HealthCheck)
+	publicHandler := routing.NewPublicHandler(cfg, settings, backend, appMetrics, readiness)
+	adminHandler := routing.NewAdminHandler(cfg, settings, backend, appMetrics, readiness)
 	go appMetrics.Export(cfg)
-	server.Listen(cfg, publicHandler, adminHandler, appMetrics)
+	go readiness.Run()
+	server.Listen(cfg, configFileName, settings, publicHandler, adminHandler, appMetrics, readiness)
 }
 
 func setLogLevel(logLevel config.LogLevel) {
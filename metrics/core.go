@@ -38,6 +38,24 @@ func (m Metrics) RecordPutDuration(duration time.Duration) {
 	}
 }
 
+func (m Metrics) RecordPutInvalidXML(reason string) {
+	for _, me := range m.MetricEngines {
+		me.RecordPutInvalidXML(reason)
+	}
+}
+
+func (m Metrics) RecordPutRequestTooLarge() {
+	for _, me := range m.MetricEngines {
+		me.RecordPutRequestTooLarge()
+	}
+}
+
+func (m Metrics) RecordPutJSONValidationDuration(duration time.Duration) {
+	for _, me := range m.MetricEngines {
+		me.RecordPutJSONValidationDuration(duration)
+	}
+}
+
 func (m Metrics) RecordPutKeyProvided() {
 	for _, me := range m.MetricEngines {
 		me.RecordPutKeyProvided()
@@ -68,15 +86,9 @@ func (m Metrics) RecordGetDuration(duration time.Duration) {
 	}
 }
 
-func (m Metrics) RecordPutBackendXml() {
-	for _, me := range m.MetricEngines {
-		me.RecordPutBackendXml()
-	}
-}
-
-func (m Metrics) RecordPutBackendJson() {
+func (m Metrics) RecordPutBackendType(contentType string) {
 	for _, me := range m.MetricEngines {
-		me.RecordPutBackendJson()
+		me.RecordPutBackendType(contentType)
 	}
 }
 
@@ -164,6 +176,84 @@ func (m Metrics) RecordAcceptConnectionErrors() {
 	}
 }
 
+func (m Metrics) RecordMigrationReadNew() {
+	for _, me := range m.MetricEngines {
+		me.RecordMigrationReadNew()
+	}
+}
+
+func (m Metrics) RecordMigrationReadOld() {
+	for _, me := range m.MetricEngines {
+		me.RecordMigrationReadOld()
+	}
+}
+
+func (m Metrics) RecordMigrationReadMiss() {
+	for _, me := range m.MetricEngines {
+		me.RecordMigrationReadMiss()
+	}
+}
+
+func (m Metrics) RecordMigrationCopy() {
+	for _, me := range m.MetricEngines {
+		me.RecordMigrationCopy()
+	}
+}
+
+func (m Metrics) RecordMigrationCopyError() {
+	for _, me := range m.MetricEngines {
+		me.RecordMigrationCopyError()
+	}
+}
+
+func (m Metrics) RecordMigrationMirrorError() {
+	for _, me := range m.MetricEngines {
+		me.RecordMigrationMirrorError()
+	}
+}
+
+func (m Metrics) RecordShadowGetMatch() {
+	for _, me := range m.MetricEngines {
+		me.RecordShadowGetMatch()
+	}
+}
+
+func (m Metrics) RecordShadowGetMismatch() {
+	for _, me := range m.MetricEngines {
+		me.RecordShadowGetMismatch()
+	}
+}
+
+func (m Metrics) RecordShadowGetError() {
+	for _, me := range m.MetricEngines {
+		me.RecordShadowGetError()
+	}
+}
+
+func (m Metrics) RecordShadowGetDuration(duration time.Duration) {
+	for _, me := range m.MetricEngines {
+		me.RecordShadowGetDuration(duration)
+	}
+}
+
+func (m Metrics) RecordShadowPutError() {
+	for _, me := range m.MetricEngines {
+		me.RecordShadowPutError()
+	}
+}
+
+func (m Metrics) RecordShadowPutDuration(duration time.Duration) {
+	for _, me := range m.MetricEngines {
+		me.RecordShadowPutDuration(duration)
+	}
+}
+
+func (m Metrics) RecordShadowDropped() {
+	for _, me := range m.MetricEngines {
+		me.RecordShadowDropped()
+	}
+}
+
 func (m Metrics) Export(cfg config.Configuration) {
 	for _, me := range m.MetricEngines {
 		me.Export(cfg.Metrics)
@@ -191,12 +281,14 @@ type CacheMetrics interface {
 	RecordPutTotal()
 	RecordPutDuration(duration time.Duration)
 	RecordPutKeyProvided()
+	RecordPutInvalidXML(reason string)
+	RecordPutRequestTooLarge()
+	RecordPutJSONValidationDuration(duration time.Duration)
 	RecordGetError()
 	RecordGetBadRequest()
 	RecordGetTotal()
 	RecordGetDuration(duration time.Duration)
-	RecordPutBackendXml()
-	RecordPutBackendJson()
+	RecordPutBackendType(contentType string)
 	RecordPutBackendInvalid()
 	RecordPutBackendDuration(duration time.Duration)
 	RecordPutBackendTTLSeconds(duration time.Duration)
@@ -211,6 +303,19 @@ type CacheMetrics interface {
 	RecordConnectionClosed()
 	RecordCloseConnectionErrors()
 	RecordAcceptConnectionErrors()
+	RecordMigrationReadNew()
+	RecordMigrationReadOld()
+	RecordMigrationReadMiss()
+	RecordMigrationCopy()
+	RecordMigrationCopyError()
+	RecordMigrationMirrorError()
+	RecordShadowGetMatch()
+	RecordShadowGetMismatch()
+	RecordShadowGetError()
+	RecordShadowGetDuration(duration time.Duration)
+	RecordShadowPutError()
+	RecordShadowPutDuration(duration time.Duration)
+	RecordShadowDropped()
 }
 
 func CreateMetrics(cfg config.Configuration) *Metrics {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
 	"github.com/rcrowley/go-metrics"
 	"github.com/sirupsen/logrus"
 	influxdb "github.com/vrischmann/go-metrics-influxdb"
@@ -17,11 +18,16 @@ const MetricsInfluxDB = "InfluxDB"
 type InfluxMetrics struct {
 	Registry    metrics.Registry
 	Puts        *InfluxMetricsEntry
+	InvalidXML  *InfluxInvalidXMLMetrics
+	TooLarge    metrics.Meter
+	JSONSchema  metrics.Timer
 	Gets        *InfluxMetricsEntry
 	PutsBackend *InfluxMetricsEntryByFormat
 	GetsBackend *InfluxMetricsEntry
 	GetsErr     *InfluxMetricsGetErrors
 	Connections *InfluxConnectionMetrics
+	Migration   *InfluxMigrationMetrics
+	Shadow      *InfluxShadowMetrics
 	MetricsName string
 }
 
@@ -43,6 +49,10 @@ type InfluxMetricsEntryByFormat struct {
 	InvalidRequest metrics.Meter
 	RequestLength  metrics.Histogram
 	RequestTTL     metrics.Timer
+
+	// name and registry register the meters of the configured content types as they get put
+	name     string
+	registry metrics.Registry
 }
 
 type InfluxConnectionMetrics struct {
@@ -56,6 +66,63 @@ type InfluxMetricsGetErrors struct {
 	MissingKeyErrors  metrics.Meter
 }
 
+// InfluxInvalidXMLMetrics account for the XML values put that failed the VAST validation, by reason
+type InfluxInvalidXMLMetrics struct {
+	ByReason map[string]metrics.Meter
+}
+
+func NewInfluxInvalidXMLMetrics(name string, r metrics.Registry) *InfluxInvalidXMLMetrics {
+	m := &InfluxInvalidXMLMetrics{ByReason: make(map[string]metrics.Meter, len(utils.VASTValidationReasons))}
+	for _, reason := range utils.VASTValidationReasons {
+		m.ByReason[reason] = metrics.GetOrRegisterMeter(fmt.Sprintf("%s.%s_count", name, reason), r)
+	}
+	return m
+}
+
+// InfluxMigrationMetrics account for the progress of a backend migration
+type InfluxMigrationMetrics struct {
+	ReadsNew     metrics.Meter
+	ReadsOld     metrics.Meter
+	ReadsMiss    metrics.Meter
+	Copies       metrics.Meter
+	CopyErrors   metrics.Meter
+	MirrorErrors metrics.Meter
+}
+
+func NewInfluxMigrationMetrics(name string, r metrics.Registry) *InfluxMigrationMetrics {
+	return &InfluxMigrationMetrics{
+		ReadsNew:     metrics.GetOrRegisterMeter(fmt.Sprintf("%s.reads.new_backend_count", name), r),
+		ReadsOld:     metrics.GetOrRegisterMeter(fmt.Sprintf("%s.reads.old_backend_count", name), r),
+		ReadsMiss:    metrics.GetOrRegisterMeter(fmt.Sprintf("%s.reads.miss_count", name), r),
+		Copies:       metrics.GetOrRegisterMeter(fmt.Sprintf("%s.writes.copied_count", name), r),
+		CopyErrors:   metrics.GetOrRegisterMeter(fmt.Sprintf("%s.writes.copy_error_count", name), r),
+		MirrorErrors: metrics.GetOrRegisterMeter(fmt.Sprintf("%s.writes.mirror_error_count", name), r),
+	}
+}
+
+// InfluxShadowMetrics account for the calls replayed against a candidate backend
+type InfluxShadowMetrics struct {
+	GetDuration  metrics.Timer
+	GetMatch     metrics.Meter
+	GetMismatch  metrics.Meter
+	GetErrors    metrics.Meter
+	PutDuration  metrics.Timer
+	PutErrors    metrics.Meter
+	DroppedCalls metrics.Meter
+}
+
+func NewInfluxShadowMetrics(name string, r metrics.Registry) *InfluxShadowMetrics {
+	return &InfluxShadowMetrics{
+		GetDuration:  metrics.GetOrRegisterTimer(fmt.Sprintf("%s.gets.request_duration", name), r),
+		GetMatch:     metrics.GetOrRegisterMeter(fmt.Sprintf("%s.gets.match_count", name), r),
+		GetMismatch:  metrics.GetOrRegisterMeter(fmt.Sprintf("%s.gets.mismatch_count", name), r),
+		GetErrors:    metrics.GetOrRegisterMeter(fmt.Sprintf("%s.gets.error_count", name), r),
+		PutDuration:  metrics.GetOrRegisterTimer(fmt.Sprintf("%s.puts.request_duration", name), r),
+		PutErrors:    metrics.GetOrRegisterMeter(fmt.Sprintf("%s.puts.error_count", name), r),
+		DroppedCalls: metrics.GetOrRegisterMeter(fmt.Sprintf("%s.dropped_count", name), r),
+	}
+}
+
 func NewInfluxGetErrorMetrics(name string, r metrics.Registry) *InfluxMetricsGetErrors {
 	return &InfluxMetricsGetErrors{
 		KeyNotFoundErrors: metrics.GetOrRegisterMeter(fmt.Sprintf("%s.key_not_found", name), r),
@@ -96,9 +163,22 @@ func NewInfluxMetricsEntryBackendPuts(name string, r metrics.Registry) *InfluxMe
 		InvalidRequest: metrics.GetOrRegisterMeter(fmt.Sprintf("%s.unknown_request_count", name), r),
 		RequestLength:  metrics.GetOrRegisterHistogram(name+".request_size_bytes", r, metrics.NewExpDecaySample(1028, 0.015)),
 		RequestTTL:     metrics.GetOrRegisterTimer(fmt.Sprintf("%s.request_ttl_seconds", name), r),
+		name:           name,
+		registry:       r,
 	}
 }
 
+// TypeRequest returns the meter of the put requests of values of contentType
+func (e *InfluxMetricsEntryByFormat) TypeRequest(contentType string) metrics.Meter {
+	switch contentType {
+	case utils.JSON_PREFIX:
+		return e.JsonRequest
+	case utils.XML_PREFIX:
+		return e.XmlRequest
+	}
+	return metrics.GetOrRegisterMeter(fmt.Sprintf("%s.%s_request_count", e.name, contentType), e.registry)
+}
+
 func NewInfluxConnectionMetrics(r metrics.Registry) *InfluxConnectionMetrics {
 	return &InfluxConnectionMetrics{
 		ActiveConnections:      metrics.GetOrRegisterCounter("connections.active_incoming", r),
@@ -113,11 +193,16 @@ func CreateInfluxMetrics() *InfluxMetrics {
 	m := &InfluxMetrics{
 		Registry:    r,
 		Puts:        NewInfluxMetricsEntryEndpointPuts("puts.current_url", r),
+		InvalidXML:  NewInfluxInvalidXMLMetrics("puts.invalid_xml", r),
+		TooLarge:    metrics.GetOrRegisterMeter("puts.current_url.request_too_large_count", r),
+		JSONSchema:  metrics.GetOrRegisterTimer("puts.json_validation.request_duration", r),
 		Gets:        NewInfluxMetricsEntryGet("gets.current_url", r),
 		PutsBackend: NewInfluxMetricsEntryBackendPuts("puts.backend", r),
 		GetsBackend: NewInfluxMetricsEntryGet("gets.backend", r),
 		GetsErr:     NewInfluxGetErrorMetrics("gets.backend_error", r),
 		Connections: NewInfluxConnectionMetrics(r),
+		Migration:   NewInfluxMigrationMetrics("migration", r),
+		Shadow:      NewInfluxShadowMetrics("shadow", r),
 		MetricsName: MetricsInfluxDB,
 	}
 
@@ -169,6 +254,20 @@ func (m *InfluxMetrics) RecordPutDuration(duration time.Duration) {
 	m.Puts.Duration.Update(duration)
 }
 
+func (m *InfluxMetrics) RecordPutInvalidXML(reason string) {
+	if meter, ok := m.InvalidXML.ByReason[reason]; ok {
+		meter.Mark(1)
+	}
+}
+
+func (m *InfluxMetrics) RecordPutRequestTooLarge() {
+	m.TooLarge.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordPutJSONValidationDuration(duration time.Duration) {
+	m.JSONSchema.Update(duration)
+}
+
 func (m *InfluxMetrics) RecordPutKeyProvided() {
 	m.Puts.Update.Mark(1)
 }
@@ -189,12 +288,8 @@ func (m *InfluxMetrics) RecordGetDuration(duration time.Duration) {
 	m.Gets.Duration.Update(duration)
 }
 
-func (m *InfluxMetrics) RecordPutBackendXml() {
-	m.PutsBackend.XmlRequest.Mark(1)
-}
-
-func (m *InfluxMetrics) RecordPutBackendJson() {
-	m.PutsBackend.JsonRequest.Mark(1)
+func (m *InfluxMetrics) RecordPutBackendType(contentType string) {
+	m.PutsBackend.TypeRequest(contentType).Mark(1)
 }
 
 func (m *InfluxMetrics) RecordPutBackendInvalid() {
@@ -252,3 +347,55 @@ func (m *InfluxMetrics) RecordCloseConnectionErrors() {
 func (m *InfluxMetrics) RecordAcceptConnectionErrors() {
 	m.Connections.ConnectionAcceptErrors.Mark(1)
 }
+
+func (m *InfluxMetrics) RecordMigrationReadNew() {
+	m.Migration.ReadsNew.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordMigrationReadOld() {
+	m.Migration.ReadsOld.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordMigrationReadMiss() {
+	m.Migration.ReadsMiss.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordMigrationCopy() {
+	m.Migration.Copies.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordMigrationCopyError() {
+	m.Migration.CopyErrors.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordMigrationMirrorError() {
+	m.Migration.MirrorErrors.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordShadowGetMatch() {
+	m.Shadow.GetMatch.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordShadowGetMismatch() {
+	m.Shadow.GetMismatch.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordShadowGetError() {
+	m.Shadow.GetErrors.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordShadowGetDuration(duration time.Duration) {
+	m.Shadow.GetDuration.Update(duration)
+}
+
+func (m *InfluxMetrics) RecordShadowPutError() {
+	m.Shadow.PutErrors.Mark(1)
+}
+
+func (m *InfluxMetrics) RecordShadowPutDuration(duration time.Duration) {
+	m.Shadow.PutDuration.Update(duration)
+}
+
+func (m *InfluxMetrics) RecordShadowDropped() {
+	m.Shadow.DroppedCalls.Mark(1)
+}
@@ -22,6 +22,14 @@ func TestRegisteredInfluxMetrics(t *testing.T) {
 		{"puts.current_url.request_count", "Meter"},
 		{"puts.current_url.updated_key_count", "Meter"},
 
+		// Invalid XML puts:
+		{"puts.invalid_xml.malformed_count", "Meter"},
+		{"puts.invalid_xml.not_vast_count", "Meter"},
+		{"puts.invalid_xml.missing_version_count", "Meter"},
+		{"puts.invalid_xml.no_ads_count", "Meter"},
+		{"puts.invalid_xml.invalid_ad_count", "Meter"},
+		{"puts.json_validation.request_duration", "Timer"},
+
 		// Gets:
 		{"gets.current_url.request_duration", "Timer"},
 		{"gets.current_url.error_count", "Meter"},
@@ -52,6 +60,23 @@ func TestRegisteredInfluxMetrics(t *testing.T) {
 		{"connections.active_incoming", "Counter"},
 		{"connections.accept_errors", "Meter"},
 		{"connections.close_errors", "Meter"},
+
+		// Backend migration:
+		{"migration.reads.new_backend_count", "Meter"},
+		{"migration.reads.old_backend_count", "Meter"},
+		{"migration.reads.miss_count", "Meter"},
+		{"migration.writes.copied_count", "Meter"},
+		{"migration.writes.copy_error_count", "Meter"},
+		{"migration.writes.mirror_error_count", "Meter"},
+
+		// Shadow traffic:
+		{"shadow.gets.request_duration", "Timer"},
+		{"shadow.gets.match_count", "Meter"},
+		{"shadow.gets.mismatch_count", "Meter"},
+		{"shadow.gets.error_count", "Meter"},
+		{"shadow.puts.request_duration", "Timer"},
+		{"shadow.puts.error_count", "Meter"},
+		{"shadow.dropped_count", "Meter"},
 	}
 
 	for _, test := range testCases {
@@ -116,6 +141,26 @@ func TestAllRecorders(t *testing.T) {
 					runTest:        func(im *InfluxMetrics) { im.RecordPutKeyProvided() },
 					metricToAssert: m.Puts.Update,
 				},
+				{
+					description:    "record a malformed XML value with RecordPutInvalidXML",
+					runTest:        func(im *InfluxMetrics) { im.RecordPutInvalidXML("malformed") },
+					metricToAssert: m.InvalidXML.ByReason["malformed"],
+				},
+				{
+					description:    "record a VAST value without ads with RecordPutInvalidXML",
+					runTest:        func(im *InfluxMetrics) { im.RecordPutInvalidXML("no_ads") },
+					metricToAssert: m.InvalidXML.ByReason["no_ads"],
+				},
+				{
+					description:    "record a put request whose body is too large with RecordPutRequestTooLarge",
+					runTest:        func(im *InfluxMetrics) { im.RecordPutRequestTooLarge() },
+					metricToAssert: m.TooLarge,
+				},
+				{
+					description:    "Five second RecordPutJSONValidationDuration",
+					runTest:        func(im *InfluxMetrics) { im.RecordPutJSONValidationDuration(fiveSeconds) },
+					metricToAssert: m.JSONSchema,
+				},
 			},
 		},
 		{
@@ -157,15 +202,20 @@ func TestAllRecorders(t *testing.T) {
 					metricToAssert: m.PutsBackend.Errors,
 				},
 				{
-					description:    "record a valid XML put request with RecordPutBackendXml",
-					runTest:        func(im *InfluxMetrics) { im.RecordPutBackendXml() },
+					description:    "record a valid XML put request with RecordPutBackendType",
+					runTest:        func(im *InfluxMetrics) { im.RecordPutBackendType("xml") },
 					metricToAssert: m.PutsBackend.XmlRequest,
 				},
 				{
-					description:    "record a valid JSON put request with RecordPutBackendJson",
-					runTest:        func(im *InfluxMetrics) { im.RecordPutBackendJson() },
+					description:    "record a valid JSON put request with RecordPutBackendType",
+					runTest:        func(im *InfluxMetrics) { im.RecordPutBackendType("json") },
 					metricToAssert: m.PutsBackend.JsonRequest,
 				},
+				{
+					description:    "record a put request of a configured content type with RecordPutBackendType",
+					runTest:        func(im *InfluxMetrics) { im.RecordPutBackendType("html") },
+					metricToAssert: metrics.GetOrRegisterMeter("puts.backend.html_request_count", m.Registry),
+				},
 				{
 					description:    "record an invalid put request with RecordPutBackendInvalid",
 					runTest:        func(im *InfluxMetrics) { im.RecordPutBackendInvalid() },
@@ -238,6 +288,81 @@ func TestAllRecorders(t *testing.T) {
 				},
 			},
 		},
+		{
+			"m.Migration",
+			[]testCase{
+				{
+					description:    "record a read served by the new backend with RecordMigrationReadNew",
+					runTest:        func(im *InfluxMetrics) { im.RecordMigrationReadNew() },
+					metricToAssert: m.Migration.ReadsNew,
+				},
+				{
+					description:    "record a read served by the old backend with RecordMigrationReadOld",
+					runTest:        func(im *InfluxMetrics) { im.RecordMigrationReadOld() },
+					metricToAssert: m.Migration.ReadsOld,
+				},
+				{
+					description:    "record a read neither backend could serve with RecordMigrationReadMiss",
+					runTest:        func(im *InfluxMetrics) { im.RecordMigrationReadMiss() },
+					metricToAssert: m.Migration.ReadsMiss,
+				},
+				{
+					description:    "record an entry copied to the new backend with RecordMigrationCopy",
+					runTest:        func(im *InfluxMetrics) { im.RecordMigrationCopy() },
+					metricToAssert: m.Migration.Copies,
+				},
+				{
+					description:    "record a failed copy with RecordMigrationCopyError",
+					runTest:        func(im *InfluxMetrics) { im.RecordMigrationCopyError() },
+					metricToAssert: m.Migration.CopyErrors,
+				},
+				{
+					description:    "record a failed mirrored write with RecordMigrationMirrorError",
+					runTest:        func(im *InfluxMetrics) { im.RecordMigrationMirrorError() },
+					metricToAssert: m.Migration.MirrorErrors,
+				},
+			},
+		},
+		{
+			"m.Shadow",
+			[]testCase{
+				{
+					description:    "Five second RecordShadowGetDuration",
+					runTest:        func(im *InfluxMetrics) { im.RecordShadowGetDuration(fiveSeconds) },
+					metricToAssert: m.Shadow.GetDuration,
+				},
+				{
+					description:    "record a candidate get that matched the primary one with RecordShadowGetMatch",
+					runTest:        func(im *InfluxMetrics) { im.RecordShadowGetMatch() },
+					metricToAssert: m.Shadow.GetMatch,
+				},
+				{
+					description:    "record a candidate get that didn't match the primary one with RecordShadowGetMismatch",
+					runTest:        func(im *InfluxMetrics) { im.RecordShadowGetMismatch() },
+					metricToAssert: m.Shadow.GetMismatch,
+				},
+				{
+					description:    "record a failed candidate get with RecordShadowGetError",
+					runTest:        func(im *InfluxMetrics) { im.RecordShadowGetError() },
+					metricToAssert: m.Shadow.GetErrors,
+				},
+				{
+					description:    "Five second RecordShadowPutDuration",
+					runTest:        func(im *InfluxMetrics) { im.RecordShadowPutDuration(fiveSeconds) },
+					metricToAssert: m.Shadow.PutDuration,
+				},
+				{
+					description:    "record a failed candidate put with RecordShadowPutError",
+					runTest:        func(im *InfluxMetrics) { im.RecordShadowPutError() },
+					metricToAssert: m.Shadow.PutErrors,
+				},
+				{
+					description:    "record a call that couldn't be shadowed with RecordShadowDropped",
+					runTest:        func(im *InfluxMetrics) { im.RecordShadowDropped() },
+					metricToAssert: m.Shadow.DroppedCalls,
+				},
+			},
+		},
 	}
 	for _, group := range testGroups {
 		for _, test := range group.testCases {
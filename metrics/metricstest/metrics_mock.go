@@ -58,19 +58,34 @@ func CreateMockMetrics() MockMetrics {
 	mockMetrics.On("RecordGetError")
 	mockMetrics.On("RecordGetTotal")
 	mockMetrics.On("RecordKeyNotFoundError")
+	mockMetrics.On("RecordMigrationCopy")
+	mockMetrics.On("RecordMigrationCopyError")
+	mockMetrics.On("RecordMigrationMirrorError")
+	mockMetrics.On("RecordMigrationReadMiss")
+	mockMetrics.On("RecordMigrationReadNew")
+	mockMetrics.On("RecordMigrationReadOld")
 	mockMetrics.On("RecordMissingKeyError")
 	mockMetrics.On("RecordPutBackendDuration", mock.Anything)
 	mockMetrics.On("RecordPutBackendError")
 	mockMetrics.On("RecordPutBackendInvalid")
-	mockMetrics.On("RecordPutBackendJson")
 	mockMetrics.On("RecordPutBackendSize", mock.Anything)
 	mockMetrics.On("RecordPutBackendTTLSeconds", mock.Anything)
-	mockMetrics.On("RecordPutBackendXml")
+	mockMetrics.On("RecordPutBackendType", mock.Anything)
 	mockMetrics.On("RecordPutBadRequest")
 	mockMetrics.On("RecordPutDuration", mock.Anything)
 	mockMetrics.On("RecordPutError")
+	mockMetrics.On("RecordPutInvalidXML", mock.Anything)
+	mockMetrics.On("RecordPutJSONValidationDuration", mock.Anything)
 	mockMetrics.On("RecordPutKeyProvided")
+	mockMetrics.On("RecordPutRequestTooLarge")
 	mockMetrics.On("RecordPutTotal")
+	mockMetrics.On("RecordShadowDropped")
+	mockMetrics.On("RecordShadowGetDuration", mock.Anything)
+	mockMetrics.On("RecordShadowGetError")
+	mockMetrics.On("RecordShadowGetMatch")
+	mockMetrics.On("RecordShadowGetMismatch")
+	mockMetrics.On("RecordShadowPutDuration", mock.Anything)
+	mockMetrics.On("RecordShadowPutError")
 
 	return mockMetrics
 }
@@ -106,6 +121,18 @@ func (m *MockMetrics) RecordPutKeyProvided() {
 	m.Called()
 	return
 }
+func (m *MockMetrics) RecordPutInvalidXML(reason string) {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordPutRequestTooLarge() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordPutJSONValidationDuration(duration time.Duration) {
+	m.Called()
+	return
+}
 func (m *MockMetrics) RecordGetError() {
 	m.Called()
 	return
@@ -122,11 +149,7 @@ func (m *MockMetrics) RecordGetDuration(duration time.Duration) {
 	m.Called()
 	return
 }
-func (m *MockMetrics) RecordPutBackendXml() {
-	m.Called()
-	return
-}
-func (m *MockMetrics) RecordPutBackendJson() {
+func (m *MockMetrics) RecordPutBackendType(contentType string) {
 	m.Called()
 	return
 }
@@ -186,3 +209,55 @@ func (m *MockMetrics) RecordAcceptConnectionErrors() {
 	m.Called()
 	return
 }
+func (m *MockMetrics) RecordMigrationReadNew() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordMigrationReadOld() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordMigrationReadMiss() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordMigrationCopy() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordMigrationCopyError() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordMigrationMirrorError() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordShadowGetMatch() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordShadowGetMismatch() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordShadowGetError() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordShadowGetDuration(duration time.Duration) {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordShadowPutError() {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordShadowPutDuration(duration time.Duration) {
+	m.Called()
+	return
+}
+func (m *MockMetrics) RecordShadowDropped() {
+	m.Called()
+	return
+}
@@ -1,16 +1,22 @@
 package metrics
 
 import (
+	"github.com/prebid/prebid-cache/utils"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 func preloadLabelValues(m *PrometheusMetrics) {
 	preloadLabelValuesForCounter(m.Puts.RequestStatus, map[string][]string{StatusKey: {ErrorVal, BadRequestVal, TotalsVal, CustomKey}})
+	preloadLabelValuesForCounter(m.InvalidXML, map[string][]string{ReasonKey: utils.VASTValidationReasons})
 	preloadLabelValuesForCounter(m.Gets.RequestStatus, map[string][]string{StatusKey: {ErrorVal, BadRequestVal, TotalsVal}})
 	preloadLabelValuesForCounter(m.PutsBackend.PutBackendRequests, map[string][]string{FormatKey: {XmlVal, JsonVal, InvFormatVal, ErrorVal}})
 	preloadLabelValuesForCounter(m.GetsBackend.RequestStatus, map[string][]string{StatusKey: {ErrorVal, BadRequestVal, TotalsVal}})
 	preloadLabelValuesForCounter(m.GetsBackend.ErrorsByType, map[string][]string{TypeKey: {KeyNotFoundVal, MissingKeyVal}})
 	preloadLabelValuesForCounter(m.Connections.ConnectionsErrors, map[string][]string{ConnErrorKey: {CloseVal, AcceptVal}})
+	preloadLabelValuesForCounter(m.Migration.Reads, map[string][]string{SourceKey: {NewBackendVal, OldBackendVal, MissVal}})
+	preloadLabelValuesForCounter(m.Migration.Writes, map[string][]string{TypeKey: {CopiedVal, CopyErrorVal, MirrorErrorVal}})
+	preloadLabelValuesForCounter(m.Shadow.Gets, map[string][]string{ResultKey: {MatchVal, MismatchVal, ErrorVal}})
+	preloadLabelValuesForCounter(m.Shadow.Puts, map[string][]string{StatusKey: {ErrorVal}})
 }
 
 func preloadLabelValuesForCounter(counter *prometheus.CounterVec, labelsWithValues map[string][]string) {
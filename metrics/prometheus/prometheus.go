@@ -14,6 +14,9 @@ const (
 	FormatKey    string = "format"
 	ConnErrorKey string = "connection_error"
 	TypeKey      string = "type"
+	SourceKey    string = "source"
+	ResultKey    string = "result"
+	ReasonKey    string = "reason"
 
 	// Label values
 	TotalsVal      string = "total"
@@ -27,21 +30,39 @@ const (
 	InvFormatVal   string = "invalid_format"
 	CloseVal       string = "close"
 	AcceptVal      string = "accept"
+	NewBackendVal  string = "new_backend"
+	OldBackendVal  string = "old_backend"
+	MissVal        string = "miss"
+	CopiedVal      string = "copied"
+	CopyErrorVal   string = "copy_error"
+	MirrorErrorVal string = "mirror_error"
+	MatchVal       string = "match"
+	MismatchVal    string = "mismatch"
 
 	// Metric names
-	PutRequestMet  string = "puts_request"
-	PutReqDurMet   string = "puts_request_duration"
-	GetRequestMet  string = "gets_request"
-	GetReqDurMet   string = "gets_request_duration"
-	PutBackendMet  string = "puts_backend"
-	PutBackDurMet  string = "puts_backend_duration"
-	PutBackSizeMet string = "puts_backend_request_size_bytes"
-	PutTTLSeconds  string = "puts_backend_request_ttl"
-	GetBackendMet  string = "gets_backend"
-	GetBackendErr  string = "gets_backend_error"
-	GetBackDurMet  string = "gets_backend_duration"
-	ConnOpenedMet  string = "connection_opened"
-	ConnClosedMet  string = "connection_closed"
+	PutRequestMet     string = "puts_request"
+	PutReqDurMet      string = "puts_request_duration"
+	PutInvalidXMLMet  string = "puts_invalid_xml"
+	PutTooLargeMet    string = "puts_request_too_large"
+	PutJSONValDurMet  string = "puts_json_validation_duration"
+	GetRequestMet     string = "gets_request"
+	GetReqDurMet      string = "gets_request_duration"
+	PutBackendMet     string = "puts_backend"
+	PutBackDurMet     string = "puts_backend_duration"
+	PutBackSizeMet    string = "puts_backend_request_size_bytes"
+	PutTTLSeconds     string = "puts_backend_request_ttl"
+	GetBackendMet     string = "gets_backend"
+	GetBackendErr     string = "gets_backend_error"
+	GetBackDurMet     string = "gets_backend_duration"
+	ConnOpenedMet     string = "connection_opened"
+	ConnClosedMet     string = "connection_closed"
+	MigrationReadMet  string = "migration_reads"
+	MigrationWriteMet string = "migration_writes"
+	ShadowGetMet      string = "shadow_gets"
+	ShadowGetDurMet   string = "shadow_gets_duration"
+	ShadowPutMet      string = "shadow_puts"
+	ShadowPutDurMet   string = "shadow_puts_duration"
+	ShadowDroppedMet  string = "shadow_dropped"
 
 	MetricsPrometheus = "Prometheus"
 )
@@ -49,10 +70,15 @@ const (
 type PrometheusMetrics struct {
 	Registry    *prometheus.Registry
 	Puts        *PrometheusRequestStatusMetric
+	InvalidXML  *prometheus.CounterVec
+	TooLarge    prometheus.Counter
+	JSONSchema  prometheus.Histogram
 	Gets        *PrometheusRequestStatusMetric
 	PutsBackend *PrometheusRequestStatusMetricByFormat
 	GetsBackend *PrometheusRequestStatusMetric
 	Connections *PrometheusConnectionMetrics
+	Migration   *PrometheusMigrationMetrics
+	Shadow      *PrometheusShadowMetrics
 	MetricsName string
 }
 
@@ -75,6 +101,19 @@ type PrometheusConnectionMetrics struct {
 	ConnectionsOpened prometheus.Counter
 }
 
+type PrometheusMigrationMetrics struct {
+	Reads  *prometheus.CounterVec
+	Writes *prometheus.CounterVec
+}
+
+type PrometheusShadowMetrics struct {
+	GetDuration  prometheus.Histogram
+	Gets         *prometheus.CounterVec
+	PutDuration  prometheus.Histogram
+	Puts         *prometheus.CounterVec
+	DroppedCalls prometheus.Counter
+}
+
 func CreatePrometheusMetrics(cfg config.PrometheusMetrics) *PrometheusMetrics {
 	timeBuckets := []float64{0.001, 0.002, 0.005, 0.01, 0.025, 0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 1}
 	// TTL seconds buckets for 1 second, half a minute as well as one, ten, fifteen, thirty minutes and 1, 2, and 3 and 10 hours
@@ -95,6 +134,20 @@ func CreatePrometheusMetrics(cfg config.PrometheusMetrics) *PrometheusMetrics {
 				[]string{StatusKey},
 			),
 		},
+		InvalidXML: newCounterVecWithLabels(cfg, registry,
+			PutInvalidXMLMet,
+			"Count of XML values put that failed the VAST validation, labeled by reason.",
+			[]string{ReasonKey},
+		),
+		TooLarge: newSingleCounter(cfg, registry,
+			PutTooLargeMet,
+			"Count of put requests rejected because their body was larger than allowed.",
+		),
+		JSONSchema: newHistogram(cfg, registry,
+			PutJSONValDurMet,
+			"Duration in seconds Prebid Cache takes to validate JSON values against their JSON schema.",
+			timeBuckets,
+		),
 		Gets: &PrometheusRequestStatusMetric{
 			Duration: newHistogram(cfg, registry,
 				GetReqDurMet,
@@ -155,6 +208,41 @@ func CreatePrometheusMetrics(cfg config.PrometheusMetrics) *PrometheusMetrics {
 				[]string{ConnErrorKey},
 			),
 		},
+		Migration: &PrometheusMigrationMetrics{
+			Reads: newCounterVecWithLabels(cfg, registry,
+				MigrationReadMet,
+				"Count of backend migration reads labeled by the backend that had the value, if any",
+				[]string{SourceKey},
+			),
+			Writes: newCounterVecWithLabels(cfg, registry,
+				MigrationWriteMet,
+				"Count of backend migration copies and failed writes labeled by type",
+				[]string{TypeKey},
+			),
+		},
+		Shadow: &PrometheusShadowMetrics{
+			GetDuration: newHistogram(cfg, registry,
+				ShadowGetDurMet,
+				"Duration in seconds the candidate backend takes to process shadowed get requests.",
+				timeBuckets,
+			),
+			Gets: newCounterVecWithLabels(cfg, registry,
+				ShadowGetMet,
+				"Count of shadowed get requests labeled by whether the candidate backend result matched the primary one.",
+				[]string{ResultKey},
+			),
+			PutDuration: newHistogram(cfg, registry,
+				ShadowPutDurMet,
+				"Duration in seconds the candidate backend takes to process shadowed put requests.",
+				timeBuckets,
+			),
+			Puts: newCounterVecWithLabels(cfg, registry,
+				ShadowPutMet,
+				"Count of shadowed put requests the candidate backend failed, labeled by status.",
+				[]string{StatusKey},
+			),
+			DroppedCalls: newSingleCounter(cfg, registry, ShadowDroppedMet, "Count the number of requests not shadowed because the queue was full"),
+		},
 		MetricsName: MetricsPrometheus,
 	}
 
@@ -241,6 +329,18 @@ func (m *PrometheusMetrics) RecordPutDuration(duration time.Duration) {
 	m.Puts.Duration.Observe(duration.Seconds())
 }
 
+func (m *PrometheusMetrics) RecordPutInvalidXML(reason string) {
+	m.InvalidXML.With(prometheus.Labels{ReasonKey: reason}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordPutRequestTooLarge() {
+	m.TooLarge.Inc()
+}
+
+func (m *PrometheusMetrics) RecordPutJSONValidationDuration(duration time.Duration) {
+	m.JSONSchema.Observe(duration.Seconds())
+}
+
 func (m *PrometheusMetrics) RecordPutKeyProvided() {
 	m.Puts.RequestStatus.With(prometheus.Labels{StatusKey: CustomKey}).Inc()
 }
@@ -261,12 +361,8 @@ func (m *PrometheusMetrics) RecordGetDuration(duration time.Duration) {
 	m.Gets.Duration.Observe(duration.Seconds())
 }
 
-func (m *PrometheusMetrics) RecordPutBackendXml() {
-	m.PutsBackend.PutBackendRequests.With(prometheus.Labels{FormatKey: XmlVal}).Inc()
-}
-
-func (m *PrometheusMetrics) RecordPutBackendJson() {
-	m.PutsBackend.PutBackendRequests.With(prometheus.Labels{FormatKey: JsonVal}).Inc()
+func (m *PrometheusMetrics) RecordPutBackendType(contentType string) {
+	m.PutsBackend.PutBackendRequests.With(prometheus.Labels{FormatKey: contentType}).Inc()
 }
 
 func (m *PrometheusMetrics) RecordPutBackendInvalid() {
@@ -328,3 +424,55 @@ func (m *PrometheusMetrics) RecordCloseConnectionErrors() {
 func (m *PrometheusMetrics) RecordAcceptConnectionErrors() {
 	m.Connections.ConnectionsErrors.With(prometheus.Labels{ConnErrorKey: AcceptVal}).Inc()
 }
+
+func (m *PrometheusMetrics) RecordMigrationReadNew() {
+	m.Migration.Reads.With(prometheus.Labels{SourceKey: NewBackendVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordMigrationReadOld() {
+	m.Migration.Reads.With(prometheus.Labels{SourceKey: OldBackendVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordMigrationReadMiss() {
+	m.Migration.Reads.With(prometheus.Labels{SourceKey: MissVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordMigrationCopy() {
+	m.Migration.Writes.With(prometheus.Labels{TypeKey: CopiedVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordMigrationCopyError() {
+	m.Migration.Writes.With(prometheus.Labels{TypeKey: CopyErrorVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordMigrationMirrorError() {
+	m.Migration.Writes.With(prometheus.Labels{TypeKey: MirrorErrorVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordShadowGetMatch() {
+	m.Shadow.Gets.With(prometheus.Labels{ResultKey: MatchVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordShadowGetMismatch() {
+	m.Shadow.Gets.With(prometheus.Labels{ResultKey: MismatchVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordShadowGetError() {
+	m.Shadow.Gets.With(prometheus.Labels{ResultKey: ErrorVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordShadowGetDuration(duration time.Duration) {
+	m.Shadow.GetDuration.Observe(duration.Seconds())
+}
+
+func (m *PrometheusMetrics) RecordShadowPutError() {
+	m.Shadow.Puts.With(prometheus.Labels{StatusKey: ErrorVal}).Inc()
+}
+
+func (m *PrometheusMetrics) RecordShadowPutDuration(duration time.Duration) {
+	m.Shadow.PutDuration.Observe(duration.Seconds())
+}
+
+func (m *PrometheusMetrics) RecordShadowDropped() {
+	m.Shadow.DroppedCalls.Inc()
+}
@@ -248,13 +248,13 @@ func TestPutBackendMetrics(t *testing.T) {
 		},
 		{
 			description: "Count put backend xml request",
-			testCase:    func(pm *PrometheusMetrics) { pm.RecordPutBackendXml() },
+			testCase:    func(pm *PrometheusMetrics) { pm.RecordPutBackendType(XmlVal) },
 			expDuration: 10,
 			expXmlCount: 1,
 		},
 		{
 			description:  "Count put backend json request",
-			testCase:     func(pm *PrometheusMetrics) { pm.RecordPutBackendJson() },
+			testCase:     func(pm *PrometheusMetrics) { pm.RecordPutBackendType(JsonVal) },
 			expDuration:  10,
 			expXmlCount:  1,
 			expJsonCount: 1,
@@ -320,6 +320,43 @@ func TestPutBackendMetrics(t *testing.T) {
 	}
 }
 
+func TestPutBackendConfiguredTypeMetrics(t *testing.T) {
+	m := createPrometheusMetricsForTesting()
+
+	m.RecordPutBackendType("html")
+
+	assertCounterVecValue(t, "Count put backend html request", m.PutsBackend.PutBackendRequests, 1, prometheus.Labels{FormatKey: "html"})
+	assertCounterVecValue(t, "Count put backend html request", m.PutsBackend.PutBackendRequests, 0, prometheus.Labels{FormatKey: JsonVal})
+}
+
+func TestPutInvalidXMLMetrics(t *testing.T) {
+	m := createPrometheusMetricsForTesting()
+
+	m.RecordPutInvalidXML("malformed")
+	m.RecordPutInvalidXML("malformed")
+	m.RecordPutInvalidXML("missing_version")
+
+	assertCounterVecValue(t, "Count malformed XML values", m.InvalidXML, 2, prometheus.Labels{ReasonKey: "malformed"})
+	assertCounterVecValue(t, "Count VAST values without version", m.InvalidXML, 1, prometheus.Labels{ReasonKey: "missing_version"})
+	assertCounterVecValue(t, "Count VAST values without ads", m.InvalidXML, 0, prometheus.Labels{ReasonKey: "no_ads"})
+}
+
+func TestPutRequestTooLargeMetrics(t *testing.T) {
+	m := createPrometheusMetricsForTesting()
+
+	m.RecordPutRequestTooLarge()
+
+	assertCounterValue(t, "Count put requests with a body too large", m.TooLarge, 1)
+}
+
+func TestPutJSONValidationDurationMetrics(t *testing.T) {
+	m := createPrometheusMetricsForTesting()
+
+	m.RecordPutJSONValidationDuration(TenSeconds)
+
+	assertHistogram(t, "Log JSON schema validation duration", m.JSONSchema, 1, 10)
+}
+
 func TestConnectionMetrics(t *testing.T) {
 	testCases := []struct {
 		description                    string
@@ -385,6 +422,46 @@ func TestConnectionMetrics(t *testing.T) {
 	}
 }
 
+func TestMigrationMetrics(t *testing.T) {
+	m := createPrometheusMetricsForTesting()
+
+	m.RecordMigrationReadNew()
+	m.RecordMigrationReadNew()
+	m.RecordMigrationReadOld()
+	m.RecordMigrationReadMiss()
+	m.RecordMigrationCopy()
+	m.RecordMigrationCopyError()
+	m.RecordMigrationMirrorError()
+
+	assertCounterVecValue(t, "new backend reads", m.Migration.Reads, 2, prometheus.Labels{SourceKey: NewBackendVal})
+	assertCounterVecValue(t, "old backend reads", m.Migration.Reads, 1, prometheus.Labels{SourceKey: OldBackendVal})
+	assertCounterVecValue(t, "missed reads", m.Migration.Reads, 1, prometheus.Labels{SourceKey: MissVal})
+	assertCounterVecValue(t, "copies", m.Migration.Writes, 1, prometheus.Labels{TypeKey: CopiedVal})
+	assertCounterVecValue(t, "copy errors", m.Migration.Writes, 1, prometheus.Labels{TypeKey: CopyErrorVal})
+	assertCounterVecValue(t, "mirror errors", m.Migration.Writes, 1, prometheus.Labels{TypeKey: MirrorErrorVal})
+}
+
+func TestShadowMetrics(t *testing.T) {
+	m := createPrometheusMetricsForTesting()
+
+	m.RecordShadowGetMatch()
+	m.RecordShadowGetMatch()
+	m.RecordShadowGetMismatch()
+	m.RecordShadowGetError()
+	m.RecordShadowGetDuration(time.Second)
+	m.RecordShadowPutError()
+	m.RecordShadowPutDuration(2 * time.Second)
+	m.RecordShadowDropped()
+
+	assertCounterVecValue(t, "get matches", m.Shadow.Gets, 2, prometheus.Labels{ResultKey: MatchVal})
+	assertCounterVecValue(t, "get mismatches", m.Shadow.Gets, 1, prometheus.Labels{ResultKey: MismatchVal})
+	assertCounterVecValue(t, "get errors", m.Shadow.Gets, 1, prometheus.Labels{ResultKey: ErrorVal})
+	assertCounterVecValue(t, "put errors", m.Shadow.Puts, 1, prometheus.Labels{StatusKey: ErrorVal})
+	assertCounterValue(t, "dropped calls", m.Shadow.DroppedCalls, 1)
+	assertHistogram(t, "get duration", m.Shadow.GetDuration, 1, 1)
+	assertHistogram(t, "put duration", m.Shadow.PutDuration, 1, 2)
+}
+
 func TestMetricCountGatekeeping(t *testing.T) {
 	expectedCardinalityCount := 100
 	actualCardinalityCount := 0
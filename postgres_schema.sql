@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS cache (
+    key text PRIMARY KEY,
+    value text NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS cache_expires_at_idx ON cache (expires_at);
@@ -1,7 +1,9 @@
-CREATE KEYSPACE prebid WITH replication = {'class': 'SimpleStrategy', 'replication_factor': '1'};
+CREATE KEYSPACE IF NOT EXISTS prebid WITH replication = {'class': 'SimpleStrategy', 'replication_factor': '1'};
 
-CREATE TABLE prebid.cache (
+CREATE TABLE IF NOT EXISTS prebid.cache (
     key text,
     value text,
     PRIMARY KEY (key)
-);
+) WITH default_time_to_live = 2400
+    AND gc_grace_seconds = 10800
+    AND compaction = {'class': 'TimeWindowCompactionStrategy', 'compaction_window_unit': 'HOURS', 'compaction_window_size': 1};
@@ -1,14 +1,22 @@
 package server
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/prebid/prebid-cache/config"
 	"github.com/prebid/prebid-cache/metrics"
 	"github.com/prebid/prebid-cache/metrics/metricstest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
 )
 
 func TestConnections(t *testing.T) {
@@ -158,3 +166,123 @@ func (m *mockAddr) Network() string {
 func (m *mockAddr) String() string {
 	return "192.0.2.1:25"
 }
+
+func TestNewUnixListener(t *testing.T) {
+	dir := t.TempDir()
+
+	testCases := []struct {
+		desc          string
+		inCfg         config.Listener
+		inStaleSocket bool
+		expectedMode  os.FileMode
+		expectedError bool
+	}{
+		{
+			desc:         "Unix socket gets the configured permissions",
+			inCfg:        config.Listener{UnixSocketPath: filepath.Join(dir, "a.sock"), UnixSocketPermissions: "0600"},
+			expectedMode: 0600,
+		},
+		{
+			desc:          "Stale socket file left behind by a previous process gets replaced",
+			inCfg:         config.Listener{UnixSocketPath: filepath.Join(dir, "b.sock"), UnixSocketPermissions: "0660"},
+			inStaleSocket: true,
+			expectedMode:  0660,
+		},
+		{
+			desc:          "Invalid permissions",
+			inCfg:         config.Listener{UnixSocketPath: filepath.Join(dir, "c.sock"), UnixSocketPermissions: "rw-rw----"},
+			expectedError: true,
+		},
+		{
+			desc:          "Socket directory doesn't exist",
+			inCfg:         config.Listener{UnixSocketPath: filepath.Join(dir, "missing", "d.sock"), UnixSocketPermissions: "0660"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.inStaleSocket {
+			stale, err := net.Listen("unix", tc.inCfg.UnixSocketPath)
+			require.NoError(t, err, tc.desc)
+			// Keep the socket file on disk, as a process that got killed would
+			stale.(*net.UnixListener).SetUnlinkOnClose(false)
+			stale.Close()
+		}
+
+		ln, err := newListener(tc.inCfg, "", nil)
+		if tc.expectedError {
+			assert.Error(t, err, tc.desc)
+			continue
+		}
+		if !assert.NoError(t, err, tc.desc) {
+			continue
+		}
+
+		info, err := os.Stat(tc.inCfg.UnixSocketPath)
+		if assert.NoError(t, err, tc.desc) {
+			assert.Equal(t, tc.expectedMode, info.Mode().Perm(), tc.desc)
+		}
+		ln.Close()
+	}
+}
+
+func TestConnectionMetricsOverH2C(t *testing.T) {
+	dir := t.TempDir()
+
+	testCases := []struct {
+		desc       string
+		inListener config.Listener
+	}{
+		{
+			desc:       "HTTP/2 cleartext over TCP",
+			inListener: config.Listener{H2C: true},
+		},
+		{
+			desc:       "HTTP/2 cleartext over a Unix domain socket",
+			inListener: config.Listener{H2C: true, UnixSocketPath: filepath.Join(dir, "main.sock"), UnixSocketPermissions: "0660"},
+		},
+	}
+
+	for _, tc := range testCases {
+		// h2c hijacks connections from the http.Server, signal when the HTTP/2 server closes them
+		closed := make(chan struct{}, 1)
+		mockMetrics := metricstest.MockMetrics{}
+		mockMetrics.On("RecordConnectionOpen")
+		mockMetrics.On("RecordAcceptConnectionErrors") // Closing the server interrupts Accept()
+		mockMetrics.On("RecordConnectionClosed").Run(func(mock.Arguments) { closed <- struct{}{} })
+		m := &metrics.Metrics{
+			MetricEngines: []metrics.CacheMetrics{
+				&mockMetrics,
+			},
+		}
+
+		server := newMainServer(config.Configuration{Listener: tc.inListener}, http.HandlerFunc(handler))
+		ln, err := newListener(tc.inListener, "127.0.0.1:0", m)
+		require.NoError(t, err, tc.desc)
+		go runServer(server, "Test", ln)
+
+		// Dial the listener directly using HTTP/2 with prior knowledge
+		client := &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(string, string, *tls.Config) (net.Conn, error) {
+				return net.Dial(ln.Addr().Network(), ln.Addr().String())
+			},
+		}}
+		resp, err := client.Get("http://prebid-cache/")
+		if assert.NoError(t, err, tc.desc) {
+			assert.Equal(t, 2, resp.ProtoMajor, tc.desc)
+			resp.Body.Close()
+		}
+
+		client.CloseIdleConnections()
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Errorf("%s: connection was not closed", tc.desc)
+		}
+		server.Close()
+
+		mockMetrics.AssertCalled(t, "RecordConnectionOpen")
+		mockMetrics.AssertNotCalled(t, "RecordCloseConnectionErrors")
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/prebid/prebid-cache/config"
+)
+
+// reloadAfterSignals reloads the configuration every time a signal comes in through inbound, until
+// inbound gets closed.
+func reloadAfterSignals(inbound <-chan os.Signal, loadConfig func() (config.Configuration, error), settings *config.Settings) {
+	for range inbound {
+		reloadConfig(loadConfig, settings)
+	}
+}
+
+// reloadConfig loads and validates the configuration, then applies the log level and the runtime
+// settings. A configuration that can't be loaded or is invalid gets rejected and the current settings
+// are kept. Changes to settings that can't be applied to a running Prebid Cache get reported.
+func reloadConfig(loadConfig func() (config.Configuration, error), settings *config.Settings) {
+	log.Info("Reloading configuration")
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Errorf("Could not load the configuration, keeping the current one: %v", err)
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Errorf("Invalid configuration, keeping the current one:\n%s", err.Error())
+		return
+	}
+
+	// Validate() made sure the log level parses
+	level, _ := log.ParseLevel(string(cfg.Log.Level))
+	log.SetLevel(level)
+
+	for _, key := range settings.Update(cfg) {
+		log.Warnf("config.%s changed but requires a restart to take effect", key)
+	}
+	log.Info("Configuration reloaded")
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	testLogrus "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prebid/prebid-cache/config"
+)
+
+func TestReloadConfig(t *testing.T) {
+	hook := testLogrus.NewGlobal()
+	defer logrus.SetLevel(logrus.GetLevel())
+
+	initial := newReloadTestConfig()
+	initial.Port = 2424
+	initial.RequestLimits.MaxNumValues = 10
+	settings := config.NewSettings(initial)
+
+	updated := initial
+	updated.Port = 8000
+	updated.Log.Level = config.Warning
+	updated.RequestLimits.MaxNumValues = 1
+
+	reloadConfig(func() (config.Configuration, error) { return updated, nil }, settings)
+
+	assert.Equal(t, logrus.WarnLevel, logrus.GetLevel())
+	assert.Equal(t, 1, settings.Load().RequestLimits.MaxNumValues)
+
+	var warnings []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel {
+			warnings = append(warnings, entry.Message)
+		}
+	}
+	assert.Equal(t, []string{"config.port changed but requires a restart to take effect"}, warnings)
+}
+
+func TestReloadInvalidConfig(t *testing.T) {
+	hook := testLogrus.NewGlobal()
+	defer logrus.SetLevel(logrus.GetLevel())
+	logrus.SetLevel(logrus.InfoLevel)
+
+	initial := newReloadTestConfig()
+	initial.RequestLimits.MaxNumValues = 10
+	settings := config.NewSettings(initial)
+
+	updated := initial
+	updated.Log.Level = config.Warning
+	updated.RequestLimits.MaxNumValues = 1
+	updated.Compression.Type = "unknown"
+
+	reloadConfig(func() (config.Configuration, error) { return updated, nil }, settings)
+
+	// The invalid configuration gets rejected as a whole
+	assert.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+	assert.Equal(t, 10, settings.Load().RequestLimits.MaxNumValues)
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "Invalid configuration, keeping the current one")
+	}
+}
+
+func TestReloadMalformedConfigFile(t *testing.T) {
+	hook := testLogrus.NewGlobal()
+	defer logrus.SetLevel(logrus.GetLevel())
+	logrus.SetLevel(logrus.InfoLevel)
+
+	initial := newReloadTestConfig()
+	initial.RequestLimits.MaxNumValues = 10
+	settings := config.NewSettings(initial)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("request_limits:\n  max_num_values: 1\n log: [unclosed"), 0600))
+
+	// A typo in the file must not take down the running server
+	reloadConfig(func() (config.Configuration, error) { return config.LoadConfigFile(path) }, settings)
+
+	assert.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+	assert.Equal(t, 10, settings.Load().RequestLimits.MaxNumValues)
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "Could not load the configuration, keeping the current one")
+	}
+}
+
+func TestReloadAfterSignals(t *testing.T) {
+	settings := config.NewSettings(newReloadTestConfig())
+	defer logrus.SetLevel(logrus.GetLevel())
+
+	loads := 0
+	loadConfig := func() (config.Configuration, error) {
+		loads++
+		cfg := newReloadTestConfig()
+		cfg.RequestLimits.MaxNumValues = loads
+		return cfg, nil
+	}
+
+	inbound := make(chan os.Signal, 2)
+	inbound <- os.Interrupt
+	inbound <- os.Interrupt
+	close(inbound)
+
+	// Returns once inbound is closed
+	reloadAfterSignals(inbound, loadConfig, settings)
+
+	assert.Equal(t, 2, loads)
+	assert.Equal(t, 2, settings.Load().RequestLimits.MaxNumValues)
+}
+
+// newReloadTestConfig returns a configuration that passes validation
+func newReloadTestConfig() config.Configuration {
+	return config.Configuration{
+		Log:         config.Log{Level: config.Info},
+		Backend:     config.Backend{Type: config.BackendMemory},
+		Compression: config.Compression{Type: config.CompressionSnappy},
+	}
+}
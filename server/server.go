@@ -12,17 +12,31 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/endpoints"
 	"github.com/prebid/prebid-cache/metrics"
 	localprometheus "github.com/prebid/prebid-cache/metrics/prometheus"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-// Listen serves requests and blocks forever, until OS signals shut down the process.
-func Listen(cfg config.Configuration, publicHandler http.Handler, adminHandler http.Handler, metrics *metrics.Metrics) {
-	stopSignals := make(chan os.Signal)
+// Listen serves requests and blocks forever, until OS signals shut down the process. As soon as a
+// shutdown signal comes in, readiness starts draining so "GET /status" tells load balancers to stop
+// sending traffic before the servers shut down. A SIGHUP reloads configFileName into settings.
+func Listen(cfg config.Configuration, configFileName string, settings *config.Settings, publicHandler http.Handler, adminHandler http.Handler, metrics *metrics.Metrics, readiness *endpoints.Readiness) {
+	stopSignals := make(chan os.Signal, 1)
 	signal.Notify(stopSignals, syscall.SIGTERM, syscall.SIGINT)
+	drainedSignals := drainAfterSignals(stopSignals, readiness, cfg.HealthCheck.DrainDelay())
+
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	defer signal.Stop(reloadSignals)
+	go reloadAfterSignals(reloadSignals, func() (config.Configuration, error) {
+		// Unlike config.NewConfig, a file that can't be read doesn't terminate the program
+		return config.LoadConfigFile(config.FindConfigFile(configFileName))
+	}, settings)
 
 	stopAdmin := make(chan os.Signal)
 	stopMain := make(chan os.Signal)
@@ -38,15 +52,28 @@ func Listen(cfg config.Configuration, publicHandler http.Handler, adminHandler h
 	go shutdownAfterSignals(mainServer, stopMain, done)
 	go shutdownAfterSignals(adminServer, stopAdmin, done)
 
+	// Load the certificates of the servers that terminate TLS. They get reloaded from disk
+	// until the servers shut down.
+	stopReloading := make(chan struct{})
+	defer close(stopReloading)
+	if err := configureTLS(mainServer, cfg.TLS, stopReloading); err != nil {
+		log.Errorf("Error configuring TLS for the main server: %v", err)
+		return
+	}
+	if err := configureTLS(adminServer, cfg.AdminTLS, stopReloading); err != nil {
+		log.Errorf("Error configuring TLS for the admin server: %v", err)
+		return
+	}
+
 	// Attach the servers to the sockets
-	mainListener, err := newListener(mainServer.Addr, metrics)
+	mainListener, err := newListener(cfg.Listener, mainServer.Addr, metrics)
 	if err != nil {
-		log.Errorf("Error listening for TCP connections on %s: %v", mainServer.Addr, err)
+		log.Errorf("Error listening for connections on %s: %v", mainServer.Addr, err)
 		return
 	}
-	adminListener, err := newListener(adminServer.Addr, nil)
+	adminListener, err := newListener(cfg.AdminListener, adminServer.Addr, nil)
 	if err != nil {
-		log.Errorf("Error listening for TCP connections on %s: %v", adminServer.Addr, err)
+		log.Errorf("Error listening for connections on %s: %v", adminServer.Addr, err)
 		return
 	}
 	go runServer(mainServer, "Main", mainListener)
@@ -60,16 +87,16 @@ func Listen(cfg config.Configuration, publicHandler http.Handler, adminHandler h
 
 		prometheusServer := newPrometheusServer(&cfg, promRegistry)
 		go shutdownAfterSignals(prometheusServer, stopPrometheus, done)
-		prometheusListener, err := newListener(prometheusServer.Addr, nil)
+		prometheusListener, err := newListener(config.Listener{}, prometheusServer.Addr, nil)
 		if err != nil {
 			log.Errorf("Error listening for TCP connections on %s: %v for prometheus server", adminServer.Addr, err)
 			return
 		}
 		go runServer(prometheusServer, "Prometheus", prometheusListener)
 
-		wait(stopSignals, done, stopMain, stopAdmin, stopPrometheus)
+		wait(drainedSignals, done, stopMain, stopAdmin, stopPrometheus)
 	} else {
-		wait(stopSignals, done, stopMain, stopAdmin)
+		wait(drainedSignals, done, stopMain, stopAdmin)
 	}
 	return
 }
@@ -77,11 +104,11 @@ func Listen(cfg config.Configuration, publicHandler http.Handler, adminHandler h
 // newAdminServer returns an http.Server with the AdminPort and RequestLimits.MaxHeaderBytes
 // from Prebid Cache's config files or environment variables. If RequestLimits.MaxHeaderBytes
 // is zero or was not specified, the http library's DefaultMaxHeaderBytes value of 1 MB
-// is set instead.
+// is set instead. If AdminListener.H2C is set, HTTP/2 cleartext requests are served as well.
 func newAdminServer(cfg config.Configuration, handler http.Handler) *http.Server {
 	server := &http.Server{
 		Addr:    ":" + strconv.Itoa(cfg.AdminPort),
-		Handler: handler,
+		Handler: handleH2C(handler, cfg.AdminListener.H2C),
 	}
 	if cfg.RequestLimits.MaxHeaderSize > 0 {
 		server.MaxHeaderBytes = cfg.RequestLimits.MaxHeaderSize
@@ -93,11 +120,11 @@ func newAdminServer(cfg config.Configuration, handler http.Handler) *http.Server
 // RequestLimits.MaxHeaderBytes values specified in Prebid Cache's config files
 // or environment variables. If RequestLimits.MaxHeaderBytes is zero or was not
 // specified, 1 MB, which is the value of the http library's DefaultMaxHeaderBytes,
-// is set instead.
+// is set instead. If Listener.H2C is set, HTTP/2 cleartext requests are served as well.
 func newMainServer(cfg config.Configuration, handler http.Handler) *http.Server {
 	server := &http.Server{
 		Addr:         ":" + strconv.Itoa(cfg.Port),
-		Handler:      handler,
+		Handler:      handleH2C(handler, cfg.Listener.H2C),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 	}
@@ -107,13 +134,50 @@ func newMainServer(cfg config.Configuration, handler http.Handler) *http.Server
 	return server
 }
 
+// runServer serves HTTPS if the server was assigned a TLS configuration, plain HTTP otherwise.
 func runServer(server *http.Server, name string, listener net.Listener) {
-	log.Infof("%s server starting on: %s", name, server.Addr)
-	err := server.Serve(listener)
+	var err error
+	if server.TLSConfig != nil {
+		log.Infof("%s server starting with TLS on: %s", name, listener.Addr())
+		err = server.ServeTLS(listener, "", "")
+	} else {
+		log.Infof("%s server starting on: %s", name, listener.Addr())
+		err = server.Serve(listener)
+	}
 	log.Errorf("%s server quit with error: %v", name, err)
 }
 
-func newListener(address string, metrics *metrics.Metrics) (net.Listener, error) {
+// handleH2C wraps handler so it also serves HTTP/2 requests over cleartext connections, both with
+// prior knowledge and through the "Upgrade: h2c" header.
+func handleH2C(handler http.Handler, enabled bool) http.Handler {
+	if !enabled {
+		return handler
+	}
+	return h2c.NewHandler(handler, &http2.Server{})
+}
+
+// newListener listens on the Unix domain socket found in cfg.UnixSocketPath if any, or on the TCP
+// address otherwise. If metrics is not nil, opened and closed connections get accounted for.
+func newListener(cfg config.Listener, address string, metrics *metrics.Metrics) (net.Listener, error) {
+	var ln net.Listener
+	var err error
+	if cfg.UnixSocketPath != "" {
+		ln, err = newUnixListener(cfg)
+	} else {
+		ln, err = newTCPListener(address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if metrics != nil {
+		ln = &monitorableListener{ln, metrics}
+	}
+
+	return ln, nil
+}
+
+func newTCPListener(address string) (net.Listener, error) {
 	ln, err := net.Listen("tcp", address)
 	if err != nil {
 		return nil, fmt.Errorf("Error listening for TCP connections on %s: %v", address, err)
@@ -126,13 +190,54 @@ func newListener(address string, metrics *metrics.Metrics) (net.Listener, error)
 		log.Warning("net.Listen(\"tcp\", \"addr\") didn't return a TCPListener as it did in Go 1.9. Things will probably work fine... but this should be investigated.")
 	}
 
-	if metrics != nil {
-		ln = &monitorableListener{ln, metrics}
+	return ln, nil
+}
+
+// newUnixListener listens on a Unix domain socket and applies the configured file permissions to it.
+// A socket file left behind by a previous process that didn't shut down cleanly gets replaced.
+func newUnixListener(cfg config.Listener) (net.Listener, error) {
+	mode, err := cfg.SocketFileMode()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid permissions %s for Unix socket %s: %v", cfg.UnixSocketPermissions, cfg.UnixSocketPath, err)
+	}
+
+	if info, err := os.Stat(cfg.UnixSocketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(cfg.UnixSocketPath); err != nil {
+			return nil, fmt.Errorf("Error removing stale Unix socket %s: %v", cfg.UnixSocketPath, err)
+		}
+	}
+
+	ln, err := net.Listen("unix", cfg.UnixSocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error listening for Unix socket connections on %s: %v", cfg.UnixSocketPath, err)
+	}
+
+	if err := os.Chmod(cfg.UnixSocketPath, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("Error setting permissions %s on Unix socket %s: %v", cfg.UnixSocketPermissions, cfg.UnixSocketPath, err)
 	}
 
 	return ln, nil
 }
 
+// drainAfterSignals marks readiness as draining as soon as a signal comes in through inbound, then waits
+// for drainDelay to elapse before passing the signal along through the returned channel.
+func drainAfterSignals(inbound <-chan os.Signal, readiness *endpoints.Readiness, drainDelay time.Duration) <-chan os.Signal {
+	outbound := make(chan os.Signal, 1)
+	go func() {
+		sig := <-inbound
+		if readiness != nil {
+			readiness.Drain()
+		}
+		if drainDelay > 0 {
+			log.Infof("Waiting %s for load balancers to stop sending traffic before shutting down", drainDelay)
+			time.Sleep(drainDelay)
+		}
+		outbound <- sig
+	}()
+	return outbound
+}
+
 func wait(inbound <-chan os.Signal, done <-chan struct{}, outbound ...chan<- os.Signal) {
 	sig := <-inbound
 
@@ -4,8 +4,12 @@ import (
 	"net/http"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/prebid/prebid-cache/backends"
 	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/endpoints"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewAdminServer(t *testing.T) {
@@ -65,6 +69,41 @@ func TestWait(t *testing.T) {
 	// If this doesn't hang, then wait() is sending and receiving messages as expected.
 }
 
+func TestDrainAfterSignals(t *testing.T) {
+	readiness := endpoints.NewReadiness(backends.NewMemoryBackend(), config.HealthCheck{})
+	inbound := make(chan os.Signal, 1)
+	drainDelay := 50 * time.Millisecond
+
+	outbound := drainAfterSignals(inbound, readiness, drainDelay)
+	assert.False(t, readiness.Draining())
+
+	start := time.Now()
+	inbound <- os.Interrupt
+
+	// Readiness starts draining right away, but the signal is held back for the drain delay
+	assert.Eventually(t, readiness.Draining, time.Second, time.Millisecond)
+	select {
+	case sig := <-outbound:
+		assert.Equal(t, os.Interrupt, sig)
+		assert.GreaterOrEqual(t, time.Since(start), drainDelay)
+	case <-time.After(time.Second):
+		t.Fatal("drainAfterSignals() did not forward the signal")
+	}
+}
+
+func TestDrainAfterSignalsNilReadiness(t *testing.T) {
+	inbound := make(chan os.Signal, 1)
+	outbound := drainAfterSignals(inbound, nil, 0)
+
+	inbound <- os.Interrupt
+	select {
+	case sig := <-outbound:
+		assert.Equal(t, os.Interrupt, sig)
+	case <-time.After(time.Second):
+		t.Fatal("drainAfterSignals() did not forward the signal")
+	}
+}
+
 func handler(w http.ResponseWriter, req *http.Request) {
 
 }
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils"
+)
+
+// configureTLS assigns a TLS configuration to server when cfg.Enabled is set. Certificates are
+// served through a utils.CertificateReloader so rotated files are picked up every
+// cfg.ReloadInterval() until stop is closed.
+func configureTLS(server *http.Server, cfg config.TLS, stop <-chan struct{}) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	tlsConfig, reloader, err := newTLSConfig(cfg)
+	if err != nil {
+		return err
+	}
+	server.TLSConfig = tlsConfig
+	go reloader.Watch(cfg.ReloadInterval(), stop)
+
+	return nil
+}
+
+// newTLSConfig builds a server side *tls.Config out of the TLS configuration values. If a client
+// CA file was configured, clients are required to present a certificate signed by it.
+func newTLSConfig(cfg config.TLS) (*tls.Config, *utils.CertificateReloader, error) {
+	reloader, err := utils.NewCertificateReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		MinVersion:     cfg.MinTLSVersion(),
+		CipherSuites:   cfg.CipherSuiteIDs(),
+	}
+
+	if cfg.ClientCAFile != "" {
+		pool, err := utils.LoadCertPool(cfg.ClientCAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, reloader, nil
+}
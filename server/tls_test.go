@@ -0,0 +1,151 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/prebid/prebid-cache/config"
+	"github.com/prebid/prebid-cache/utils/certtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	caFile := ca.WriteCA(t, dir)
+	pair := ca.Issue(t, dir, "server")
+
+	testCases := []struct {
+		desc               string
+		inCfg              config.TLS
+		expectedError      bool
+		expectedClientAuth tls.ClientAuthType
+		expectedMinVersion uint16
+		expectedSuites     []uint16
+	}{
+		{
+			desc:               "Certificate and key only. Expect TLS 1.2 and default cipher suites",
+			inCfg:              config.TLS{Enabled: true, CertFile: pair.CertFile, KeyFile: pair.KeyFile},
+			expectedClientAuth: tls.NoClientCert,
+			expectedMinVersion: tls.VersionTLS12,
+		},
+		{
+			desc: "Minimum version and cipher suites",
+			inCfg: config.TLS{
+				Enabled:      true,
+				CertFile:     pair.CertFile,
+				KeyFile:      pair.KeyFile,
+				MinVersion:   "1.3",
+				CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+			},
+			expectedClientAuth: tls.NoClientCert,
+			expectedMinVersion: tls.VersionTLS13,
+			expectedSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		},
+		{
+			desc:               "Client CA requires clients to present a certificate",
+			inCfg:              config.TLS{Enabled: true, CertFile: pair.CertFile, KeyFile: pair.KeyFile, ClientCAFile: caFile},
+			expectedClientAuth: tls.RequireAndVerifyClientCert,
+			expectedMinVersion: tls.VersionTLS12,
+		},
+		{
+			desc:          "Certificate file doesn't exist",
+			inCfg:         config.TLS{Enabled: true, CertFile: filepath.Join(dir, "missing.pem"), KeyFile: pair.KeyFile},
+			expectedError: true,
+		},
+		{
+			desc:          "Client CA file doesn't exist",
+			inCfg:         config.TLS{Enabled: true, CertFile: pair.CertFile, KeyFile: pair.KeyFile, ClientCAFile: filepath.Join(dir, "missing.pem")},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tlsConfig, reloader, err := newTLSConfig(tc.inCfg)
+		if tc.expectedError {
+			assert.Error(t, err, tc.desc)
+			continue
+		}
+		if !assert.NoError(t, err, tc.desc) {
+			continue
+		}
+		assert.NotNil(t, reloader, tc.desc)
+		assert.Equal(t, tc.expectedClientAuth, tlsConfig.ClientAuth, tc.desc)
+		assert.Equal(t, tc.expectedMinVersion, tlsConfig.MinVersion, tc.desc)
+		assert.Equal(t, tc.expectedSuites, tlsConfig.CipherSuites, tc.desc)
+
+		cert, err := tlsConfig.GetCertificate(nil)
+		assert.NoError(t, err, tc.desc)
+		assert.NotNil(t, cert, tc.desc)
+	}
+}
+
+func TestConfigureTLSDisabled(t *testing.T) {
+	server := &http.Server{}
+	assert.NoError(t, configureTLS(server, config.TLS{Enabled: false}, nil))
+	assert.Nil(t, server.TLSConfig)
+}
+
+func TestRunServerWithTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	caFile := ca.WriteCA(t, dir)
+	serverPair := ca.Issue(t, dir, "server")
+	clientPair := ca.Issue(t, dir, "client")
+
+	testCases := []struct {
+		desc          string
+		inClientCA    string
+		inClientCerts []tls.Certificate
+		expectedError bool
+	}{
+		{
+			desc: "TLS without client authentication",
+		},
+		{
+			desc:          "Mutual TLS, client presents a certificate signed by the client CA",
+			inClientCA:    caFile,
+			inClientCerts: []tls.Certificate{clientPair.Load(t)},
+		},
+		{
+			desc:          "Mutual TLS, client doesn't present a certificate",
+			inClientCA:    caFile,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		stop := make(chan struct{})
+		server := &http.Server{Handler: http.HandlerFunc(handler)}
+		err := configureTLS(server, config.TLS{
+			Enabled:      true,
+			CertFile:     serverPair.CertFile,
+			KeyFile:      serverPair.KeyFile,
+			ClientCAFile: tc.inClientCA,
+		}, stop)
+		require.NoError(t, err, tc.desc)
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err, tc.desc)
+		go runServer(server, "Test", ln)
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      ca.Pool(),
+			Certificates: tc.inClientCerts,
+		}}}
+		resp, err := client.Get("https://" + ln.Addr().String() + "/")
+		if tc.expectedError {
+			assert.Error(t, err, tc.desc)
+		} else if assert.NoError(t, err, tc.desc) {
+			assert.Equal(t, http.StatusOK, resp.StatusCode, tc.desc)
+			resp.Body.Close()
+		}
+
+		close(stop)
+		server.Close()
+	}
+}
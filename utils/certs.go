@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Reloader is implemented by the types that keep TLS files loaded from disk up to date
+type Reloader interface {
+	Watch(interval time.Duration, stop <-chan struct{})
+}
+
+// CertificateReloader holds an X.509 key pair loaded from disk and reloads it whenever the
+// certificate or the key files change, so rotated certificates get picked up without
+// restarting Prebid Cache.
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mutex   sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewCertificateReloader loads the key pair found in certFile and keyFile. An error is returned
+// if the files cannot be read or don't hold a valid key pair.
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	r := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the key pair from disk if either file was modified since it was last loaded.
+// It returns true if a new certificate was loaded. If the new files are invalid, the previous
+// certificate is kept and an error is returned.
+func (r *CertificateReloader) Reload() (bool, error) {
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	r.mutex.RLock()
+	unchanged := r.cert != nil && modTime.Equal(r.modTime)
+	r.mutex.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("Failed to load key pair %s, %s: %v", r.certFile, r.keyFile, err)
+	}
+
+	r.mutex.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mutex.Unlock()
+
+	return true, nil
+}
+
+// Watch calls Reload every interval until stop is closed. Reload errors are logged and the
+// certificate that was last loaded successfully remains in use.
+func (r *CertificateReloader) Watch(interval time.Duration, stop <-chan struct{}) {
+	watch(interval, stop, r.Reload, "TLS certificate "+r.certFile)
+}
+
+// Certificate returns the key pair that was last loaded
+func (r *CertificateReloader) Certificate() *tls.Certificate {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.cert
+}
+
+// GetCertificate can be assigned to tls.Config.GetCertificate on the server side
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate can be assigned to tls.Config.GetClientCertificate on the client side
+func (r *CertificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// LoadCertPool returns a certificate pool holding the PEM encoded certificates found in caFile
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CA file %s: %v", caFile, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("No valid PEM certificates found in CA file %s", caFile)
+	}
+	return pool, nil
+}
+
+// CertPoolReloader holds the certificate authorities found in a PEM file and reloads them whenever
+// the file changes, so rotated CA bundles get picked up without restarting Prebid Cache.
+type CertPoolReloader struct {
+	caFile string
+
+	mutex   sync.RWMutex
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+// NewCertPoolReloader loads the certificates found in caFile. An error is returned if the file
+// cannot be read or holds no PEM certificate.
+func NewCertPoolReloader(caFile string) (*CertPoolReloader, error) {
+	r := &CertPoolReloader{caFile: caFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the certificates from disk if the file was modified since it was last loaded. It
+// returns true if a new pool was loaded. If the new file is invalid, the previous pool is kept and
+// an error is returned.
+func (r *CertPoolReloader) Reload() (bool, error) {
+	modTime, err := latestModTime(r.caFile)
+	if err != nil {
+		return false, err
+	}
+
+	r.mutex.RLock()
+	unchanged := r.pool != nil && modTime.Equal(r.modTime)
+	r.mutex.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	pool, err := LoadCertPool(r.caFile)
+	if err != nil {
+		return false, err
+	}
+
+	r.mutex.Lock()
+	r.pool = pool
+	r.modTime = modTime
+	r.mutex.Unlock()
+
+	return true, nil
+}
+
+// Watch calls Reload every interval until stop is closed. Reload errors are logged and the
+// certificates that were last loaded successfully remain in use.
+func (r *CertPoolReloader) Watch(interval time.Duration, stop <-chan struct{}) {
+	watch(interval, stop, r.Reload, "CA file "+r.caFile)
+}
+
+// Pool returns the certificate pool that was last loaded
+func (r *CertPoolReloader) Pool() *x509.CertPool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.pool
+}
+
+// VerifyConnection can be assigned to tls.Config.VerifyConnection on the client side. It verifies the
+// server certificate chain and host name against the pool that was last loaded, which a fixed
+// tls.Config.RootCAs can't do, so tls.Config.InsecureSkipVerify must be set to skip the default
+// verification.
+func (r *CertPoolReloader) VerifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("Server presented no certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         r.Pool(),
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// watch calls reload every interval until stop is closed, logging the outcome of every reload that
+// either failed or picked up a change in the files described by name
+func watch(interval time.Duration, stop <-chan struct{}, reload func() (bool, error), name string) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if reloaded, err := reload(); err != nil {
+				log.Errorf("Could not reload %s: %v", name, err)
+			} else if reloaded {
+				log.Infof("Reloaded %s", name)
+			}
+		}
+	}
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Failed to read %s: %v", file, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
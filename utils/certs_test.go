@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-cache/utils/certtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	pair := certtest.NewAuthority(t, "test-ca").Issue(t, dir, "server")
+
+	testCases := []struct {
+		desc          string
+		inCertFile    string
+		inKeyFile     string
+		expectedError bool
+	}{
+		{
+			desc:       "Valid key pair",
+			inCertFile: pair.CertFile,
+			inKeyFile:  pair.KeyFile,
+		},
+		{
+			desc:          "Certificate file doesn't exist",
+			inCertFile:    filepath.Join(dir, "missing.pem"),
+			inKeyFile:     pair.KeyFile,
+			expectedError: true,
+		},
+		{
+			desc:          "Key file holds a certificate instead of a key",
+			inCertFile:    pair.CertFile,
+			inKeyFile:     pair.CertFile,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		reloader, err := NewCertificateReloader(tc.inCertFile, tc.inKeyFile)
+		if tc.expectedError {
+			assert.Error(t, err, tc.desc)
+			assert.Nil(t, reloader, tc.desc)
+		} else {
+			assert.NoError(t, err, tc.desc)
+			assert.NotNil(t, reloader.Certificate(), tc.desc)
+		}
+	}
+}
+
+func TestCertificateReloaderReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	pair := ca.Issue(t, dir, "server")
+
+	reloader, err := NewCertificateReloader(pair.CertFile, pair.KeyFile)
+	require.NoError(t, err)
+	original := reloader.Certificate()
+
+	// Files didn't change, nothing gets reloaded
+	reloaded, err := reloader.Reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+	assert.Same(t, original, reloader.Certificate())
+
+	// Rotate the key pair
+	ca.Issue(t, dir, "server")
+	bumpModTime(t, pair.CertFile, pair.KeyFile)
+
+	reloaded, err = reloader.Reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.NotEqual(t, original.Certificate[0], reloader.Certificate().Certificate[0])
+
+	// Invalid files keep the previous certificate in place
+	rotated := reloader.Certificate()
+	require.NoError(t, os.WriteFile(pair.KeyFile, []byte("not a key"), 0600))
+	bumpModTime(t, pair.KeyFile)
+
+	reloaded, err = reloader.Reload()
+	assert.Error(t, err)
+	assert.False(t, reloaded)
+	assert.Same(t, rotated, reloader.Certificate())
+}
+
+func TestCertificateReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	pair := ca.Issue(t, dir, "server")
+
+	reloader, err := NewCertificateReloader(pair.CertFile, pair.KeyFile)
+	require.NoError(t, err)
+	original := reloader.Certificate()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go reloader.Watch(10*time.Millisecond, stop)
+
+	ca.Issue(t, dir, "server")
+	bumpModTime(t, pair.CertFile, pair.KeyFile)
+
+	assert.Eventually(t, func() bool {
+		return reloader.Certificate() != original
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLoadCertPool(t *testing.T) {
+	dir := t.TempDir()
+	caFile := certtest.NewAuthority(t, "test-ca").WriteCA(t, dir)
+	notPEM := filepath.Join(dir, "not-pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("garbage"), 0600))
+
+	pool, err := LoadCertPool(caFile)
+	assert.NoError(t, err)
+	assert.NotNil(t, pool)
+
+	_, err = LoadCertPool(notPEM)
+	assert.Error(t, err)
+
+	_, err = LoadCertPool(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestCertPoolReloaderReload(t *testing.T) {
+	dir := t.TempDir()
+	caFile := certtest.NewAuthority(t, "test-ca").WriteCA(t, dir)
+
+	reloader, err := NewCertPoolReloader(caFile)
+	require.NoError(t, err)
+	original := reloader.Pool()
+
+	// File didn't change, nothing gets reloaded
+	reloaded, err := reloader.Reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+	assert.Same(t, original, reloader.Pool())
+
+	// Rotate the CA
+	certtest.NewAuthority(t, "rotated-ca").WriteCA(t, dir)
+	bumpModTime(t, caFile)
+
+	reloaded, err = reloader.Reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.NotSame(t, original, reloader.Pool())
+
+	// An invalid file keeps the previous pool in place
+	rotated := reloader.Pool()
+	require.NoError(t, os.WriteFile(caFile, []byte("garbage"), 0600))
+	bumpModTime(t, caFile)
+
+	reloaded, err = reloader.Reload()
+	assert.Error(t, err)
+	assert.False(t, reloaded)
+	assert.Same(t, rotated, reloader.Pool())
+
+	_, err = NewCertPoolReloader(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestCertPoolReloaderVerifyConnection(t *testing.T) {
+	dir := t.TempDir()
+	ca := certtest.NewAuthority(t, "test-ca")
+	caFile := ca.WriteCA(t, dir)
+	server := ca.Issue(t, dir, "server").Load(t)
+
+	reloader, err := NewCertPoolReloader(caFile)
+	require.NoError(t, err)
+
+	state := connectionState(t, server, "localhost")
+	assert.NoError(t, reloader.VerifyConnection(state), "Certificate signed by the CA")
+	assert.Error(t, reloader.VerifyConnection(connectionState(t, server, "redis.internal")), "Host name not in the certificate")
+	assert.Error(t, reloader.VerifyConnection(tls.ConnectionState{ServerName: "localhost"}), "No certificate")
+
+	// Once the CA gets rotated, only the certificates it signs are trusted
+	rotatedCA := certtest.NewAuthority(t, "rotated-ca")
+	rotatedCA.WriteCA(t, dir)
+	bumpModTime(t, caFile)
+	_, err = reloader.Reload()
+	require.NoError(t, err)
+
+	assert.Error(t, reloader.VerifyConnection(state), "Certificate signed by the previous CA")
+	rotatedServer := rotatedCA.Issue(t, dir, "rotated-server").Load(t)
+	assert.NoError(t, reloader.VerifyConnection(connectionState(t, rotatedServer, "localhost")), "Certificate signed by the rotated CA")
+}
+
+func connectionState(t *testing.T, cert tls.Certificate, serverName string) tls.ConnectionState {
+	t.Helper()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return tls.ConnectionState{ServerName: serverName, PeerCertificates: []*x509.Certificate{leaf}}
+}
+
+// bumpModTime makes sure file modification times change even on file systems with coarse timestamps
+func bumpModTime(t *testing.T, files ...string) {
+	t.Helper()
+
+	future := time.Now().Add(time.Minute)
+	for _, file := range files {
+		require.NoError(t, os.Chtimes(file, future, future))
+	}
+}
@@ -14,4 +14,13 @@ const (
 	REQUEST_MAX_SIZE_BYTES           = 10 * 1024
 	REQUEST_MAX_NUM_VALUES           = 10
 	REQUEST_MAX_TTL_SECONDS          = 3600
+	TLS_RELOAD_INTERVAL_SECONDS      = 60
+	UNIX_SOCKET_PERMISSIONS          = "0660"
+	HEALTH_CHECK_INTERVAL_MS         = 5000
+	HEALTH_CHECK_TIMEOUT_MS          = 1000
+	SHADOW_QUEUE_SIZE                = 1000
+	SHADOW_WORKERS                   = 4
+	SHADOW_TIMEOUT_MS                = 100
+	BOLT_COMPACTION_INTERVAL_SECONDS = 60
+	MEMCACHE_TIMEOUT_MS              = 100
 )
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Encodings of the values of a content type
+const (
+	// ENCODING_RAW values are stored and served as they are put
+	ENCODING_RAW = "raw"
+	// ENCODING_BASE64 values are put and stored base64-encoded, and served decoded, which allows
+	// caching binary data such as images
+	ENCODING_BASE64 = "base64"
+)
+
+// ContentType is a type of the values Prebid Cache stores. Values are stored prefixed by the name of
+// their type, which tells the Content-Type they get served with.
+type ContentType struct {
+	Name string
+	// MediaType is the Content-Type header the values get served with
+	MediaType string
+	Encoding  string
+	// Validate returns an error if the value, decoded if base64-encoded, isn't valid. It's nil if
+	// values of the type aren't validated.
+	Validate func(value []byte) error
+}
+
+// ContentValidators are the validators content types can use, by name
+var ContentValidators = map[string]func(value []byte) error{
+	"json": validateJSON,
+	"xml":  validateXML,
+	"utf8": validateUTF8,
+}
+
+// ContentTypes holds the content types Prebid Cache accepts. The "json" and "xml" types are always
+// part of them.
+type ContentTypes struct {
+	types []ContentType
+}
+
+// NewContentTypes returns the built-in content types followed by types. Names of content types can't
+// start with the name of another, so the type of a stored value can be told from its prefix.
+func NewContentTypes(types ...ContentType) (*ContentTypes, error) {
+	c := &ContentTypes{
+		types: []ContentType{
+			{Name: JSON_PREFIX, MediaType: "application/json", Encoding: ENCODING_RAW},
+			{Name: XML_PREFIX, MediaType: "application/xml", Encoding: ENCODING_RAW},
+		},
+	}
+	for _, contentType := range types {
+		for _, existing := range c.types {
+			if strings.HasPrefix(contentType.Name, existing.Name) || strings.HasPrefix(existing.Name, contentType.Name) {
+				return nil, fmt.Errorf("content type %s conflicts with content type %s, as one of their names starts with the other", contentType.Name, existing.Name)
+			}
+		}
+		c.types = append(c.types, contentType)
+	}
+	return c, nil
+}
+
+// Get returns the content type named name
+func (c *ContentTypes) Get(name string) (ContentType, bool) {
+	for _, contentType := range c.types {
+		if contentType.Name == name {
+			return contentType, true
+		}
+	}
+	return ContentType{}, false
+}
+
+// Match returns the content type of a stored value, which starts with the name of its type
+func (c *ContentTypes) Match(storedValue string) (ContentType, bool) {
+	for _, contentType := range c.types {
+		if strings.HasPrefix(storedValue, contentType.Name) {
+			return contentType, true
+		}
+	}
+	return ContentType{}, false
+}
+
+// Names returns the names of the content types, built-in ones first
+func (c *ContentTypes) Names() []string {
+	names := make([]string, 0, len(c.types))
+	for _, contentType := range c.types {
+		names = append(names, contentType.Name)
+	}
+	return names
+}
+
+func validateJSON(value []byte) error {
+	if !json.Valid(value) {
+		return errors.New("value is not valid JSON")
+	}
+	return nil
+}
+
+// validateXML makes sure value is a well-formed XML document
+func validateXML(value []byte) error {
+	decoder := newXMLDecoder(string(value))
+	hasElement := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("value is not well-formed XML: %v", err)
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			hasElement = true
+		}
+	}
+	if !hasElement {
+		return errors.New("value is not well-formed XML: no root element")
+	}
+	return nil
+}
+
+func validateUTF8(value []byte) error {
+	if !utf8.Valid(value) {
+		return errors.New("value is not valid UTF-8")
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContentTypes(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		in            []ContentType
+		expectedNames []string
+		expectedError string
+	}{
+		{
+			desc:          "Built-in content types only",
+			expectedNames: []string{"json", "xml"},
+		},
+		{
+			desc: "Configured content types follow the built-in ones",
+			in: []ContentType{
+				{Name: "html", MediaType: "text/html"},
+				{Name: "image", MediaType: "image/png", Encoding: ENCODING_BASE64},
+			},
+			expectedNames: []string{"json", "xml", "html", "image"},
+		},
+		{
+			desc:          "Name starting with the name of a built-in type",
+			in:            []ContentType{{Name: "xml_vast", MediaType: "application/xml"}},
+			expectedError: "content type xml_vast conflicts with content type xml, as one of their names starts with the other",
+		},
+		{
+			desc: "Name that's the start of the name of another type",
+			in: []ContentType{
+				{Name: "image_png", MediaType: "image/png"},
+				{Name: "image", MediaType: "image/gif"},
+			},
+			expectedError: "content type image conflicts with content type image_png, as one of their names starts with the other",
+		},
+	}
+
+	for _, tc := range testCases {
+		contentTypes, err := NewContentTypes(tc.in...)
+
+		if tc.expectedError != "" {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+			continue
+		}
+		if assert.NoError(t, err, tc.desc) {
+			assert.Equal(t, tc.expectedNames, contentTypes.Names(), tc.desc)
+		}
+	}
+}
+
+func TestContentTypesGetAndMatch(t *testing.T) {
+	contentTypes, err := NewContentTypes(ContentType{Name: "html", MediaType: "text/html"})
+	assert.NoError(t, err)
+
+	html, ok := contentTypes.Get("html")
+	assert.True(t, ok, "Get a configured type")
+	assert.Equal(t, "text/html", html.MediaType, "Get a configured type")
+
+	_, ok = contentTypes.Get("htm")
+	assert.False(t, ok, "Get an unknown type")
+
+	jsonType, ok := contentTypes.Match(`json{"field":"value"}`)
+	assert.True(t, ok, "Match a built-in type")
+	assert.Equal(t, "application/json", jsonType.MediaType, "Match a built-in type")
+
+	html, ok = contentTypes.Match("html<p>Ad</p>")
+	assert.True(t, ok, "Match a configured type")
+	assert.Equal(t, "html", html.Name, "Match a configured type")
+
+	_, ok = contentTypes.Match("css p {}")
+	assert.False(t, ok, "Match a value of an unknown type")
+}
+
+func TestContentValidators(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		validator string
+		in        string
+		expectErr bool
+	}{
+		{desc: "Valid JSON", validator: "json", in: `{"field":"value"}`},
+		{desc: "Invalid JSON", validator: "json", in: `{"field":`, expectErr: true},
+		{desc: "Well-formed XML", validator: "xml", in: `<?xml version="1.0"?><VAST version="4.0"></VAST>`},
+		{desc: "Unclosed XML element", validator: "xml", in: "<VAST>", expectErr: true},
+		{desc: "XML without a root element", validator: "xml", in: "just text", expectErr: true},
+		{desc: "Valid UTF-8", validator: "utf8", in: "<p>Ad ✓</p>"},
+		{desc: "Invalid UTF-8", validator: "utf8", in: "\xff\xfe", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		err := ContentValidators[tc.validator]([]byte(tc.in))
+
+		if tc.expectErr {
+			assert.Error(t, err, tc.desc)
+		} else {
+			assert.NoError(t, err, tc.desc)
+		}
+	}
+}
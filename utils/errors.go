@@ -7,6 +7,7 @@ import (
 // Prebid Cache error types
 const (
 	MISSING_KEY               = iota // GET http.StatusBadRequest 400
+	GET_BAD_REQUEST                  // GET http.StatusBadRequest 400
 	RECORD_EXISTS                    // PUT http.StatusBadRequest 400
 	PUT_MAX_NUM_VALUES               // PUT http.StatusBadRequest 400
 	PUT_BAD_REQUEST                  // PUT http.StatusBadRequest 400
@@ -15,8 +16,12 @@ const (
 	UNSUPPORTED_DATA_TO_STORE        // PUT http.StatusBadRequest 400
 	MISSING_VALUE                    // PUT http.StatusBadRequest 400
 	BAD_PAYLOAD_SIZE                 // PUT http.StatusBadRequest 400
+	INVALID_XML                      // PUT http.StatusBadRequest 400
+	INVALID_VAST                     // PUT http.StatusBadRequest 400
+	INVALID_JSON                     // PUT http.StatusBadRequest 400
 	KEY_NOT_FOUND                    // GET http.StatusNotFound 404
 	KEY_LENGTH                       // GET http.StatusNotFound 404
+	PUT_REQUEST_TOO_LARGE            // PUT http.StatusRequestEntityTooLarge 413
 	UNKNOWN_STORED_DATA_TYPE         // GET http.StatusInternalServerError 500
 	GET_INTERNAL_SERVER              // GET http.StatusInternalServerError 500
 	PUT_INTERNAL_SERVER              // PUT http.StatusInternalServerError 500
@@ -30,6 +35,7 @@ const HTTPDependencyTimeout = 597
 // Map Prebid Cache's error codes to their corresponding response status codes
 var errToStatusCodes map[int]int = map[int]int{
 	MISSING_KEY:               http.StatusBadRequest,
+	GET_BAD_REQUEST:           http.StatusBadRequest,
 	RECORD_EXISTS:             http.StatusBadRequest,
 	PUT_MAX_NUM_VALUES:        http.StatusBadRequest,
 	PUT_BAD_REQUEST:           http.StatusBadRequest,
@@ -38,12 +44,16 @@ var errToStatusCodes map[int]int = map[int]int{
 	UNSUPPORTED_DATA_TO_STORE: http.StatusBadRequest,
 	MISSING_VALUE:             http.StatusBadRequest,
 	BAD_PAYLOAD_SIZE:          http.StatusBadRequest,
+	INVALID_XML:               http.StatusBadRequest,
+	INVALID_VAST:              http.StatusBadRequest,
+	INVALID_JSON:              http.StatusBadRequest,
 	UNKNOWN_STORED_DATA_TYPE:  http.StatusInternalServerError,
 	GET_INTERNAL_SERVER:       http.StatusInternalServerError,
 	PUT_INTERNAL_SERVER:       http.StatusInternalServerError,
 	MARSHAL_RESPONSE:          http.StatusInternalServerError,
 	KEY_NOT_FOUND:             http.StatusNotFound,
 	KEY_LENGTH:                http.StatusNotFound,
+	PUT_REQUEST_TOO_LARGE:     http.StatusRequestEntityTooLarge,
 	PUT_DEADLINE_EXCEEDED:     HTTPDependencyTimeout,
 }
 
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// JSONSchema is a compiled JSON Schema values can be validated against
+type JSONSchema struct {
+	Name   string
+	schema *jsonschema.Schema
+}
+
+// NewJSONSchema compiles the JSON Schema in file. The references it makes to other schema files are
+// resolved relative to it.
+func NewJSONSchema(name, file string) (*JSONSchema, error) {
+	schema, err := jsonschema.NewCompiler().Compile(file)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONSchema{Name: name, schema: schema}, nil
+}
+
+// Validate returns a description of the parts of value that don't match the schema, if any
+func (s *JSONSchema) Validate(value []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return fmt.Errorf("value is not valid JSON: %v", err)
+	}
+
+	err := s.schema.Validate(v)
+	if validationErr, ok := err.(*jsonschema.ValidationError); ok {
+		return fmt.Errorf("value doesn't match the %s JSON schema: %s", s.Name, strings.Join(validationMessages(validationErr), "; "))
+	}
+	return err
+}
+
+// validationMessages lists where value failed the schema and why, one message per failed keyword
+func validationMessages(err *jsonschema.ValidationError) []string {
+	if len(err.Causes) == 0 {
+		location := err.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		return []string{fmt.Sprintf("%s: %s", location, err.Message)}
+	}
+
+	var messages []string
+	for _, cause := range err.Causes {
+		messages = append(messages, validationMessages(cause)...)
+	}
+	return messages
+}
+
+// JSONSchemas holds the JSON Schemas the values of content types must match, by content type
+type JSONSchemas struct {
+	byType map[string]*JSONSchema
+}
+
+// NewJSONSchemas returns JSONSchemas holding no schemas
+func NewJSONSchemas() *JSONSchemas {
+	return &JSONSchemas{byType: make(map[string]*JSONSchema)}
+}
+
+// Add makes the values of contentTypes match schema. Each content type has one schema at most.
+func (s *JSONSchemas) Add(schema *JSONSchema, contentTypes ...string) error {
+	for _, contentType := range contentTypes {
+		if existing, ok := s.byType[contentType]; ok {
+			return fmt.Errorf("content type %s already matches the %s JSON schema", contentType, existing.Name)
+		}
+	}
+	for _, contentType := range contentTypes {
+		s.byType[contentType] = schema
+	}
+	return nil
+}
+
+// Get returns the schema values of contentType must match, if any
+func (s *JSONSchemas) Get(contentType string) (*JSONSchema, bool) {
+	schema, ok := s.byType[contentType]
+	return schema, ok
+}
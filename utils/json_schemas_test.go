@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const bidSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "required": ["id", "impid", "price"],
+  "properties": {
+    "id": {"type": "string"},
+    "impid": {"type": "string"},
+    "price": {"type": "number", "minimum": 0},
+    "adm": {"type": "string"}
+  }
+}`
+
+// writeSchema writes schema to a file of a temporary directory and returns its path
+func writeSchema(t *testing.T, schema string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(file, []byte(schema), 0600); err != nil {
+		t.Fatalf("Failed to write the JSON schema: %v", err)
+	}
+	return file
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema, err := NewJSONSchema("openrtb_bid", writeSchema(t, bidSchema))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	testCases := []struct {
+		desc          string
+		in            string
+		expectedError string
+	}{
+		{
+			desc: "Matching value",
+			in:   `{"id":"bid-1","impid":"imp-1","price":1.25,"adm":"<VAST version=\"4.0\"></VAST>"}`,
+		},
+		{
+			desc:          "Value of the wrong type",
+			in:            `{"id":"bid-1","impid":"imp-1","price":"1.25"}`,
+			expectedError: "value doesn't match the openrtb_bid JSON schema: /price: expected number, but got string",
+		},
+		{
+			desc:          "Value missing required properties and out of range",
+			in:            `{"id":"bid-1","price":-1}`,
+			expectedError: "value doesn't match the openrtb_bid JSON schema: /: missing properties: 'impid'; /price: must be >= 0 but found -1",
+		},
+		{
+			desc:          "Invalid JSON",
+			in:            `{"id":`,
+			expectedError: "value is not valid JSON: unexpected EOF",
+		},
+	}
+
+	for _, tc := range testCases {
+		err := schema.Validate([]byte(tc.in))
+
+		if tc.expectedError == "" {
+			assert.NoError(t, err, tc.desc)
+		} else {
+			assert.EqualError(t, err, tc.expectedError, tc.desc)
+		}
+	}
+}
+
+func TestNewJSONSchemaInvalidSchema(t *testing.T) {
+	_, err := NewJSONSchema("invalid", writeSchema(t, `{"type": 5}`))
+	assert.Error(t, err, "Schema that doesn't match the meta-schema")
+
+	_, err = NewJSONSchema("missing", filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err, "Missing schema file")
+}
+
+func TestJSONSchemasAdd(t *testing.T) {
+	bid, err := NewJSONSchema("openrtb_bid", writeSchema(t, bidSchema))
+	if !assert.NoError(t, err) {
+		return
+	}
+	other, err := NewJSONSchema("other", writeSchema(t, `{"type": "object"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	schemas := NewJSONSchemas()
+	assert.NoError(t, schemas.Add(bid, "json", "bid"))
+	assert.EqualError(t, schemas.Add(other, "html", "bid"), "content type bid already matches the openrtb_bid JSON schema")
+
+	schema, ok := schemas.Get("json")
+	assert.True(t, ok, "Type with a schema")
+	assert.Equal(t, "openrtb_bid", schema.Name, "Type with a schema")
+
+	_, ok = schemas.Get("html")
+	assert.False(t, ok, "Schemas aren't added when one of their types already has a schema")
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Reasons an XML value fails the VAST validation
+const (
+	// VAST_MALFORMED values aren't well-formed XML documents
+	VAST_MALFORMED = "malformed"
+	// VAST_NOT_VAST values don't have a <VAST> root element
+	VAST_NOT_VAST = "not_vast"
+	// VAST_MISSING_VERSION values have a <VAST> root element without a version attribute
+	VAST_MISSING_VERSION = "missing_version"
+	// VAST_NO_ADS values have no <Ad> elements
+	VAST_NO_ADS = "no_ads"
+	// VAST_INVALID_AD values have an <Ad> element that's neither an InLine nor a Wrapper ad
+	VAST_INVALID_AD = "invalid_ad"
+)
+
+// VASTValidationReasons lists the reasons an XML value fails the VAST validation
+var VASTValidationReasons = []string{VAST_MALFORMED, VAST_NOT_VAST, VAST_MISSING_VERSION, VAST_NO_ADS, VAST_INVALID_AD}
+
+// ValidateVAST makes sure value is a well-formed XML document with a <VAST> root element that has a
+// version attribute, and whose ads are either InLine or Wrapper ads. Invalid values get the reason
+// they failed the validation along an INVALID_XML or INVALID_VAST error.
+func ValidateVAST(value string) (string, error) {
+	decoder := newXMLDecoder(value)
+
+	var reason, msg string
+	invalid := func(r, m string) {
+		// Only the first structural problem is reported, but the whole document must still be
+		// well-formed
+		if reason == "" {
+			reason, msg = r, m
+		}
+	}
+
+	depth, ads := 0, 0
+	hasRoot, adIsValid := false, false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return VAST_MALFORMED, NewPBCError(INVALID_XML, fmt.Sprintf("Invalid XML value: %v", err))
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1:
+				hasRoot = true
+				if element.Name.Local != "VAST" {
+					invalid(VAST_NOT_VAST, fmt.Sprintf("the root element is <%s> instead of <VAST>", element.Name.Local))
+				} else if !hasVersion(element) {
+					invalid(VAST_MISSING_VERSION, "the <VAST> element has no version attribute")
+				}
+			case depth == 2 && element.Name.Local == "Ad":
+				ads++
+				adIsValid = false
+			case depth == 3 && (element.Name.Local == "InLine" || element.Name.Local == "Wrapper"):
+				adIsValid = true
+			}
+		case xml.EndElement:
+			if depth == 2 && element.Name.Local == "Ad" && !adIsValid {
+				invalid(VAST_INVALID_AD, fmt.Sprintf("ad %d is neither an InLine nor a Wrapper ad", ads))
+			}
+			depth--
+		}
+	}
+
+	if !hasRoot {
+		return VAST_MALFORMED, NewPBCError(INVALID_XML, "Invalid XML value: no root element")
+	}
+	if reason == "" && ads == 0 {
+		invalid(VAST_NO_ADS, "the <VAST> element has no <Ad> elements")
+	}
+	if reason != "" {
+		return reason, NewPBCError(INVALID_VAST, "Invalid VAST value: "+msg)
+	}
+	return "", nil
+}
+
+func hasVersion(element xml.StartElement) bool {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == "version" && attr.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// newXMLDecoder returns a strict decoder of value. Values are put as JSON strings, so they're
+// always UTF-8 whatever encoding their XML declaration states.
+func newXMLDecoder(value string) *xml.Decoder {
+	decoder := xml.NewDecoder(strings.NewReader(value))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
+
+// VAST_WRAPPER_AD_SYSTEM is the AdSystem of the Wrapper VAST documents Prebid Cache generates
+const VAST_WRAPPER_AD_SYSTEM = "prebid-cache"
+
+type vastWrapperDocument struct {
+	XMLName xml.Name      `xml:"VAST"`
+	Version string        `xml:"version,attr"`
+	Ad      vastWrapperAd `xml:"Ad"`
+}
+
+type vastWrapperAd struct {
+	ID      string      `xml:"id,attr"`
+	Wrapper vastWrapper `xml:"Wrapper"`
+}
+
+type vastWrapper struct {
+	AdSystem     string      `xml:"AdSystem"`
+	VASTAdTagURI vastCDATA   `xml:"VASTAdTagURI"`
+	Errors       []vastCDATA `xml:"Error"`
+	Impressions  []vastCDATA `xml:"Impression"`
+	Creatives    struct{}    `xml:"Creatives"`
+}
+
+type vastCDATA struct {
+	Value string `xml:",cdata"`
+}
+
+// NewVASTWrapper returns a Wrapper VAST document whose VASTAdTagURI points at adTagURI, where the vast
+// document gets served. The wrapper has the same VAST version as vast, and the given impression and error
+// tracking URLs. Values that aren't VAST documents get an INVALID_XML or INVALID_VAST error.
+func NewVASTWrapper(vast, adID, adTagURI string, impressionURLs, errorURLs []string) (string, error) {
+	version, err := vastVersion(vast)
+	if err != nil {
+		return "", err
+	}
+
+	wrapper := vastWrapper{
+		AdSystem:     VAST_WRAPPER_AD_SYSTEM,
+		VASTAdTagURI: vastCDATA{adTagURI},
+	}
+	for _, errorURL := range errorURLs {
+		wrapper.Errors = append(wrapper.Errors, vastCDATA{errorURL})
+	}
+	for _, impressionURL := range impressionURLs {
+		wrapper.Impressions = append(wrapper.Impressions, vastCDATA{impressionURL})
+	}
+
+	document, err := xml.Marshal(vastWrapperDocument{
+		Version: version,
+		Ad:      vastWrapperAd{ID: adID, Wrapper: wrapper},
+	})
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(document), nil
+}
+
+// vastVersion returns the version attribute of the <VAST> root element of value. Versionless
+// documents are taken as VAST 3.0.
+func vastVersion(value string) (string, error) {
+	decoder := newXMLDecoder(value)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", NewPBCError(INVALID_XML, "Invalid XML value: no root element")
+		}
+		if err != nil {
+			return "", NewPBCError(INVALID_XML, fmt.Sprintf("Invalid XML value: %v", err))
+		}
+
+		if element, ok := token.(xml.StartElement); ok {
+			if element.Name.Local != "VAST" {
+				return "", NewPBCError(INVALID_VAST, fmt.Sprintf("Invalid VAST value: the root element is <%s> instead of <VAST>", element.Name.Local))
+			}
+			for _, attr := range element.Attr {
+				if attr.Name.Local == "version" && attr.Value != "" {
+					return attr.Value, nil
+				}
+			}
+			return "3.0", nil
+		}
+	}
+}
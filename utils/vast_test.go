@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateVAST(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		in             string
+		expectedReason string
+		expectedError  error
+	}{
+		{
+			desc: "InLine ad",
+			in:   `<?xml version="1.0" encoding="UTF-8"?><VAST version="4.0"><Ad id="1"><InLine><AdSystem>Prebid</AdSystem></InLine></Ad></VAST>`,
+		},
+		{
+			desc: "Wrapper and InLine ads",
+			in:   `<VAST version="3.0"><Ad><Wrapper><VASTAdTagURI><![CDATA[https://prebid.org/vast]]></VASTAdTagURI></Wrapper></Ad><Ad><InLine/></Ad></VAST>`,
+		},
+		{
+			desc: "XML declaration of another encoding",
+			in:   `<?xml version="1.0" encoding="ISO-8859-1"?><VAST version="2.0"><Ad><InLine/></Ad></VAST>`,
+		},
+		{
+			desc:           "Truncated document",
+			in:             `<VAST version="4.0"><Ad><InLine>`,
+			expectedReason: VAST_MALFORMED,
+			expectedError:  NewPBCError(INVALID_XML, "Invalid XML value: XML syntax error on line 1: unexpected EOF"),
+		},
+		{
+			desc:           "Mismatched elements",
+			in:             `<VAST version="4.0"><Ad></InLine></VAST>`,
+			expectedReason: VAST_MALFORMED,
+			expectedError:  NewPBCError(INVALID_XML, "Invalid XML value: XML syntax error on line 1: element <Ad> closed by </InLine>"),
+		},
+		{
+			desc:           "Text without elements",
+			in:             `not xml`,
+			expectedReason: VAST_MALFORMED,
+			expectedError:  NewPBCError(INVALID_XML, "Invalid XML value: no root element"),
+		},
+		{
+			desc:           "Root element other than VAST",
+			in:             `<VMAP version="1.0"><Ad><InLine/></Ad></VMAP>`,
+			expectedReason: VAST_NOT_VAST,
+			expectedError:  NewPBCError(INVALID_VAST, "Invalid VAST value: the root element is <VMAP> instead of <VAST>"),
+		},
+		{
+			desc:           "VAST without version",
+			in:             `<VAST><Ad><InLine/></Ad></VAST>`,
+			expectedReason: VAST_MISSING_VERSION,
+			expectedError:  NewPBCError(INVALID_VAST, "Invalid VAST value: the <VAST> element has no version attribute"),
+		},
+		{
+			desc:           "VAST without ads",
+			in:             `<VAST version="4.0"></VAST>`,
+			expectedReason: VAST_NO_ADS,
+			expectedError:  NewPBCError(INVALID_VAST, "Invalid VAST value: the <VAST> element has no <Ad> elements"),
+		},
+		{
+			desc:           "Ad neither InLine nor Wrapper",
+			in:             `<VAST version="4.0"><Ad><InLine/></Ad><Ad><Creatives/></Ad></VAST>`,
+			expectedReason: VAST_INVALID_AD,
+			expectedError:  NewPBCError(INVALID_VAST, "Invalid VAST value: ad 2 is neither an InLine nor a Wrapper ad"),
+		},
+		{
+			desc:           "Malformed document with structural problems too",
+			in:             `<VAST><Ad>`,
+			expectedReason: VAST_MALFORMED,
+			expectedError:  NewPBCError(INVALID_XML, "Invalid XML value: XML syntax error on line 1: unexpected EOF"),
+		},
+	}
+
+	for _, tc := range testCases {
+		reason, err := ValidateVAST(tc.in)
+
+		assert.Equal(t, tc.expectedReason, reason, tc.desc)
+		assert.Equal(t, tc.expectedError, err, tc.desc)
+	}
+}
+
+func TestNewVASTWrapper(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		vast           string
+		impressionURLs []string
+		errorURLs      []string
+		expected       string
+		expectedError  error
+	}{
+		{
+			desc:     "VAST 4.0 document, no tracking URLs",
+			vast:     `<VAST version="4.0"><Ad><InLine/></Ad></VAST>`,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<VAST version="4.0"><Ad id="some-uuid"><Wrapper><AdSystem>prebid-cache</AdSystem><VASTAdTagURI><![CDATA[https://cache.prebid.org/cache?uuid=some-uuid]]></VASTAdTagURI><Creatives></Creatives></Wrapper></Ad></VAST>`,
+		},
+		{
+			desc:           "Versionless document with tracking URLs",
+			vast:           `<?xml version="1.0"?><VAST><Ad><InLine/></Ad></VAST>`,
+			impressionURLs: []string{"https://tracking.prebid.org/imp?a=1&b=2", "https://tracking.prebid.org/imp2"},
+			errorURLs:      []string{"https://tracking.prebid.org/error?code=[ERRORCODE]"},
+			expected:       `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<VAST version="3.0"><Ad id="some-uuid"><Wrapper><AdSystem>prebid-cache</AdSystem><VASTAdTagURI><![CDATA[https://cache.prebid.org/cache?uuid=some-uuid]]></VASTAdTagURI><Error><![CDATA[https://tracking.prebid.org/error?code=[ERRORCODE]]]></Error><Impression><![CDATA[https://tracking.prebid.org/imp?a=1&b=2]]></Impression><Impression><![CDATA[https://tracking.prebid.org/imp2]]></Impression><Creatives></Creatives></Wrapper></Ad></VAST>`,
+		},
+		{
+			desc:          "Not a VAST document",
+			vast:          `<tag>xml data here</tag>`,
+			expectedError: NewPBCError(INVALID_VAST, "Invalid VAST value: the root element is <tag> instead of <VAST>"),
+		},
+		{
+			desc:          "Not XML",
+			vast:          `plain text`,
+			expectedError: NewPBCError(INVALID_XML, "Invalid XML value: no root element"),
+		},
+	}
+
+	for _, tc := range testCases {
+		wrapper, err := NewVASTWrapper(tc.vast, "some-uuid", "https://cache.prebid.org/cache?uuid=some-uuid", tc.impressionURLs, tc.errorURLs)
+
+		assert.Equal(t, tc.expectedError, err, tc.desc)
+		assert.Equal(t, tc.expected, wrapper, tc.desc)
+		if tc.expectedError == nil {
+			_, err := ValidateVAST(wrapper)
+			assert.NoError(t, err, tc.desc)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/prebid/prebid-cache/config"
+)
+
+// runValidate implements the "prebid-cache validate -config path" subcommand. It writes the effective
+// configuration, secrets redacted, along with every problem found in it to out, and returns the exit
+// code: zero if the configuration is valid, non-zero otherwise.
+func runValidate(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	configPath := flags.String("config", "", "path to the configuration file to validate")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *configPath == "" {
+		fmt.Fprintln(out, "Missing the -config flag")
+		flags.Usage()
+		return 2
+	}
+
+	// The values logged while validating are already part of the report
+	log.SetOutput(ioutil.Discard)
+
+	cfg, err := config.LoadConfigFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(out, "%s is invalid: %v\n", *configPath, err)
+		return 1
+	}
+
+	var errs config.ValidationErrors
+	if err := cfg.Validate(); err != nil {
+		errs = err.(config.ValidationErrors)
+	}
+
+	effective, err := cfg.MarshalRedacted()
+	if err != nil {
+		fmt.Fprintf(out, "Failed to print the effective configuration: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(out, "Effective configuration (secrets redacted):\n\n%s\n", effective)
+
+	if len(errs) > 0 {
+		fmt.Fprintf(out, "%s is invalid. Found %d error(s):\n", *configPath, len(errs))
+		for _, err := range errs {
+			fmt.Fprintf(out, "  - %s\n", err.Error())
+		}
+		return 1
+	}
+
+	fmt.Fprintf(out, "%s is valid\n", *configPath)
+	return 0
+}
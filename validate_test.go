@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunValidate(t *testing.T) {
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	writeConfig := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		return path
+	}
+
+	validPath := writeConfig("valid.yaml", `
+backend:
+  type: "redis"
+  redis:
+    host: "127.0.0.1"
+    port: 6379
+    password: "redis-password"
+`)
+	invalidPath := writeConfig("invalid.yaml", `
+request_limits:
+  max_num_values: -1
+backend:
+  type: "unknown"
+compression:
+  type: "unknown"
+`)
+	malformedPath := writeConfig("malformed.yaml", "malformed yaml file")
+
+	testCases := []struct {
+		description      string
+		inArgs           []string
+		expectedExitCode int
+		expectedOutput   []string
+	}{
+		{
+			description:      "Missing -config flag",
+			inArgs:           []string{},
+			expectedExitCode: 2,
+			expectedOutput:   []string{"Missing the -config flag"},
+		},
+		{
+			description:      "Valid configuration, secrets get redacted",
+			inArgs:           []string{"-config", validPath},
+			expectedExitCode: 0,
+			expectedOutput:   []string{"password: '[REDACTED]'", validPath + " is valid"},
+		},
+		{
+			description:      "Every error gets listed",
+			inArgs:           []string{"-config", invalidPath},
+			expectedExitCode: 1,
+			expectedOutput: []string{
+				invalidPath + " is invalid. Found 3 error(s):",
+				"  - invalid config.request_limits.max_num_values: -1. Value cannot be negative.",
+				"  - invalid config.backend.type: unknown.",
+				`  - invalid config.compression.type: unknown. It must be "none" or "snappy"`,
+			},
+		},
+		{
+			description:      "Malformed file",
+			inArgs:           []string{"-config", malformedPath},
+			expectedExitCode: 1,
+			expectedOutput:   []string{malformedPath + " is invalid: Configuration file could not be read"},
+		},
+		{
+			description:      "Missing file",
+			inArgs:           []string{"-config", filepath.Join(dir, "does_not_exist.yaml")},
+			expectedExitCode: 1,
+			expectedOutput:   []string{"does_not_exist.yaml is invalid"},
+		},
+	}
+
+	for _, tc := range testCases {
+		out := &bytes.Buffer{}
+
+		exitCode := runValidate(tc.inArgs, out)
+
+		assert.Equal(t, tc.expectedExitCode, exitCode, tc.description)
+		for _, expected := range tc.expectedOutput {
+			assert.Contains(t, out.String(), expected, tc.description)
+		}
+		assert.NotContains(t, out.String(), "redis-password", tc.description)
+	}
+}